@@ -0,0 +1,427 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"restaurant-management/gateway"
+	"restaurant-management/models"
+	"restaurant-management/store"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RefundRequest refunds part or all of what was paid on an invoice: the
+// listed lines in full, a given amount, or, when neither is given, everything
+// not yet refunded.
+type RefundRequest struct {
+	Reason string        `json:"reason" validate:"required,min=3,max=500"`
+	Lines  []string      `json:"lines"`
+	Amount *models.Money `json:"amount"`
+	// Method CASH pays the whole refund out in cash. Otherwise card payments
+	// are refunded to their cards first and only the rest is paid in cash.
+	Method string `json:"method" validate:"omitempty,oneof=CASH"`
+}
+
+// VoidRequest cancels an invoice outright, refunding whatever was paid.
+type VoidRequest struct {
+	Reason string `json:"reason" validate:"required,min=3,max=500"`
+	Method string `json:"method" validate:"omitempty,oneof=CASH"`
+}
+
+// lineCredit is what a line cost the customer: its amount, its tax and its
+// share of the service charge.
+func lineCredit(line models.InvoiceLine, serviceChargeRate *float64) models.Money {
+	credit, _ := line.Amount.Add(line.Tax)
+	if serviceChargeRate != nil {
+		credit, _ = credit.Add(line.Amount.MulRate(*serviceChargeRate))
+	}
+	return credit
+}
+
+// runningTotals returns what has been paid and refunded on invoice, in the
+// currency of total.
+func runningTotals(invoice models.Invoice, total models.Money) (paid, refunded models.Money) {
+	paid = models.NewMoney(0, total.Currency)
+	refunded = models.NewMoney(0, total.Currency)
+	if invoice.AmountPaid != nil {
+		paid = *invoice.AmountPaid
+	}
+	if invoice.AmountRefunded != nil {
+		refunded = *invoice.AmountRefunded
+	}
+	return paid, refunded
+}
+
+// payOut works out how amount goes back to the customer: to the card
+// captures of provider, newest payment first and as far as earlier credit
+// notes have not already refunded them, and the rest in cash. Nothing is
+// refunded yet; makeRefunds does that once the credit note is stored.
+func payOut(provider gateway.Provider, payments []models.Payment, notes []models.CreditNote, amount models.Money, cashOnly bool) []models.Refund {
+	var refunds []models.Refund
+	remaining := amount
+
+	if !cashOnly {
+		refundedFrom := map[string]int64{}
+		for _, note := range notes {
+			for _, refund := range note.Refunds {
+				if refund.Capture != nil {
+					refundedFrom[*refund.Capture] += refund.Amount.Amount
+				}
+			}
+		}
+
+		for i := len(payments) - 1; i >= 0 && remaining.Amount > 0; i-- {
+			for _, tender := range payments[i].Tenders {
+				if tender.Method != models.TenderCard || tender.Reference == nil || tender.Provider == nil || *tender.Provider != provider.Name() {
+					continue
+				}
+				available := tender.Amount.Amount - refundedFrom[*tender.Reference]
+				if available <= 0 || remaining.Amount == 0 {
+					continue
+				}
+				if available > remaining.Amount {
+					available = remaining.Amount
+				}
+
+				part := models.NewMoney(available, remaining.Currency)
+				refunds = append(refunds, models.Refund{
+					Method:   models.TenderCard,
+					Amount:   part,
+					Provider: tender.Provider,
+					Capture:  tender.Reference,
+				})
+				remaining, _ = remaining.Sub(part)
+			}
+		}
+	}
+
+	if remaining.Amount > 0 {
+		refunds = append(refunds, models.Refund{Method: models.TenderCash, Amount: remaining})
+	}
+	return refunds
+}
+
+// makeRefunds refunds the card refunds among refunds through provider,
+// setting the reference of each one made. It stops at the first the provider
+// turns down.
+func makeRefunds(ctx context.Context, provider gateway.Provider, refunds []models.Refund) error {
+	for i := range refunds {
+		refund := &refunds[i]
+		if refund.Method != models.TenderCard {
+			continue
+		}
+		transaction, err := provider.Refund(ctx, *refund.Capture, refund.Amount)
+		if err != nil {
+			return err
+		}
+		refund.Reference = &transaction.Reference
+	}
+	return nil
+}
+
+// unrecordedRefunds logs card refunds the provider made for a credit note
+// that could not then be recorded, so they can be reconciled by hand.
+func unrecordedRefunds(refunds []models.Refund) {
+	for _, refund := range refunds {
+		if refund.Reference != nil {
+			log.Printf("card refund %s of %s was made but its credit note was not recorded", *refund.Reference, refund.Amount)
+		}
+	}
+}
+
+// issueCreditNote records a credit note, pays it out and writes the
+// response. The note is stored first, so a concurrent credit note on the same
+// invoice fails its compare-and-set before any card is refunded; if the
+// provider then turns a refund down, the note is taken back.
+func issueCreditNote(ctx context.Context, c *gin.Context, creditNotes store.CreditNoteStore, payments store.PaymentStore, provider gateway.Provider,
+	invoice models.Invoice, notes []models.CreditNote, note models.CreditNote, refund models.Money, credit store.Credit, cashOnly bool) {
+
+	invoicePayments, err := payments.ListByInvoice(ctx, invoice.InvoiceID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch payments"})
+		return
+	}
+
+	if refund.Amount > 0 {
+		note.Refunds = payOut(provider, invoicePayments, notes, refund, cashOnly)
+	}
+
+	note.ID = primitive.NewObjectID()
+	note.CreditNoteID = note.ID.Hex()
+	note.InvoiceID = invoice.InvoiceID
+	note.IssuedBy = c.GetString("uid")
+	note.CreatedAt = time.Now().UTC()
+
+	credit.StatusBefore = *invoice.PaymentStatus
+	credit.AmountRefunded, _ = credit.RefundedBefore.Add(refund)
+
+	if err := creditNotes.Insert(ctx, note, credit); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "invoice not found"})
+		case store.ErrConflict:
+			c.JSON(http.StatusConflict, gin.H{"error": "the invoice changed while the credit note was issued, please retry"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to issue credit note"})
+		}
+		return
+	}
+
+	refunds := make([]models.Refund, len(note.Refunds))
+	copy(refunds, note.Refunds)
+	if err := makeRefunds(ctx, provider, refunds); err != nil {
+		unrecordedRefunds(refunds)
+		if cancelErr := creditNotes.Cancel(ctx, note, credit); cancelErr != nil {
+			log.Printf("credit note %s was not taken back after its refund failed: %v", note.CreditNoteID, cancelErr)
+		}
+		c.JSON(http.StatusBadGateway, gin.H{"error": "payment provider error: " + err.Error()})
+		return
+	}
+	note.Refunds = refunds
+	if err := creditNotes.RecordRefunds(ctx, note.CreditNoteID, note.Refunds); err != nil {
+		log.Printf("card refunds of credit note %s were made but not recorded: %v", note.CreditNoteID, err)
+	}
+
+	c.JSON(http.StatusCreated, note)
+}
+
+// findCreditable loads the invoice a credit note is issued against along with
+// what it totals and the credit notes already issued on it. It writes the
+// error response and returns false if there is none to credit.
+func findCreditable(ctx context.Context, c *gin.Context, invoices store.InvoiceStore, orderItems store.OrderItemStore, creditNotes store.CreditNoteStore) (models.Invoice, models.Money, []models.CreditNote, bool) {
+	invoice, err := invoices.Find(ctx, c.Param("invoice_id"))
+	if err != nil {
+		if err == store.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "invoice not found"})
+			return invoice, models.Money{}, nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch invoice"})
+		return invoice, models.Money{}, nil, false
+	}
+	if invoice.PaymentStatus == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "invoice has no payment status to credit"})
+		return invoice, models.Money{}, nil, false
+	}
+	if *invoice.PaymentStatus == models.PaymentVoid {
+		c.JSON(http.StatusConflict, gin.H{"error": "invoice is void"})
+		return invoice, models.Money{}, nil, false
+	}
+
+	total, err := invoiceTotal(ctx, orderItems, invoice)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch related order items"})
+		return invoice, models.Money{}, nil, false
+	}
+
+	notes, err := creditNotes.ListByInvoice(ctx, invoice.InvoiceID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch credit notes"})
+		return invoice, models.Money{}, nil, false
+	}
+	return invoice, total, notes, true
+}
+
+// creditedLines returns the order items already credited by notes.
+func creditedLines(notes []models.CreditNote) map[string]bool {
+	credited := map[string]bool{}
+	for _, note := range notes {
+		for _, line := range note.Lines {
+			credited[line.OrderItemID] = true
+		}
+	}
+	return credited
+}
+
+// POST /invoices/:invoice_id/refunds
+//
+// Refunds part or all of what was paid on a fully paid invoice. An invoice
+// still PENDING or PARTIALLY_PAID cannot be refunded: its balance is its total
+// less what was paid, so money given back would not be owed again. Such an
+// invoice is voided instead.
+func RefundInvoice(invoices store.InvoiceStore, orderItems store.OrderItemStore, payments store.PaymentStore, creditNotes store.CreditNoteStore, provider gateway.Provider) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var req RefundRequest
+		if err := c.BindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+		if err := validate.Struct(req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if len(req.Lines) > 0 && req.Amount != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "refund either lines or an amount, not both"})
+			return
+		}
+
+		invoice, total, notes, ok := findCreditable(ctx, c, invoices, orderItems, creditNotes)
+		if !ok {
+			return
+		}
+		if status := *invoice.PaymentStatus; status == models.PaymentPending || status == models.PaymentPartiallyPaid {
+			c.JSON(http.StatusConflict, gin.H{"error": "only fully paid invoices can be refunded, invoice is " + status + "; void it instead"})
+			return
+		}
+		paid, refunded := runningTotals(invoice, total)
+		refundable, _ := paid.Sub(refunded)
+		if refundable.Amount <= 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "nothing has been paid on this invoice that is not already refunded"})
+			return
+		}
+
+		note := models.CreditNote{Kind: models.CreditNoteRefund, Reason: req.Reason}
+		refund := refundable
+		switch {
+		case len(req.Lines) > 0:
+			index := make(map[string]models.InvoiceLine, len(invoice.Lines))
+			for _, line := range invoice.Lines {
+				index[line.OrderItemID] = line
+			}
+			credited := creditedLines(notes)
+
+			note.Amount = models.NewMoney(0, total.Currency)
+			for _, orderItemID := range req.Lines {
+				line, ok := index[orderItemID]
+				if !ok {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "order item " + orderItemID + " is not on this invoice"})
+					return
+				}
+				if credited[orderItemID] {
+					c.JSON(http.StatusConflict, gin.H{"error": "order item " + orderItemID + " has already been refunded"})
+					return
+				}
+				credited[orderItemID] = true
+				note.Lines = append(note.Lines, line)
+				note.Amount, _ = note.Amount.Add(lineCredit(line, invoice.ServiceChargeRate))
+			}
+			if note.Amount.Amount < refund.Amount {
+				refund = note.Amount
+			}
+		case req.Amount != nil:
+			if req.Amount.Amount <= 0 || req.Amount.Currency != total.Currency {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "amount must be positive and in the invoice currency " + total.Currency})
+				return
+			}
+			if req.Amount.Amount > refundable.Amount {
+				c.JSON(http.StatusConflict, gin.H{"error": "at most " + refundable.String() + " can be refunded"})
+				return
+			}
+			refund = *req.Amount
+			note.Amount = refund
+		default:
+			note.Amount = refundable
+		}
+
+		status := *invoice.PaymentStatus
+		if refund == refundable && paid == total {
+			status = models.PaymentRefunded
+		}
+
+		issueCreditNote(ctx, c, creditNotes, payments, provider, invoice, notes, note, refund, store.Credit{
+			PaidBefore:     paid,
+			RefundedBefore: refunded,
+			PaymentStatus:  status,
+		}, req.Method == models.TenderCash)
+	}
+}
+
+// POST /invoices/:invoice_id/void
+func VoidInvoice(invoices store.InvoiceStore, orderItems store.OrderItemStore, payments store.PaymentStore, creditNotes store.CreditNoteStore, provider gateway.Provider) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var req VoidRequest
+		if err := c.BindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+		if err := validate.Struct(req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		invoice, total, notes, ok := findCreditable(ctx, c, invoices, orderItems, creditNotes)
+		if !ok {
+			return
+		}
+		paid, refunded := runningTotals(invoice, total)
+		refund, _ := paid.Sub(refunded)
+
+		// The void credits whatever earlier refunds have not.
+		note := models.CreditNote{Kind: models.CreditNoteVoid, Reason: req.Reason, Amount: total}
+		credited := creditedLines(notes)
+		for _, line := range invoice.Lines {
+			if !credited[line.OrderItemID] {
+				note.Lines = append(note.Lines, line)
+			}
+		}
+		for _, earlier := range notes {
+			note.Amount, _ = note.Amount.Sub(earlier.Amount)
+		}
+		if note.Amount.IsNegative() {
+			note.Amount = models.NewMoney(0, total.Currency)
+		}
+
+		issueCreditNote(ctx, c, creditNotes, payments, provider, invoice, notes, note, refund, store.Credit{
+			PaidBefore:     paid,
+			RefundedBefore: refunded,
+			PaymentStatus:  models.PaymentVoid,
+		}, req.Method == models.TenderCash)
+	}
+}
+
+// GET /invoices/:invoice_id/credit-notes
+func GetCreditNotes(invoices store.InvoiceStore, creditNotes store.CreditNoteStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		invoiceID := c.Param("invoice_id")
+		if _, err := invoices.Find(ctx, invoiceID); err != nil {
+			if err == store.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "invoice not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch invoice"})
+			return
+		}
+
+		notes, err := creditNotes.ListByInvoice(ctx, invoiceID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch credit notes"})
+			return
+		}
+
+		c.JSON(http.StatusOK, notes)
+	}
+}
+
+// GET /invoices/:invoice_id/credit-notes/:credit_note_id
+func GetCreditNote(creditNotes store.CreditNoteStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		note, err := creditNotes.Find(ctx, c.Param("credit_note_id"))
+		if err == nil && note.InvoiceID != c.Param("invoice_id") {
+			err = store.ErrNotFound
+		}
+		if err != nil {
+			if err == store.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "credit note not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch credit note"})
+			return
+		}
+
+		c.JSON(http.StatusOK, note)
+	}
+}
@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"restaurant-management/database/memory"
+	"restaurant-management/gateway"
+	"restaurant-management/models"
+	"restaurant-management/store"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestRefundInvoiceOnlyWhenPaid(t *testing.T) {
+	tests := []struct {
+		name    string
+		paid    int64
+		refund  int
+		payment int
+	}{
+		{name: "partly paid", paid: 1000, refund: http.StatusConflict, payment: http.StatusCreated},
+		{name: "fully paid", paid: 2000, refund: http.StatusCreated, payment: http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			stores := memory.NewStores()
+			order := seedOrder(t, stores, models.OrderStatusServed)
+
+			status, method := models.PaymentPending, ""
+			total := models.NewMoney(2000, "USD")
+			invoice := models.Invoice{ID: primitive.NewObjectID(), OrderID: order.OrderID, PaymentMethod: &method, PaymentStatus: &status, GrandTotal: &total}
+			invoice.InvoiceID = invoice.ID.Hex()
+			if err := stores.Invoices.Insert(ctx, invoice, "INV"); err != nil {
+				t.Fatalf("Invoices.Insert: %v", err)
+			}
+
+			amount := models.NewMoney(tt.paid, "USD")
+			payment := models.Payment{ID: primitive.NewObjectID(), InvoiceID: invoice.InvoiceID, Tenders: []models.Tender{{Method: models.TenderCash, Amount: amount}},
+				Tendered: amount, Amount: amount, Change: models.NewMoney(0, "USD")}
+			payment.PaymentID = payment.ID.Hex()
+			settlement := store.Settlement{PaidBefore: models.NewMoney(0, "USD"), AmountPaid: amount, PaymentStatus: models.PaymentPartiallyPaid, PaymentMethod: models.TenderCash}
+			if tt.paid == total.Amount {
+				settlement.PaymentStatus = models.PaymentPaid
+			}
+			if err := stores.Payments.Insert(ctx, payment, settlement); err != nil {
+				t.Fatalf("Payments.Insert: %v", err)
+			}
+
+			provider := gateway.NewFake("secret")
+			refund := RefundInvoice(stores.Invoices, stores.OrderItems, stores.Payments, stores.CreditNotes, provider)
+			w := serve(refund, models.RoleManager, http.MethodPost, "/invoices/:invoice_id/refunds", "/invoices/"+invoice.InvoiceID+"/refunds",
+				`{"reason":"cold soup","amount":{"amount":500,"currency":"USD"}}`)
+			if w.Code != tt.refund {
+				t.Fatalf("refund: status %d, want %d: %s", w.Code, tt.refund, w.Body)
+			}
+
+			// Whatever was refunded, the customer owes the rest of the
+			// total and no more.
+			pay := CreatePayment(stores.Invoices, stores.OrderItems, stores.Payments, provider)
+			w = serve(pay, models.RoleCashier, http.MethodPost, "/invoices/:invoice_id/payments", "/invoices/"+invoice.InvoiceID+"/payments",
+				`{"tenders":[{"method":"CASH","amount":{"amount":5000,"currency":"USD"}}]}`)
+			if w.Code != tt.payment {
+				t.Fatalf("payment: status %d, want %d: %s", w.Code, tt.payment, w.Body)
+			}
+			stored, err := stores.Invoices.Find(ctx, invoice.InvoiceID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stored.AmountPaid.Amount != total.Amount {
+				t.Errorf("paid %v, want %v", stored.AmountPaid, total)
+			}
+		})
+	}
+}
@@ -3,25 +3,28 @@ package controllers
 import (
 	"context"
 	"net/http"
-	"restaurant-management/database"
 	"restaurant-management/models"
+	"restaurant-management/store"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-var foodCollection *mongo.Collection = database.OpenCollection(database.Client, "food")
-
 var validate = validator.New()
 
-// GET /foods
-func GetFoods() gin.HandlerFunc {
+// FoodAvailabilityRequest takes a food off ("86es" it) or puts it back on.
+type FoodAvailabilityRequest struct {
+	Available *bool `json:"available" validate:"required"`
+}
+
+// GET /foods?available=true&orderable=true
+//
+// available=true leaves out the foods that have been taken off; orderable=true
+// also leaves out the foods on menus not being served now.
+func GetFoods(foods store.FoodStore, menus store.MenuStore, location *time.Location) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
 		defer cancel()
@@ -43,64 +46,47 @@ func GetFoods() gin.HandlerFunc {
 			}
 		}
 
-		matchStage := bson.D{
-			{Key: "$match", Value: bson.D{}},
-		}
-
-		groupStage := bson.D{
-			{Key: "$group", Value: bson.D{
-				{Key: "_id", Value: nil},
-				{Key: "total_count", Value: bson.D{
-					{Key: "$sum", Value: 1},
-				}},
-				{Key: "data", Value: bson.D{
-					{Key: "$push", Value: "$$ROOT"},
-				}},
-			}},
+		var filter store.FoodFilter
+		if available := c.Query("available"); available != "" {
+			filter.Available, err = strconv.ParseBool(available)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "available must be true or false"})
+				return
+			}
 		}
-
-		projectStage := bson.D{
-			{Key: "$project", Value: bson.D{
-				{Key: "_id", Value: 0},
-				{Key: "total_count", Value: 1},
-				{Key: "food_items", Value: bson.D{
-					{Key: "$slice", Value: []interface{}{"$data", startIndex, recordPerPage}},
-				}},
-			}},
+		if orderable := c.Query("orderable"); orderable != "" {
+			orderableOnly, err := strconv.ParseBool(orderable)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "orderable must be true or false"})
+				return
+			}
+			if orderableOnly {
+				filter.Available = true
+				filter.MenuIDs, err = activeMenuIDs(ctx, menus, time.Now().In(location))
+				if err != nil {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "error fetching menu items"})
+					return
+				}
+			}
 		}
 
-		var results []bson.M
-		cursor, err := foodCollection.Aggregate(ctx, mongo.Pipeline{
-			matchStage, groupStage, projectStage,
-		})
+		totalCount, foodItems, err := foods.List(ctx, filter, startIndex, recordPerPage)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"error": "error fetching food items",
 			})
 			return
 		}
-		defer cursor.Close(ctx)
-
-		if err := cursor.All(ctx, &results); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "error decoding food items",
-			})
-			return
-		}
 
-		if len(results) > 0 {
-			c.JSON(http.StatusOK, results[0])
-		} else {
-			c.JSON(http.StatusOK, gin.H{
-				"total_count": 0,
-				"food_items":  []interface{}{},
-			})
-		}
+		c.JSON(http.StatusOK, gin.H{
+			"total_count": totalCount,
+			"food_items":  foodItems,
+		})
 	}
 }
 
 // GET /foods/:food_id
-func GetFood() gin.HandlerFunc {
+func GetFood(foods store.FoodStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
 		defer cancel()
@@ -111,11 +97,9 @@ func GetFood() gin.HandlerFunc {
 			return
 		}
 
-		var food models.Food
-
-		err := foodCollection.FindOne(ctx, bson.M{"food_id": foodID}).Decode(&food)
+		food, err := foods.Find(ctx, foodID)
 		if err != nil {
-			if err == mongo.ErrNoDocuments {
+			if err == store.ErrNotFound {
 				c.JSON(http.StatusNotFound, gin.H{
 					"error": "Food item not found",
 				})
@@ -132,13 +116,12 @@ func GetFood() gin.HandlerFunc {
 }
 
 // POST /foods
-func CreateFood() gin.HandlerFunc {
+func CreateFood(foods store.FoodStore, menus store.MenuStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
 		defer cancel()
 
 		var food models.Food
-		var menu models.Menu
 
 		if err := c.BindJSON(&food); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{
@@ -153,9 +136,31 @@ func CreateFood() gin.HandlerFunc {
 			return
 		}
 
-		err := menuCollection.FindOne(ctx, bson.M{"menu_id": food.MenuID}).Decode(&menu)
-		if err != nil {
-			if err == mongo.ErrNoDocuments {
+		if food.Price.IsNegative() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "price cannot be negative"})
+			return
+		}
+		if msg := checkModifierGroups(food.ModifierGroups, food.Price.Currency); msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+		if msg := checkSizePrices(food.SizePrices, food.Price.Currency); msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+		if food.ModifierGroups == nil {
+			food.ModifierGroups = []models.ModifierGroup{}
+		}
+		if food.SizePrices == nil {
+			food.SizePrices = []models.SizePrice{}
+		}
+
+		var menuID string
+		if food.MenuID != nil {
+			menuID = *food.MenuID
+		}
+		if _, err := menus.Find(ctx, menuID); err != nil {
+			if err == store.ErrNotFound {
 				c.JSON(http.StatusNotFound, gin.H{
 					"error": "menu not found",
 				})
@@ -167,15 +172,18 @@ func CreateFood() gin.HandlerFunc {
 			return
 		}
 
+		food.UnavailableReason = nil
+		if !food.IsAvailable() {
+			reason := models.FoodTakenOffByHand
+			food.UnavailableReason = &reason
+		}
+
 		food.ID = primitive.NewObjectID()
 		food.CreatedAt = time.Now().UTC()
 		food.UpdatedAt = time.Now().UTC()
 		food.FoodID = food.ID.Hex()
 
-		num := toFixed(*food.Price, 2)
-		food.Price = &num
-
-		result, insertErr := foodCollection.InsertOne(ctx, food)
+		insertErr := foods.Insert(ctx, food)
 		if insertErr != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"error": "Error inserting food item",
@@ -183,19 +191,18 @@ func CreateFood() gin.HandlerFunc {
 			return
 		}
 
-		c.JSON(http.StatusCreated, result)
+		c.JSON(http.StatusCreated, store.InsertResult{InsertedID: food.ID})
 	}
 }
 
 // PATCH /foods/:food_id
-func UpdateFood() gin.HandlerFunc {
+func UpdateFood(foods store.FoodStore, menus store.MenuStore) gin.HandlerFunc {
 
 	return func(c *gin.Context) {
 
 		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
 		defer cancel()
 
-		var menu models.Menu
 		var food models.Food
 
 		foodID := c.Param("food_id")
@@ -207,22 +214,76 @@ func UpdateFood() gin.HandlerFunc {
 			return
 		}
 
-		updateObj := bson.D{{Key: "updated_at", Value: time.Now().UTC()}}
+		if food.Station != nil {
+			if err := validate.Var(*food.Station, "oneof=grill bar cold"); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "station must be one of grill, bar or cold"})
+				return
+			}
+		}
 
-		if food.Name != nil {
-			updateObj = append(updateObj, bson.E{Key: "name", Value: *food.Name})
+		if food.Price != nil && food.Price.IsNegative() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "price cannot be negative"})
+			return
 		}
-		if food.Price != nil {
-			updateObj = append(updateObj, bson.E{Key: "price", Value: *food.Price})
+		if food.ModifierGroups != nil {
+			if err := validate.Var(food.ModifierGroups, "dive"); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
 		}
-		if food.FoodImage != nil {
-			updateObj = append(updateObj, bson.E{Key: "food_image", Value: *food.FoodImage})
+		if food.SizePrices != nil {
+			if err := validate.Var(food.SizePrices, "dive"); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
 		}
-		if food.MenuID != nil {
-			err := menuCollection.FindOne(ctx, bson.M{"menu_id": *food.MenuID}).Decode(&menu)
-
+		// Price adjustments and size prices must stay in the food's currency,
+		// so a new price is checked against those kept and new ones against
+		// the price.
+		if food.Price != nil || food.ModifierGroups != nil || food.SizePrices != nil {
+			existing, err := foods.Find(ctx, foodID)
 			if err != nil {
-				if err == mongo.ErrNoDocuments {
+				if err == store.ErrNotFound {
+					c.JSON(http.StatusNotFound, gin.H{"error": "Food item not found"})
+					return
+				}
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching the food item"})
+				return
+			}
+			price, groups, sizePrices := existing.Price, existing.ModifierGroups, existing.SizePrices
+			if food.Price != nil {
+				price = food.Price
+			}
+			if food.ModifierGroups != nil {
+				groups = food.ModifierGroups
+			}
+			if food.SizePrices != nil {
+				sizePrices = food.SizePrices
+			}
+			var currency string
+			if price != nil {
+				currency = price.Currency
+			}
+			if msg := checkModifierGroups(groups, currency); msg != "" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+				return
+			}
+			if msg := checkSizePrices(sizePrices, currency); msg != "" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+				return
+			}
+		}
+
+		if food.TaxCategory != nil {
+			if err := validate.Var(*food.TaxCategory, "oneof=standard reduced zero"); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "tax_category must be one of standard, reduced or zero"})
+				return
+			}
+		}
+
+		if food.MenuID != nil {
+			if _, err := menus.Find(ctx, *food.MenuID); err != nil {
+				if err == store.ErrNotFound {
 					c.JSON(http.StatusNotFound, gin.H{
 						"error": "Menu item not found",
 					})
@@ -233,28 +294,109 @@ func UpdateFood() gin.HandlerFunc {
 				})
 				return
 			}
+		}
 
-			updateObj = append(updateObj, bson.E{Key: "menu_id", Value: *food.MenuID})
+		result, err := foods.Update(ctx, foodID, food)
+		if err != nil {
+			if err == store.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Food item not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update food item: " + err.Error()})
+			return
 		}
 
-		upsert := true
-		opts := options.Update().SetUpsert(upsert)
+		c.JSON(http.StatusOK, result)
+	}
+
+}
+
+// PUT /foods/:food_id/availability
+//
+// Takes a food off or puts it back on by hand. A food taken off by hand stays
+// off until it is put back, whatever happens to its ingredients' stock.
+func SetFoodAvailability(foods store.FoodStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var request FoodAvailabilityRequest
 
-		filter := bson.M{"food_id": foodID}
+		if err := c.BindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+			return
+		}
 
-		result, err := foodCollection.UpdateOne(
-			ctx,
-			filter,
-			bson.D{{Key: "$set", Value: updateObj}},
-			opts,
-		)
+		if err := validate.Struct(request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+			return
+		}
+
+		foodID := c.Param("food_id")
+		if err := foods.SetAvailability(ctx, foodID, *request.Available, models.FoodTakenOffByHand); err != nil {
+			if err == store.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Food item not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update food availability"})
+			return
+		}
 
+		food, err := foods.Find(ctx, foodID)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update food item: " + err.Error()})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching the food item"})
 			return
 		}
 
-		c.JSON(http.StatusOK, result)
+		c.JSON(http.StatusOK, food)
 	}
+}
+
+// checkModifierGroups returns what is wrong with groups offered with a food
+// priced in currency, if anything. Group names, and option names within a
+// group, must be unique, a group cannot take more options than it has, and
+// price adjustments are in the food's currency.
+func checkModifierGroups(groups []models.ModifierGroup, currency string) string {
+	names := map[string]bool{}
+	for _, group := range groups {
+		if names[group.Name] {
+			return "modifier group " + group.Name + " is listed twice"
+		}
+		names[group.Name] = true
+		if group.MaxSelections > len(group.Options) {
+			return "modifier group " + group.Name + " has fewer options than max_selections"
+		}
+
+		options := map[string]bool{}
+		for _, option := range group.Options {
+			if options[option.Name] {
+				return "modifier group " + group.Name + " lists " + option.Name + " twice"
+			}
+			options[option.Name] = true
+			if option.PriceAdjustment != nil && option.PriceAdjustment.Currency != currency {
+				return "price adjustments must be in the food's currency"
+			}
+		}
+	}
+	return ""
+}
 
+// checkSizePrices returns what is wrong with the size prices of a food priced
+// in currency, if anything: each size is priced once, in the food's currency,
+// and not below zero.
+func checkSizePrices(sizePrices []models.SizePrice, currency string) string {
+	sizes := map[string]bool{}
+	for _, sizePrice := range sizePrices {
+		if sizes[sizePrice.Size] {
+			return "size " + sizePrice.Size + " is priced twice"
+		}
+		sizes[sizePrice.Size] = true
+		if sizePrice.Price.IsNegative() {
+			return "price cannot be negative"
+		}
+		if sizePrice.Price.Currency != currency {
+			return "size prices must be in the food's currency"
+		}
+	}
+	return ""
 }
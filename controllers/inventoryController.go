@@ -0,0 +1,544 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"restaurant-management/models"
+	"restaurant-management/store"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// StockMovementRequest records stock coming in or going out by hand. A
+// DELIVERY adds quantity and WASTE takes it away; an ADJUSTMENT corrects the
+// stock by a signed quantity, such as after a stocktake.
+type StockMovementRequest struct {
+	Reason   string          `json:"reason" validate:"required,oneof=DELIVERY WASTE ADJUSTMENT"`
+	Quantity models.Quantity `json:"quantity" validate:"required"`
+	// Unit is the unit quantity is given in, if not the ingredient's own.
+	Unit string  `json:"unit" validate:"omitempty,oneof=g kg ml l pc"`
+	Note *string `json:"note" validate:"omitempty,max=500"`
+}
+
+// GET /inventory/ingredients
+func GetIngredients(ingredients store.IngredientStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		allIngredients, err := ingredients.List(ctx)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occured while listing ingredients"})
+			return
+		}
+		c.JSON(http.StatusOK, allIngredients)
+	}
+}
+
+// GET /inventory/low-stock
+//
+// Lists the ingredients at or below their reorder level.
+func GetLowStock(ingredients store.IngredientStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		lowStock, err := ingredients.ListLowStock(ctx)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occured while listing low stock"})
+			return
+		}
+		c.JSON(http.StatusOK, lowStock)
+	}
+}
+
+// GET /inventory/ingredients/:ingredient_id
+func GetIngredient(ingredients store.IngredientStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		ingredient, err := ingredients.Find(ctx, c.Param("ingredient_id"))
+		if err != nil {
+			if err == store.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "ingredient not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occured while fetching the ingredient"})
+			return
+		}
+		c.JSON(http.StatusOK, ingredient)
+	}
+}
+
+// POST /inventory/ingredients
+//
+// New ingredients start out of stock; deliveries are recorded as movements.
+func CreateIngredient(ingredients store.IngredientStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var ingredient models.Ingredient
+
+		if err := c.BindJSON(&ingredient); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := validate.Struct(ingredient); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		ingredient.ID = primitive.NewObjectID()
+		ingredient.IngredientID = ingredient.ID.Hex()
+		ingredient.Stock = 0
+		ingredient.CreatedAt = time.Now().UTC()
+		ingredient.UpdatedAt = ingredient.CreatedAt
+
+		if err := ingredients.Insert(ctx, ingredient); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "ingredient was not created"})
+			return
+		}
+
+		c.JSON(http.StatusOK, store.InsertResult{InsertedID: ingredient.ID})
+	}
+}
+
+// PATCH /inventory/ingredients/:ingredient_id
+//
+// Only the name and reorder level can be changed.
+func UpdateIngredient(ingredients store.IngredientStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var ingredient models.Ingredient
+
+		if err := c.BindJSON(&ingredient); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if ingredient.Name != nil && (len(*ingredient.Name) < 2 || len(*ingredient.Name) > 100) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "name must be between 2 and 100 characters"})
+			return
+		}
+		if ingredient.ReorderLevel != nil && *ingredient.ReorderLevel < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "reorder_level cannot be negative"})
+			return
+		}
+
+		result, err := ingredients.Update(ctx, c.Param("ingredient_id"), ingredient)
+		if err != nil {
+			if err == store.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "ingredient not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "ingredient update failed"})
+			return
+		}
+
+		c.JSON(http.StatusOK, result)
+	}
+}
+
+// GET /inventory/ingredients/:ingredient_id/movements
+func GetStockMovements(ingredients store.IngredientStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		ingredientID := c.Param("ingredient_id")
+		if _, err := ingredients.Find(ctx, ingredientID); err != nil {
+			if err == store.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "ingredient not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occured while fetching the ingredient"})
+			return
+		}
+
+		movements, err := ingredients.Movements(ctx, ingredientID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occured while listing stock movements"})
+			return
+		}
+		c.JSON(http.StatusOK, movements)
+	}
+}
+
+// POST /inventory/ingredients/:ingredient_id/movements
+func RecordStockMovement(ingredients store.IngredientStore, recipes store.RecipeStore, foods store.FoodStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var request StockMovementRequest
+
+		if err := c.BindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+			return
+		}
+
+		if err := validate.Struct(request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+			return
+		}
+		if request.Reason != models.StockAdjustment && request.Quantity < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "quantity must be positive for a " + request.Reason})
+			return
+		}
+
+		ingredient, err := ingredients.Find(ctx, c.Param("ingredient_id"))
+		if err != nil {
+			if err == store.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "ingredient not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occured while fetching the ingredient"})
+			return
+		}
+
+		change := request.Quantity
+		if request.Unit != "" {
+			change, err = models.ConvertQuantity(request.Quantity, request.Unit, *ingredient.Unit)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		if request.Reason == models.StockWaste {
+			change = -change
+		}
+
+		movement := newStockMovement(ingredient.IngredientID, change, request.Reason, c.GetString("uid"))
+		movement.Note = request.Note
+
+		movements := []models.StockMovement{movement}
+		if err := ingredients.Move(ctx, movements); err != nil {
+			if err == store.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "ingredient not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to record stock movement"})
+			return
+		}
+		updateAvailability(ctx, foods, recipes, ingredients, movements)
+
+		c.JSON(http.StatusOK, movements[0])
+	}
+}
+
+// GET /foods/:food_id/recipe
+func GetRecipe(recipes store.RecipeStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		recipe, err := recipes.Find(ctx, c.Param("food_id"))
+		if err != nil {
+			if err == store.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "food has no recipe"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occured while fetching the recipe"})
+			return
+		}
+		c.JSON(http.StatusOK, recipe)
+	}
+}
+
+// PUT /foods/:food_id/recipe
+//
+// Replaces the recipe of the food. Quantities are for one portion and are
+// stored in the unit of their ingredient.
+func PutRecipe(recipes store.RecipeStore, foods store.FoodStore, ingredients store.IngredientStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var recipe models.Recipe
+
+		if err := c.BindJSON(&recipe); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := validate.Struct(recipe); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		recipe.FoodID = c.Param("food_id")
+		if _, err := foods.Find(ctx, recipe.FoodID); err != nil {
+			if err == store.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "food not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occured while fetching the food"})
+			return
+		}
+
+		seen := map[string]bool{}
+		for i, item := range recipe.Items {
+			if seen[item.IngredientID] {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "ingredient " + item.IngredientID + " is listed more than once"})
+				return
+			}
+			seen[item.IngredientID] = true
+
+			ingredient, err := ingredients.Find(ctx, item.IngredientID)
+			if err != nil {
+				if err == store.ErrNotFound {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "ingredient " + item.IngredientID + " not found"})
+					return
+				}
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "error occured while fetching the ingredient"})
+				return
+			}
+
+			if item.Unit != "" {
+				recipe.Items[i].Quantity, err = models.ConvertQuantity(item.Quantity, item.Unit, *ingredient.Unit)
+				if err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "ingredient " + item.IngredientID + ": " + err.Error()})
+					return
+				}
+			}
+			recipe.Items[i].Unit = *ingredient.Unit
+		}
+		if recipe.Items == nil {
+			recipe.Items = []models.RecipeItem{}
+		}
+		recipe.UpdatedAt = time.Now().UTC()
+
+		if err := recipes.Put(ctx, recipe); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "recipe was not saved"})
+			return
+		}
+		checkAvailability(ctx, foods, ingredients, recipe)
+
+		c.JSON(http.StatusOK, recipe)
+	}
+}
+
+func newStockMovement(ingredientID string, change models.Quantity, reason, recordedBy string) models.StockMovement {
+	movement := models.StockMovement{
+		ID:           primitive.NewObjectID(),
+		IngredientID: ingredientID,
+		Change:       change,
+		Reason:       reason,
+		RecordedBy:   recordedBy,
+		CreatedAt:    time.Now().UTC(),
+	}
+	movement.MovementID = movement.ID.Hex()
+	return movement
+}
+
+// reserveStock takes what each of items uses out of stock before the items
+// are stored, one portion of its food's recipe for every one of its quantity.
+// Foods without a recipe use nothing. If there is not enough stock for them
+// all nothing is taken, and the foods short of stock are returned with
+// store.ErrConflict. Otherwise the movements taken are returned, for
+// releaseStock to put back should the items not be stored after all.
+func reserveStock(ctx context.Context, foods store.FoodStore, recipes store.RecipeStore, ingredients store.IngredientStore, items []models.OrderItem, recordedBy string) ([]models.StockMovement, []string, error) {
+	var movements []models.StockMovement
+	for _, item := range items {
+		if item.FoodID == nil {
+			continue
+		}
+		recipe, err := recipes.Find(ctx, *item.FoodID)
+		if err != nil {
+			if err == store.ErrNotFound {
+				continue
+			}
+			return nil, nil, err
+		}
+		for _, use := range recipe.Items {
+			movement := newStockMovement(use.IngredientID, -use.Quantity*models.Quantity(item.Quantity), models.StockOrder, recordedBy)
+			movement.OrderID = &item.OrderID
+			movement.OrderItemID = &item.OrderItemID
+			movements = append(movements, movement)
+		}
+	}
+	if len(movements) == 0 {
+		return nil, nil, nil
+	}
+
+	if err := ingredients.Reserve(ctx, movements); err != nil {
+		if err == store.ErrConflict {
+			return nil, shortOfStock(ctx, ingredients, items, movements), err
+		}
+		return nil, nil, err
+	}
+	updateAvailability(ctx, foods, recipes, ingredients, movements)
+	return movements, nil, nil
+}
+
+// shortOfStock lists, once each, the foods of items that use an ingredient
+// movements would take below zero.
+func shortOfStock(ctx context.Context, ingredients store.IngredientStore, items []models.OrderItem, movements []models.StockMovement) []string {
+	needed := map[string]models.Quantity{}
+	for _, movement := range movements {
+		needed[movement.IngredientID] -= movement.Change
+	}
+	short := map[string]bool{}
+	for ingredientID, quantity := range needed {
+		ingredient, err := ingredients.Find(ctx, ingredientID)
+		short[ingredientID] = err != nil || ingredient.Stock < quantity
+	}
+
+	foodOf := make(map[string]string, len(items))
+	for _, item := range items {
+		if item.FoodID != nil {
+			foodOf[item.OrderItemID] = *item.FoodID
+		}
+	}
+	foodIDs := []string{}
+	listed := map[string]bool{}
+	for _, movement := range movements {
+		foodID := foodOf[*movement.OrderItemID]
+		if short[movement.IngredientID] && !listed[foodID] {
+			listed[foodID] = true
+			foodIDs = append(foodIDs, foodID)
+		}
+	}
+	return foodIDs
+}
+
+// releaseStock puts back the stock reserveStock took for items that were not
+// stored after all. Failures are only logged.
+func releaseStock(ctx context.Context, foods store.FoodStore, recipes store.RecipeStore, ingredients store.IngredientStore, reserved []models.StockMovement, recordedBy string) {
+	if len(reserved) == 0 {
+		return
+	}
+	restores := make([]models.StockMovement, len(reserved))
+	for i, movement := range reserved {
+		restores[i] = newStockMovement(movement.IngredientID, -movement.Change, models.StockRestore, recordedBy)
+		restores[i].OrderID = movement.OrderID
+		restores[i].OrderItemID = movement.OrderItemID
+	}
+
+	if err := ingredients.Move(ctx, restores); err != nil {
+		log.Printf("stock not released for order %s: %v", *reserved[0].OrderID, err)
+		return
+	}
+	updateAvailability(ctx, foods, recipes, ingredients, restores)
+}
+
+// restoreStock puts back what the items of orderID took out of stock and
+// has not already been put back, so it is safe to call more than once. Only
+// orderItemID's stock is put back unless it is empty.
+func restoreStock(ctx context.Context, foods store.FoodStore, recipes store.RecipeStore, ingredients store.IngredientStore, orderID, orderItemID, recordedBy string) {
+	movements, err := ingredients.MovementsByOrder(ctx, orderID)
+	if err != nil {
+		log.Printf("stock not restored for order %s: %v", orderID, err)
+		return
+	}
+
+	type use struct{ orderItemID, ingredientID string }
+	var uses []use
+	net := map[use]models.Quantity{}
+	for _, movement := range movements {
+		key := use{ingredientID: movement.IngredientID}
+		if movement.OrderItemID != nil {
+			key.orderItemID = *movement.OrderItemID
+		}
+		if orderItemID != "" && key.orderItemID != orderItemID {
+			continue
+		}
+		if _, ok := net[key]; !ok {
+			uses = append(uses, key)
+		}
+		net[key] += movement.Change
+	}
+
+	var restores []models.StockMovement
+	for _, key := range uses {
+		if net[key] >= 0 {
+			continue
+		}
+		movement := newStockMovement(key.ingredientID, -net[key], models.StockRestore, recordedBy)
+		movement.OrderID = &orderID
+		if key.orderItemID != "" {
+			orderItemID := key.orderItemID
+			movement.OrderItemID = &orderItemID
+		}
+		restores = append(restores, movement)
+	}
+	if len(restores) == 0 {
+		return
+	}
+
+	if err := ingredients.Move(ctx, restores); err != nil {
+		log.Printf("stock not restored for order %s: %v", orderID, err)
+		return
+	}
+	updateAvailability(ctx, foods, recipes, ingredients, restores)
+}
+
+// updateAvailability re-checks the availability of every food that uses an
+// ingredient whose stock movements changed.
+func updateAvailability(ctx context.Context, foods store.FoodStore, recipes store.RecipeStore, ingredients store.IngredientStore, movements []models.StockMovement) {
+	checked := map[string]bool{}
+	for _, movement := range movements {
+		using, err := recipes.ListByIngredient(ctx, movement.IngredientID)
+		if err != nil {
+			log.Printf("availability not updated for ingredient %s: %v", movement.IngredientID, err)
+			continue
+		}
+		for _, recipe := range using {
+			if !checked[recipe.FoodID] {
+				checked[recipe.FoodID] = true
+				checkAvailability(ctx, foods, ingredients, recipe)
+			}
+		}
+	}
+}
+
+// checkAvailability takes the food of recipe off when one of its ingredients
+// has run out, and puts it back once they are all in stock again if it was
+// taken off for want of stock. Foods taken off by hand are left alone.
+func checkAvailability(ctx context.Context, foods store.FoodStore, ingredients store.IngredientStore, recipe models.Recipe) {
+	food, err := foods.Find(ctx, recipe.FoodID)
+	if err != nil {
+		log.Printf("availability not updated for food %s: %v", recipe.FoodID, err)
+		return
+	}
+
+	available, err := inStock(ctx, ingredients, recipe)
+	if err != nil {
+		log.Printf("availability not updated for food %s: %v", food.FoodID, err)
+		return
+	}
+
+	outOfStock := food.UnavailableReason != nil && *food.UnavailableReason == models.FoodOutOfStock
+	switch {
+	case !available && food.IsAvailable():
+		err = foods.SetAvailability(ctx, food.FoodID, false, models.FoodOutOfStock)
+	case available && !food.IsAvailable() && outOfStock:
+		err = foods.SetAvailability(ctx, food.FoodID, true, "")
+	}
+	if err != nil {
+		log.Printf("availability not updated for food %s: %v", food.FoodID, err)
+	}
+}
+
+// inStock reports whether there is stock left of every ingredient recipe uses
+// for at least one portion.
+func inStock(ctx context.Context, ingredients store.IngredientStore, recipe models.Recipe) (bool, error) {
+	for _, item := range recipe.Items {
+		ingredient, err := ingredients.Find(ctx, item.IngredientID)
+		if err != nil {
+			return false, err
+		}
+		if ingredient.Stock < item.Quantity {
+			return false, nil
+		}
+	}
+	return true, nil
+}
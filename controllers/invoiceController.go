@@ -2,19 +2,21 @@ package controllers
 
 import (
 	"context"
+	"errors"
 	"net/http"
-	"restaurant-management/database"
 	"restaurant-management/models"
+	"restaurant-management/pricing"
+	"restaurant-management/store"
+	"sort"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type InvoiceViewFormat struct {
 	InvoiceID      string
+	InvoiceNumber  string
 	OrderID        string
 	PaymentMethod  *string
 	PaymentStatus  *string
@@ -22,48 +24,224 @@ type InvoiceViewFormat struct {
 	PaymentDue     interface{}
 	PaymentDueDate time.Time
 	OrderDetails   interface{}
+	Lines          []models.InvoiceLine
+	Subtotal       *models.Money
+	Taxes          []models.InvoiceTax
+	ServiceCharge  *models.Money
+	Rounding       *models.Money
+	Split          *models.InvoiceSplit
+	AmountPaid     *models.Money
+	AmountRefunded *models.Money
 }
 
-var invoiceCollection *mongo.Collection = database.OpenCollection(database.Client, "invoice")
+// InvoiceSplitRequest asks for an order's bill to be split into several
+// invoices: by listing which items go on each invoice, one invoice per seat,
+// or evenly between a number of ways.
+type InvoiceSplitRequest struct {
+	OrderID string     `json:"order_id" validate:"required"`
+	Mode    string     `json:"mode" validate:"required,oneof=items seats even"`
+	Ways    int        `json:"ways" validate:"omitempty,min=2,max=50"`
+	Items   [][]string `json:"items"`
+}
+
+var errNothingToInvoice = errors.New("order has no items to invoice")
+
+// priceInvoice prices the items of invoice.OrderID that have not been voided
+// onto invoice at the unit price each was ordered at, less the discount of the
+// order's voucher, taxed by its food's tax category.
+func priceInvoice(ctx context.Context, prices pricing.Config, orderItems store.OrderItemStore, foods store.FoodStore, vouchers store.VoucherStore, invoice *models.Invoice) error {
+	items, err := orderItems.ListByOrder(ctx, invoice.OrderID)
+	if err != nil {
+		return err
+	}
+	items = billableItems(items)
+	if len(items) == 0 {
+		return errNothingToInvoice
+	}
+
+	lines, err := orderLines(ctx, foods, items)
+	if err != nil {
+		return err
+	}
+	if invoice.VoucherCode, err = orderDiscount(ctx, vouchers, invoice.OrderID, lines); err != nil {
+		return err
+	}
+	return prices.Price(invoice, lines)
+}
+
+// orderLines turns order items into the unpriced invoice lines Price expects,
+// in the same order.
+func orderLines(ctx context.Context, foods store.FoodStore, items []models.OrderItem) ([]models.InvoiceLine, error) {
+	lines := make([]models.InvoiceLine, 0, len(items))
+	for _, item := range items {
+		line := models.InvoiceLine{OrderItemID: item.OrderItemID, Quantity: item.Quantity}
+		var food models.Food
+		if item.FoodID != nil {
+			line.FoodID = *item.FoodID
+			var err error
+			food, err = foods.Find(ctx, *item.FoodID)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if food.Name != nil {
+			line.FoodName = *food.Name
+		}
+		if food.TaxCategory != nil {
+			line.TaxCategory = *food.TaxCategory
+		}
+		switch {
+		case item.UnitPrice != nil:
+			line.UnitPrice = *item.UnitPrice
+		case food.Price != nil:
+			line.UnitPrice = *food.Price
+		default:
+			line.UnitPrice = models.NewMoney(0, "")
+		}
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+// billableItems lists the items of an order that have not been voided, in the
+// order ListByOrder returned them.
+func billableItems(items []models.OrderItem) []models.OrderItem {
+	billable := make([]models.OrderItem, 0, len(items))
+	for _, item := range items {
+		if !item.IsVoided() {
+			billable = append(billable, item)
+		}
+	}
+	return billable
+}
+
+// splitByItems groups lines as listed in groups, which must name every order
+// item exactly once.
+func splitByItems(lines []models.InvoiceLine, groups [][]string) ([][]models.InvoiceLine, error) {
+	if len(groups) < 2 {
+		return nil, errors.New("items must list at least two invoices")
+	}
+
+	index := make(map[string]int, len(lines))
+	for i, line := range lines {
+		index[line.OrderItemID] = i
+	}
+
+	billed := make(map[string]bool, len(lines))
+	split := make([][]models.InvoiceLine, 0, len(groups))
+	for _, group := range groups {
+		if len(group) == 0 {
+			return nil, errors.New("every invoice must have at least one item")
+		}
+		var part []models.InvoiceLine
+		for _, orderItemID := range group {
+			i, ok := index[orderItemID]
+			if !ok {
+				return nil, errors.New("order item " + orderItemID + " is not on this order")
+			}
+			if billed[orderItemID] {
+				return nil, errors.New("order item " + orderItemID + " is billed more than once")
+			}
+			billed[orderItemID] = true
+			part = append(part, lines[i])
+		}
+		split = append(split, part)
+	}
+
+	for _, line := range lines {
+		if !billed[line.OrderItemID] {
+			return nil, errors.New("order item " + line.OrderItemID + " is not billed")
+		}
+	}
+	return split, nil
+}
+
+// splitBySeats groups lines by the seat of their order item, lowest seat
+// first, and returns the seats alongside.
+func splitBySeats(lines []models.InvoiceLine, items []models.OrderItem) ([][]models.InvoiceLine, []int, error) {
+	bySeat := map[int][]models.InvoiceLine{}
+	for i, item := range items {
+		if item.Seat == nil {
+			return nil, nil, errors.New("order item " + item.OrderItemID + " has no seat")
+		}
+		bySeat[*item.Seat] = append(bySeat[*item.Seat], lines[i])
+	}
+	if len(bySeat) < 2 {
+		return nil, nil, errors.New("every item is on the same seat")
+	}
+
+	seats := make([]int, 0, len(bySeat))
+	for seat := range bySeat {
+		seats = append(seats, seat)
+	}
+	sort.Ints(seats)
+
+	split := make([][]models.InvoiceLine, len(seats))
+	for i, seat := range seats {
+		split[i] = bySeat[seat]
+	}
+	return split, seats, nil
+}
+
+// clearBill drops any bill fields a client sent; only priceInvoice sets them.
+func clearBill(invoice *models.Invoice) {
+	invoice.Lines = nil
+	invoice.Taxes = nil
+	invoice.VoucherCode = nil
+	invoice.Discount = nil
+	invoice.Subtotal = nil
+	invoice.TaxTotal = nil
+	invoice.ServiceChargeRate = nil
+	invoice.ServiceCharge = nil
+	invoice.Rounding = nil
+	invoice.GrandTotal = nil
+}
+
+// clearPayments drops any payment state a client sent with a new invoice; an
+// invoice starts out PENDING with nothing paid or refunded and no payment
+// method, and only recording payments, refunds and voids moves it on from
+// there.
+func clearPayments(invoice *models.Invoice) {
+	status, method := models.PaymentPending, ""
+	invoice.PaymentStatus = &status
+	invoice.PaymentMethod = &method
+	invoice.AmountPaid = nil
+	invoice.AmountRefunded = nil
+	invoice.PaymentProvider = nil
+	invoice.ProviderReference = nil
+	invoice.Split = nil
+	invoice.InvoiceNumber = ""
+}
 
 // GET /invoices
-func GetInvoices() gin.HandlerFunc {
+func GetInvoices(invoices store.InvoiceStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
 		defer cancel()
 
-		cursor, err := invoiceCollection.Find(ctx, bson.M{})
+		allInvoices, err := invoices.List(ctx)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"error": "Failed to fetch invoices: " + err.Error(),
 			})
 			return
 		}
-		defer cursor.Close(ctx)
 
-		var invoices []bson.M
-		if err := cursor.All(ctx, &invoices); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "Failed to decode invoices: " + err.Error(),
-			})
-			return
-		}
-
-		c.JSON(http.StatusOK, invoices)
+		c.JSON(http.StatusOK, allInvoices)
 	}
 }
 
 // GET /invoices/:invoice_id
-func GetInvoice() gin.HandlerFunc {
+func GetInvoice(invoices store.InvoiceStore, orderItems store.OrderItemStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
 		defer cancel()
 
 		invoiceID := c.Param("invoice_id")
 
-		var invoice models.Invoice
-		if err := invoiceCollection.FindOne(ctx, bson.M{"invoice_id": invoiceID}).Decode(&invoice); err != nil {
-			if err == mongo.ErrNoDocuments {
+		invoice, err := invoices.Find(ctx, invoiceID)
+		if err != nil {
+			if err == store.ErrNotFound {
 				c.JSON(http.StatusNotFound, gin.H{"message": "invoice item not found"})
 				return
 			}
@@ -71,44 +249,79 @@ func GetInvoice() gin.HandlerFunc {
 			return
 		}
 
-		allOrderItems, err := ItemsByOrder(invoice.OrderID)
+		allOrderItems, err := orderItems.ItemsByOrder(ctx, invoice.OrderID)
 		if err != nil || len(allOrderItems) == 0 {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch related order items"})
 			return
 		}
 
+		orderDetails := billedItems(invoice, allOrderItems[0].OrderItems)
+
 		invoiceView := InvoiceViewFormat{
 			InvoiceID:      invoice.InvoiceID,
+			InvoiceNumber:  invoice.InvoiceNumber,
 			OrderID:        invoice.OrderID,
 			PaymentDueDate: invoice.PaymentDueDate,
 			PaymentMethod:  invoice.PaymentMethod,
 			PaymentStatus:  invoice.PaymentStatus,
-			PaymentDue:     allOrderItems[0]["payment_due"],
-			TableNumber:    allOrderItems[0]["table_number"],
-			OrderDetails:   allOrderItems[0]["order_items"],
+			PaymentDue:     allOrderItems[0].PaymentDue,
+			TableNumber:    allOrderItems[0].TableNumber,
+			OrderDetails:   orderDetails,
+			Split:          invoice.Split,
+			AmountPaid:     invoice.AmountPaid,
+			AmountRefunded: invoice.AmountRefunded,
+			Lines:          invoice.Lines,
+			Subtotal:       invoice.Subtotal,
+			Taxes:          invoice.Taxes,
+			ServiceCharge:  invoice.ServiceCharge,
+			Rounding:       invoice.Rounding,
+		}
+		// Invoices created before pricing have no stored bill and fall back
+		// to the live sum of the order's food prices.
+		if invoice.GrandTotal != nil {
+			invoiceView.PaymentDue = *invoice.GrandTotal
 		}
 
 		c.JSON(http.StatusOK, invoiceView)
 	}
 }
 
+// billedItems narrows an order's items to those billed on invoice, which for
+// a split invoice is only some of them.
+func billedItems(invoice models.Invoice, items []store.OrderItemLine) []store.OrderItemLine {
+	if len(invoice.Lines) == 0 {
+		return items
+	}
+	billed := make(map[string]bool, len(invoice.Lines))
+	for _, line := range invoice.Lines {
+		billed[line.OrderItemID] = true
+	}
+	filtered := []store.OrderItemLine{}
+	for _, item := range items {
+		if billed[item.OrderItemID] {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered
+}
+
 // POST /invoices
-func CreateInvoice() gin.HandlerFunc {
+func CreateInvoice(invoices store.InvoiceStore, orders store.OrderStore, orderItems store.OrderItemStore, foods store.FoodStore, vouchers store.VoucherStore, prices pricing.Config, numbering models.InvoiceNumbering) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
 		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
 		defer cancel()
 
 		var invoice models.Invoice
-		var order models.Order
 
 		if err := c.BindJSON(&invoice); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Request body"})
 			return
 		}
 
-		if err := orderCollection.FindOne(ctx, bson.M{"order_id": invoice.OrderID}).Decode(&order); err != nil {
-			if err == mongo.ErrNoDocuments {
+		order, err := orders.Find(ctx, invoice.OrderID)
+		if err != nil {
+			if err == store.ErrNotFound {
 				c.JSON(http.StatusNotFound, gin.H{
 					"error": "order not found",
 				})
@@ -121,9 +334,22 @@ func CreateInvoice() gin.HandlerFunc {
 			return
 		}
 
-		status := "PENDING"
-		if invoice.PaymentStatus == nil {
-			invoice.PaymentStatus = &status
+		if order.CurrentStatus() != models.OrderStatusServed {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": "invoices can only be created for SERVED orders, order is " + order.CurrentStatus(),
+			})
+			return
+		}
+
+		clearPayments(&invoice)
+		clearBill(&invoice)
+		if err := priceInvoice(ctx, prices, orderItems, foods, vouchers, &invoice); err != nil {
+			if err == errNothingToInvoice || err == models.ErrCurrencyMismatch || err == pricing.ErrMinimumSpend {
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to price invoice"})
+			return
 		}
 
 		invoice.PaymentDueDate = time.Now().UTC().Add(24 * time.Hour)
@@ -138,21 +364,164 @@ func CreateInvoice() gin.HandlerFunc {
 			return
 		}
 
-		result, insertErr := invoiceCollection.InsertOne(ctx, invoice)
+		insertErr := invoices.Insert(ctx, invoice, numbering.Series(invoice.CreatedAt))
 
 		if insertErr != nil {
+			if insertErr == store.ErrConflict {
+				c.JSON(http.StatusConflict, gin.H{"error": "order is already invoiced"})
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"error": "failed to create invoice",
 			})
 			return
 		}
 
-		c.JSON(http.StatusCreated, result)
+		c.JSON(http.StatusCreated, store.InsertResult{InsertedID: invoice.ID})
+	}
+}
+
+// POST /invoices/split
+func SplitInvoice(invoices store.InvoiceStore, orders store.OrderStore, orderItems store.OrderItemStore, foods store.FoodStore, vouchers store.VoucherStore, prices pricing.Config, numbering models.InvoiceNumbering) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var req InvoiceSplitRequest
+		if err := c.BindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Request body"})
+			return
+		}
+		if err := validate.Struct(req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if req.Mode == models.SplitEvenly && req.Ways == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ways is required to split evenly"})
+			return
+		}
+
+		order, err := orders.Find(ctx, req.OrderID)
+		if err != nil {
+			if err == store.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while fetching the order"})
+			return
+		}
+		if order.CurrentStatus() != models.OrderStatusServed {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": "invoices can only be created for SERVED orders, order is " + order.CurrentStatus(),
+			})
+			return
+		}
+
+		items, err := orderItems.ListByOrder(ctx, req.OrderID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch order items"})
+			return
+		}
+		items = billableItems(items)
+		if len(items) == 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": errNothingToInvoice.Error()})
+			return
+		}
+		lines, err := orderLines(ctx, foods, items)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to price invoice"})
+			return
+		}
+
+		var whole models.Invoice
+		if whole.VoucherCode, err = orderDiscount(ctx, vouchers, req.OrderID, lines); err != nil {
+			if err == pricing.ErrMinimumSpend || err == models.ErrCurrencyMismatch {
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to price invoice"})
+			return
+		}
+		if err := prices.Price(&whole, lines); err != nil {
+			if err == models.ErrCurrencyMismatch {
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to price invoice"})
+			return
+		}
+
+		var parts []models.Invoice
+		var seats []int
+		switch req.Mode {
+		case models.SplitByItems, models.SplitBySeats:
+			var groups [][]models.InvoiceLine
+			if req.Mode == models.SplitByItems {
+				groups, err = splitByItems(lines, req.Items)
+			} else {
+				groups, seats, err = splitBySeats(lines, items)
+			}
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			if parts, err = prices.PriceParts(groups); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to price invoice"})
+				return
+			}
+		case models.SplitEvenly:
+			parts = prices.SplitEvenly(whole, req.Ways)
+		}
+
+		total := models.NewMoney(0, whole.GrandTotal.Currency)
+		for _, part := range parts {
+			total, _ = total.Add(*part.GrandTotal)
+		}
+		if total != *whole.GrandTotal {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "split invoices do not add up to the order total"})
+			return
+		}
+
+		now := time.Now().UTC()
+		insertedIDs := make([]interface{}, len(parts))
+		for i := range parts {
+			invoice := &parts[i]
+			clearPayments(invoice)
+			invoice.ID = primitive.NewObjectID()
+			invoice.InvoiceID = invoice.ID.Hex()
+			invoice.OrderID = req.OrderID
+			invoice.VoucherCode = whole.VoucherCode
+			invoice.PaymentDueDate = now.Add(24 * time.Hour)
+			invoice.CreatedAt = now
+			invoice.UpdatedAt = now
+			invoice.Split = &models.InvoiceSplit{Mode: req.Mode, Part: i + 1, Parts: len(parts)}
+			if seats != nil {
+				seat := seats[i]
+				invoice.Split.Seat = &seat
+			}
+
+			if err := validate.Struct(invoice); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			insertedIDs[i] = invoice.ID
+		}
+
+		if err := invoices.InsertMany(ctx, parts, numbering.Series(now)); err != nil {
+			if err == store.ErrConflict {
+				c.JSON(http.StatusConflict, gin.H{"error": "order is already invoiced"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create invoices"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, store.InsertManyResult{InsertedIDs: insertedIDs})
 	}
 }
 
 // PATCH /invoices/:invoice_id
-func UpdateInvoice() gin.HandlerFunc {
+func UpdateInvoice(invoices store.InvoiceStore, orders store.OrderStore, orderItems store.OrderItemStore, foods store.FoodStore, vouchers store.VoucherStore, prices pricing.Config) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
 		defer cancel()
@@ -167,49 +536,87 @@ func UpdateInvoice() gin.HandlerFunc {
 			return
 		}
 
-		updateObj := bson.D{{Key: "updated_at", Value: time.Now().UTC()}}
+		// The payment status follows the payments, refunds and voids
+		// recorded against the invoice and cannot be set directly.
+		if invoice.PaymentStatus != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "payment_status is set by recording payments, refunds and voids"})
+			return
+		}
+
+		existing, err := invoices.Find(ctx, invoiceID)
+		if err != nil {
+			if err == store.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "invoice not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch invoice"})
+			return
+		}
+		if existing.AmountPaid != nil && existing.AmountPaid.Amount != 0 || existing.PaymentStatus != nil && *existing.PaymentStatus == models.PaymentVoid {
+			c.JSON(http.StatusConflict, gin.H{"error": "invoices that have been paid or voided cannot be changed; issue a refund or void instead"})
+			return
+		}
 
 		if invoice.OrderID != "" {
-			var order models.Order
-			if err := orderCollection.FindOne(ctx, bson.M{"order_id": invoice.OrderID}).Decode(&order); err != nil {
-				if err == mongo.ErrNoDocuments {
+			if existing.Split != nil {
+				c.JSON(http.StatusConflict, gin.H{"error": "a split invoice cannot be moved to another order"})
+				return
+			}
+			if invoice.OrderID != existing.OrderID {
+				billed, err := invoices.ListByOrder(ctx, invoice.OrderID)
+				if err != nil {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch invoices"})
+					return
+				}
+				for _, other := range billed {
+					if other.PaymentStatus == nil || *other.PaymentStatus != models.PaymentVoid {
+						c.JSON(http.StatusConflict, gin.H{"error": "order is already invoiced"})
+						return
+					}
+				}
+			}
+
+			order, err := orders.Find(ctx, invoice.OrderID)
+			if err != nil {
+				if err == store.ErrNotFound {
 					c.JSON(http.StatusNotFound, gin.H{"error": "order item not found"})
 					return
 				}
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while fetching the order item"})
 				return
 			}
-			updateObj = append(updateObj, bson.E{Key: "order_id", Value: invoice.OrderID})
-		}
-
-		if invoice.PaymentMethod != nil {
-			updateObj = append(updateObj, bson.E{Key: "payment_method", Value: *invoice.PaymentMethod})
-		}
-		if invoice.PaymentStatus != nil {
-			updateObj = append(updateObj, bson.E{Key: "payment_status", Value: *invoice.PaymentStatus})
-		}
-		if !invoice.PaymentDueDate.IsZero() {
-			updateObj = append(updateObj, bson.E{Key: "payment_due_date", Value: invoice.PaymentDueDate})
+			if order.CurrentStatus() != models.OrderStatusServed {
+				c.JSON(http.StatusConflict, gin.H{"error": "invoices can only be moved to SERVED orders, order is " + order.CurrentStatus()})
+				return
+			}
 		}
 
-		filter := bson.M{"invoice_id": invoiceID}
-		existing := invoiceCollection.FindOne(ctx, filter)
-		if existing.Err() == mongo.ErrNoDocuments {
-			c.JSON(http.StatusNotFound, gin.H{"error": "invoice not found"})
-			return
-		} else if existing.Err() != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch invoice"})
-			return
+		// Moving an invoice to another order reprices it for that order;
+		// otherwise the bill it was created with stays as it is.
+		clearBill(&invoice)
+		if invoice.OrderID != "" {
+			if err := priceInvoice(ctx, prices, orderItems, foods, vouchers, &invoice); err != nil {
+				if err == errNothingToInvoice || err == models.ErrCurrencyMismatch || err == pricing.ErrMinimumSpend {
+					c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+					return
+				}
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to price invoice"})
+				return
+			}
 		}
 
-		_, err := invoiceCollection.UpdateOne(ctx, filter, bson.M{"$set": updateObj})
+		_, err = invoices.Update(ctx, invoiceID, invoice)
 		if err != nil {
+			if err == store.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "invoice not found"})
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update invoice: " + err.Error()})
 			return
 		}
 
-		var updatedInvoice models.Invoice
-		if err := invoiceCollection.FindOne(ctx, filter).Decode(&updatedInvoice); err != nil {
+		updatedInvoice, err := invoices.Find(ctx, invoiceID)
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch updated invoice"})
 			return
 		}
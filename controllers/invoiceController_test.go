@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"restaurant-management/database/memory"
+	"restaurant-management/models"
+	"restaurant-management/pricing"
+	"testing"
+)
+
+func TestNewInvoicesStartUnpaid(t *testing.T) {
+	tests := []struct {
+		name     string
+		invoices int
+		body     func(orderID string) string
+	}{
+		{name: "whole bill", invoices: 1, body: func(orderID string) string {
+			return `{"order_id":"` + orderID + `","payment_method":"CARD","payment_status":"PAID","amount_paid":{"amount":2400}}`
+		}},
+		{name: "split evenly", invoices: 2, body: func(orderID string) string {
+			return `{"order_id":"` + orderID + `","mode":"even","ways":2,"payment_method":"CARD"}`
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			stores := memory.NewStores()
+			order := seedOrder(t, stores, models.OrderStatusServed)
+			food := seedFood(t, stores)
+			item := models.OrderItem{Quantity: 2, UnitPrice: food.Price, ListPrice: food.Price, FoodID: &food.FoodID, OrderID: order.OrderID}
+			if err := stores.OrderItems.InsertMany(ctx, []models.OrderItem{item}); err != nil {
+				t.Fatalf("OrderItems.InsertMany: %v", err)
+			}
+
+			handler, route := CreateInvoice, "/invoices"
+			if tt.invoices > 1 {
+				handler, route = SplitInvoice, "/invoices/split"
+			}
+			w := serve(handler(stores.Invoices, stores.Orders, stores.OrderItems, stores.Foods, stores.Vouchers, pricing.DefaultConfig(), models.DefaultInvoiceNumbering()),
+				models.RoleCashier, http.MethodPost, route, route, tt.body(order.OrderID))
+			if w.Code != http.StatusCreated {
+				t.Fatalf("status %d: %s", w.Code, w.Body)
+			}
+
+			invoices, err := stores.Invoices.List(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(invoices) != tt.invoices {
+				t.Fatalf("%d invoices stored, want %d", len(invoices), tt.invoices)
+			}
+			for _, invoice := range invoices {
+				if *invoice.PaymentMethod != "" || *invoice.PaymentStatus != models.PaymentPending || invoice.AmountPaid != nil {
+					t.Errorf("invoice %s starts %s by %q with %v paid, want PENDING with no method and nothing paid",
+						invoice.InvoiceID, *invoice.PaymentStatus, *invoice.PaymentMethod, invoice.AmountPaid)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"context"
+	"io"
+	"log"
+	"restaurant-management/kitchen"
+	"restaurant-management/models"
+	"restaurant-management/printer"
+	"restaurant-management/store"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GET /kitchen/stream?station=grill
+func StreamKitchen(hub *kitchen.Hub) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		station := c.Query("station")
+
+		events := hub.Subscribe()
+		defer hub.Unsubscribe(events)
+
+		heartbeat := time.NewTicker(15 * time.Second)
+		defer heartbeat.Stop()
+
+		c.Header("Cache-Control", "no-cache")
+		c.Header("X-Accel-Buffering", "no")
+
+		c.Stream(func(w io.Writer) bool {
+			select {
+			case <-c.Request.Context().Done():
+				return false
+			case <-heartbeat.C:
+				c.SSEvent("ping", time.Now().UTC())
+				return true
+			case event, ok := <-events:
+				if !ok {
+					return false
+				}
+				if ticket, ok := event.Ticket.ForStation(station); ok {
+					c.SSEvent(event.Name, ticket)
+				}
+				return true
+			}
+		})
+	}
+}
+
+// orderTicket builds the ticket header for order: its status and the number
+// of the table it was placed at.
+func orderTicket(ctx context.Context, tables store.TableStore, order models.Order) kitchen.Ticket {
+	ticket := kitchen.Ticket{
+		OrderID: order.OrderID,
+		Status:  order.CurrentStatus(),
+	}
+
+	if order.TableID != nil {
+		if table, err := tables.Find(ctx, *order.TableID); err == nil {
+			ticket.TableNumber = table.TableNumber
+		}
+	}
+	return ticket
+}
+
+// kitchenTicket builds the ticket for items just added to or voided on order,
+// listing each with the food name and station it is prepared at and the
+// modifiers and notes it was ordered with.
+func kitchenTicket(ctx context.Context, foods store.FoodStore, tables store.TableStore, order models.Order, items []models.OrderItem) kitchen.Ticket {
+	ticket := orderTicket(ctx, tables, order)
+
+	for _, item := range items {
+		ticketItem := kitchen.TicketItem{
+			OrderItemID: item.OrderItemID,
+			FoodID:      item.FoodID,
+			Quantity:    item.Quantity,
+			Size:        item.Size,
+			Notes:       item.Notes,
+			Voided:      item.IsVoided(),
+		}
+		for _, modifier := range item.Modifiers {
+			ticketItem.Modifiers = append(ticketItem.Modifiers, modifier.Group+": "+modifier.Option)
+		}
+		if item.FoodID != nil {
+			if food, err := foods.Find(ctx, *item.FoodID); err == nil {
+				ticketItem.FoodName = food.Name
+				if food.Station != nil {
+					ticketItem.Station = *food.Station
+				}
+			}
+		}
+		ticket.Items = append(ticket.Items, ticketItem)
+	}
+
+	return ticket
+}
+
+// printTicket prints ticket on the kitchen printers without holding up the
+// request that created it. Printer problems are logged.
+func printTicket(printers printer.Printers, ticket kitchen.Ticket) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		if err := printers.PrintTicket(ctx, ticket, time.Now()); err != nil {
+			log.Printf("kitchen ticket for order %s: %v", ticket.OrderID, err)
+		}
+	}()
+}
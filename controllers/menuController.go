@@ -3,27 +3,35 @@ package controllers
 import (
 	"context"
 	"net/http"
-	"restaurant-management/database"
 	"restaurant-management/models"
+	"restaurant-management/store"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-var menuCollection *mongo.Collection = database.OpenCollection(database.Client, "menu")
-
-// GET /menus
-func GetMenus() gin.HandlerFunc {
+// GET /menus?active=true
+//
+// With active=true only the menus being served now are listed, judged in
+// location, the time zone menu schedules are kept in.
+func GetMenus(menus store.MenuStore, location *time.Location) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
 		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
 		defer cancel()
 
-		cursor, err := menuCollection.Find(ctx, bson.M{})
+		var activeOnly bool
+		if active := c.Query("active"); active != "" {
+			var err error
+			if activeOnly, err = strconv.ParseBool(active); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "active must be true or false"})
+				return
+			}
+		}
+
+		allMenus, err := menus.List(ctx)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"error": "error fetching menu items",
@@ -31,33 +39,32 @@ func GetMenus() gin.HandlerFunc {
 			return
 		}
 
-		defer cursor.Close(ctx)
-
-		var menus []bson.M
-
-		if err = cursor.All(ctx, &menus); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "error decoding menu items",
-			})
-			return
+		if activeOnly {
+			now := time.Now().In(location)
+			activeMenus := []models.Menu{}
+			for _, menu := range allMenus {
+				if menu.ActiveAt(now) {
+					activeMenus = append(activeMenus, menu)
+				}
+			}
+			allMenus = activeMenus
 		}
 
-		c.JSON(http.StatusOK, menus)
+		c.JSON(http.StatusOK, allMenus)
 	}
 }
 
 // GET /menus/:menu_id
-func GetMenu() gin.HandlerFunc {
+func GetMenu(menus store.MenuStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
 		defer cancel()
 
-		var menu models.Menu
 		menuID := c.Param("menu_id")
 
-		err := menuCollection.FindOne(ctx, bson.M{"menu_id": menuID}).Decode(&menu)
+		menu, err := menus.Find(ctx, menuID)
 		if err != nil {
-			if err == mongo.ErrNoDocuments {
+			if err == store.ErrNotFound {
 				c.JSON(http.StatusNotFound, gin.H{
 					"error": "menu not found",
 				})
@@ -74,7 +81,7 @@ func GetMenu() gin.HandlerFunc {
 }
 
 // POST /menus
-func CreateMenu() gin.HandlerFunc {
+func CreateMenu(menus store.MenuStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
 		defer cancel()
@@ -96,12 +103,17 @@ func CreateMenu() gin.HandlerFunc {
 			return
 		}
 
+		if !validMenuDates(menu.StartDate, menu.EndDate) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "end_date must be after start_date"})
+			return
+		}
+
 		menu.ID = primitive.NewObjectID()
 		menu.MenuID = menu.ID.Hex()
 		menu.CreatedAt = time.Now().UTC()
 		menu.UpdatedAt = time.Now().UTC()
 
-		result, insertErr := menuCollection.InsertOne(ctx, menu)
+		insertErr := menus.Insert(ctx, menu)
 		if insertErr != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"error": "Error inserting menu",
@@ -109,12 +121,12 @@ func CreateMenu() gin.HandlerFunc {
 			return
 		}
 
-		c.JSON(http.StatusCreated, result)
+		c.JSON(http.StatusCreated, store.InsertResult{InsertedID: menu.ID})
 	}
 }
 
 // PATCH /menus/:menu_id
-func UpdateMenu() gin.HandlerFunc {
+func UpdateMenu(menus store.MenuStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
 		defer cancel()
@@ -127,43 +139,41 @@ func UpdateMenu() gin.HandlerFunc {
 		}
 
 		menuID := c.Param("menu_id")
-		filter := bson.M{"menu_id": menuID}
-
-		if menu.StartDate != nil && menu.EndDate != nil {
-			if !inTimeSpan(*menu.StartDate, *menu.EndDate, time.Now()) {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid time span, please re-enter the dates"})
-				return
-			}
-		}
 
-		updateObj := bson.D{
-			{Key: "updated_at", Value: time.Now().UTC()},
+		if err := validate.Var(menu.Schedule, "dive"); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid schedule: " + err.Error()})
+			return
 		}
 
-		if menu.StartDate != nil {
-			updateObj = append(updateObj, bson.E{Key: "start_date", Value: *menu.StartDate})
-		}
-		if menu.EndDate != nil {
-			updateObj = append(updateObj, bson.E{Key: "end_date", Value: *menu.EndDate})
-		}
-		if menu.Name != "" {
-			updateObj = append(updateObj, bson.E{Key: "name", Value: menu.Name})
-		}
-		if menu.Category != "" {
-			updateObj = append(updateObj, bson.E{Key: "category", Value: menu.Category})
+		if menu.StartDate != nil || menu.EndDate != nil {
+			existing, err := menus.Find(ctx, menuID)
+			if err != nil {
+				if err == store.ErrNotFound {
+					c.JSON(http.StatusNotFound, gin.H{"error": "menu not found"})
+					return
+				}
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching the menu item"})
+				return
+			}
+			start, end := existing.StartDate, existing.EndDate
+			if menu.StartDate != nil {
+				start = menu.StartDate
+			}
+			if menu.EndDate != nil {
+				end = menu.EndDate
+			}
+			if !validMenuDates(start, end) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid time span: end_date must be after start_date"})
+				return
+			}
 		}
 
-		upsert := true
-		opts := options.Update().SetUpsert(upsert)
-
-		result, err := menuCollection.UpdateOne(
-			ctx,
-			filter,
-			bson.D{{Key: "$set", Value: updateObj}},
-			opts,
-		)
-
+		result, err := menus.Update(ctx, menuID, menu)
 		if err != nil {
+			if err == store.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "menu not found"})
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update menu: " + err.Error()})
 			return
 		}
@@ -171,3 +181,25 @@ func UpdateMenu() gin.HandlerFunc {
 		c.JSON(http.StatusOK, result)
 	}
 }
+
+// validMenuDates reports whether a menu served from start until end is
+// served at all. Either may be open.
+func validMenuDates(start, end *time.Time) bool {
+	return start == nil || end == nil || end.After(*start)
+}
+
+// activeMenuIDs lists the menus being served at now.
+func activeMenuIDs(ctx context.Context, menus store.MenuStore, now time.Time) ([]string, error) {
+	allMenus, err := menus.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	menuIDs := []string{}
+	for _, menu := range allMenus {
+		if menu.ActiveAt(now) {
+			menuIDs = append(menuIDs, menu.MenuID)
+		}
+	}
+	return menuIDs, nil
+}
@@ -3,47 +3,38 @@ package controllers
 import (
 	"context"
 	"net/http"
-	"restaurant-management/database"
+	"restaurant-management/kitchen"
 	"restaurant-management/models"
+	"restaurant-management/store"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
-var orderCollection = database.OpenCollection(database.Client, "order")
+type OrderTransitionRequest struct {
+	Status string `json:"status" validate:"required,oneof=PLACED IN_KITCHEN READY SERVED CLOSED CANCELLED VOIDED"`
+}
 
 // GET  /orders
-func GetOrders() gin.HandlerFunc {
+func GetOrders(orders store.OrderStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
 		defer cancel()
 
-		cursor, err := orderCollection.Find(ctx, bson.M{})
-
+		allOrders, err := orders.List(ctx)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "error fetching order items"})
 			return
 		}
-		defer cursor.Close(ctx)
 
-		var orders []bson.M
-
-		err = cursor.All(ctx, &orders)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "error decoding order items"})
-			return
-		}
-
-		c.JSON(http.StatusOK, gin.H{"error": ""})
+		c.JSON(http.StatusOK, allOrders)
 
 	}
 }
 
 // GET  /orders/:order_id
-func GetOrder() gin.HandlerFunc {
+func GetOrder(orders store.OrderStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
 		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
@@ -55,12 +46,10 @@ func GetOrder() gin.HandlerFunc {
 			return
 		}
 
-		var order models.Order
-
-		err := orderCollection.FindOne(ctx, bson.M{"order_id": orderID}).Decode(&order)
+		order, err := orders.Find(ctx, orderID)
 
 		if err != nil {
-			if err == mongo.ErrNoDocuments {
+			if err == store.ErrNotFound {
 				c.JSON(http.StatusNotFound, gin.H{
 					"error": "Order item not found",
 				})
@@ -78,14 +67,13 @@ func GetOrder() gin.HandlerFunc {
 }
 
 // POST /orders
-func CreateOrder() gin.HandlerFunc {
+func CreateOrder(orders store.OrderStore, tables store.TableStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
 		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
 		defer cancel()
 
 		var order models.Order
-		var table models.Table
 		if err := c.BindJSON(&order); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{
 				"error": "Invalid request: " + err.Error(),
@@ -100,10 +88,10 @@ func CreateOrder() gin.HandlerFunc {
 			return
 		}
 
-		err := tableCollection.FindOne(ctx, bson.M{"table_id": order.TableID}).Decode(&table)
+		_, err := tables.Find(ctx, *order.TableID)
 
 		if err != nil {
-			if err == mongo.ErrNoDocuments {
+			if err == store.ErrNotFound {
 				c.JSON(http.StatusNotFound, gin.H{
 					"error": "table not found",
 				})
@@ -115,12 +103,15 @@ func CreateOrder() gin.HandlerFunc {
 			return
 		}
 
+		status := models.OrderStatusPlaced
 		order.ID = primitive.NewObjectID()
 		order.OrderID = order.ID.Hex()
 		order.CreatedAt = time.Now().UTC()
 		order.UpdatedAt = time.Now().UTC()
+		order.Status = &status
+		order.StatusHistory = nil
 
-		result, err := orderCollection.InsertOne(ctx, order)
+		err = orders.Insert(ctx, order)
 
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
@@ -129,19 +120,18 @@ func CreateOrder() gin.HandlerFunc {
 			return
 		}
 
-		c.JSON(http.StatusOK, result)
+		c.JSON(http.StatusOK, store.InsertResult{InsertedID: order.ID})
 
 	}
 }
 
 // PATCH orders/:order_id"
-func UpdateOrder() gin.HandlerFunc {
+func UpdateOrder(orders store.OrderStore, tables store.TableStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
 		defer cancel()
 
 		var order models.Order
-		var table models.Table
 
 		orderID := c.Param("order_id")
 
@@ -152,17 +142,11 @@ func UpdateOrder() gin.HandlerFunc {
 			return
 		}
 
-		updateObj := bson.D{{Key: "updated_at", Value: time.Now().UTC()}}
-
-		if !order.OrderDate.IsZero() {
-			updateObj = append(updateObj, bson.E{Key: "order_date", Value: order.OrderDate})
-		}
-
 		if order.TableID != nil {
-			err := tableCollection.FindOne(ctx, bson.M{"table_id": *order.TableID}).Decode(&table)
+			_, err := tables.Find(ctx, *order.TableID)
 
 			if err != nil {
-				if err == mongo.ErrNoDocuments {
+				if err == store.ErrNotFound {
 					c.JSON(http.StatusNotFound, gin.H{
 						"error": "table not found",
 					})
@@ -173,15 +157,14 @@ func UpdateOrder() gin.HandlerFunc {
 				})
 				return
 			}
-			updateObj = append(updateObj, bson.E{Key: "table_id", Value: order.TableID})
-
 		}
 
-		result, err := orderCollection.UpdateOne(
-			ctx,
-			bson.M{"order_id": orderID},
-			bson.D{{Key: "$set", Value: updateObj}})
+		result, err := orders.Update(ctx, orderID, order)
 		if err != nil {
+			if err == store.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Order item not found"})
+				return
+			}
 
 			c.JSON(
 				http.StatusInternalServerError,
@@ -193,16 +176,116 @@ func UpdateOrder() gin.HandlerFunc {
 		c.JSON(http.StatusOK, result)
 	}
 }
-func OrderItemOrderCreator(order models.Order) (string, error) {
+
+// POST /orders/:order_id/transitions
+//
+// Every role can reach the route, but each status can only be set by the
+// roles that own that step (models.RoleCanTransitionOrderTo): voiding needs
+// management, as voiding an item the kitchen has does.
+//
+// Cancelling or voiding an order releases its voucher. Only cancelling puts
+// the stock its items used back: an order can only be cancelled while it is
+// still PLACED, and once the kitchen has had it the stock may already have
+// been used, as with voided items.
+func TransitionOrder(orders store.OrderStore, tables store.TableStore, foods store.FoodStore, recipes store.RecipeStore, ingredients store.IngredientStore, vouchers store.VoucherStore, hub *kitchen.Hub) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var request OrderTransitionRequest
+
+		orderID := c.Param("order_id")
+
+		if err := c.BindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid request: " + err.Error(),
+			})
+			return
+		}
+
+		if err := validate.Struct(request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid request: " + err.Error(),
+			})
+			return
+		}
+
+		order, err := orders.Find(ctx, orderID)
+		if err != nil {
+			if err == store.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Order item not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching the order item"})
+			return
+		}
+
+		if !order.CanTransitionTo(request.Status) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": "cannot move order from " + order.CurrentStatus() + " to " + request.Status,
+			})
+			return
+		}
+
+		if !models.RoleCanTransitionOrderTo(c.GetString("role"), request.Status) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "your role is not allowed to move an order to " + request.Status})
+			return
+		}
+
+		transition := models.OrderTransition{
+			From:      order.CurrentStatus(),
+			To:        request.Status,
+			ChangedBy: c.GetString("uid"),
+			ChangedAt: time.Now().UTC(),
+		}
+
+		if err := orders.Transition(ctx, orderID, transition); err != nil {
+			if err == store.ErrConflict {
+				c.JSON(http.StatusConflict, gin.H{"error": "order status changed concurrently, please retry"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update order status: " + err.Error()})
+			return
+		}
+
+		if request.Status == models.OrderStatusCancelled && transition.From == models.OrderStatusPlaced {
+			restoreStock(ctx, foods, recipes, ingredients, orderID, "", transition.ChangedBy)
+		}
+		if request.Status == models.OrderStatusCancelled || request.Status == models.OrderStatusVoided {
+			releaseVoucher(ctx, vouchers, orderID)
+		}
+
+		order, err = orders.Find(ctx, orderID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching the order item"})
+			return
+		}
+
+		hub.Publish(kitchen.Event{
+			Name:   kitchen.EventOrderStatus,
+			Ticket: orderTicket(ctx, tables, order),
+		})
+
+		c.JSON(http.StatusOK, order)
+	}
+}
+
+// OrderItemOrderCreator stores order as a new PLACED order and returns its
+// ID, giving it one unless the caller already has.
+func OrderItemOrderCreator(orders store.OrderStore, order models.Order) (string, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
+	status := models.OrderStatusPlaced
 	order.CreatedAt = time.Now().UTC()
 	order.UpdatedAt = time.Now().UTC()
-	order.ID = primitive.NewObjectID()
+	if order.ID.IsZero() {
+		order.ID = primitive.NewObjectID()
+	}
 	order.OrderID = order.ID.Hex()
+	order.Status = &status
 
-	_, err := orderCollection.InsertOne(ctx, order)
+	err := orders.Insert(ctx, order)
 	if err != nil {
 		return "", err
 	}
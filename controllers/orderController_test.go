@@ -0,0 +1,128 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"restaurant-management/database/memory"
+	"restaurant-management/kitchen"
+	"restaurant-management/models"
+	"restaurant-management/store"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// serve sends a request to handler, mounted at route, as a user with role,
+// and returns the response.
+func serve(handler gin.HandlerFunc, role, method, route, path, body string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Handle(method, route, func(c *gin.Context) {
+		c.Set("uid", "u1")
+		c.Set("role", role)
+	}, handler)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+	return w
+}
+
+// seedTable stores a table for four.
+func seedTable(t *testing.T, stores store.Stores) models.Table {
+	t.Helper()
+	now := time.Now().UTC()
+
+	guests, number := 4, 7
+	table := models.Table{ID: primitive.NewObjectID(), NumberOfGuests: &guests, TableNumber: &number, CreatedAt: now, UpdatedAt: now}
+	table.TableID = table.ID.Hex()
+	if err := stores.Tables.Insert(context.Background(), table); err != nil {
+		t.Fatalf("Tables.Insert: %v", err)
+	}
+	return table
+}
+
+// seedOrder stores a table and an order for it at status.
+func seedOrder(t *testing.T, stores store.Stores, status string) models.Order {
+	t.Helper()
+	ctx := context.Background()
+	now := time.Now().UTC()
+	table := seedTable(t, stores)
+
+	order := models.Order{ID: primitive.NewObjectID(), OrderDate: now, CreatedAt: now, UpdatedAt: now, TableID: &table.TableID, Status: &status}
+	order.OrderID = order.ID.Hex()
+	if err := stores.Orders.Insert(ctx, order); err != nil {
+		t.Fatalf("Orders.Insert: %v", err)
+	}
+	return order
+}
+
+// seedRedemption applies a new voucher, which can be redeemed once, to order.
+func seedRedemption(t *testing.T, stores store.Stores, order models.Order) models.Voucher {
+	t.Helper()
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	limit := 1
+	amount := models.NewMoney(500, "USD")
+	voucher := models.Voucher{ID: primitive.NewObjectID(), Code: "FIVEOFF", Kind: models.VoucherFixed, Amount: &amount, MaxRedemptions: &limit, CreatedAt: now, UpdatedAt: now}
+	voucher.VoucherID = voucher.ID.Hex()
+	if err := stores.Vouchers.Insert(ctx, voucher); err != nil {
+		t.Fatalf("Vouchers.Insert: %v", err)
+	}
+
+	redemption := models.VoucherRedemption{ID: primitive.NewObjectID(), VoucherID: voucher.VoucherID, Code: voucher.Code, OrderID: order.OrderID, RedeemedBy: "u1", RedeemedAt: now}
+	redemption.RedemptionID = redemption.ID.Hex()
+	if err := stores.Vouchers.Redeem(ctx, redemption); err != nil {
+		t.Fatalf("Vouchers.Redeem: %v", err)
+	}
+	return voucher
+}
+
+func TestTransitionOrderReleasesVoucher(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to string
+		role     string
+		code     int
+		released bool
+	}{
+		{name: "voided by a manager", from: models.OrderStatusServed, to: models.OrderStatusVoided, role: models.RoleManager, code: http.StatusOK, released: true},
+		{name: "cancelled by a waiter", from: models.OrderStatusPlaced, to: models.OrderStatusCancelled, role: models.RoleWaiter, code: http.StatusOK, released: true},
+		{name: "void refused to a waiter", from: models.OrderStatusServed, to: models.OrderStatusVoided, role: models.RoleWaiter, code: http.StatusForbidden},
+		{name: "closed", from: models.OrderStatusServed, to: models.OrderStatusClosed, role: models.RoleCashier, code: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			stores := memory.NewStores()
+			order := seedOrder(t, stores, tt.from)
+			voucher := seedRedemption(t, stores, order)
+
+			handler := TransitionOrder(stores.Orders, stores.Tables, stores.Foods, stores.Recipes, stores.Ingredients, stores.Vouchers, kitchen.NewHub())
+			w := serve(handler, tt.role, http.MethodPost, "/orders/:order_id/transitions", "/orders/"+order.OrderID+"/transitions", `{"status":"`+tt.to+`"}`)
+			if w.Code != tt.code {
+				t.Fatalf("status %d, want %d: %s", w.Code, tt.code, w.Body)
+			}
+
+			_, err := stores.Vouchers.RedemptionByOrder(ctx, order.OrderID)
+			if released := err == store.ErrNotFound; released != tt.released {
+				t.Errorf("redemption released = %v, want %v (err %v)", released, tt.released, err)
+			}
+			stored, err := stores.Vouchers.Find(ctx, voucher.VoucherID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := 1
+			if tt.released {
+				want = 0
+			}
+			if stored.Redemptions != want {
+				t.Errorf("Redemptions = %d, want %d", stored.Redemptions, want)
+			}
+		})
+	}
+}
@@ -2,61 +2,70 @@ package controllers
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"net/http"
-	"restaurant-management/database"
+	"restaurant-management/kitchen"
 	"restaurant-management/models"
+	"restaurant-management/printer"
+	"restaurant-management/store"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type OrderItemPack struct {
 	TableID    *string
-	OrderItems []models.OrderItem
+	OrderItems []models.OrderItem `validate:"required,min=1"`
 }
 
+// OrderItemsRequest lists the items to add to an open order.
+type OrderItemsRequest struct {
+	OrderItems []models.OrderItem `json:"order_items" validate:"required,min=1"`
+}
+
+// OrderItemUpdateRequest moves an item to another seat or changes its notes.
+// What was ordered is never changed in place: the item is voided and a new
+// one added instead, so the kitchen and the bill both see the change.
+type OrderItemUpdateRequest struct {
+	Seat  *int    `json:"seat" validate:"omitempty,min=1"`
+	Notes *string `json:"notes" validate:"omitempty,max=200"`
+}
 
-var orderItemCollection *mongo.Collection = database.OpenCollection(database.Client, "orderItem")
+// VoidItemRequest says why an item is being voided.
+type VoidItemRequest struct {
+	Reason string  `json:"reason" validate:"required,oneof=ENTERED_IN_ERROR CUSTOMER_CHANGED_MIND QUALITY OUT_OF_STOCK OTHER"`
+	Note   *string `json:"note" validate:"omitempty,max=200"`
+}
 
 // GET /orderItems
-func GetOrderItems() gin.HandlerFunc {
+func GetOrderItems(orderItems store.OrderItemStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
 		defer cancel()
 
-		cursor, err := orderItemCollection.Find(ctx, bson.M{})
+		allOrderItems, err := orderItems.List(ctx)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"error": "error fetching orderitems",
 			})
-		}
-		defer cursor.Close(ctx)
-
-		var orderItems []models.OrderItem
-
-		if err = cursor.All(ctx, &orderItems); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "error decoding orderitems",
-			})
 			return
 		}
 
-		c.JSON(http.StatusOK, orderItems)
+		c.JSON(http.StatusOK, allOrderItems)
 
 	}
 }
 
 // GET /orderItems-order/:order_id
-func GetOrderItemsByOrder() gin.HandlerFunc {
+func GetOrderItemsByOrder(orderItems store.OrderItemStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
 
 		orderID := c.Param("order_id")
-		allOrderItems, err := ItemsByOrder(orderID)
+		allOrderItems, err := orderItems.ItemsByOrder(ctx, orderID)
 
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occured while listing order items by order ID"})
@@ -67,134 +76,18 @@ func GetOrderItemsByOrder() gin.HandlerFunc {
 	}
 }
 
-func ItemsByOrder(id string) (OrderItems []primitive.M, err error) {
-
-	c, cancel := context.WithTimeout(context.Background(), 100*time.Second)
-	defer cancel()
-
-	matchStage := bson.D{{Key: "$match", Value: bson.D{{Key: "order_id", Value: id}}}}
-
-	lookupStage := bson.D{{
-		Key: "$lookup", Value: bson.D{
-			{Key: "from", Value: "food"},
-			{Key: "localField", Value: "food_id"},
-			{Key: "foreignField", Value: "food_id"},
-			{Key: "as", Value: "food"},
-		},
-	}}
-
-	unwindStage := bson.D{
-		{
-			Key: "$unwind", Value: bson.D{
-				{Key: "path", Value: "$food"},
-				{Key: "preserveNullAndEmptyArrays", Value: true},
-			},
-		},
-	}
-
-	lookupOrderStage := bson.D{{
-		Key: "$lookup", Value: bson.D{
-			{Key: "from", Value: "order"},
-			{Key: "localField", Value: "order_id"},
-			{Key: "foreignField", Value: "order_id"},
-			{Key: "as", Value: "order"},
-		}},
-	}
-
-	unwindOrderStage := bson.D{{
-		Key: "$unwind", Value: bson.D{
-			{Key: "path", Value: "$order"},
-			{Key: "preserveNullAndEmptyArrays", Value: true},
-		},
-	}}
-
-	lookupTableStage := bson.D{{Key: "$lookup", Value: bson.D{
-		{Key: "from", Value: "table"},
-		{Key: "localField", Value: "order.table_id"},
-		{Key: "foreignField", Value: "table_id"},
-		{Key: "as", Value: "table"},
-	}}}
-	unwindTableStage := bson.D{{Key: "$unwind", Value: bson.D{
-		{Key: "path", Value: "$table"},
-		{Key: "preserveNullAndEmptyArrays", Value: true},
-	}}}
-
-	projectStage := bson.D{{Key: "$project", Value: bson.D{
-		{Key: "id", Value: 0},
-		{Key: "amount", Value: "$food.price"},
-		{Key: "total_count", Value: 1},
-		{Key: "food_name", Value: "$food.name"},
-		{Key: "food_image", Value: "$food.food_image"},
-		{Key: "table_number", Value: "$table.table_number"},
-		{Key: "table_id", Value: "$table.table_id"},
-		{Key: "order_id", Value: "$order.order_id"},
-		{Key: "price", Value: "$food.price"},
-		{Key: "quantity", Value: 1},
-	}}}
-
-	groupStage := bson.D{{Key: "$group", Value: bson.D{
-		{Key: "_id", Value: bson.D{
-			{Key: "order_id", Value: "$order_id"},
-			{Key: "table_id", Value: "$table_id"},
-			{Key: "table_number", Value: "$table_number"},
-		}},
-		{Key: "payment_due", Value: bson.D{{Key: "$sum", Value: "$amount"}}},
-		{Key: "total_count", Value: bson.D{{Key: "$sum", Value: 1}}},
-		{Key: "order_items", Value: bson.D{{Key: "$push", Value: "$$ROOT"}}},
-	}}}
-
-	projectStage2 := bson.D{{Key: "$project", Value: bson.D{
-		{Key: "id", Value: 0},
-		{Key: "payment_due", Value: 1},
-		{Key: `total_count`, Value: 1},
-		{Key: "table_number", Value: "$_id.table_number"},
-		{Key: "order_items", Value: 1},
-	}}}
-
-	result, err := orderItemCollection.Aggregate(c, mongo.Pipeline{
-		matchStage,
-		lookupStage,
-		unwindStage,
-		lookupOrderStage,
-		unwindOrderStage,
-		lookupTableStage,
-		unwindTableStage,
-		projectStage,
-		groupStage,
-		projectStage2,
-	})
-
-	if err = result.All(c, &OrderItems); err != nil {
-		panic(err)
-	}
-
-	if err != nil {
-		panic(err)
-	}
-
-	if err = result.All(c, &OrderItems); err != nil {
-		panic(err)
-	}
-
-	defer cancel()
-
-	return OrderItems, err
-
-}
-
 // GET /orderItems/:orderItem_id
-func GetOrderItem() gin.HandlerFunc {
+func GetOrderItem(orderItems store.OrderItemStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
 		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
 		defer cancel()
 
 		OrderItemID := c.Param("orderItem_id")
-		var OrderItem models.OrderItem
-		err := orderItemCollection.FindOne(ctx, bson.M{"orderItem_id": OrderItemID}).Decode(&OrderItem)
+		OrderItem, err := orderItems.Find(ctx, OrderItemID)
 
 		if err != nil {
-			if err == mongo.ErrNoDocuments {
+			if err == store.ErrNotFound {
 				c.JSON(http.StatusNotFound, gin.H{
 					"error": "orderItem not found",
 				})
@@ -212,7 +105,14 @@ func GetOrderItem() gin.HandlerFunc {
 }
 
 // POST /orderItems
-func CreateOrderItem() gin.HandlerFunc {
+//
+// Every food ordered must be available and on a menu being served now, judged
+// in location, the time zone menu schedules are kept in. Items are charged the
+// food's price in the size ordered under the price rules in force then, plus
+// the price adjustments of the modifiers chosen for them; any unit_price sent
+// is ignored. What the items use is taken out of stock before they are stored,
+// and the order is turned away if there is not enough.
+func CreateOrderItem(orderItems store.OrderItemStore, orders store.OrderStore, foods store.FoodStore, menus store.MenuStore, tables store.TableStore, recipes store.RecipeStore, ingredients store.IngredientStore, priceRules store.PriceRuleStore, hub *kitchen.Hub, printers printer.Printers, location *time.Location) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 		defer cancel()
@@ -223,92 +123,502 @@ func CreateOrderItem() gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
+		if err := validate.Struct(orderItemPack); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+			return
+		}
+
+		items, status, body := orderableItems(ctx, foods, menus, priceRules, orderItemPack.OrderItems, time.Now().In(location))
+		if body != nil {
+			c.JSON(status, body)
+			return
+		}
+
+		// The items are checked against the order's ID before the order is
+		// stored, so a request turned away leaves no empty order behind.
+		order.ID = primitive.NewObjectID()
+		orderItemsToBeInserted, err := newOrderItems(order.ID.Hex(), items)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		reserved, ok := reserveOrderStock(ctx, c, foods, recipes, ingredients, orderItemsToBeInserted)
+		if !ok {
+			return
+		}
 
 		order.OrderDate, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 
-		orderItemsToBeInserted := []interface{}{}
 		order.TableID = orderItemPack.TableID
-		order_id, _ := OrderItemOrderCreator(order)
-
-		for _, orderItem := range orderItemPack.OrderItems {
-			orderItem.OrderID = order_id
+		order_id, err := OrderItemOrderCreator(orders, order)
+		if err != nil {
+			releaseStock(ctx, foods, recipes, ingredients, reserved, c.GetString("uid"))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error inserting order"})
+			return
+		}
 
-			validationErr := validate.Struct(orderItem)
+		err = orderItems.InsertMany(ctx, orderItemsToBeInserted)
 
-			if validationErr != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
-				return
+		if err != nil {
+			releaseStock(ctx, foods, recipes, ingredients, reserved, c.GetString("uid"))
+			if deleteErr := orders.Delete(ctx, order_id); deleteErr != nil {
+				log.Printf("order %s left without items: %v", order_id, deleteErr)
 			}
-			orderItem.ID = primitive.NewObjectID()
-			orderItem.CreatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
-			orderItem.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
-			orderItem.OrderItemID = orderItem.ID.Hex()
-			var num = toFixed(*orderItem.UnitPrice, 2)
-			orderItem.UnitPrice = &num
-			orderItemsToBeInserted = append(orderItemsToBeInserted, orderItem)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error inserting order items"})
+			return
 		}
 
-		insertedOrderItems, err := orderItemCollection.InsertMany(ctx, orderItemsToBeInserted)
+		order.OrderID = order_id
+		sendToKitchen(ctx, foods, tables, hub, printers, order, orderItemsToBeInserted)
 
-		if err != nil {
-			log.Fatal(err)
+		insertedIDs := []interface{}{}
+		for _, orderItem := range orderItemsToBeInserted {
+			insertedIDs = append(insertedIDs, orderItem.ID)
 		}
-		defer cancel()
 
-		c.JSON(http.StatusOK, insertedOrderItems)
+		c.JSON(http.StatusOK, store.InsertManyResult{InsertedIDs: insertedIDs})
 	}
 }
-// PATCH /orderItems/:orderItem_id
-func UpdateOrderItem() gin.HandlerFunc {
+
+// POST /orders/:order_id/items
+//
+// Adds items to an order that is still open, on the same terms as
+// POST /orderItems, and sends them to the kitchen.
+func AddOrderItems(orderItems store.OrderItemStore, orders store.OrderStore, invoices store.InvoiceStore, foods store.FoodStore, menus store.MenuStore, tables store.TableStore, recipes store.RecipeStore, ingredients store.IngredientStore, priceRules store.PriceRuleStore, hub *kitchen.Hub, printers printer.Printers, location *time.Location) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
 		defer cancel()
 
-		var orderItem models.OrderItem
+		var request OrderItemsRequest
+		if err := c.BindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+			return
+		}
+		if err := validate.Struct(request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+			return
+		}
+
+		orderID := c.Param("order_id")
+		if status, msg := openOrderCheck(ctx, orders, invoices, orderID); msg != "" {
+			c.JSON(status, gin.H{"error": msg})
+			return
+		}
+
+		items, status, body := orderableItems(ctx, foods, menus, priceRules, request.OrderItems, time.Now().In(location))
+		if body != nil {
+			c.JSON(status, body)
+			return
+		}
+		items, err := newOrderItems(orderID, items)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		reserved, ok := reserveOrderStock(ctx, c, foods, recipes, ingredients, items)
+		if !ok {
+			return
+		}
 
-		orderItemId := c.Param("order_item_id")
+		if err := orderItems.InsertMany(ctx, items); err != nil {
+			releaseStock(ctx, foods, recipes, ingredients, reserved, c.GetString("uid"))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error inserting order items"})
+			return
+		}
 
-		filter := bson.M{"order_item_id": orderItemId}
+		order, err := orders.Find(ctx, orderID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while fetching the order"})
+			return
+		}
+		sendToKitchen(ctx, foods, tables, hub, printers, order, items)
 
-		var updateObj primitive.D
+		c.JSON(http.StatusCreated, items)
+	}
+}
 
-		if orderItem.UnitPrice != nil {
-			updateObj = append(updateObj, bson.E{Key: "unit_price", Value: orderItem.UnitPrice})
+// orderableItems checks that the foods of items can be ordered at now, in
+// quantities validation allows, and prices them, returning the items ready to
+// be given IDs, or the status and body to turn the request away with.
+func orderableItems(ctx context.Context, foods store.FoodStore, menus store.MenuStore, priceRules store.PriceRuleStore, items []models.OrderItem, now time.Time) ([]models.OrderItem, int, gin.H) {
+	for _, item := range items {
+		if err := validate.StructPartial(item, "Quantity"); err != nil {
+			return nil, http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()}
 		}
+	}
 
-		if orderItem.Quantity != nil {
-			updateObj = append(updateObj, bson.E{Key: "quantity", Value: *orderItem.Quantity})
+	unavailable, offMenu, err := unorderableFoods(ctx, foods, menus, items, now)
+	if err != nil {
+		return nil, http.StatusInternalServerError, gin.H{"error": "error occured while fetching the food items"}
+	}
+	if len(unavailable) > 0 {
+		return nil, http.StatusConflict, gin.H{
+			"error":    "some of the foods ordered are not available",
+			"food_ids": unavailable,
+		}
+	}
+	if len(offMenu) > 0 {
+		return nil, http.StatusConflict, gin.H{
+			"error":    "some of the foods ordered are not on a menu being served now",
+			"food_ids": offMenu,
 		}
+	}
 
-		if orderItem.FoodID != nil {
-			updateObj = append(updateObj, bson.E{Key: "food_id", Value: *orderItem.FoodID})
+	msg, err := chooseModifiers(ctx, foods, items)
+	if err != nil {
+		return nil, http.StatusInternalServerError, gin.H{"error": "error occured while fetching the food items"}
+	}
+	if msg != "" {
+		return nil, http.StatusBadRequest, gin.H{"error": msg}
+	}
+
+	items, err = priceOrderItems(ctx, foods, priceRules, items, now)
+	if err != nil {
+		if err == store.ErrNotFound {
+			return nil, http.StatusBadRequest, gin.H{"error": "some of the foods ordered do not exist"}
 		}
+		if err == models.ErrSizeNotOffered {
+			return nil, http.StatusBadRequest, gin.H{"error": "some of the foods ordered do not come in the size asked for"}
+		}
+		return nil, http.StatusInternalServerError, gin.H{"error": "error occured while pricing the order items"}
+	}
+	return items, 0, nil
+}
 
+// newOrderItems puts items on orderID, validates them and gives them their
+// IDs. Items are never ordered already voided.
+func newOrderItems(orderID string, items []models.OrderItem) ([]models.OrderItem, error) {
+	created := make([]models.OrderItem, 0, len(items))
+	for _, orderItem := range items {
+		orderItem.OrderID = orderID
+		orderItem.Void = nil
+
+		if err := validate.Struct(orderItem); err != nil {
+			return nil, err
+		}
+		orderItem.ID = primitive.NewObjectID()
+		orderItem.CreatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 		orderItem.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
-		updateObj = append(updateObj, bson.E{Key: "updated_at", Value: orderItem.UpdatedAt})
+		orderItem.OrderItemID = orderItem.ID.Hex()
+		created = append(created, orderItem)
+	}
+	return created, nil
+}
 
-		upsert := true
-		opt := options.UpdateOptions{
-			Upsert: &upsert,
+// reserveOrderStock takes what items use out of stock before they are
+// stored. It writes the error response and returns false if there is not
+// enough stock for them.
+func reserveOrderStock(ctx context.Context, c *gin.Context, foods store.FoodStore, recipes store.RecipeStore, ingredients store.IngredientStore, items []models.OrderItem) ([]models.StockMovement, bool) {
+	reserved, short, err := reserveStock(ctx, foods, recipes, ingredients, items, c.GetString("uid"))
+	if err != nil {
+		if err == store.ErrConflict {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":    "there is not enough stock for some of the foods ordered",
+				"food_ids": short,
+			})
+			return nil, false
 		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error occured while reserving stock"})
+		return nil, false
+	}
+	return reserved, true
+}
+
+// sendToKitchen sends the kitchen the ticket for items just added to order.
+func sendToKitchen(ctx context.Context, foods store.FoodStore, tables store.TableStore, hub *kitchen.Hub, printers printer.Printers, order models.Order, items []models.OrderItem) {
+	ticket := kitchenTicket(ctx, foods, tables, order, items)
+	hub.Publish(kitchen.Event{
+		Name:   kitchen.EventOrderItems,
+		Ticket: ticket,
+	})
+	printTicket(printers, ticket)
+}
 
-		result, err := orderItemCollection.UpdateOne(
-			ctx,
-			filter,
-			bson.D{
-				{Key: "$set", Value: updateObj},
-			},
-			&opt,
-		)
+// unorderableFoods lists, once each, the foods of items that have been taken
+// off and those on a menu not being served at now. Foods and menus that do
+// not exist are left for validation to report.
+func unorderableFoods(ctx context.Context, foods store.FoodStore, menus store.MenuStore, items []models.OrderItem, now time.Time) (unavailable, offMenu []string, err error) {
+	unavailable, offMenu = []string{}, []string{}
+	seen := map[string]bool{}
+	active := map[string]bool{}
+	for _, item := range items {
+		if item.FoodID == nil || seen[*item.FoodID] {
+			continue
+		}
+		seen[*item.FoodID] = true
 
+		food, err := foods.Find(ctx, *item.FoodID)
 		if err != nil {
+			if err == store.ErrNotFound {
+				continue
+			}
+			return nil, nil, err
+		}
+		if !food.IsAvailable() {
+			unavailable = append(unavailable, food.FoodID)
+		}
+
+		if food.MenuID == nil {
+			continue
+		}
+		served, ok := active[*food.MenuID]
+		if !ok {
+			menu, err := menus.Find(ctx, *food.MenuID)
+			if err != nil {
+				if err == store.ErrNotFound {
+					continue
+				}
+				return nil, nil, err
+			}
+			served = menu.ActiveAt(now)
+			active[*food.MenuID] = served
+		}
+		if !served {
+			offMenu = append(offMenu, food.FoodID)
+		}
+	}
+	return unavailable, offMenu, nil
+}
+
+// chooseModifiers checks the modifiers of every item against the modifier
+// groups of its food, taking the price adjustment of each option chosen from
+// the food, and returns what is wrong with them, if anything. Foods that do
+// not exist are left for validation to report.
+func chooseModifiers(ctx context.Context, foods store.FoodStore, items []models.OrderItem) (string, error) {
+	found := map[string]models.Food{}
+	for i, item := range items {
+		if item.FoodID == nil {
+			continue
+		}
+		food, ok := found[*item.FoodID]
+		if !ok {
+			var err error
+			if food, err = foods.Find(ctx, *item.FoodID); err != nil {
+				if err == store.ErrNotFound {
+					continue
+				}
+				return "", err
+			}
+			found[food.FoodID] = food
+		}
+		name := food.FoodID
+		if food.Name != nil {
+			name = *food.Name
+		}
+
+		chosen := map[string]int{}
+		seen := map[[2]string]bool{}
+		modifiers := make([]models.OrderItemModifier, 0, len(item.Modifiers))
+		for _, modifier := range item.Modifiers {
+			group, ok := food.ModifierGroup(modifier.Group)
+			if !ok {
+				return name + " has no modifier group " + modifier.Group, nil
+			}
+			option, ok := group.Option(modifier.Option)
+			if !ok {
+				return group.Name + " of " + name + " has no option " + modifier.Option, nil
+			}
+			if seen[[2]string{group.Name, option.Name}] {
+				return option.Name + " is chosen twice for " + name, nil
+			}
+			seen[[2]string{group.Name, option.Name}] = true
+			chosen[group.Name]++
+			modifiers = append(modifiers, models.OrderItemModifier{
+				Group:           group.Name,
+				Option:          option.Name,
+				PriceAdjustment: option.PriceAdjustment,
+			})
+		}
+		for _, group := range food.ModifierGroups {
+			if n := chosen[group.Name]; n < group.MinSelections || n > group.MaxSelections {
+				return fmt.Sprintf("%s takes between %d and %d of %s", name, group.MinSelections, group.MaxSelections, group.Name), nil
+			}
+		}
+		items[i].Modifiers = modifiers
+	}
+	return "", nil
+}
+
+// PATCH /orderItems/:orderItem_id
+func UpdateOrderItem(orderItems store.OrderItemStore, orders store.OrderStore, invoices store.InvoiceStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var request OrderItemUpdateRequest
+		if err := c.BindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+			return
+		}
+		if err := validate.Struct(request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+			return
+		}
+
+		orderItemId := c.Param("orderItem_id")
+		if _, status, msg := openOrderItem(ctx, orderItems, orders, invoices, orderItemId); msg != "" {
+			c.JSON(status, gin.H{"error": msg})
+			return
+		}
+
+		result, err := orderItems.Update(ctx, orderItemId, models.OrderItem{
+			Seat:  request.Seat,
+			Notes: request.Notes,
+		})
+
+		if err != nil {
+			if err == store.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "orderItem not found"})
+				return
+			}
 			msg := "Order item update failed"
 			c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
 			return
 		}
 
+		c.JSON(http.StatusOK, result)
+	}
+}
+
+// POST /orderItems/:orderItem_id/void
+//
+// An item voided while its order is still PLACED is taken off at once and
+// what it took out of stock is put back. Once the kitchen has the order the
+// void waits for a manager's approval, unless a manager asked for it, and the
+// item is billed until then; its stock is not put back, as it may already
+// have been used.
+func VoidOrderItem(orderItems store.OrderItemStore, orders store.OrderStore, invoices store.InvoiceStore, foods store.FoodStore, tables store.TableStore, recipes store.RecipeStore, ingredients store.IngredientStore, hub *kitchen.Hub, printers printer.Printers) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
 		defer cancel()
 
-		c.JSON(http.StatusOK, result)
+		var request VoidItemRequest
+		if err := c.BindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+			return
+		}
+		if err := validate.Struct(request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+			return
+		}
+
+		orderItemID := c.Param("orderItem_id")
+		orderItem, status, msg := openOrderItem(ctx, orderItems, orders, invoices, orderItemID)
+		if msg != "" {
+			c.JSON(status, gin.H{"error": msg})
+			return
+		}
+		if orderItem.Void != nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "order item has already been voided"})
+			return
+		}
+		order, err := orders.Find(ctx, orderItem.OrderID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while fetching the order"})
+			return
+		}
+
+		uid := c.GetString("uid")
+		now := time.Now().UTC()
+		void := models.OrderItemVoid{
+			Reason:      request.Reason,
+			Note:        request.Note,
+			RequestedBy: uid,
+			RequestedAt: now,
+		}
+		placed := order.CurrentStatus() == models.OrderStatusPlaced
+		switch role := c.GetString("role"); {
+		case placed:
+			void.VoidedAt = &now
+		case role == models.RoleManager || role == models.RoleAdmin:
+			void.ApprovedBy = &uid
+			void.VoidedAt = &now
+		}
+
+		if err := orderItems.Void(ctx, orderItemID, void); err != nil {
+			if err == store.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "orderItem not found"})
+				return
+			}
+			if err == store.ErrConflict {
+				c.JSON(http.StatusConflict, gin.H{"error": "order item has already been voided"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "order item was not voided"})
+			return
+		}
+		orderItem.Void = &void
+
+		if !orderItem.IsVoided() {
+			c.JSON(http.StatusAccepted, orderItem)
+			return
+		}
+		if placed {
+			restoreStock(ctx, foods, recipes, ingredients, orderItem.OrderID, orderItemID, uid)
+		}
+		publishVoid(ctx, foods, tables, hub, printers, order, orderItem)
+		c.JSON(http.StatusOK, orderItem)
+	}
+}
+
+// POST /orderItems/:orderItem_id/void/approve
+func ApproveVoid(orderItems store.OrderItemStore, orders store.OrderStore, invoices store.InvoiceStore, foods store.FoodStore, tables store.TableStore, hub *kitchen.Hub, printers printer.Printers) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		orderItemID := c.Param("orderItem_id")
+		if _, status, msg := openOrderItem(ctx, orderItems, orders, invoices, orderItemID); msg != "" {
+			c.JSON(status, gin.H{"error": msg})
+			return
+		}
+
+		if err := orderItems.ApproveVoid(ctx, orderItemID, c.GetString("uid"), time.Now().UTC()); err != nil {
+			if err == store.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "orderItem not found"})
+				return
+			}
+			if err == store.ErrConflict {
+				c.JSON(http.StatusConflict, gin.H{"error": "order item has no void waiting for approval"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "void was not approved"})
+			return
+		}
+
+		orderItem, err := orderItems.Find(ctx, orderItemID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching the order item"})
+			return
+		}
+		if order, err := orders.Find(ctx, orderItem.OrderID); err == nil {
+			publishVoid(ctx, foods, tables, hub, printers, order, orderItem)
+		}
+		c.JSON(http.StatusOK, orderItem)
 	}
 }
+
+// openOrderItem fetches orderItemID, reporting as a status and message why
+// it cannot be changed if it does not exist or its order is no longer open.
+func openOrderItem(ctx context.Context, orderItems store.OrderItemStore, orders store.OrderStore, invoices store.InvoiceStore, orderItemID string) (models.OrderItem, int, string) {
+	orderItem, err := orderItems.Find(ctx, orderItemID)
+	if err != nil {
+		if err == store.ErrNotFound {
+			return orderItem, http.StatusNotFound, "orderItem not found"
+		}
+		return orderItem, http.StatusInternalServerError, "Error occurred while fetching the order item"
+	}
+	status, msg := openOrderCheck(ctx, orders, invoices, orderItem.OrderID)
+	return orderItem, status, msg
+}
+
+// publishVoid tells the kitchen that orderItem of order has been voided, on
+// its screens and with a slip at the item's station.
+func publishVoid(ctx context.Context, foods store.FoodStore, tables store.TableStore, hub *kitchen.Hub, printers printer.Printers, order models.Order, orderItem models.OrderItem) {
+	ticket := kitchenTicket(ctx, foods, tables, order, []models.OrderItem{orderItem})
+	hub.Publish(kitchen.Event{
+		Name:   kitchen.EventOrderItemVoided,
+		Ticket: ticket,
+	})
+	printTicket(printers, ticket)
+}
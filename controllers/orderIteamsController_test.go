@@ -0,0 +1,150 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"restaurant-management/database/memory"
+	"restaurant-management/kitchen"
+	"restaurant-management/models"
+	"restaurant-management/printer"
+	"restaurant-management/store"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// failingItemInserts is an OrderItemStore whose inserts always fail.
+type failingItemInserts struct {
+	store.OrderItemStore
+}
+
+func (failingItemInserts) InsertMany(ctx context.Context, orderItems []models.OrderItem) error {
+	return errors.New("disk full")
+}
+
+// seedFood stores a food on a menu served all day.
+func seedFood(t *testing.T, stores store.Stores) models.Food {
+	t.Helper()
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	menu := models.Menu{ID: primitive.NewObjectID(), Name: "Lunch", Category: "main", CreatedAt: now, UpdatedAt: now}
+	menu.MenuID = menu.ID.Hex()
+	if err := stores.Menus.Insert(ctx, menu); err != nil {
+		t.Fatalf("Menus.Insert: %v", err)
+	}
+
+	name, image := "Pizza", "pizza.png"
+	price := models.NewMoney(1200, "USD")
+	food := models.Food{ID: primitive.NewObjectID(), Name: &name, Price: &price, FoodImage: &image, MenuID: &menu.MenuID, CreatedAt: now, UpdatedAt: now}
+	food.FoodID = food.ID.Hex()
+	if err := stores.Foods.Insert(ctx, food); err != nil {
+		t.Fatalf("Foods.Insert: %v", err)
+	}
+	return food
+}
+
+func TestCreateOrderItemLeavesNoOrderBehind(t *testing.T) {
+	tests := []struct {
+		name       string
+		items      func(food models.Food) string
+		failInsert bool
+		code       int
+		orders     int
+	}{
+		{name: "ordered", items: func(food models.Food) string { return `[{"quantity":2,"food_id":"` + food.FoodID + `"}]` }, code: http.StatusOK, orders: 1},
+		{name: "no items", items: func(models.Food) string { return `[]` }, code: http.StatusBadRequest},
+		{name: "items left out", items: func(models.Food) string { return `null` }, code: http.StatusBadRequest},
+		{name: "item without a food", items: func(models.Food) string { return `[{"quantity":1}]` }, code: http.StatusBadRequest},
+		{name: "items not stored", items: func(food models.Food) string { return `[{"quantity":1,"food_id":"` + food.FoodID + `"}]` }, failInsert: true, code: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stores := memory.NewStores()
+			food := seedFood(t, stores)
+			table := seedTable(t, stores)
+
+			orderItems := stores.OrderItems
+			if tt.failInsert {
+				orderItems = failingItemInserts{orderItems}
+			}
+			handler := CreateOrderItem(orderItems, stores.Orders, stores.Foods, stores.Menus, stores.Tables, stores.Recipes, stores.Ingredients, stores.PriceRules, kitchen.NewHub(), printer.Printers{}, time.UTC)
+			body := `{"TableID":"` + table.TableID + `","OrderItems":` + tt.items(food) + `}`
+			w := serve(handler, models.RoleWaiter, http.MethodPost, "/orderItems", "/orderItems", body)
+			if w.Code != tt.code {
+				t.Fatalf("status %d, want %d: %s", w.Code, tt.code, w.Body)
+			}
+
+			orders, err := stores.Orders.List(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(orders) != tt.orders {
+				t.Errorf("%d orders stored, want %d", len(orders), tt.orders)
+			}
+		})
+	}
+}
+
+func TestCreateOrderItemReservesStock(t *testing.T) {
+	ctx := context.Background()
+	stores := memory.NewStores()
+	food := seedFood(t, stores)
+	table := seedTable(t, stores)
+
+	// Each pizza takes 0.4 kg of dough, of which there is 1 kg.
+	name, unit := "Dough", models.UnitKilogram
+	dough := models.Ingredient{ID: primitive.NewObjectID(), Name: &name, Unit: &unit, Stock: 1000}
+	dough.IngredientID = dough.ID.Hex()
+	if err := stores.Ingredients.Insert(ctx, dough); err != nil {
+		t.Fatalf("Ingredients.Insert: %v", err)
+	}
+	recipe := models.Recipe{FoodID: food.FoodID, Items: []models.RecipeItem{{IngredientID: dough.IngredientID, Quantity: 400, Unit: unit}}}
+	if err := stores.Recipes.Put(ctx, recipe); err != nil {
+		t.Fatalf("Recipes.Put: %v", err)
+	}
+
+	order := func(orderItems store.OrderItemStore, quantity string) *httptest.ResponseRecorder {
+		handler := CreateOrderItem(orderItems, stores.Orders, stores.Foods, stores.Menus, stores.Tables, stores.Recipes, stores.Ingredients, stores.PriceRules, kitchen.NewHub(), printer.Printers{}, time.UTC)
+		body := `{"TableID":"` + table.TableID + `","OrderItems":[{"quantity":` + quantity + `,"food_id":"` + food.FoodID + `"}]}`
+		return serve(handler, models.RoleWaiter, http.MethodPost, "/orderItems", "/orderItems", body)
+	}
+	stock := func(want models.Quantity) {
+		t.Helper()
+		found, err := stores.Ingredients.Find(ctx, dough.IngredientID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if found.Stock != want {
+			t.Errorf("dough stock = %s, want %s", found.Stock, want)
+		}
+	}
+
+	if w := order(stores.OrderItems, "3"); w.Code != http.StatusConflict || !strings.Contains(w.Body.String(), food.FoodID) {
+		t.Errorf("ordering more than there is stock for: status %d, want 409 naming the food: %s", w.Code, w.Body)
+	}
+	stock(1000)
+
+	if w := order(failingItemInserts{stores.OrderItems}, "2"); w.Code != http.StatusInternalServerError {
+		t.Errorf("items not stored: status %d, want 500: %s", w.Code, w.Body)
+	}
+	stock(1000)
+
+	if w := order(stores.OrderItems, "2"); w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	stock(200)
+
+	// 0.2 kg is not enough for another pizza, so it is taken off.
+	stored, err := stores.Foods.Find(ctx, food.FoodID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.IsAvailable() {
+		t.Errorf("food still available with stock for less than a portion")
+	}
+}
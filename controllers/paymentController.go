@@ -0,0 +1,305 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"restaurant-management/gateway"
+	"restaurant-management/models"
+	"restaurant-management/store"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// invoiceTotal is what invoice asks to be paid: its grand total, or for
+// invoices created before pricing the live sum of its order's food prices.
+func invoiceTotal(ctx context.Context, orderItems store.OrderItemStore, invoice models.Invoice) (models.Money, error) {
+	if invoice.GrandTotal != nil {
+		return *invoice.GrandTotal, nil
+	}
+	groups, err := orderItems.ItemsByOrder(ctx, invoice.OrderID)
+	if err != nil {
+		return models.Money{}, err
+	}
+	if len(groups) == 0 {
+		return models.NewMoney(0, ""), nil
+	}
+	return groups[0].PaymentDue, nil
+}
+
+// chargeCards authorizes and captures every card tender of payment through
+// provider, and records the capture on its tender in place of the card
+// source. If a card fails, the ones already charged are refunded.
+func chargeCards(ctx context.Context, provider gateway.Provider, payment *models.Payment) ([]gateway.Transaction, error) {
+	var captures []gateway.Transaction
+	for i := range payment.Tenders {
+		tender := &payment.Tenders[i]
+		if tender.Method != models.TenderCard {
+			continue
+		}
+
+		auth, err := provider.Authorize(ctx, gateway.AuthorizeRequest{
+			Amount:         tender.Amount,
+			Source:         *tender.Source,
+			IdempotencyKey: payment.PaymentID + "/" + strconv.Itoa(i),
+		})
+		if err != nil {
+			refundCards(ctx, provider, captures)
+			return nil, err
+		}
+		capture, err := provider.Capture(ctx, auth.Reference, tender.Amount)
+		if err != nil {
+			if _, voidErr := provider.Void(ctx, auth.Reference); voidErr != nil {
+				log.Printf("could not void card authorization %s: %v", auth.Reference, voidErr)
+			}
+			refundCards(ctx, provider, captures)
+			return nil, err
+		}
+
+		name := provider.Name()
+		tender.Provider = &name
+		tender.Reference = &capture.Reference
+		tender.Source = nil
+		captures = append(captures, capture)
+	}
+	return captures, nil
+}
+
+// refundCards gives back card captures taken for a payment that could not be
+// recorded.
+func refundCards(ctx context.Context, provider gateway.Provider, captures []gateway.Transaction) {
+	for _, capture := range captures {
+		if _, err := provider.Refund(ctx, capture.Reference, capture.Amount); err != nil {
+			log.Printf("could not refund card capture %s: %v", capture.Reference, err)
+		}
+	}
+}
+
+// GET /invoices/:invoice_id/payments
+func GetPayments(invoices store.InvoiceStore, payments store.PaymentStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		invoiceID := c.Param("invoice_id")
+		if _, err := invoices.Find(ctx, invoiceID); err != nil {
+			if err == store.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "invoice not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch invoice"})
+			return
+		}
+
+		allPayments, err := payments.ListByInvoice(ctx, invoiceID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch payments"})
+			return
+		}
+
+		c.JSON(http.StatusOK, allPayments)
+	}
+}
+
+// GET /invoices/:invoice_id/payments/:payment_id
+func GetPayment(payments store.PaymentStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		payment, err := payments.Find(ctx, c.Param("payment_id"))
+		if err == nil && payment.InvoiceID != c.Param("invoice_id") {
+			err = store.ErrNotFound
+		}
+		if err != nil {
+			if err == store.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "payment not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch payment"})
+			return
+		}
+
+		c.JSON(http.StatusOK, payment)
+	}
+}
+
+// POST /invoices/:invoice_id/payments
+//
+// Takes a payment of one or more tenders against the invoice's balance. Card
+// and voucher tenders can only cover up to the balance; cash beyond it is
+// returned as change. Card tenders are charged through the payment provider
+// before the payment is recorded. The invoice becomes PARTIALLY_PAID until
+// the balance reaches zero and PAID once it does. The balance leaves refunds
+// out: only fully paid invoices are refunded, and a refund does not reopen
+// the balance.
+func CreatePayment(invoices store.InvoiceStore, orderItems store.OrderItemStore, payments store.PaymentStore, provider gateway.Provider) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var payment models.Payment
+		if err := c.BindJSON(&payment); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+		if err := validate.Struct(payment); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		invoice, err := invoices.Find(ctx, c.Param("invoice_id"))
+		if err != nil {
+			if err == store.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "invoice not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch invoice"})
+			return
+		}
+
+		if invoice.PaymentStatus != nil && *invoice.PaymentStatus == models.PaymentVoid {
+			c.JSON(http.StatusConflict, gin.H{"error": "invoice is void"})
+			return
+		}
+
+		total, err := invoiceTotal(ctx, orderItems, invoice)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch related order items"})
+			return
+		}
+		paid, _ := runningTotals(invoice, total)
+		balance, err := total.Sub(paid)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if balance.Amount <= 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "invoice is already paid"})
+			return
+		}
+
+		tendered := models.NewMoney(0, total.Currency)
+		nonCash := models.NewMoney(0, total.Currency)
+		method := ""
+		for i, tender := range payment.Tenders {
+			// Only the provider says which provider settled a tender.
+			payment.Tenders[i].Provider = nil
+			if tender.Amount.Amount <= 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "tender amounts must be positive"})
+				return
+			}
+			if tender.Method == models.TenderCard && (tender.Source == nil || *tender.Source == "") {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "card tenders need the provider's card source"})
+				return
+			}
+			if tendered, err = tendered.Add(tender.Amount); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "tenders must be in the invoice currency " + total.Currency})
+				return
+			}
+			if tender.Method != models.TenderCash {
+				nonCash, _ = nonCash.Add(tender.Amount)
+			}
+			if method == "" {
+				method = tender.Method
+			} else if method != tender.Method {
+				method = models.PaymentMixed
+			}
+		}
+		if nonCash.Amount > balance.Amount {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "card and voucher tenders cannot exceed the balance of " + balance.String()})
+			return
+		}
+
+		applied := tendered
+		if applied.Amount > balance.Amount {
+			applied = balance
+		}
+		payment.Tendered = tendered
+		payment.Amount = applied
+		payment.Change, _ = tendered.Sub(applied)
+		payment.Balance, _ = balance.Sub(applied)
+
+		// An invoice paid off over several payments in different ways is
+		// recorded as MIXED.
+		if paid.Amount > 0 && invoice.PaymentMethod != nil && *invoice.PaymentMethod != method {
+			method = models.PaymentMixed
+		}
+		settlement := store.Settlement{
+			PaidBefore:    paid,
+			PaymentStatus: models.PaymentPartiallyPaid,
+			PaymentMethod: method,
+		}
+		settlement.AmountPaid, _ = paid.Add(applied)
+		if payment.Balance.Amount == 0 {
+			settlement.PaymentStatus = models.PaymentPaid
+		}
+
+		payment.ID = primitive.NewObjectID()
+		payment.PaymentID = payment.ID.Hex()
+		payment.InvoiceID = invoice.InvoiceID
+		payment.TakenBy = c.GetString("uid")
+		payment.CreatedAt = time.Now().UTC()
+
+		captures, err := chargeCards(ctx, provider, &payment)
+		if err != nil {
+			if err == gateway.ErrDeclined {
+				c.JSON(http.StatusPaymentRequired, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusBadGateway, gin.H{"error": "payment provider error: " + err.Error()})
+			return
+		}
+		if len(captures) > 0 {
+			settlement.Provider = provider.Name()
+			settlement.ProviderReference = captures[len(captures)-1].Reference
+		}
+
+		if err := payments.Insert(ctx, payment, settlement); err != nil {
+			refundCards(ctx, provider, captures)
+			switch err {
+			case store.ErrNotFound:
+				c.JSON(http.StatusNotFound, gin.H{"error": "invoice not found"})
+			case store.ErrConflict:
+				c.JSON(http.StatusConflict, gin.H{"error": "another payment was taken on this invoice, please retry"})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to record payment"})
+			}
+			return
+		}
+
+		c.JSON(http.StatusCreated, payment)
+	}
+}
+
+// POST /payments/webhook
+//
+// Receives notifications from the payment provider. Card payments are
+// settled synchronously, so events are only verified and logged.
+func PaymentWebhook(provider gateway.Provider) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		payload, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+
+		event, err := provider.VerifyWebhook(payload, c.GetHeader("X-Signature"))
+		if err != nil {
+			if errors.Is(err, gateway.ErrBadSignature) {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook event: " + err.Error()})
+			return
+		}
+
+		log.Printf("payment provider %s: %s for %s", provider.Name(), event.Type, event.Reference)
+		c.JSON(http.StatusOK, gin.H{"received": true})
+	}
+}
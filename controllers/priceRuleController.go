@@ -0,0 +1,269 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"restaurant-management/models"
+	"restaurant-management/pricing"
+	"restaurant-management/store"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GET /price-rules
+func GetPriceRules(priceRules store.PriceRuleStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		rules, err := priceRules.List(ctx)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occured while listing price rules"})
+			return
+		}
+		c.JSON(http.StatusOK, rules)
+	}
+}
+
+// GET /price-rules/:price_rule_id
+func GetPriceRule(priceRules store.PriceRuleStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		rule, err := priceRules.Find(ctx, c.Param("price_rule_id"))
+		if err != nil {
+			if err == store.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "price rule not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occured while fetching the price rule"})
+			return
+		}
+		c.JSON(http.StatusOK, rule)
+	}
+}
+
+// POST /price-rules
+func CreatePriceRule(priceRules store.PriceRuleStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var rule models.PriceRule
+		if err := c.BindJSON(&rule); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if msg := checkPriceRule(&rule); msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+
+		rule.ID = primitive.NewObjectID()
+		rule.PriceRuleID = rule.ID.Hex()
+		rule.CreatedAt = time.Now().UTC()
+		rule.UpdatedAt = rule.CreatedAt
+
+		if err := priceRules.Insert(ctx, rule); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "price rule was not created"})
+			return
+		}
+		c.JSON(http.StatusCreated, rule)
+	}
+}
+
+// PUT /price-rules/:price_rule_id
+//
+// The rule is replaced as a whole. Items already ordered keep the price they
+// were charged.
+func ReplacePriceRule(priceRules store.PriceRuleStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var rule models.PriceRule
+		if err := c.BindJSON(&rule); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if msg := checkPriceRule(&rule); msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+
+		existing, err := priceRules.Find(ctx, c.Param("price_rule_id"))
+		if err != nil {
+			if err == store.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "price rule not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occured while fetching the price rule"})
+			return
+		}
+		rule.ID = existing.ID
+		rule.PriceRuleID = existing.PriceRuleID
+		rule.CreatedAt = existing.CreatedAt
+		rule.UpdatedAt = time.Now().UTC()
+
+		if err := priceRules.Replace(ctx, rule); err != nil {
+			if err == store.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "price rule not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "price rule was not updated"})
+			return
+		}
+		c.JSON(http.StatusOK, rule)
+	}
+}
+
+// checkPriceRule validates rule, including the fields its kind needs and
+// those it cannot have, and returns what is wrong with it, if anything.
+// Missing lists are made empty.
+func checkPriceRule(rule *models.PriceRule) string {
+	if err := validate.Struct(rule); err != nil {
+		return err.Error()
+	}
+	if !validMenuDates(rule.StartDate, rule.EndDate) {
+		return "end_date must be after start_date"
+	}
+	if rule.FoodIDs == nil {
+		rule.FoodIDs = []string{}
+	}
+	if rule.MenuIDs == nil {
+		rule.MenuIDs = []string{}
+	}
+	if rule.Schedule == nil {
+		rule.Schedule = []models.MenuSlot{}
+	}
+	for _, amount := range []*models.Money{rule.AmountOff, rule.Price} {
+		if amount != nil && amount.IsNegative() {
+			return "amounts cannot be negative"
+		}
+	}
+
+	adjustments := 0
+	for _, set := range []bool{rule.DiscountRate != nil, rule.AmountOff != nil, rule.Price != nil} {
+		if set {
+			adjustments++
+		}
+	}
+	quantities := rule.BuyQuantity != 0 || rule.FreeQuantity != 0
+
+	switch rule.Kind {
+	case models.PriceRuleHappyHour, models.PriceRuleMenuOverride:
+		if rule.Kind == models.PriceRuleHappyHour && len(rule.Schedule) == 0 {
+			return "a HAPPY_HOUR rule needs a schedule"
+		}
+		if rule.Kind == models.PriceRuleMenuOverride && len(rule.MenuIDs) == 0 {
+			return "a MENU_OVERRIDE rule needs menu_ids"
+		}
+		if adjustments != 1 || quantities {
+			return "a " + rule.Kind + " rule takes exactly one of discount_rate, amount_off and price"
+		}
+	case models.PriceRuleBuyXGetY:
+		if rule.BuyQuantity < 1 || rule.FreeQuantity < 1 {
+			return "a BUY_X_GET_Y rule needs buy_quantity and free_quantity of at least 1"
+		}
+		if adjustments != 0 {
+			return "a BUY_X_GET_Y rule takes no discount_rate, amount_off or price"
+		}
+	case models.PriceRuleBundle:
+		if len(rule.FoodIDs) < 2 {
+			return "a BUNDLE rule needs at least two food_ids"
+		}
+		if rule.Price == nil || rule.DiscountRate != nil || rule.AmountOff != nil || quantities {
+			return "a BUNDLE rule takes a price and nothing else to price it by"
+		}
+		if len(rule.MenuIDs) > 0 {
+			return "a BUNDLE rule takes no menu_ids"
+		}
+	}
+	return ""
+}
+
+// priceOrderItems prices items from the price of their food in the size
+// ordered and the price rules in force at now, recording the list price and
+// the rule applied. Rules count three burgers ordered as one item as they
+// would three items, and an item whose units come to different prices, as
+// under buy two get one free, is split into an item for each price. The price
+// adjustments of an item's modifiers are then added to both prices in full;
+// rules only ever discount the dish itself. It returns store.ErrNotFound if a
+// food does not exist and models.ErrSizeNotOffered if one is ordered in a size
+// it does not come in. Items with no food are left for validation to report.
+func priceOrderItems(ctx context.Context, foods store.FoodStore, priceRules store.PriceRuleStore, items []models.OrderItem, now time.Time) ([]models.OrderItem, error) {
+	found := map[string]models.Food{}
+	lines := []pricing.Line{}
+	owners := []int{}
+	for i, item := range items {
+		if item.FoodID == nil {
+			continue
+		}
+		food, ok := found[*item.FoodID]
+		if !ok {
+			var err error
+			if food, err = foods.Find(ctx, *item.FoodID); err != nil {
+				return nil, err
+			}
+			found[food.FoodID] = food
+		}
+		price, err := food.PriceFor(item.Size)
+		if err != nil {
+			return nil, err
+		}
+		food.Price = &price
+		lines = append(lines, pricing.Line{Food: food, Quantity: item.Quantity})
+		owners = append(owners, i)
+	}
+
+	rules, err := priceRules.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	prices, err := pricing.Prices(lines, rules, now)
+	if err != nil {
+		return nil, err
+	}
+	groups := make([][]pricing.LinePrice, len(items))
+	for n, i := range owners {
+		groups[i] = prices[n]
+	}
+
+	priced := make([]models.OrderItem, 0, len(items))
+	for i, item := range items {
+		if len(groups[i]) == 0 {
+			priced = append(priced, item)
+			continue
+		}
+		for _, g := range groups[i] {
+			unitPrice, listPrice := g.Price, g.ListPrice
+			for _, modifier := range item.Modifiers {
+				if modifier.PriceAdjustment == nil {
+					continue
+				}
+				if unitPrice, err = unitPrice.Add(*modifier.PriceAdjustment); err != nil {
+					return nil, err
+				}
+				if listPrice, err = listPrice.Add(*modifier.PriceAdjustment); err != nil {
+					return nil, err
+				}
+			}
+			unitPrice.Amount = max(unitPrice.Amount, 0)
+			listPrice.Amount = max(listPrice.Amount, 0)
+
+			part := item
+			part.Quantity = g.Quantity
+			part.UnitPrice = &unitPrice
+			part.ListPrice = &listPrice
+			part.PriceRuleID = nil
+			if g.Rule != nil {
+				part.PriceRuleID = &g.Rule.PriceRuleID
+			}
+			priced = append(priced, part)
+		}
+	}
+	return priced, nil
+}
@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"restaurant-management/printer"
+	"restaurant-management/receipt"
+	"restaurant-management/store"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// loadReceipt lays out the receipt for the invoice in the request. It writes
+// the error response and returns false if it cannot.
+func loadReceipt(ctx context.Context, c *gin.Context, invoices store.InvoiceStore, orderItems store.OrderItemStore, config receipt.Config) (receipt.Receipt, bool) {
+	invoice, err := invoices.Find(ctx, c.Param("invoice_id"))
+	if err != nil {
+		if err == store.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "invoice not found"})
+			return receipt.Receipt{}, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch invoice"})
+		return receipt.Receipt{}, false
+	}
+
+	allOrderItems, err := orderItems.ItemsByOrder(ctx, invoice.OrderID)
+	if err != nil || len(allOrderItems) == 0 {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch related order items"})
+		return receipt.Receipt{}, false
+	}
+	group := allOrderItems[0]
+	group.OrderItems = billedItems(invoice, group.OrderItems)
+
+	return receipt.New(config, invoice, group), true
+}
+
+// GET /invoices/:invoice_id/receipt?format=pdf|txt|html|escpos
+//
+// Renders the invoice as a printable receipt, as a PDF unless another format
+// is asked for. The escpos format is the byte stream a thermal printer takes.
+func GetReceipt(invoices store.InvoiceStore, orderItems store.OrderItemStore, config receipt.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var write func(io.Writer, receipt.Receipt) error
+		var contentType string
+		switch format := c.DefaultQuery("format", "pdf"); format {
+		case "pdf":
+			write = receipt.WritePDF
+			contentType = "application/pdf"
+		case "txt":
+			write = receipt.WriteText
+			contentType = "text/plain; charset=utf-8"
+		case "html":
+			write = receipt.WriteHTML
+			contentType = "text/html; charset=utf-8"
+		case "escpos":
+			write = func(w io.Writer, r receipt.Receipt) error {
+				_, err := w.Write(printer.EncodeReceipt(r))
+				return err
+			}
+			contentType = "application/octet-stream"
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "format must be pdf, txt, html or escpos"})
+			return
+		}
+
+		r, ok := loadReceipt(ctx, c, invoices, orderItems, config)
+		if !ok {
+			return
+		}
+
+		var body bytes.Buffer
+		if err := write(&body, r); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to render receipt"})
+			return
+		}
+
+		c.Data(http.StatusOK, contentType, body.Bytes())
+	}
+}
+
+// POST /invoices/:invoice_id/receipt/print
+func PrintReceipt(invoices store.InvoiceStore, orderItems store.OrderItemStore, config receipt.Config, printers printer.Printers) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		r, ok := loadReceipt(ctx, c, invoices, orderItems, config)
+		if !ok {
+			return
+		}
+
+		if err := printers.PrintReceipt(ctx, r); err != nil {
+			if err == printer.ErrNoPrinter {
+				c.JSON(http.StatusServiceUnavailable, gin.H{"error": "no receipt printer is configured"})
+				return
+			}
+			c.JSON(http.StatusBadGateway, gin.H{"error": "receipt printer error: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"printed": true})
+	}
+}
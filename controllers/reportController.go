@@ -0,0 +1,141 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"restaurant-management/models"
+	"restaurant-management/store"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DailySales totals the money taken and handed back over one local day.
+type DailySales struct {
+	Date string `json:"date"`
+	// Payments and Collected count and total the payments taken, net of
+	// change, with CollectedByMethod splitting that by tender.
+	Payments          int                     `json:"payments"`
+	Collected         models.Money            `json:"collected"`
+	CollectedByMethod map[string]models.Money `json:"collected_by_method"`
+	// CreditNotes, Refunded and Voided cover the credit notes issued: the
+	// money handed back, split by method, and the value of invoices voided.
+	CreditNotes      int                     `json:"credit_notes"`
+	Refunded         models.Money            `json:"refunded"`
+	RefundedByMethod map[string]models.Money `json:"refunded_by_method"`
+	Voided           models.Money            `json:"voided"`
+	// Net is Collected less Refunded.
+	Net models.Money `json:"net"`
+}
+
+// addTo adds amount to totals[key], starting from zero.
+func addTo(totals map[string]models.Money, key string, amount models.Money) error {
+	total, ok := totals[key]
+	if !ok {
+		total = models.NewMoney(0, amount.Currency)
+	}
+	sum, err := total.Add(amount)
+	if err != nil {
+		return err
+	}
+	totals[key] = sum
+	return nil
+}
+
+// dailySales works out the totals for the day that starts at day, dated in
+// day's time zone.
+func dailySales(day time.Time, payments []models.Payment, notes []models.CreditNote) (DailySales, error) {
+	zero := models.NewMoney(0, "")
+	sales := DailySales{
+		Date:              day.Format("2006-01-02"),
+		Payments:          len(payments),
+		Collected:         zero,
+		CollectedByMethod: map[string]models.Money{},
+		CreditNotes:       len(notes),
+		Refunded:          zero,
+		RefundedByMethod:  map[string]models.Money{},
+		Voided:            zero,
+	}
+
+	var err error
+	for _, payment := range payments {
+		if sales.Collected, err = sales.Collected.Add(payment.Amount); err != nil {
+			return sales, err
+		}
+		// Change only ever comes out of the cash tendered.
+		change := payment.Change.Amount
+		for _, tender := range payment.Tenders {
+			amount := tender.Amount
+			if tender.Method == models.TenderCash && change > 0 {
+				given := min(change, amount.Amount)
+				amount.Amount -= given
+				change -= given
+			}
+			if err := addTo(sales.CollectedByMethod, tender.Method, amount); err != nil {
+				return sales, err
+			}
+		}
+	}
+
+	for _, note := range notes {
+		if note.Kind == models.CreditNoteVoid {
+			if sales.Voided, err = sales.Voided.Add(note.Amount); err != nil {
+				return sales, err
+			}
+		}
+		for _, refund := range note.Refunds {
+			if sales.Refunded, err = sales.Refunded.Add(refund.Amount); err != nil {
+				return sales, err
+			}
+			if err := addTo(sales.RefundedByMethod, refund.Method, refund.Amount); err != nil {
+				return sales, err
+			}
+		}
+	}
+
+	sales.Net, err = sales.Collected.Sub(sales.Refunded)
+	return sales, err
+}
+
+// GET /reports/daily-sales?date=YYYY-MM-DD
+//
+// The day, today unless date is given, runs from midnight to midnight in
+// location, the restaurant's time zone menu schedules are kept in, so it
+// may be more or less than 24 hours when the clocks change.
+func GetDailySales(payments store.PaymentStore, creditNotes store.CreditNoteStore, location *time.Location) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		now := time.Now().In(location)
+		day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, location)
+		if date := c.Query("date"); date != "" {
+			parsed, err := time.ParseInLocation("2006-01-02", date, location)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "date must be in the form YYYY-MM-DD"})
+				return
+			}
+			day = parsed
+		}
+		next := day.AddDate(0, 0, 1)
+
+		dayPayments, err := payments.ListBetween(ctx, day.UTC(), next.UTC())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch payments"})
+			return
+		}
+		dayNotes, err := creditNotes.ListBetween(ctx, day.UTC(), next.UTC())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch credit notes"})
+			return
+		}
+
+		sales, err := dailySales(day, dayPayments, dayNotes)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to total sales: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, sales)
+	}
+}
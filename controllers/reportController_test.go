@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"restaurant-management/database/memory"
+	"restaurant-management/models"
+	"restaurant-management/store"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestGetDailySalesUsesLocalDays(t *testing.T) {
+	ctx := context.Background()
+	stores := memory.NewStores()
+	order := seedOrder(t, stores, models.OrderStatusServed)
+
+	status, method := models.PaymentPending, ""
+	total := models.NewMoney(2000, "USD")
+	invoice := models.Invoice{ID: primitive.NewObjectID(), OrderID: order.OrderID, PaymentMethod: &method, PaymentStatus: &status, GrandTotal: &total}
+	invoice.InvoiceID = invoice.ID.Hex()
+	if err := stores.Invoices.Insert(ctx, invoice, "INV"); err != nil {
+		t.Fatalf("Invoices.Insert: %v", err)
+	}
+
+	// Five hours behind UTC, 23:30 on the 14th is 04:30 UTC on the 15th, and
+	// 02:00 UTC on the 14th is still the evening of the 13th.
+	location := time.FixedZone("UTC-5", -5*60*60)
+	paid := models.NewMoney(0, "USD")
+	for _, at := range []time.Time{
+		time.Date(2026, 3, 14, 23, 30, 0, 0, location),
+		time.Date(2026, 3, 14, 2, 0, 0, 0, time.UTC),
+	} {
+		amount := models.NewMoney(1000, "USD")
+		payment := models.Payment{ID: primitive.NewObjectID(), InvoiceID: invoice.InvoiceID, Tenders: []models.Tender{{Method: models.TenderCash, Amount: amount}},
+			Tendered: amount, Amount: amount, Change: models.NewMoney(0, "USD"), CreatedAt: at.UTC()}
+		payment.PaymentID = payment.ID.Hex()
+		settled, _ := paid.Add(amount)
+		if err := stores.Payments.Insert(ctx, payment, store.Settlement{PaidBefore: paid, AmountPaid: settled, PaymentStatus: models.PaymentPartiallyPaid, PaymentMethod: models.TenderCash}); err != nil {
+			t.Fatalf("Payments.Insert: %v", err)
+		}
+		paid = settled
+	}
+
+	tests := []struct {
+		date      string
+		payments  int
+		collected int64
+	}{
+		{date: "2026-03-13", payments: 1, collected: 1000},
+		{date: "2026-03-14", payments: 1, collected: 1000},
+		{date: "2026-03-15", payments: 0, collected: 0},
+	}
+	handler := GetDailySales(stores.Payments, stores.CreditNotes, location)
+	for _, tt := range tests {
+		w := serve(handler, models.RoleManager, http.MethodGet, "/reports/daily-sales", "/reports/daily-sales?date="+tt.date, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: status %d: %s", tt.date, w.Code, w.Body)
+		}
+		var sales DailySales
+		if err := json.Unmarshal(w.Body.Bytes(), &sales); err != nil {
+			t.Fatal(err)
+		}
+		if sales.Date != tt.date || sales.Payments != tt.payments || sales.Collected.Amount != tt.collected {
+			t.Errorf("%s: got %s with %d payments collecting %v, want %d collecting %d", tt.date, sales.Date, sales.Payments, sales.Collected, tt.payments, tt.collected)
+		}
+	}
+
+	w := serve(handler, models.RoleManager, http.MethodGet, "/reports/daily-sales", "/reports/daily-sales?date=14/03/2026", "")
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("malformed date: status %d, want 400", w.Code)
+	}
+}
@@ -0,0 +1,279 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"restaurant-management/models"
+	"restaurant-management/store"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// freeTables returns the tables that seat at least guests and have no booking
+// overlapping [start, end), smallest first so the first one is the best fit.
+func freeTables(ctx context.Context, tables store.TableStore, reservations store.ReservationStore, start, end time.Time, guests int) ([]models.Table, error) {
+	allTables, err := tables.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	booked, err := reservations.Overlapping(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	taken := map[string]bool{}
+	for _, reservation := range booked {
+		if reservation.TableID != nil {
+			taken[*reservation.TableID] = true
+		}
+	}
+
+	free := []models.Table{}
+	for _, table := range allTables {
+		if taken[table.TableID] || table.NumberOfGuests == nil || *table.NumberOfGuests < guests {
+			continue
+		}
+		free = append(free, table)
+	}
+	sort.SliceStable(free, func(i, j int) bool {
+		return *free[i].NumberOfGuests < *free[j].NumberOfGuests
+	})
+	return free, nil
+}
+
+// GET /tables/availability?at=<RFC3339>&guests=<n>[&duration=<minutes>]
+func GetTableAvailability(tables store.TableStore, reservations store.ReservationStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		at, err := time.Parse(time.RFC3339, c.Query("at"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "at must be an RFC3339 time"})
+			return
+		}
+		guests, err := strconv.Atoi(c.Query("guests"))
+		if err != nil || guests < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "guests must be a positive number"})
+			return
+		}
+		length := models.DefaultReservationLength
+		if value := c.Query("duration"); value != "" {
+			minutes, err := strconv.Atoi(value)
+			if err != nil || minutes < 1 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "duration must be a positive number of minutes"})
+				return
+			}
+			length = time.Duration(minutes) * time.Minute
+		}
+
+		start := at.UTC().Truncate(time.Second)
+		end := start.Add(length)
+
+		free, err := freeTables(ctx, tables, reservations, start, end, guests)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occured while checking table availability"})
+			return
+		}
+
+		var suggested *models.Table
+		if len(free) > 0 {
+			suggested = &free[0]
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"start_time": start,
+			"end_time":   end,
+			"guests":     guests,
+			"available":  free,
+			"suggested":  suggested,
+		})
+	}
+}
+
+// GET /reservations?table_id=<id>
+func GetReservations(reservations store.ReservationStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		allReservations, err := reservations.List(ctx, c.Query("table_id"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occured while listing reservations"})
+			return
+		}
+		c.JSON(http.StatusOK, allReservations)
+	}
+}
+
+// GET /reservations/:reservation_id
+func GetReservation(reservations store.ReservationStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		reservation, err := reservations.Find(ctx, c.Param("reservation_id"))
+		if err != nil {
+			if err == store.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "reservation not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occured while fetching the reservation"})
+			return
+		}
+		c.JSON(http.StatusOK, reservation)
+	}
+}
+
+// POST /reservations
+//
+// When table_id is left out the smallest free table that seats the party is
+// booked.
+func CreateReservation(reservations store.ReservationStore, tables store.TableStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var reservation models.Reservation
+
+		if err := c.BindJSON(&reservation); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		validationErr := validate.Struct(reservation)
+		if validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+
+		reservation.StartTime = reservation.StartTime.UTC().Truncate(time.Second)
+		if reservation.EndTime.IsZero() {
+			reservation.EndTime = reservation.StartTime.Add(models.DefaultReservationLength)
+		}
+		reservation.EndTime = reservation.EndTime.UTC().Truncate(time.Second)
+
+		if !reservation.EndTime.After(reservation.StartTime) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "end_time must be after start_time"})
+			return
+		}
+		if reservation.StartTime.Before(time.Now()) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "start_time must be in the future"})
+			return
+		}
+
+		var candidates []models.Table
+		conflictMsg := "no table is free for that party at that time"
+		if reservation.TableID != nil {
+			table, err := tables.Find(ctx, *reservation.TableID)
+			if err != nil {
+				if err == store.ErrNotFound {
+					c.JSON(http.StatusNotFound, gin.H{"error": "table not found"})
+					return
+				}
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "error occured while fetching the table"})
+				return
+			}
+			if table.NumberOfGuests == nil || *table.NumberOfGuests < *reservation.NumberOfGuests {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "party is larger than the table seats"})
+				return
+			}
+			candidates = []models.Table{table}
+			conflictMsg = "table is already booked for that time"
+		} else {
+			free, err := freeTables(ctx, tables, reservations, reservation.StartTime, reservation.EndTime, *reservation.NumberOfGuests)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "error occured while checking table availability"})
+				return
+			}
+			candidates = free
+		}
+
+		reservation.Status = models.ReservationBooked
+		reservation.CreatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		reservation.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+		// Another booking can take a free table between the availability check
+		// and the insert, so fall through to the next candidate on a conflict.
+		for _, table := range candidates {
+			tableID := table.TableID
+			reservation.TableID = &tableID
+			reservation.ID = primitive.NewObjectID()
+			reservation.ReservationID = reservation.ID.Hex()
+
+			err := reservations.Insert(ctx, reservation)
+			if err == store.ErrConflict {
+				continue
+			}
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "reservation was not created"})
+				return
+			}
+			c.JSON(http.StatusOK, reservation)
+			return
+		}
+
+		c.JSON(http.StatusConflict, gin.H{"error": conflictMsg})
+	}
+}
+
+func updateReservationStatus(c *gin.Context, reservations store.ReservationStore, status string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	reservationId := c.Param("reservation_id")
+
+	if status == models.ReservationNoShow {
+		reservation, err := reservations.Find(ctx, reservationId)
+		if err != nil {
+			if err == store.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "reservation not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occured while fetching the reservation"})
+			return
+		}
+		if time.Now().Before(reservation.StartTime) {
+			c.JSON(http.StatusConflict, gin.H{"error": "a reservation cannot be a no-show before it starts"})
+			return
+		}
+	}
+
+	err := reservations.UpdateStatus(ctx, reservationId, status)
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "reservation not found"})
+		case store.ErrConflict:
+			c.JSON(http.StatusConflict, gin.H{"error": "reservation is no longer booked"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "reservation update failed"})
+		}
+		return
+	}
+
+	reservation, err := reservations.Find(ctx, reservationId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error occured while fetching the reservation"})
+		return
+	}
+	c.JSON(http.StatusOK, reservation)
+}
+
+// POST /reservations/:reservation_id/cancel
+func CancelReservation(reservations store.ReservationStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		updateReservationStatus(c, reservations, models.ReservationCancelled)
+	}
+}
+
+// POST /reservations/:reservation_id/no-show
+func MarkReservationNoShow(reservations store.ReservationStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		updateReservationStatus(c, reservations, models.ReservationNoShow)
+	}
+}
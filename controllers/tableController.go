@@ -2,54 +2,49 @@ package controllers
 
 import (
 	"context"
-	"log"
 	"net/http"
-	"restaurant-management/database"
 	"restaurant-management/models"
+	"restaurant-management/store"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-var tableCollection *mongo.Collection = database.OpenCollection(database.Client, "table")
-
-func GetTables() gin.HandlerFunc {
+func GetTables(tables store.TableStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
-
-		result, err := orderCollection.Find(context.TODO(), bson.M{})
 		defer cancel()
+
+		allTables, err := tables.List(ctx)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occured while listing table items"})
-		}
-		var allTables []bson.M
-		if err = result.All(ctx, &allTables); err != nil {
-			log.Fatal(err)
+			return
 		}
 		c.JSON(http.StatusOK, allTables)
 	}
 }
 
-func GetTable() gin.HandlerFunc {
+func GetTable(tables store.TableStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
 		tableId := c.Param("table_id")
-		var table models.Table
 
-		err := tableCollection.FindOne(ctx, bson.M{"table_id": tableId}).Decode(&table)
-		defer cancel()
+		table, err := tables.Find(ctx, tableId)
 		if err != nil {
+			if err == store.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "table not found"})
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occured while fetching the tables"})
+			return
 		}
 		c.JSON(http.StatusOK, table)
 	}
 }
 
-func CreateTable() gin.HandlerFunc {
+func CreateTable(tables store.TableStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
 		defer cancel()
@@ -74,21 +69,20 @@ func CreateTable() gin.HandlerFunc {
 		table.ID = primitive.NewObjectID()
 		table.TableID = table.ID.Hex()
 
-		result, insertErr := tableCollection.InsertOne(ctx, table)
+		insertErr := tables.Insert(ctx, table)
 
 		if insertErr != nil {
 			msg := "Table item was not created"
 			c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
 			return
 		}
-		defer cancel()
 
-		c.JSON(http.StatusOK, result)
+		c.JSON(http.StatusOK, store.InsertResult{InsertedID: table.ID})
 
 	}
 }
 
-func UpdateTable() gin.HandlerFunc {
+func UpdateTable(tables store.TableStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 		defer cancel()
@@ -101,41 +95,18 @@ func UpdateTable() gin.HandlerFunc {
 			return
 		}
 
-		var updateObj primitive.D
-
-		if table.NumberOfGuests != nil {
-			updateObj = append(updateObj, bson.E{Key: "number_of_guests", Value: table.NumberOfGuests})
-		}
-
-		if table.TableNumber != nil {
-			updateObj = append(updateObj, bson.E{Key: "table_number", Value: table.TableNumber})
-		}
-
-		table.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
-
-		upsert := true
-		opt := options.UpdateOptions{
-			Upsert: &upsert,
-		}
-
-		filter := bson.M{"table_id": tableId}
-
-		result, err := tableCollection.UpdateOne(
-			ctx,
-			filter,
-			bson.D{
-				{Key: "$set", Value: updateObj},
-			},
-			&opt,
-		)
+		result, err := tables.Update(ctx, tableId, table)
 
 		if err != nil {
+			if err == store.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "table not found"})
+				return
+			}
 			msg := "table item update failed"
 			c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
 			return
 		}
 
-		defer cancel()
 		c.JSON(http.StatusOK, result)
 	}
 }
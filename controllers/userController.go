@@ -8,24 +8,29 @@ import (
 	"log"
 	"net/http"
 	infrastructure "restaurant-management/Infrastructure"
-	"restaurant-management/database"
 	"restaurant-management/helpers"
 	"restaurant-management/models"
+	"restaurant-management/store"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
-var userCollection *mongo.Collection = database.OpenCollection(database.Client, "user")
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type UserRoleRequest struct {
+	Role string `json:"role" validate:"required,oneof=admin manager cashier waiter kitchen"`
+}
 
 // GET /users
-func GetUsers() gin.HandlerFunc {
+func GetUsers(users store.UserStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
 
 		recordPerPage, err := strconv.Atoi(c.Query("recordPerPage"))
 		if err != nil || recordPerPage < 1 {
@@ -44,50 +49,42 @@ func GetUsers() gin.HandlerFunc {
 			}
 		}
 
-		matchStage := bson.D{{Key: "$match", Value: bson.D{{}}}}
-		projectStage := bson.D{
-			{Key: "$project", Value: bson.D{
-				{Key: "_id", Value: 0},
-				{Key: "total_count", Value: 1},
-				{Key: "user_items", Value: bson.D{{Key: "$slice", Value: []interface{}{"$data", startIndex, recordPerPage}}}},
-			}}}
-
-		result, err := userCollection.Aggregate(ctx, mongo.Pipeline{
-			matchStage, projectStage})
-		defer cancel()
+		totalCount, allUsers, err := users.List(ctx, startIndex, recordPerPage)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occured while listing user items"})
+			return
 		}
 
-		var allUsers []bson.M
-		if err = result.All(ctx, &allUsers); err != nil {
-			log.Fatal(err)
-		}
-		c.JSON(http.StatusOK, allUsers[0])
+		c.JSON(http.StatusOK, gin.H{
+			"total_count": totalCount,
+			"user_items":  allUsers,
+		})
 
 	}
 }
 
 // GET /users/:user_id
-func GetUser() gin.HandlerFunc {
+func GetUser(users store.UserStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
 		userId := c.Param("user_id")
 
-		var user models.User
-
-		err := userCollection.FindOne(ctx, bson.M{"user_id": userId}).Decode(&user)
-
-		defer cancel()
+		user, err := users.Find(ctx, userId)
 		if err != nil {
+			if err == store.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occured while listing user items"})
+			return
 		}
 		c.JSON(http.StatusOK, user)
 	}
 }
 
 // POST /users/signup
-func SignUp() gin.HandlerFunc {
+func SignUp(users store.UserStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
 		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
@@ -108,7 +105,7 @@ func SignUp() gin.HandlerFunc {
 			return
 		}
 
-		count, err := userCollection.CountDocuments(ctx, bson.M{"email": user.Email})
+		count, err := users.CountByEmail(ctx, *user.Email)
 
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
@@ -130,7 +127,7 @@ func SignUp() gin.HandlerFunc {
 		}
 		user.Password = &password
 
-		count, err = userCollection.CountDocuments(ctx, bson.M{"phone": user.Phone})
+		count, err = users.CountByPhone(ctx, *user.Phone)
 
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
@@ -149,30 +146,57 @@ func SignUp() gin.HandlerFunc {
 		user.UpdatedAt = time.Now().UTC()
 		user.UserID = user.ID.Hex()
 
-		token, refreshToken, _ := helpers.GenerateAllTokens(*user.Email, *user.FirstName, *user.LastName, user.UserID)
+		// Roles are granted by an admin, never chosen at signup. The very first
+		// account becomes the admin so a fresh install can be bootstrapped;
+		// claiming that is atomic, so of two signups racing on an empty
+		// install only one becomes the admin.
+		role := models.RoleWaiter
+		total, _, err := users.List(ctx, 0, 1)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occured while counting users"})
+			return
+		}
+		if total == 0 {
+			switch err := users.ClaimFirstAdmin(ctx, user.UserID); err {
+			case nil:
+				role = models.RoleAdmin
+			case store.ErrConflict:
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "error occured while counting users"})
+				return
+			}
+		}
+		user.Role = &role
+
+		token, refreshToken, _ := helpers.GenerateAllTokens(*user.Email, *user.FirstName, *user.LastName, user.UserID, role)
 		user.Token = &token
 		user.RefreshToken = &refreshToken
-		resultInsertionNumber, insertErr := userCollection.InsertOne(ctx, user)
+		insertErr := users.Insert(ctx, user)
 		if insertErr != nil {
+			// Give the first admin back, or no account ever could be.
+			if role == models.RoleAdmin {
+				if err := users.ReleaseFirstAdmin(ctx, user.UserID); err != nil {
+					log.Printf("first admin claimed by %s, which was not stored, was not released: %v", user.UserID, err)
+				}
+			}
 			msg := "User item was not created"
 			c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
 			return
 		}
 
-		c.JSON(http.StatusOK, resultInsertionNumber)
+		c.JSON(http.StatusOK, store.InsertResult{InsertedID: user.ID})
 	}
 
 }
 
 // POST /users/login
-func Login() gin.HandlerFunc {
+func Login(users store.UserStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
 		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 		defer cancel()
 
 		var user models.User
-		var foundUser models.User
 
 		if err := c.BindJSON(&user); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{
@@ -181,7 +205,12 @@ func Login() gin.HandlerFunc {
 			return
 		}
 
-		err := userCollection.FindOne(ctx, bson.M{"email": user.Email}).Decode(&foundUser)
+		if user.Email == nil || user.Password == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "email and password are required"})
+			return
+		}
+
+		foundUser, err := users.FindByEmail(ctx, *user.Email)
 
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "incorrect email or password"})
@@ -189,16 +218,148 @@ func Login() gin.HandlerFunc {
 		}
 
 		passwordIsValid := infrastructure.VerifyPassword(*user.Password, *foundUser.Password)
-		defer cancel()
 		if !passwordIsValid {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "incorrect email or password"})
 			return
 		}
 
-		// token, refreshToken, _ := helpers.GenerateAllTokens(*user.Email, *user.FirstName, *user.LastName, user.UserID)
-		// helpers.UpdateAllTokens(token, refreshToken, foundUser.UserID)
+		token, refreshToken, err := helpers.GenerateAllTokens(*foundUser.Email, *foundUser.FirstName, *foundUser.LastName, foundUser.UserID, foundUser.CurrentRole())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occured while generating tokens"})
+			return
+		}
+
+		if err := helpers.UpdateAllTokens(users, token, refreshToken, foundUser.UserID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occured while storing tokens"})
+			return
+		}
+		foundUser.Token = &token
+		foundUser.RefreshToken = &refreshToken
 
 		c.JSON(http.StatusOK, foundUser)
 
 	}
 }
+
+// PATCH /users/:user_id/role
+func UpdateUserRole(users store.UserStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var request UserRoleRequest
+
+		userId := c.Param("user_id")
+
+		if err := c.BindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid request body",
+			})
+			return
+		}
+
+		if err := validate.Struct(request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		result, err := users.UpdateRole(ctx, userId, request.Role)
+		if err != nil {
+			if err == store.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occured while updating the user role"})
+			return
+		}
+
+		c.JSON(http.StatusOK, result)
+	}
+}
+
+// POST /users/refresh
+func RefreshTokens(users store.UserStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var request RefreshRequest
+
+		if err := c.BindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid request body",
+			})
+			return
+		}
+
+		if err := validate.Struct(request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		claims, msg := helpers.ValidateToken(request.RefreshToken)
+		if msg != "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": msg})
+			return
+		}
+		if claims.TokenType != helpers.RefreshToken {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "not a refresh token"})
+			return
+		}
+
+		foundUser, err := users.Find(ctx, claims.Uid)
+		if err != nil {
+			if err == store.ErrNotFound {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occured while fetching the user"})
+			return
+		}
+
+		token, refreshToken, err := helpers.GenerateAllTokens(*foundUser.Email, *foundUser.FirstName, *foundUser.LastName, foundUser.UserID, foundUser.CurrentRole())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occured while generating tokens"})
+			return
+		}
+
+		err = users.RotateTokens(ctx, foundUser.UserID, request.RefreshToken, token, refreshToken)
+		if err == store.ErrConflict {
+			// A correctly signed refresh token that is no longer the stored one
+			// has already been used. Assume it was stolen and end every session.
+			if err := users.UpdateTokens(ctx, foundUser.UserID, "", ""); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "error occured while revoking tokens"})
+				return
+			}
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "refresh token reuse detected, all sessions have been revoked"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occured while storing tokens"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"token":         token,
+			"refresh_token": refreshToken,
+		})
+	}
+}
+
+// POST /users/logout
+func Logout(users store.UserStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		if err := users.UpdateTokens(ctx, c.GetString("uid"), "", ""); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occured while revoking tokens"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "logged out"})
+	}
+}
@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"restaurant-management/database/memory"
+	"restaurant-management/models"
+	"restaurant-management/store"
+	"testing"
+)
+
+// failingInserts is a UserStore whose inserts fail while fail is set.
+type failingInserts struct {
+	store.UserStore
+	fail bool
+}
+
+func (s *failingInserts) Insert(ctx context.Context, user models.User) error {
+	if s.fail {
+		return errors.New("disk full")
+	}
+	return s.UserStore.Insert(ctx, user)
+}
+
+func TestSignUpReleasesFirstAdminWhenInsertFails(t *testing.T) {
+	t.Setenv("SECRET_KEY", "test")
+	users := &failingInserts{UserStore: memory.NewStores().Users, fail: true}
+	handler := SignUp(users)
+	signUp := func(email, phone string) int {
+		body := `{"first_name":"Ann","last_name":"Bee","Password":"secret1","email":"` + email + `","phone":"` + phone + `","user_id":"ignored"}`
+		return serve(handler, "", http.MethodPost, "/users/signup", "/users/signup", body).Code
+	}
+
+	if code := signUp("ann@example.com", "123"); code != http.StatusInternalServerError {
+		t.Fatalf("failed signup: status %d, want 500", code)
+	}
+
+	users.fail = false
+	if code := signUp("bob@example.com", "124"); code != http.StatusOK {
+		t.Fatalf("signup: status %d, want 200", code)
+	}
+	bob, err := users.FindByEmail(context.Background(), "bob@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if role := bob.CurrentRole(); role != models.RoleAdmin {
+		t.Errorf("first stored account has role %s, want %s", role, models.RoleAdmin)
+	}
+
+	if code := signUp("cat@example.com", "125"); code != http.StatusOK {
+		t.Fatalf("second signup: status %d, want 200", code)
+	}
+	cat, err := users.FindByEmail(context.Background(), "cat@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if role := cat.CurrentRole(); role != models.RoleWaiter {
+		t.Errorf("second account has role %s, want %s", role, models.RoleWaiter)
+	}
+}
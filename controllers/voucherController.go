@@ -0,0 +1,326 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"restaurant-management/models"
+	"restaurant-management/pricing"
+	"restaurant-management/store"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// VoucherUpdateRequest changes when a voucher expires and how many times it
+// can be redeemed; nothing else about a voucher changes once it is issued.
+type VoucherUpdateRequest struct {
+	ExpiresAt      *time.Time `json:"expires_at"`
+	MaxRedemptions *int       `json:"max_redemptions" validate:"omitempty,min=1"`
+}
+
+// VoucherRequest names the voucher to apply to an order.
+type VoucherRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+// GET /vouchers
+func GetVouchers(vouchers store.VoucherStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		allVouchers, err := vouchers.List(ctx)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occured while listing vouchers"})
+			return
+		}
+		c.JSON(http.StatusOK, allVouchers)
+	}
+}
+
+// GET /vouchers/:voucher_id
+func GetVoucher(vouchers store.VoucherStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		voucher, err := vouchers.Find(ctx, c.Param("voucher_id"))
+		if err != nil {
+			if err == store.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "voucher not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occured while fetching the voucher"})
+			return
+		}
+		c.JSON(http.StatusOK, voucher)
+	}
+}
+
+// POST /vouchers
+func CreateVoucher(vouchers store.VoucherStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var voucher models.Voucher
+		if err := c.BindJSON(&voucher); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := validate.Struct(voucher); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if voucher.Kind == models.VoucherPercentage && (voucher.Rate == nil || voucher.Amount != nil) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "a PERCENTAGE voucher takes a rate and no amount"})
+			return
+		}
+		if voucher.Kind != models.VoucherPercentage && (voucher.Amount == nil || voucher.Rate != nil) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "a " + voucher.Kind + " voucher takes an amount and no rate"})
+			return
+		}
+		for _, amount := range []*models.Money{voucher.Amount, voucher.MinimumSpend} {
+			if amount != nil && amount.IsNegative() {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "amounts cannot be negative"})
+				return
+			}
+		}
+
+		voucher.ID = primitive.NewObjectID()
+		voucher.VoucherID = voucher.ID.Hex()
+		voucher.Code = strings.ToUpper(voucher.Code)
+		voucher.Redemptions = 0
+		if voucher.FoodIDs == nil {
+			voucher.FoodIDs = []string{}
+		}
+		voucher.CreatedAt = time.Now().UTC()
+		voucher.UpdatedAt = voucher.CreatedAt
+
+		if err := vouchers.Insert(ctx, voucher); err != nil {
+			if err == store.ErrConflict {
+				c.JSON(http.StatusConflict, gin.H{"error": "a voucher with this code already exists"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "voucher was not created"})
+			return
+		}
+		c.JSON(http.StatusCreated, voucher)
+	}
+}
+
+// PATCH /vouchers/:voucher_id
+func UpdateVoucher(vouchers store.VoucherStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var request VoucherUpdateRequest
+		if err := c.BindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+			return
+		}
+		if err := validate.Struct(request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+			return
+		}
+
+		result, err := vouchers.Update(ctx, c.Param("voucher_id"), models.Voucher{
+			ExpiresAt:      request.ExpiresAt,
+			MaxRedemptions: request.MaxRedemptions,
+		})
+		if err != nil {
+			if err == store.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "voucher not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "voucher update failed"})
+			return
+		}
+		c.JSON(http.StatusOK, result)
+	}
+}
+
+// POST /orders/:order_id/voucher
+//
+// The voucher is redeemed as it is applied, so it counts against its limit
+// from then on; the discount itself is taken when the order is invoiced.
+func ApplyVoucher(vouchers store.VoucherStore, orders store.OrderStore, orderItems store.OrderItemStore, invoices store.InvoiceStore, foods store.FoodStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var request VoucherRequest
+		if err := c.BindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+			return
+		}
+		if err := validate.Struct(request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+			return
+		}
+
+		orderID := c.Param("order_id")
+		if status, msg := openOrderCheck(ctx, orders, invoices, orderID); msg != "" {
+			c.JSON(status, gin.H{"error": msg})
+			return
+		}
+		if _, err := vouchers.RedemptionByOrder(ctx, orderID); err != store.ErrNotFound {
+			if err == nil {
+				c.JSON(http.StatusConflict, gin.H{"error": "order already has a voucher applied"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occured while fetching the order's voucher"})
+			return
+		}
+
+		voucher, err := vouchers.FindByCode(ctx, strings.ToUpper(request.Code))
+		if err != nil {
+			if err == store.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "voucher not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occured while fetching the voucher"})
+			return
+		}
+		now := time.Now().UTC()
+		if voucher.ExpiredAt(now) {
+			c.JSON(http.StatusConflict, gin.H{"error": "voucher has expired"})
+			return
+		}
+
+		items, err := orderItems.ListByOrder(ctx, orderID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch order items"})
+			return
+		}
+		lines, err := orderLines(ctx, foods, billableItems(items))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch order items"})
+			return
+		}
+		if err := pricing.Discount(voucher, lines); err != nil {
+			if err == pricing.ErrMinimumSpend || err == models.ErrCurrencyMismatch {
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to work out the discount"})
+			return
+		}
+
+		redemption := models.VoucherRedemption{
+			ID:         primitive.NewObjectID(),
+			VoucherID:  voucher.VoucherID,
+			Code:       voucher.Code,
+			OrderID:    orderID,
+			RedeemedBy: c.GetString("uid"),
+			RedeemedAt: now,
+		}
+		redemption.RedemptionID = redemption.ID.Hex()
+
+		if err := vouchers.Redeem(ctx, redemption); err != nil {
+			if err == store.ErrConflict {
+				c.JSON(http.StatusConflict, gin.H{"error": "voucher has been redeemed as many times as it can be, or the order already has one"})
+				return
+			}
+			if err == store.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "voucher not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "voucher was not redeemed"})
+			return
+		}
+
+		discount := models.NewMoney(0, "")
+		for _, line := range lines {
+			discount, _ = discount.Add(line.Discount)
+		}
+		c.JSON(http.StatusCreated, gin.H{"redemption": redemption, "discount": discount})
+	}
+}
+
+// DELETE /orders/:order_id/voucher
+//
+// Taking a voucher off an order releases its redemption, so a single-use
+// voucher can be used again.
+func RemoveVoucher(vouchers store.VoucherStore, orders store.OrderStore, invoices store.InvoiceStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		orderID := c.Param("order_id")
+		if status, msg := openOrderCheck(ctx, orders, invoices, orderID); msg != "" {
+			c.JSON(status, gin.H{"error": msg})
+			return
+		}
+
+		if err := vouchers.Release(ctx, orderID, time.Now().UTC()); err != nil {
+			if err == store.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "order has no voucher applied"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "voucher was not released"})
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// openOrderCheck reports, as a status and message, why orderID can no
+// longer be changed, its items or its voucher: the order does not exist, is
+// finished, or has already been invoiced. The message is empty if it can.
+func openOrderCheck(ctx context.Context, orders store.OrderStore, invoices store.InvoiceStore, orderID string) (int, string) {
+	order, err := orders.Find(ctx, orderID)
+	if err != nil {
+		if err == store.ErrNotFound {
+			return http.StatusNotFound, "order not found"
+		}
+		return http.StatusInternalServerError, "error occurred while fetching the order"
+	}
+	switch order.CurrentStatus() {
+	case models.OrderStatusClosed, models.OrderStatusCancelled, models.OrderStatusVoided:
+		return http.StatusConflict, "order is " + order.CurrentStatus()
+	}
+
+	billed, err := invoices.ListByOrder(ctx, orderID)
+	if err != nil {
+		return http.StatusInternalServerError, "failed to fetch invoices"
+	}
+	for _, invoice := range billed {
+		if invoice.PaymentStatus == nil || *invoice.PaymentStatus != models.PaymentVoid {
+			return http.StatusConflict, "order is already invoiced"
+		}
+	}
+	return 0, ""
+}
+
+// orderDiscount sets the discount of the voucher applied to orderID, if it
+// has one, on lines, and returns the voucher's code.
+func orderDiscount(ctx context.Context, vouchers store.VoucherStore, orderID string, lines []models.InvoiceLine) (*string, error) {
+	redemption, err := vouchers.RedemptionByOrder(ctx, orderID)
+	if err != nil {
+		if err == store.ErrNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	voucher, err := vouchers.Find(ctx, redemption.VoucherID)
+	if err != nil {
+		return nil, err
+	}
+	if err := pricing.Discount(voucher, lines); err != nil {
+		return nil, err
+	}
+	return &voucher.Code, nil
+}
+
+// releaseVoucher releases the voucher applied to orderID, if any, as when
+// the order is cancelled or voided.
+func releaseVoucher(ctx context.Context, vouchers store.VoucherStore, orderID string) {
+	if err := vouchers.Release(ctx, orderID, time.Now().UTC()); err != nil && err != store.ErrNotFound {
+		log.Printf("voucher of order %s not released: %v", orderID, err)
+	}
+}
@@ -0,0 +1,136 @@
+package database
+
+import (
+	"context"
+	"restaurant-management/models"
+	"restaurant-management/store"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type CreditNoteStore struct {
+	collection *mongo.Collection
+	invoices   *mongo.Collection
+}
+
+func NewCreditNoteStore(client *mongo.Client) *CreditNoteStore {
+	return &CreditNoteStore{
+		collection: OpenCollection(client, "credit_note"),
+		invoices:   OpenCollection(client, "invoice"),
+	}
+}
+
+func (s *CreditNoteStore) find(ctx context.Context, filter bson.M) ([]models.CreditNote, error) {
+	cursor, err := s.collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	notes := []models.CreditNote{}
+	if err := cursor.All(ctx, &notes); err != nil {
+		return nil, err
+	}
+	return notes, nil
+}
+
+func (s *CreditNoteStore) ListByInvoice(ctx context.Context, invoiceID string) ([]models.CreditNote, error) {
+	return s.find(ctx, bson.M{"invoice_id": invoiceID})
+}
+
+func (s *CreditNoteStore) ListBetween(ctx context.Context, from, to time.Time) ([]models.CreditNote, error) {
+	return s.find(ctx, bson.M{"created_at": bson.M{"$gte": from, "$lt": to}})
+}
+
+func (s *CreditNoteStore) Find(ctx context.Context, creditNoteID string) (models.CreditNote, error) {
+	var note models.CreditNote
+	err := s.collection.FindOne(ctx, bson.M{"credit_note_id": creditNoteID}).Decode(&note)
+	return note, findOne(err)
+}
+
+// Insert applies the credit to the invoice with a compare-and-set on its
+// status and running totals before storing the note, the same way
+// PaymentStore.Insert settles a payment.
+func (s *CreditNoteStore) Insert(ctx context.Context, note models.CreditNote, credit store.Credit) error {
+	result, err := s.invoices.UpdateOne(ctx,
+		bson.M{
+			"invoice_id":             note.InvoiceID,
+			"payment_status":         credit.StatusBefore,
+			"amount_paid.amount":     unchanged(credit.PaidBefore),
+			"amount_refunded.amount": unchanged(credit.RefundedBefore),
+		},
+		bson.D{{Key: "$set", Value: bson.D{
+			{Key: "updated_at", Value: note.CreatedAt},
+			{Key: "amount_refunded", Value: credit.AmountRefunded},
+			{Key: "payment_status", Value: credit.PaymentStatus},
+		}}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		if err := s.invoices.FindOne(ctx, bson.M{"invoice_id": note.InvoiceID}).Err(); err != nil {
+			return findOne(err)
+		}
+		return store.ErrConflict
+	}
+
+	if _, err := s.collection.InsertOne(ctx, note); err != nil {
+		s.invoices.UpdateOne(ctx,
+			bson.M{"invoice_id": note.InvoiceID, "amount_refunded.amount": unchanged(credit.AmountRefunded)},
+			bson.D{{Key: "$set", Value: bson.D{
+				{Key: "amount_refunded", Value: credit.RefundedBefore},
+				{Key: "payment_status", Value: credit.StatusBefore},
+			}}},
+		)
+		return err
+	}
+	return nil
+}
+
+func (s *CreditNoteStore) RecordRefunds(ctx context.Context, creditNoteID string, refunds []models.Refund) error {
+	result, err := s.collection.UpdateOne(ctx,
+		bson.M{"credit_note_id": creditNoteID},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "refunds", Value: refunds}}}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+// Cancel puts the invoice back with a compare-and-set on what Insert left it
+// at before removing the note, so a note is never removed while its credit
+// still counts.
+func (s *CreditNoteStore) Cancel(ctx context.Context, note models.CreditNote, credit store.Credit) error {
+	result, err := s.invoices.UpdateOne(ctx,
+		bson.M{
+			"invoice_id":             note.InvoiceID,
+			"payment_status":         credit.PaymentStatus,
+			"amount_refunded.amount": credit.AmountRefunded.Amount,
+		},
+		bson.D{{Key: "$set", Value: bson.D{
+			{Key: "updated_at", Value: time.Now().UTC()},
+			{Key: "amount_refunded", Value: credit.RefundedBefore},
+			{Key: "payment_status", Value: credit.StatusBefore},
+		}}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		if err := s.invoices.FindOne(ctx, bson.M{"invoice_id": note.InvoiceID}).Err(); err != nil {
+			return findOne(err)
+		}
+		return store.ErrConflict
+	}
+
+	_, err = s.collection.DeleteOne(ctx, bson.M{"credit_note_id": note.CreditNoteID})
+	return err
+}
@@ -41,8 +41,6 @@ func DBinstance() *mongo.Client {
 	return client
 }
 
-var Client *mongo.Client = DBinstance()
-
 func OpenCollection(client *mongo.Client, collectionName string) *mongo.Collection {
 
 	var collection *mongo.Collection = client.Database("restaurant").Collection(collectionName)
@@ -0,0 +1,150 @@
+package database
+
+import (
+	"context"
+	"restaurant-management/models"
+	"restaurant-management/store"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type FoodStore struct {
+	collection *mongo.Collection
+}
+
+func NewFoodStore(client *mongo.Client) *FoodStore {
+	return &FoodStore{collection: OpenCollection(client, "food")}
+}
+
+func (s *FoodStore) List(ctx context.Context, filter store.FoodFilter, startIndex, recordPerPage int) (int, []models.Food, error) {
+	match := bson.D{}
+	if filter.Available {
+		match = append(match, bson.E{Key: "available", Value: bson.M{"$ne": false}})
+	}
+	if filter.MenuIDs != nil {
+		match = append(match, bson.E{Key: "$or", Value: bson.A{
+			bson.M{"menu_id": bson.M{"$in": filter.MenuIDs}},
+			bson.M{"menu_id": nil},
+		}})
+	}
+	matchStage := bson.D{
+		{Key: "$match", Value: match},
+	}
+
+	groupStage := bson.D{
+		{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: nil},
+			{Key: "total_count", Value: bson.D{
+				{Key: "$sum", Value: 1},
+			}},
+			{Key: "data", Value: bson.D{
+				{Key: "$push", Value: "$$ROOT"},
+			}},
+		}},
+	}
+
+	projectStage := bson.D{
+		{Key: "$project", Value: bson.D{
+			{Key: "_id", Value: 0},
+			{Key: "total_count", Value: 1},
+			{Key: "food_items", Value: bson.D{
+				{Key: "$slice", Value: []interface{}{"$data", startIndex, recordPerPage}},
+			}},
+		}},
+	}
+
+	cursor, err := s.collection.Aggregate(ctx, mongo.Pipeline{
+		matchStage, groupStage, projectStage,
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		TotalCount int           `bson:"total_count"`
+		FoodItems  []models.Food `bson:"food_items"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return 0, nil, err
+	}
+
+	if len(results) == 0 {
+		return 0, []models.Food{}, nil
+	}
+	return results[0].TotalCount, results[0].FoodItems, nil
+}
+
+func (s *FoodStore) Find(ctx context.Context, foodID string) (models.Food, error) {
+	var food models.Food
+	err := s.collection.FindOne(ctx, bson.M{"food_id": foodID}).Decode(&food)
+	return food, findOne(err)
+}
+
+func (s *FoodStore) Insert(ctx context.Context, food models.Food) error {
+	_, err := s.collection.InsertOne(ctx, food)
+	return err
+}
+
+func (s *FoodStore) Update(ctx context.Context, foodID string, food models.Food) (store.UpdateResult, error) {
+	updateObj := bson.D{{Key: "updated_at", Value: time.Now().UTC()}}
+
+	if food.Name != nil {
+		updateObj = append(updateObj, bson.E{Key: "name", Value: *food.Name})
+	}
+	if food.Price != nil {
+		updateObj = append(updateObj, bson.E{Key: "price", Value: *food.Price})
+	}
+	if food.FoodImage != nil {
+		updateObj = append(updateObj, bson.E{Key: "food_image", Value: *food.FoodImage})
+	}
+	if food.MenuID != nil {
+		updateObj = append(updateObj, bson.E{Key: "menu_id", Value: *food.MenuID})
+	}
+	if food.Station != nil {
+		updateObj = append(updateObj, bson.E{Key: "station", Value: *food.Station})
+	}
+	if food.TaxCategory != nil {
+		updateObj = append(updateObj, bson.E{Key: "tax_category", Value: *food.TaxCategory})
+	}
+	if food.ModifierGroups != nil {
+		updateObj = append(updateObj, bson.E{Key: "modifier_groups", Value: food.ModifierGroups})
+	}
+	if food.SizePrices != nil {
+		updateObj = append(updateObj, bson.E{Key: "size_prices", Value: food.SizePrices})
+	}
+
+	result, err := s.collection.UpdateOne(
+		ctx,
+		bson.M{"food_id": foodID},
+		bson.D{{Key: "$set", Value: updateObj}},
+	)
+	if err != nil {
+		return store.UpdateResult{}, err
+	}
+	return updateResult(result)
+}
+
+func (s *FoodStore) SetAvailability(ctx context.Context, foodID string, available bool, reason string) error {
+	var unavailableReason interface{}
+	if !available {
+		unavailableReason = reason
+	}
+
+	result, err := s.collection.UpdateOne(
+		ctx,
+		bson.M{"food_id": foodID},
+		bson.D{{Key: "$set", Value: bson.D{
+			{Key: "updated_at", Value: time.Now().UTC()},
+			{Key: "available", Value: available},
+			{Key: "unavailable_reason", Value: unavailableReason},
+		}}},
+	)
+	if err != nil {
+		return err
+	}
+	_, err = updateResult(result)
+	return err
+}
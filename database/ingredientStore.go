@@ -0,0 +1,202 @@
+package database
+
+import (
+	"context"
+	"log"
+	"restaurant-management/models"
+	"restaurant-management/store"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type IngredientStore struct {
+	collection *mongo.Collection
+	movements  *mongo.Collection
+}
+
+func NewIngredientStore(client *mongo.Client) *IngredientStore {
+	return &IngredientStore{
+		collection: OpenCollection(client, "ingredient"),
+		movements:  OpenCollection(client, "stock_movement"),
+	}
+}
+
+func (s *IngredientStore) find(ctx context.Context, filter interface{}) ([]models.Ingredient, error) {
+	cursor, err := s.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	ingredients := []models.Ingredient{}
+	if err := cursor.All(ctx, &ingredients); err != nil {
+		return nil, err
+	}
+	return ingredients, nil
+}
+
+func (s *IngredientStore) List(ctx context.Context) ([]models.Ingredient, error) {
+	return s.find(ctx, bson.M{})
+}
+
+func (s *IngredientStore) ListLowStock(ctx context.Context) ([]models.Ingredient, error) {
+	return s.find(ctx, bson.M{
+		"reorder_level": bson.M{"$ne": nil},
+		"$expr":         bson.M{"$lte": bson.A{"$stock", "$reorder_level"}},
+	})
+}
+
+func (s *IngredientStore) Find(ctx context.Context, ingredientID string) (models.Ingredient, error) {
+	var ingredient models.Ingredient
+	err := s.collection.FindOne(ctx, bson.M{"ingredient_id": ingredientID}).Decode(&ingredient)
+	return ingredient, findOne(err)
+}
+
+func (s *IngredientStore) Insert(ctx context.Context, ingredient models.Ingredient) error {
+	_, err := s.collection.InsertOne(ctx, ingredient)
+	return err
+}
+
+func (s *IngredientStore) Update(ctx context.Context, ingredientID string, ingredient models.Ingredient) (store.UpdateResult, error) {
+	updateObj := bson.D{{Key: "updated_at", Value: time.Now().UTC()}}
+
+	if ingredient.Name != nil {
+		updateObj = append(updateObj, bson.E{Key: "name", Value: *ingredient.Name})
+	}
+	if ingredient.ReorderLevel != nil {
+		updateObj = append(updateObj, bson.E{Key: "reorder_level", Value: *ingredient.ReorderLevel})
+	}
+
+	result, err := s.collection.UpdateOne(
+		ctx,
+		bson.M{"ingredient_id": ingredientID},
+		bson.D{{Key: "$set", Value: updateObj}},
+	)
+	if err != nil {
+		return store.UpdateResult{}, err
+	}
+	return updateResult(result)
+}
+
+// Move increments each ingredient's stock atomically, reading back the stock
+// it leaves, and then records the movements. There is no transaction, so if a
+// step fails the changes already applied are taken back out again.
+func (s *IngredientStore) Move(ctx context.Context, movements []models.StockMovement) error {
+	return s.move(ctx, movements, false)
+}
+
+func (s *IngredientStore) Reserve(ctx context.Context, movements []models.StockMovement) error {
+	return s.move(ctx, movements, true)
+}
+
+// move applies movements one at a time, undoing those already applied if one
+// fails. When reserving, a movement only matches its ingredient while the
+// stock covers it.
+func (s *IngredientStore) move(ctx context.Context, movements []models.StockMovement, reserve bool) error {
+	for i := range movements {
+		filter := bson.M{"ingredient_id": movements[i].IngredientID}
+		if reserve {
+			filter["stock"] = bson.M{"$gte": -movements[i].Change}
+		}
+		var ingredient models.Ingredient
+		err := s.collection.FindOneAndUpdate(ctx,
+			filter,
+			bson.M{
+				"$inc": bson.M{"stock": movements[i].Change},
+				"$set": bson.M{"updated_at": movements[i].CreatedAt},
+			},
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&ingredient)
+		if err != nil {
+			s.undo(ctx, movements[:i])
+			if reserve && err == mongo.ErrNoDocuments {
+				if n, countErr := s.collection.CountDocuments(ctx, bson.M{"ingredient_id": movements[i].IngredientID}); countErr == nil && n > 0 {
+					return store.ErrConflict
+				}
+			}
+			return findOne(err)
+		}
+		movements[i].StockAfter = ingredient.Stock
+	}
+
+	documents := make([]interface{}, len(movements))
+	for i, movement := range movements {
+		documents[i] = movement
+	}
+	if _, err := s.movements.InsertMany(ctx, documents); err != nil {
+		s.undo(ctx, movements)
+		return err
+	}
+	return nil
+}
+
+// undo takes the changes of movements that were applied back out of stock.
+func (s *IngredientStore) undo(ctx context.Context, movements []models.StockMovement) {
+	for _, movement := range movements {
+		_, err := s.collection.UpdateOne(ctx,
+			bson.M{"ingredient_id": movement.IngredientID},
+			bson.M{"$inc": bson.M{"stock": -movement.Change}},
+		)
+		if err != nil {
+			log.Printf("stock of ingredient %s is off by %s: %v", movement.IngredientID, movement.Change, err)
+		}
+	}
+}
+
+func (s *IngredientStore) findMovements(ctx context.Context, filter bson.M) ([]models.StockMovement, error) {
+	cursor, err := s.movements.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	movements := []models.StockMovement{}
+	if err := cursor.All(ctx, &movements); err != nil {
+		return nil, err
+	}
+	return movements, nil
+}
+
+func (s *IngredientStore) Movements(ctx context.Context, ingredientID string) ([]models.StockMovement, error) {
+	return s.findMovements(ctx, bson.M{"ingredient_id": ingredientID})
+}
+
+func (s *IngredientStore) MovementsByOrder(ctx context.Context, orderID string) ([]models.StockMovement, error) {
+	return s.findMovements(ctx, bson.M{"order_id": orderID})
+}
+
+type RecipeStore struct {
+	collection *mongo.Collection
+}
+
+func NewRecipeStore(client *mongo.Client) *RecipeStore {
+	return &RecipeStore{collection: OpenCollection(client, "recipe")}
+}
+
+func (s *RecipeStore) Find(ctx context.Context, foodID string) (models.Recipe, error) {
+	var recipe models.Recipe
+	err := s.collection.FindOne(ctx, bson.M{"food_id": foodID}).Decode(&recipe)
+	return recipe, findOne(err)
+}
+
+func (s *RecipeStore) Put(ctx context.Context, recipe models.Recipe) error {
+	_, err := s.collection.ReplaceOne(ctx, bson.M{"food_id": recipe.FoodID}, recipe, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (s *RecipeStore) ListByIngredient(ctx context.Context, ingredientID string) ([]models.Recipe, error) {
+	cursor, err := s.collection.Find(ctx, bson.M{"items.ingredient_id": ingredientID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	recipes := []models.Recipe{}
+	if err := cursor.All(ctx, &recipes); err != nil {
+		return nil, err
+	}
+	return recipes, nil
+}
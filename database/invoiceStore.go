@@ -0,0 +1,164 @@
+package database
+
+import (
+	"context"
+	"restaurant-management/models"
+	"restaurant-management/store"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type InvoiceStore struct {
+	client     *mongo.Client
+	collection *mongo.Collection
+	// counters holds the last number issued in each invoice series, keyed
+	// by the series.
+	counters *mongo.Collection
+}
+
+func NewInvoiceStore(client *mongo.Client) *InvoiceStore {
+	return &InvoiceStore{
+		client:     client,
+		collection: OpenCollection(client, "invoice"),
+		counters:   OpenCollection(client, "invoice_counter"),
+	}
+}
+
+func (s *InvoiceStore) List(ctx context.Context) ([]models.Invoice, error) {
+	return s.find(ctx, bson.M{})
+}
+
+func (s *InvoiceStore) ListByOrder(ctx context.Context, orderID string) ([]models.Invoice, error) {
+	return s.find(ctx, bson.M{"order_id": orderID})
+}
+
+func (s *InvoiceStore) find(ctx context.Context, filter bson.M) ([]models.Invoice, error) {
+	cursor, err := s.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	invoices := []models.Invoice{}
+	if err := cursor.All(ctx, &invoices); err != nil {
+		return nil, err
+	}
+	return invoices, nil
+}
+
+func (s *InvoiceStore) Find(ctx context.Context, invoiceID string) (models.Invoice, error) {
+	var invoice models.Invoice
+	err := s.collection.FindOne(ctx, bson.M{"invoice_id": invoiceID}).Decode(&invoice)
+	return invoice, findOne(err)
+}
+
+func (s *InvoiceStore) Insert(ctx context.Context, invoice models.Invoice, series string) error {
+	return s.InsertMany(ctx, []models.Invoice{invoice}, series)
+}
+
+// InsertMany numbers and writes the invoices in one transaction, so a number
+// is only used up by an invoice that is stored and no invoice is ever seen
+// without one. As in the SQL store, the invoices are refused if the order
+// already has a live invoice. Transactions need MongoDB to run as a replica
+// set.
+func (s *InvoiceStore) InsertMany(ctx context.Context, invoices []models.Invoice, series string) error {
+	session, err := s.client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	numbered := make([]models.Invoice, len(invoices))
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		copy(numbered, invoices)
+		return nil, s.insertNumbered(sc, numbered, series)
+	})
+	if err != nil {
+		return err
+	}
+	copy(invoices, numbered)
+	return nil
+}
+
+// insertNumbered does the work of InsertMany inside its transaction, which
+// may run it more than once. The counter is advanced first: concurrent
+// transactions numbering in the same series then conflict on it and are
+// retried one after another, so the second sees the first's invoices.
+func (s *InvoiceStore) insertNumbered(ctx mongo.SessionContext, invoices []models.Invoice, series string) error {
+	var counter struct {
+		LastNumber int64 `bson:"last_number"`
+	}
+	err := s.counters.FindOneAndUpdate(ctx,
+		bson.M{"_id": series},
+		bson.M{"$inc": bson.M{"last_number": int64(len(invoices))}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&counter)
+	if err != nil {
+		return err
+	}
+
+	count, err := s.collection.CountDocuments(ctx, bson.M{
+		"order_id":       invoices[0].OrderID,
+		"payment_status": bson.M{"$ne": models.PaymentVoid},
+	})
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return store.ErrConflict
+	}
+
+	first := counter.LastNumber - int64(len(invoices)) + 1
+	docs := make([]interface{}, 0, len(invoices))
+	for i := range invoices {
+		invoices[i].InvoiceNumber = models.FormatInvoiceNumber(series, first+int64(i))
+		docs = append(docs, invoices[i])
+	}
+	_, err = s.collection.InsertMany(ctx, docs)
+	return err
+}
+
+func (s *InvoiceStore) Update(ctx context.Context, invoiceID string, invoice models.Invoice) (store.UpdateResult, error) {
+	updateObj := bson.D{{Key: "updated_at", Value: time.Now().UTC()}}
+
+	if invoice.OrderID != "" {
+		updateObj = append(updateObj, bson.E{Key: "order_id", Value: invoice.OrderID})
+	}
+	if invoice.PaymentMethod != nil {
+		updateObj = append(updateObj, bson.E{Key: "payment_method", Value: *invoice.PaymentMethod})
+	}
+	if invoice.PaymentStatus != nil {
+		updateObj = append(updateObj, bson.E{Key: "payment_status", Value: *invoice.PaymentStatus})
+	}
+	if !invoice.PaymentDueDate.IsZero() {
+		updateObj = append(updateObj, bson.E{Key: "payment_due_date", Value: invoice.PaymentDueDate})
+	}
+	if invoice.GrandTotal != nil {
+		updateObj = append(updateObj,
+			bson.E{Key: "lines", Value: invoice.Lines},
+			bson.E{Key: "taxes", Value: invoice.Taxes},
+			bson.E{Key: "voucher_code", Value: invoice.VoucherCode},
+			bson.E{Key: "discount", Value: invoice.Discount},
+			bson.E{Key: "subtotal", Value: invoice.Subtotal},
+			bson.E{Key: "tax_total", Value: invoice.TaxTotal},
+			bson.E{Key: "service_charge_rate", Value: invoice.ServiceChargeRate},
+			bson.E{Key: "service_charge", Value: invoice.ServiceCharge},
+			bson.E{Key: "rounding", Value: invoice.Rounding},
+			bson.E{Key: "grand_total", Value: invoice.GrandTotal},
+			bson.E{Key: "split", Value: invoice.Split},
+		)
+	}
+
+	result, err := s.collection.UpdateOne(
+		ctx,
+		bson.M{"invoice_id": invoiceID},
+		bson.D{{Key: "$set", Value: updateObj}},
+	)
+	if err != nil {
+		return store.UpdateResult{}, err
+	}
+	return updateResult(result)
+}
@@ -0,0 +1,96 @@
+package memory
+
+import (
+	"context"
+	"restaurant-management/models"
+	"restaurant-management/store"
+	"time"
+)
+
+type CreditNoteStore struct {
+	db *DB
+}
+
+func (s *CreditNoteStore) ListByInvoice(ctx context.Context, invoiceID string) ([]models.CreditNote, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	return s.db.creditNotes.filter(func(n models.CreditNote) bool { return n.InvoiceID == invoiceID }), nil
+}
+
+func (s *CreditNoteStore) ListBetween(ctx context.Context, from, to time.Time) ([]models.CreditNote, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	return s.db.creditNotes.filter(func(n models.CreditNote) bool { return between(n.CreatedAt, from, to) }), nil
+}
+
+func (s *CreditNoteStore) Find(ctx context.Context, creditNoteID string) (models.CreditNote, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	note, ok := s.db.creditNotes.find(creditNoteID)
+	if !ok {
+		return note, store.ErrNotFound
+	}
+	return note, nil
+}
+
+func (s *CreditNoteStore) Insert(ctx context.Context, note models.CreditNote, credit store.Credit) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	invoice, ok := s.db.invoices.find(note.InvoiceID)
+	if !ok {
+		return store.ErrNotFound
+	}
+	var paid, refunded int64
+	if invoice.AmountPaid != nil {
+		paid = invoice.AmountPaid.Amount
+	}
+	if invoice.AmountRefunded != nil {
+		refunded = invoice.AmountRefunded.Amount
+	}
+	if paid != credit.PaidBefore.Amount || refunded != credit.RefundedBefore.Amount || invoice.PaymentStatus == nil || *invoice.PaymentStatus != credit.StatusBefore {
+		return store.ErrConflict
+	}
+
+	s.db.creditNotes.insert(note)
+	s.db.invoices.update(note.InvoiceID, func(existing *models.Invoice) {
+		existing.UpdatedAt = note.CreatedAt
+		existing.AmountRefunded = &credit.AmountRefunded
+		existing.PaymentStatus = &credit.PaymentStatus
+	})
+	return nil
+}
+
+func (s *CreditNoteStore) RecordRefunds(ctx context.Context, creditNoteID string, refunds []models.Refund) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if !s.db.creditNotes.update(creditNoteID, func(existing *models.CreditNote) { existing.Refunds = refunds }) {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (s *CreditNoteStore) Cancel(ctx context.Context, note models.CreditNote, credit store.Credit) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	invoice, ok := s.db.invoices.find(note.InvoiceID)
+	if !ok {
+		return store.ErrNotFound
+	}
+	if invoice.AmountRefunded == nil || invoice.AmountRefunded.Amount != credit.AmountRefunded.Amount || invoice.PaymentStatus == nil || *invoice.PaymentStatus != credit.PaymentStatus {
+		return store.ErrConflict
+	}
+
+	s.db.creditNotes.remove(note.CreditNoteID)
+	s.db.invoices.update(note.InvoiceID, func(existing *models.Invoice) {
+		existing.UpdatedAt = time.Now().UTC()
+		existing.AmountRefunded = &credit.RefundedBefore
+		existing.PaymentStatus = &credit.StatusBefore
+	})
+	return nil
+}
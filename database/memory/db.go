@@ -0,0 +1,157 @@
+// Package memory is an in-process implementation of every entity store. It
+// needs no external service, so it backs local demos and tests.
+package memory
+
+import (
+	"restaurant-management/models"
+	"restaurant-management/store"
+	"sync"
+	"time"
+)
+
+// DB holds every collection in memory. The stores returned by NewStores share
+// one DB so that ItemsByOrder can join across collections the way the Mongo
+// pipeline does.
+type DB struct {
+	mu           sync.RWMutex
+	foods        collection[models.Food]
+	menus        collection[models.Menu]
+	tables       collection[models.Table]
+	reservations collection[models.Reservation]
+	orders       collection[models.Order]
+	orderItems   collection[models.OrderItem]
+	invoices     collection[models.Invoice]
+	payments     collection[models.Payment]
+	creditNotes  collection[models.CreditNote]
+	ingredients  collection[models.Ingredient]
+	recipes      collection[models.Recipe]
+	movements    collection[models.StockMovement]
+	priceRules   collection[models.PriceRule]
+	vouchers     collection[models.Voucher]
+	redemptions  collection[models.VoucherRedemption]
+	users        collection[models.User]
+	// invoiceNumbers holds the last number issued in each invoice series.
+	invoiceNumbers map[string]int64
+	// firstAdmin is the user that claimed the first admin account.
+	firstAdmin string
+}
+
+func NewDB() *DB {
+	return &DB{
+		foods:        newCollection(func(f models.Food) string { return f.FoodID }),
+		menus:        newCollection(func(m models.Menu) string { return m.MenuID }),
+		tables:       newCollection(func(t models.Table) string { return t.TableID }),
+		reservations: newCollection(func(r models.Reservation) string { return r.ReservationID }),
+		orders:       newCollection(func(o models.Order) string { return o.OrderID }),
+		orderItems:   newCollection(func(i models.OrderItem) string { return i.OrderItemID }),
+		invoices:     newCollection(func(i models.Invoice) string { return i.InvoiceID }),
+		payments:     newCollection(func(p models.Payment) string { return p.PaymentID }),
+		creditNotes:  newCollection(func(n models.CreditNote) string { return n.CreditNoteID }),
+		ingredients:  newCollection(func(i models.Ingredient) string { return i.IngredientID }),
+		recipes:      newCollection(func(r models.Recipe) string { return r.FoodID }),
+		movements:    newCollection(func(m models.StockMovement) string { return m.MovementID }),
+		priceRules:   newCollection(func(r models.PriceRule) string { return r.PriceRuleID }),
+		vouchers:     newCollection(func(v models.Voucher) string { return v.VoucherID }),
+		redemptions:  newCollection(func(r models.VoucherRedemption) string { return r.RedemptionID }),
+		users:        newCollection(func(u models.User) string { return u.UserID }),
+
+		invoiceNumbers: map[string]int64{},
+	}
+}
+
+// NewStores returns the in-memory implementation of every entity store, all
+// backed by a fresh DB.
+func NewStores() store.Stores {
+	db := NewDB()
+	return store.Stores{
+		Foods:        &FoodStore{db: db},
+		Menus:        &MenuStore{db: db},
+		Tables:       &TableStore{db: db},
+		Reservations: &ReservationStore{db: db},
+		Orders:       &OrderStore{db: db},
+		OrderItems:   &OrderItemStore{db: db},
+		Invoices:     &InvoiceStore{db: db},
+		Payments:     &PaymentStore{db: db},
+		CreditNotes:  &CreditNoteStore{db: db},
+		Ingredients:  &IngredientStore{db: db},
+		Recipes:      &RecipeStore{db: db},
+		PriceRules:   &PriceRuleStore{db: db},
+		Vouchers:     &VoucherStore{db: db},
+		Users:        &UserStore{db: db},
+	}
+}
+
+// collection keeps records in insertion order, which is the order Mongo
+// returns them in for an unsorted find.
+type collection[T any] struct {
+	items []T
+	key   func(T) string
+}
+
+func newCollection[T any](key func(T) string) collection[T] {
+	return collection[T]{key: key}
+}
+
+func (c *collection[T]) all() []T {
+	out := make([]T, len(c.items))
+	copy(out, c.items)
+	return out
+}
+
+func (c *collection[T]) find(id string) (T, bool) {
+	for _, item := range c.items {
+		if c.key(item) == id {
+			return item, true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+func (c *collection[T]) filter(match func(T) bool) []T {
+	out := []T{}
+	for _, item := range c.items {
+		if match(item) {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+func (c *collection[T]) insert(items ...T) {
+	c.items = append(c.items, items...)
+}
+
+// update applies fn to the record with id and reports whether it existed.
+func (c *collection[T]) update(id string, fn func(*T)) bool {
+	for i := range c.items {
+		if c.key(c.items[i]) == id {
+			fn(&c.items[i])
+			return true
+		}
+	}
+	return false
+}
+
+// remove drops the record with id and reports whether it existed.
+func (c *collection[T]) remove(id string) bool {
+	for i := range c.items {
+		if c.key(c.items[i]) == id {
+			c.items = append(c.items[:i], c.items[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// between reports whether t falls in [from, to).
+func between(t, from, to time.Time) bool {
+	return !t.Before(from) && t.Before(to)
+}
+
+func updated(found bool) (store.UpdateResult, error) {
+	if !found {
+		return store.UpdateResult{}, store.ErrNotFound
+	}
+	return store.UpdateResult{MatchedCount: 1, ModifiedCount: 1}, nil
+}
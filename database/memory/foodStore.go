@@ -0,0 +1,105 @@
+package memory
+
+import (
+	"context"
+	"restaurant-management/models"
+	"restaurant-management/store"
+	"slices"
+	"time"
+)
+
+type FoodStore struct {
+	db *DB
+}
+
+func (s *FoodStore) List(ctx context.Context, filter store.FoodFilter, startIndex, recordPerPage int) (int, []models.Food, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	foods := s.db.foods.filter(func(f models.Food) bool {
+		if filter.Available && !f.IsAvailable() {
+			return false
+		}
+		return filter.MenuIDs == nil || f.MenuID == nil || slices.Contains(filter.MenuIDs, *f.MenuID)
+	})
+	return len(foods), page(foods, startIndex, recordPerPage), nil
+}
+
+func (s *FoodStore) Find(ctx context.Context, foodID string) (models.Food, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	food, ok := s.db.foods.find(foodID)
+	if !ok {
+		return food, store.ErrNotFound
+	}
+	return food, nil
+}
+
+func (s *FoodStore) Insert(ctx context.Context, food models.Food) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	s.db.foods.insert(food)
+	return nil
+}
+
+func (s *FoodStore) Update(ctx context.Context, foodID string, food models.Food) (store.UpdateResult, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	return updated(s.db.foods.update(foodID, func(existing *models.Food) {
+		existing.UpdatedAt = time.Now().UTC()
+		if food.Name != nil {
+			existing.Name = food.Name
+		}
+		if food.Price != nil {
+			existing.Price = food.Price
+		}
+		if food.FoodImage != nil {
+			existing.FoodImage = food.FoodImage
+		}
+		if food.MenuID != nil {
+			existing.MenuID = food.MenuID
+		}
+		if food.Station != nil {
+			existing.Station = food.Station
+		}
+		if food.TaxCategory != nil {
+			existing.TaxCategory = food.TaxCategory
+		}
+		if food.ModifierGroups != nil {
+			existing.ModifierGroups = food.ModifierGroups
+		}
+		if food.SizePrices != nil {
+			existing.SizePrices = food.SizePrices
+		}
+	}))
+}
+
+func (s *FoodStore) SetAvailability(ctx context.Context, foodID string, available bool, reason string) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	_, err := updated(s.db.foods.update(foodID, func(existing *models.Food) {
+		existing.UpdatedAt = time.Now().UTC()
+		existing.Available = &available
+		existing.UnavailableReason = nil
+		if !available {
+			existing.UnavailableReason = &reason
+		}
+	}))
+	return err
+}
+
+// page mirrors Mongo's $slice: out-of-range windows yield an empty slice.
+func page[T any](items []T, startIndex, recordPerPage int) []T {
+	if startIndex >= len(items) {
+		return []T{}
+	}
+	end := startIndex + recordPerPage
+	if end > len(items) {
+		end = len(items)
+	}
+	return items[startIndex:end]
+}
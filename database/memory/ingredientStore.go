@@ -0,0 +1,153 @@
+package memory
+
+import (
+	"context"
+	"restaurant-management/models"
+	"restaurant-management/store"
+	"time"
+)
+
+type IngredientStore struct {
+	db *DB
+}
+
+func (s *IngredientStore) List(ctx context.Context) ([]models.Ingredient, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	return s.db.ingredients.all(), nil
+}
+
+func (s *IngredientStore) ListLowStock(ctx context.Context) ([]models.Ingredient, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	return s.db.ingredients.filter(models.Ingredient.LowOnStock), nil
+}
+
+func (s *IngredientStore) Find(ctx context.Context, ingredientID string) (models.Ingredient, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	ingredient, ok := s.db.ingredients.find(ingredientID)
+	if !ok {
+		return ingredient, store.ErrNotFound
+	}
+	return ingredient, nil
+}
+
+func (s *IngredientStore) Insert(ctx context.Context, ingredient models.Ingredient) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	s.db.ingredients.insert(ingredient)
+	return nil
+}
+
+func (s *IngredientStore) Update(ctx context.Context, ingredientID string, ingredient models.Ingredient) (store.UpdateResult, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	return updated(s.db.ingredients.update(ingredientID, func(existing *models.Ingredient) {
+		existing.UpdatedAt = time.Now().UTC()
+		if ingredient.Name != nil {
+			existing.Name = ingredient.Name
+		}
+		if ingredient.ReorderLevel != nil {
+			existing.ReorderLevel = ingredient.ReorderLevel
+		}
+	}))
+}
+
+func (s *IngredientStore) Move(ctx context.Context, movements []models.StockMovement) error {
+	return s.move(movements, false)
+}
+
+func (s *IngredientStore) Reserve(ctx context.Context, movements []models.StockMovement) error {
+	return s.move(movements, true)
+}
+
+// move applies movements, or none of them if reserving and one would take
+// stock below zero.
+func (s *IngredientStore) move(movements []models.StockMovement, reserve bool) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	stock := map[string]models.Quantity{}
+	for _, movement := range movements {
+		ingredient, ok := s.db.ingredients.find(movement.IngredientID)
+		if !ok {
+			return store.ErrNotFound
+		}
+		if _, ok := stock[movement.IngredientID]; !ok {
+			stock[movement.IngredientID] = ingredient.Stock
+		}
+		stock[movement.IngredientID] += movement.Change
+		if reserve && stock[movement.IngredientID] < 0 {
+			return store.ErrConflict
+		}
+	}
+
+	for i := range movements {
+		s.db.ingredients.update(movements[i].IngredientID, func(existing *models.Ingredient) {
+			existing.Stock += movements[i].Change
+			existing.UpdatedAt = movements[i].CreatedAt
+			movements[i].StockAfter = existing.Stock
+		})
+	}
+	s.db.movements.insert(movements...)
+	return nil
+}
+
+func (s *IngredientStore) Movements(ctx context.Context, ingredientID string) ([]models.StockMovement, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	return s.db.movements.filter(func(m models.StockMovement) bool { return m.IngredientID == ingredientID }), nil
+}
+
+func (s *IngredientStore) MovementsByOrder(ctx context.Context, orderID string) ([]models.StockMovement, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	return s.db.movements.filter(func(m models.StockMovement) bool { return m.OrderID != nil && *m.OrderID == orderID }), nil
+}
+
+type RecipeStore struct {
+	db *DB
+}
+
+func (s *RecipeStore) Find(ctx context.Context, foodID string) (models.Recipe, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	recipe, ok := s.db.recipes.find(foodID)
+	if !ok {
+		return recipe, store.ErrNotFound
+	}
+	return recipe, nil
+}
+
+func (s *RecipeStore) Put(ctx context.Context, recipe models.Recipe) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if !s.db.recipes.update(recipe.FoodID, func(existing *models.Recipe) { *existing = recipe }) {
+		s.db.recipes.insert(recipe)
+	}
+	return nil
+}
+
+func (s *RecipeStore) ListByIngredient(ctx context.Context, ingredientID string) ([]models.Recipe, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	return s.db.recipes.filter(func(r models.Recipe) bool {
+		for _, item := range r.Items {
+			if item.IngredientID == ingredientID {
+				return true
+			}
+		}
+		return false
+	}), nil
+}
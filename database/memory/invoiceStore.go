@@ -0,0 +1,95 @@
+package memory
+
+import (
+	"context"
+	"restaurant-management/models"
+	"restaurant-management/store"
+	"time"
+)
+
+type InvoiceStore struct {
+	db *DB
+}
+
+func (s *InvoiceStore) List(ctx context.Context) ([]models.Invoice, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	return s.db.invoices.all(), nil
+}
+
+func (s *InvoiceStore) Find(ctx context.Context, invoiceID string) (models.Invoice, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	invoice, ok := s.db.invoices.find(invoiceID)
+	if !ok {
+		return invoice, store.ErrNotFound
+	}
+	return invoice, nil
+}
+
+func (s *InvoiceStore) ListByOrder(ctx context.Context, orderID string) ([]models.Invoice, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	return s.db.invoices.filter(func(i models.Invoice) bool { return i.OrderID == orderID }), nil
+}
+
+func (s *InvoiceStore) Insert(ctx context.Context, invoice models.Invoice, series string) error {
+	return s.InsertMany(ctx, []models.Invoice{invoice}, series)
+}
+
+func (s *InvoiceStore) InsertMany(ctx context.Context, invoices []models.Invoice, series string) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	orderID := invoices[0].OrderID
+	billed := func(i models.Invoice) bool {
+		return i.OrderID == orderID && (i.PaymentStatus == nil || *i.PaymentStatus != models.PaymentVoid)
+	}
+	if len(s.db.invoices.filter(billed)) > 0 {
+		return store.ErrConflict
+	}
+
+	for i := range invoices {
+		s.db.invoiceNumbers[series]++
+		invoices[i].InvoiceNumber = models.FormatInvoiceNumber(series, s.db.invoiceNumbers[series])
+	}
+	s.db.invoices.insert(invoices...)
+	return nil
+}
+
+func (s *InvoiceStore) Update(ctx context.Context, invoiceID string, invoice models.Invoice) (store.UpdateResult, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	return updated(s.db.invoices.update(invoiceID, func(existing *models.Invoice) {
+		existing.UpdatedAt = time.Now().UTC()
+		if invoice.OrderID != "" {
+			existing.OrderID = invoice.OrderID
+		}
+		if invoice.PaymentMethod != nil {
+			existing.PaymentMethod = invoice.PaymentMethod
+		}
+		if invoice.PaymentStatus != nil {
+			existing.PaymentStatus = invoice.PaymentStatus
+		}
+		if !invoice.PaymentDueDate.IsZero() {
+			existing.PaymentDueDate = invoice.PaymentDueDate
+		}
+		if invoice.GrandTotal != nil {
+			existing.Lines = invoice.Lines
+			existing.Taxes = invoice.Taxes
+			existing.VoucherCode = invoice.VoucherCode
+			existing.Discount = invoice.Discount
+			existing.Subtotal = invoice.Subtotal
+			existing.TaxTotal = invoice.TaxTotal
+			existing.ServiceChargeRate = invoice.ServiceChargeRate
+			existing.ServiceCharge = invoice.ServiceCharge
+			existing.Rounding = invoice.Rounding
+			existing.GrandTotal = invoice.GrandTotal
+			existing.Split = invoice.Split
+		}
+	}))
+}
@@ -0,0 +1,62 @@
+package memory
+
+import (
+	"context"
+	"restaurant-management/models"
+	"restaurant-management/store"
+	"time"
+)
+
+type MenuStore struct {
+	db *DB
+}
+
+func (s *MenuStore) List(ctx context.Context) ([]models.Menu, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	return s.db.menus.all(), nil
+}
+
+func (s *MenuStore) Find(ctx context.Context, menuID string) (models.Menu, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	menu, ok := s.db.menus.find(menuID)
+	if !ok {
+		return menu, store.ErrNotFound
+	}
+	return menu, nil
+}
+
+func (s *MenuStore) Insert(ctx context.Context, menu models.Menu) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	s.db.menus.insert(menu)
+	return nil
+}
+
+func (s *MenuStore) Update(ctx context.Context, menuID string, menu models.Menu) (store.UpdateResult, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	return updated(s.db.menus.update(menuID, func(existing *models.Menu) {
+		existing.UpdatedAt = time.Now().UTC()
+		if menu.StartDate != nil {
+			existing.StartDate = menu.StartDate
+		}
+		if menu.EndDate != nil {
+			existing.EndDate = menu.EndDate
+		}
+		if menu.Name != "" {
+			existing.Name = menu.Name
+		}
+		if menu.Category != "" {
+			existing.Category = menu.Category
+		}
+		if menu.Schedule != nil {
+			existing.Schedule = menu.Schedule
+		}
+	}))
+}
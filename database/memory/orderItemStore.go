@@ -0,0 +1,206 @@
+package memory
+
+import (
+	"context"
+	"restaurant-management/models"
+	"restaurant-management/store"
+	"time"
+)
+
+type OrderItemStore struct {
+	db *DB
+}
+
+func (s *OrderItemStore) List(ctx context.Context) ([]models.OrderItem, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	return s.db.orderItems.all(), nil
+}
+
+func (s *OrderItemStore) ListByOrder(ctx context.Context, orderID string) ([]models.OrderItem, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	return s.db.orderItems.filter(func(i models.OrderItem) bool { return i.OrderID == orderID }), nil
+}
+
+func (s *OrderItemStore) Find(ctx context.Context, orderItemID string) (models.OrderItem, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	orderItem, ok := s.db.orderItems.find(orderItemID)
+	if !ok {
+		return orderItem, store.ErrNotFound
+	}
+	return orderItem, nil
+}
+
+func (s *OrderItemStore) InsertMany(ctx context.Context, orderItems []models.OrderItem) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	s.db.orderItems.insert(orderItems...)
+	return nil
+}
+
+func (s *OrderItemStore) Update(ctx context.Context, orderItemID string, orderItem models.OrderItem) (store.UpdateResult, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	return updated(s.db.orderItems.update(orderItemID, func(existing *models.OrderItem) {
+		existing.UpdatedAt = time.Now().UTC()
+		if orderItem.UnitPrice != nil {
+			existing.UnitPrice = orderItem.UnitPrice
+		}
+		if orderItem.Quantity != 0 {
+			existing.Quantity = orderItem.Quantity
+		}
+		if orderItem.Size != nil {
+			existing.Size = orderItem.Size
+		}
+		if orderItem.FoodID != nil {
+			existing.FoodID = orderItem.FoodID
+		}
+		if orderItem.Seat != nil {
+			existing.Seat = orderItem.Seat
+		}
+		if orderItem.Notes != nil {
+			existing.Notes = orderItem.Notes
+		}
+	}))
+}
+
+func (s *OrderItemStore) Void(ctx context.Context, orderItemID string, void models.OrderItemVoid) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	orderItem, ok := s.db.orderItems.find(orderItemID)
+	if !ok {
+		return store.ErrNotFound
+	}
+	if orderItem.Void != nil {
+		return store.ErrConflict
+	}
+	s.db.orderItems.update(orderItemID, func(existing *models.OrderItem) {
+		existing.UpdatedAt = time.Now().UTC()
+		existing.Void = &void
+	})
+	return nil
+}
+
+func (s *OrderItemStore) ApproveVoid(ctx context.Context, orderItemID, approvedBy string, voidedAt time.Time) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	orderItem, ok := s.db.orderItems.find(orderItemID)
+	if !ok {
+		return store.ErrNotFound
+	}
+	if orderItem.Void == nil || orderItem.Void.VoidedAt != nil {
+		return store.ErrConflict
+	}
+	// Items handed out earlier share the old void, so it is replaced rather
+	// than changed.
+	void := *orderItem.Void
+	void.ApprovedBy = &approvedBy
+	void.VoidedAt = &voidedAt
+	s.db.orderItems.update(orderItemID, func(existing *models.OrderItem) {
+		existing.UpdatedAt = time.Now().UTC()
+		existing.Void = &void
+	})
+	return nil
+}
+
+func (s *OrderItemStore) ItemsByOrder(ctx context.Context, id string) ([]store.OrderItemsGroup, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	orderItems := s.db.orderItems.filter(func(i models.OrderItem) bool { return i.OrderID == id })
+	return groupItemsByOrder(orderItems, &s.db.foods, &s.db.orders, &s.db.tables), nil
+}
+
+// groupItemsByOrder is the Go equivalent of the Mongo ItemsByOrder pipeline:
+// each item is joined with its food, order and table, then the items are
+// grouped by order, table and table number with the amounts of those not
+// voided summed. Items ordered before they were priced are charged their
+// food's price.
+func groupItemsByOrder(orderItems []models.OrderItem, foods *collection[models.Food], orders *collection[models.Order], tables *collection[models.Table]) []store.OrderItemsGroup {
+	type groupKey struct {
+		orderID, tableID string
+		tableNumber      int
+		hasTableNumber   bool
+	}
+
+	groups := []store.OrderItemsGroup{}
+	index := map[groupKey]int{}
+
+	for _, orderItem := range orderItems {
+		line := store.OrderItemLine{
+			OrderItemID: orderItem.OrderItemID,
+			Quantity:    orderItem.Quantity,
+			Size:        orderItem.Size,
+			Price:       orderItem.UnitPrice,
+			Voided:      orderItem.IsVoided(),
+		}
+
+		if orderItem.FoodID != nil {
+			if food, ok := foods.find(*orderItem.FoodID); ok {
+				if line.Price == nil {
+					line.Price = food.Price
+				}
+				line.FoodName = food.Name
+				line.FoodImage = food.FoodImage
+			}
+		}
+		if line.Price != nil {
+			amount := line.Price.Mul(int64(line.Quantity))
+			line.Amount = &amount
+		}
+
+		if order, ok := orders.find(orderItem.OrderID); ok {
+			orderID := order.OrderID
+			line.OrderID = &orderID
+			if order.TableID != nil {
+				if table, ok := tables.find(*order.TableID); ok {
+					tableID := table.TableID
+					line.TableID = &tableID
+					line.TableNumber = table.TableNumber
+				}
+			}
+		}
+
+		var key groupKey
+		if line.OrderID != nil {
+			key.orderID = *line.OrderID
+		}
+		if line.TableID != nil {
+			key.tableID = *line.TableID
+		}
+		if line.TableNumber != nil {
+			key.tableNumber, key.hasTableNumber = *line.TableNumber, true
+		}
+
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, store.OrderItemsGroup{
+				PaymentDue:  models.NewMoney(0, ""),
+				TableNumber: line.TableNumber,
+				OrderItems:  []store.OrderItemLine{},
+			})
+		}
+
+		group := &groups[i]
+		if !line.Voided {
+			if line.Amount != nil {
+				group.PaymentDue, _ = group.PaymentDue.Add(*line.Amount)
+			}
+			group.TotalCount += line.Quantity
+		}
+		group.OrderItems = append(group.OrderItems, line)
+	}
+
+	return groups
+}
@@ -0,0 +1,87 @@
+package memory
+
+import (
+	"context"
+	"restaurant-management/models"
+	"restaurant-management/store"
+	"time"
+)
+
+type OrderStore struct {
+	db *DB
+}
+
+func (s *OrderStore) List(ctx context.Context) ([]models.Order, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	return s.db.orders.all(), nil
+}
+
+func (s *OrderStore) Find(ctx context.Context, orderID string) (models.Order, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	order, ok := s.db.orders.find(orderID)
+	if !ok {
+		return order, store.ErrNotFound
+	}
+	return order, nil
+}
+
+func (s *OrderStore) Insert(ctx context.Context, order models.Order) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	s.db.orders.insert(order)
+	return nil
+}
+
+func (s *OrderStore) Update(ctx context.Context, orderID string, order models.Order) (store.UpdateResult, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	return updated(s.db.orders.update(orderID, func(existing *models.Order) {
+		existing.UpdatedAt = time.Now().UTC()
+		if !order.OrderDate.IsZero() {
+			existing.OrderDate = order.OrderDate
+		}
+		if order.TableID != nil {
+			existing.TableID = order.TableID
+		}
+	}))
+}
+
+func (s *OrderStore) Delete(ctx context.Context, orderID string) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if !s.db.orders.remove(orderID) {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (s *OrderStore) Transition(ctx context.Context, orderID string, transition models.OrderTransition) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	order, ok := s.db.orders.find(orderID)
+	if !ok {
+		return store.ErrNotFound
+	}
+	if order.CurrentStatus() != transition.From {
+		return store.ErrConflict
+	}
+
+	s.db.orders.update(orderID, func(existing *models.Order) {
+		status := transition.To
+		existing.Status = &status
+		existing.UpdatedAt = transition.ChangedAt
+		// Copy rather than append in place so orders handed out earlier keep
+		// the history they were read with.
+		history := append([]models.OrderTransition{}, existing.StatusHistory...)
+		existing.StatusHistory = append(history, transition)
+	})
+	return nil
+}
@@ -0,0 +1,67 @@
+package memory
+
+import (
+	"context"
+	"restaurant-management/models"
+	"restaurant-management/store"
+	"time"
+)
+
+type PaymentStore struct {
+	db *DB
+}
+
+func (s *PaymentStore) ListByInvoice(ctx context.Context, invoiceID string) ([]models.Payment, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	return s.db.payments.filter(func(p models.Payment) bool { return p.InvoiceID == invoiceID }), nil
+}
+
+func (s *PaymentStore) ListBetween(ctx context.Context, from, to time.Time) ([]models.Payment, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	return s.db.payments.filter(func(p models.Payment) bool { return between(p.CreatedAt, from, to) }), nil
+}
+
+func (s *PaymentStore) Find(ctx context.Context, paymentID string) (models.Payment, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	payment, ok := s.db.payments.find(paymentID)
+	if !ok {
+		return payment, store.ErrNotFound
+	}
+	return payment, nil
+}
+
+func (s *PaymentStore) Insert(ctx context.Context, payment models.Payment, settlement store.Settlement) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	invoice, ok := s.db.invoices.find(payment.InvoiceID)
+	if !ok {
+		return store.ErrNotFound
+	}
+	var paid int64
+	if invoice.AmountPaid != nil {
+		paid = invoice.AmountPaid.Amount
+	}
+	if paid != settlement.PaidBefore.Amount || invoice.PaymentStatus != nil && *invoice.PaymentStatus == models.PaymentVoid {
+		return store.ErrConflict
+	}
+
+	s.db.payments.insert(payment)
+	s.db.invoices.update(payment.InvoiceID, func(existing *models.Invoice) {
+		existing.UpdatedAt = payment.CreatedAt
+		existing.AmountPaid = &settlement.AmountPaid
+		existing.PaymentStatus = &settlement.PaymentStatus
+		existing.PaymentMethod = &settlement.PaymentMethod
+		if settlement.ProviderReference != "" {
+			existing.PaymentProvider = &settlement.Provider
+			existing.ProviderReference = &settlement.ProviderReference
+		}
+	})
+	return nil
+}
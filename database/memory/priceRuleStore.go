@@ -0,0 +1,47 @@
+package memory
+
+import (
+	"context"
+	"restaurant-management/models"
+	"restaurant-management/store"
+)
+
+type PriceRuleStore struct {
+	db *DB
+}
+
+func (s *PriceRuleStore) List(ctx context.Context) ([]models.PriceRule, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	return s.db.priceRules.all(), nil
+}
+
+func (s *PriceRuleStore) Find(ctx context.Context, priceRuleID string) (models.PriceRule, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	rule, ok := s.db.priceRules.find(priceRuleID)
+	if !ok {
+		return rule, store.ErrNotFound
+	}
+	return rule, nil
+}
+
+func (s *PriceRuleStore) Insert(ctx context.Context, rule models.PriceRule) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	s.db.priceRules.insert(rule)
+	return nil
+}
+
+func (s *PriceRuleStore) Replace(ctx context.Context, rule models.PriceRule) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if !s.db.priceRules.update(rule.PriceRuleID, func(existing *models.PriceRule) { *existing = rule }) {
+		return store.ErrNotFound
+	}
+	return nil
+}
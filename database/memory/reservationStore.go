@@ -0,0 +1,84 @@
+package memory
+
+import (
+	"context"
+	"restaurant-management/models"
+	"restaurant-management/store"
+	"sort"
+	"time"
+)
+
+type ReservationStore struct {
+	db *DB
+}
+
+func byStartTime(reservations []models.Reservation) []models.Reservation {
+	sort.SliceStable(reservations, func(i, j int) bool {
+		return reservations[i].StartTime.Before(reservations[j].StartTime)
+	})
+	return reservations
+}
+
+func (s *ReservationStore) List(ctx context.Context, tableID string) ([]models.Reservation, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	return byStartTime(s.db.reservations.filter(func(r models.Reservation) bool {
+		return tableID == "" || equal(r.TableID, tableID)
+	})), nil
+}
+
+func (s *ReservationStore) Find(ctx context.Context, reservationID string) (models.Reservation, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	reservation, ok := s.db.reservations.find(reservationID)
+	if !ok {
+		return reservation, store.ErrNotFound
+	}
+	return reservation, nil
+}
+
+func (s *ReservationStore) Insert(ctx context.Context, reservation models.Reservation) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	clashes := s.db.reservations.filter(func(r models.Reservation) bool {
+		return reservation.TableID != nil && equal(r.TableID, *reservation.TableID) &&
+			r.Overlaps(reservation.StartTime, reservation.EndTime)
+	})
+	if len(clashes) > 0 {
+		return store.ErrConflict
+	}
+
+	s.db.reservations.insert(reservation)
+	return nil
+}
+
+func (s *ReservationStore) UpdateStatus(ctx context.Context, reservationID, status string) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	reservation, ok := s.db.reservations.find(reservationID)
+	if !ok {
+		return store.ErrNotFound
+	}
+	if reservation.Status != models.ReservationBooked {
+		return store.ErrConflict
+	}
+
+	s.db.reservations.update(reservationID, func(existing *models.Reservation) {
+		existing.Status = status
+		existing.UpdatedAt = time.Now().UTC()
+	})
+	return nil
+}
+
+func (s *ReservationStore) Overlapping(ctx context.Context, start, end time.Time) ([]models.Reservation, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	return byStartTime(s.db.reservations.filter(func(r models.Reservation) bool {
+		return r.Overlaps(start, end)
+	})), nil
+}
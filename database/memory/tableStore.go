@@ -0,0 +1,53 @@
+package memory
+
+import (
+	"context"
+	"restaurant-management/models"
+	"restaurant-management/store"
+	"time"
+)
+
+type TableStore struct {
+	db *DB
+}
+
+func (s *TableStore) List(ctx context.Context) ([]models.Table, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	return s.db.tables.all(), nil
+}
+
+func (s *TableStore) Find(ctx context.Context, tableID string) (models.Table, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	table, ok := s.db.tables.find(tableID)
+	if !ok {
+		return table, store.ErrNotFound
+	}
+	return table, nil
+}
+
+func (s *TableStore) Insert(ctx context.Context, table models.Table) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	s.db.tables.insert(table)
+	return nil
+}
+
+func (s *TableStore) Update(ctx context.Context, tableID string, table models.Table) (store.UpdateResult, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	return updated(s.db.tables.update(tableID, func(existing *models.Table) {
+		existing.UpdatedAt = time.Now().UTC()
+		if table.NumberOfGuests != nil {
+			existing.NumberOfGuests = table.NumberOfGuests
+		}
+		if table.TableNumber != nil {
+			existing.TableNumber = table.TableNumber
+		}
+	}))
+}
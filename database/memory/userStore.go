@@ -0,0 +1,134 @@
+package memory
+
+import (
+	"context"
+	"restaurant-management/models"
+	"restaurant-management/store"
+	"time"
+)
+
+type UserStore struct {
+	db *DB
+}
+
+func (s *UserStore) List(ctx context.Context, startIndex, recordPerPage int) (int, []models.User, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	users := s.db.users.all()
+	return len(users), page(users, startIndex, recordPerPage), nil
+}
+
+func (s *UserStore) Find(ctx context.Context, userID string) (models.User, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	user, ok := s.db.users.find(userID)
+	if !ok {
+		return user, store.ErrNotFound
+	}
+	return user, nil
+}
+
+func (s *UserStore) FindByEmail(ctx context.Context, email string) (models.User, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	users := s.db.users.filter(func(u models.User) bool { return equal(u.Email, email) })
+	if len(users) == 0 {
+		return models.User{}, store.ErrNotFound
+	}
+	return users[0], nil
+}
+
+func (s *UserStore) CountByEmail(ctx context.Context, email string) (int64, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	users := s.db.users.filter(func(u models.User) bool { return equal(u.Email, email) })
+	return int64(len(users)), nil
+}
+
+func (s *UserStore) CountByPhone(ctx context.Context, phone string) (int64, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	users := s.db.users.filter(func(u models.User) bool { return equal(u.Phone, phone) })
+	return int64(len(users)), nil
+}
+
+func (s *UserStore) Insert(ctx context.Context, user models.User) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	s.db.users.insert(user)
+	return nil
+}
+
+func (s *UserStore) UpdateTokens(ctx context.Context, userID, token, refreshToken string) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	s.db.users.update(userID, func(existing *models.User) {
+		existing.Token = &token
+		existing.RefreshToken = &refreshToken
+		existing.UpdatedAt = time.Now().UTC()
+	})
+	return nil
+}
+
+func (s *UserStore) RotateTokens(ctx context.Context, userID, currentRefreshToken, token, refreshToken string) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	user, ok := s.db.users.find(userID)
+	if !ok {
+		return store.ErrNotFound
+	}
+	if !equal(user.RefreshToken, currentRefreshToken) {
+		return store.ErrConflict
+	}
+
+	s.db.users.update(userID, func(existing *models.User) {
+		existing.Token = &token
+		existing.RefreshToken = &refreshToken
+		existing.UpdatedAt = time.Now().UTC()
+	})
+	return nil
+}
+
+func (s *UserStore) UpdateRole(ctx context.Context, userID, role string) (store.UpdateResult, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	return updated(s.db.users.update(userID, func(existing *models.User) {
+		existing.Role = &role
+		existing.UpdatedAt = time.Now().UTC()
+	}))
+}
+
+func (s *UserStore) ClaimFirstAdmin(ctx context.Context, userID string) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if s.db.firstAdmin != "" && s.db.firstAdmin != userID {
+		return store.ErrConflict
+	}
+	s.db.firstAdmin = userID
+	return nil
+}
+
+func (s *UserStore) ReleaseFirstAdmin(ctx context.Context, userID string) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if s.db.firstAdmin == userID {
+		s.db.firstAdmin = ""
+	}
+	return nil
+}
+
+// equal compares an optional model field against a lookup value.
+func equal(field *string, value string) bool {
+	return field != nil && *field == value
+}
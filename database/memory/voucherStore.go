@@ -0,0 +1,129 @@
+package memory
+
+import (
+	"context"
+	"restaurant-management/models"
+	"restaurant-management/store"
+	"time"
+)
+
+type VoucherStore struct {
+	db *DB
+}
+
+func (s *VoucherStore) List(ctx context.Context) ([]models.Voucher, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	return s.db.vouchers.all(), nil
+}
+
+func (s *VoucherStore) Find(ctx context.Context, voucherID string) (models.Voucher, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	voucher, ok := s.db.vouchers.find(voucherID)
+	if !ok {
+		return voucher, store.ErrNotFound
+	}
+	return voucher, nil
+}
+
+func (s *VoucherStore) FindByCode(ctx context.Context, code string) (models.Voucher, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	vouchers := s.db.vouchers.filter(func(v models.Voucher) bool { return v.Code == code })
+	if len(vouchers) == 0 {
+		return models.Voucher{}, store.ErrNotFound
+	}
+	return vouchers[0], nil
+}
+
+func (s *VoucherStore) Insert(ctx context.Context, voucher models.Voucher) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if len(s.db.vouchers.filter(func(v models.Voucher) bool { return v.Code == voucher.Code })) > 0 {
+		return store.ErrConflict
+	}
+	s.db.vouchers.insert(voucher)
+	return nil
+}
+
+func (s *VoucherStore) Update(ctx context.Context, voucherID string, voucher models.Voucher) (store.UpdateResult, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	return updated(s.db.vouchers.update(voucherID, func(existing *models.Voucher) {
+		existing.UpdatedAt = time.Now().UTC()
+		if voucher.ExpiresAt != nil {
+			existing.ExpiresAt = voucher.ExpiresAt
+		}
+		if voucher.MaxRedemptions != nil {
+			existing.MaxRedemptions = voucher.MaxRedemptions
+		}
+	}))
+}
+
+func (s *VoucherStore) Redeem(ctx context.Context, redemption models.VoucherRedemption) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	voucher, ok := s.db.vouchers.find(redemption.VoucherID)
+	if !ok {
+		return store.ErrNotFound
+	}
+	if voucher.MaxRedemptions != nil && voucher.Redemptions >= *voucher.MaxRedemptions {
+		return store.ErrConflict
+	}
+	if _, ok := s.applied(redemption.OrderID); ok {
+		return store.ErrConflict
+	}
+
+	s.db.vouchers.update(redemption.VoucherID, func(existing *models.Voucher) {
+		existing.Redemptions++
+	})
+	s.db.redemptions.insert(redemption)
+	return nil
+}
+
+func (s *VoucherStore) Release(ctx context.Context, orderID string, releasedAt time.Time) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	redemption, ok := s.applied(orderID)
+	if !ok {
+		return store.ErrNotFound
+	}
+	s.db.redemptions.update(redemption.RedemptionID, func(existing *models.VoucherRedemption) {
+		existing.ReleasedAt = &releasedAt
+	})
+	s.db.vouchers.update(redemption.VoucherID, func(existing *models.Voucher) {
+		existing.Redemptions--
+	})
+	return nil
+}
+
+func (s *VoucherStore) RedemptionByOrder(ctx context.Context, orderID string) (models.VoucherRedemption, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	redemption, ok := s.applied(orderID)
+	if !ok {
+		return redemption, store.ErrNotFound
+	}
+	return redemption, nil
+}
+
+// applied finds the redemption applied to orderID that has not been
+// released. The caller holds the lock.
+func (s *VoucherStore) applied(orderID string) (models.VoucherRedemption, bool) {
+	redemptions := s.db.redemptions.filter(func(r models.VoucherRedemption) bool {
+		return r.OrderID == orderID && r.ReleasedAt == nil
+	})
+	if len(redemptions) == 0 {
+		return models.VoucherRedemption{}, false
+	}
+	return redemptions[0], true
+}
@@ -0,0 +1,76 @@
+package database
+
+import (
+	"context"
+	"restaurant-management/models"
+	"restaurant-management/store"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type MenuStore struct {
+	collection *mongo.Collection
+}
+
+func NewMenuStore(client *mongo.Client) *MenuStore {
+	return &MenuStore{collection: OpenCollection(client, "menu")}
+}
+
+func (s *MenuStore) List(ctx context.Context) ([]models.Menu, error) {
+	cursor, err := s.collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	menus := []models.Menu{}
+	if err := cursor.All(ctx, &menus); err != nil {
+		return nil, err
+	}
+	return menus, nil
+}
+
+func (s *MenuStore) Find(ctx context.Context, menuID string) (models.Menu, error) {
+	var menu models.Menu
+	err := s.collection.FindOne(ctx, bson.M{"menu_id": menuID}).Decode(&menu)
+	return menu, findOne(err)
+}
+
+func (s *MenuStore) Insert(ctx context.Context, menu models.Menu) error {
+	_, err := s.collection.InsertOne(ctx, menu)
+	return err
+}
+
+func (s *MenuStore) Update(ctx context.Context, menuID string, menu models.Menu) (store.UpdateResult, error) {
+	updateObj := bson.D{
+		{Key: "updated_at", Value: time.Now().UTC()},
+	}
+
+	if menu.StartDate != nil {
+		updateObj = append(updateObj, bson.E{Key: "start_date", Value: *menu.StartDate})
+	}
+	if menu.EndDate != nil {
+		updateObj = append(updateObj, bson.E{Key: "end_date", Value: *menu.EndDate})
+	}
+	if menu.Name != "" {
+		updateObj = append(updateObj, bson.E{Key: "name", Value: menu.Name})
+	}
+	if menu.Category != "" {
+		updateObj = append(updateObj, bson.E{Key: "category", Value: menu.Category})
+	}
+	if menu.Schedule != nil {
+		updateObj = append(updateObj, bson.E{Key: "schedule", Value: menu.Schedule})
+	}
+
+	result, err := s.collection.UpdateOne(
+		ctx,
+		bson.M{"menu_id": menuID},
+		bson.D{{Key: "$set", Value: updateObj}},
+	)
+	if err != nil {
+		return store.UpdateResult{}, err
+	}
+	return updateResult(result)
+}
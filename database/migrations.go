@@ -0,0 +1,108 @@
+package database
+
+import (
+	"context"
+	"restaurant-management/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MigrateMoney rewrites amounts stored as float64 major units, from before
+// prices became models.Money, into {amount, currency} documents in the
+// default currency. The stores decode either form, but aggregations such as
+// ItemsByOrder only sum the new one. Documents already migrated are left as
+// they are, so it is safe to run on every start.
+func MigrateMoney(ctx context.Context, client *mongo.Client) error {
+	currency := models.DefaultCurrency()
+	scale := 1
+	for i := 0; i < models.MinorDigits(currency); i++ {
+		scale *= 10
+	}
+
+	fields := map[string][]string{
+		"food":      {"price"},
+		"orderItem": {"unit_price"},
+		"invoice":   {"subtotal", "tax_total", "service_charge", "rounding", "grand_total"},
+	}
+	for name, paths := range fields {
+		set := bson.D{}
+		legacy := bson.A{}
+		for _, path := range paths {
+			set = append(set, bson.E{Key: path, Value: legacyMoney("$"+path, currency, scale)})
+			legacy = append(legacy, bson.M{path: bson.M{"$type": "number"}})
+		}
+
+		if name == "invoice" {
+			set = append(set,
+				bson.E{Key: "lines", Value: mapMoney("$lines", currency, scale, "unit_price", "amount", "tax")},
+				bson.E{Key: "taxes", Value: mapMoney("$taxes", currency, scale, "taxable", "amount")},
+			)
+			legacy = append(legacy,
+				bson.M{"lines.amount": bson.M{"$type": "number"}},
+				bson.M{"taxes.amount": bson.M{"$type": "number"}},
+			)
+		}
+
+		_, err := OpenCollection(client, name).UpdateMany(ctx,
+			bson.M{"$or": legacy},
+			mongo.Pipeline{{{Key: "$set", Value: set}}},
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MigrateQuantities moves the portion size order items kept in quantity,
+// from before quantity counted how many were ordered, into size, and makes
+// each such item one of it. Items already migrated are left as they are, so
+// it is safe to run on every start.
+func MigrateQuantities(ctx context.Context, client *mongo.Client) error {
+	_, err := OpenCollection(client, "orderItem").UpdateMany(ctx,
+		bson.M{"$or": bson.A{
+			bson.M{"quantity": bson.M{"$type": "string"}},
+			bson.M{"quantity": nil},
+		}},
+		mongo.Pipeline{{{Key: "$set", Value: bson.D{
+			{Key: "size", Value: "$quantity"},
+			{Key: "quantity", Value: 1},
+		}}}},
+	)
+	return err
+}
+
+// legacyMoney converts the number at expr into a Money document, rounding
+// half away from zero, and leaves anything else untouched.
+func legacyMoney(expr, currency string, scale int) bson.M {
+	minor := bson.M{"$multiply": bson.A{expr, scale}}
+	rounded := bson.M{"$cond": bson.A{
+		bson.M{"$gte": bson.A{minor, 0}},
+		bson.M{"$floor": bson.M{"$add": bson.A{minor, 0.5}}},
+		bson.M{"$ceil": bson.M{"$subtract": bson.A{minor, 0.5}}},
+	}}
+	return bson.M{"$cond": bson.A{
+		bson.M{"$isNumber": expr},
+		bson.D{{Key: "amount", Value: bson.M{"$toLong": rounded}}, {Key: "currency", Value: currency}},
+		expr,
+	}}
+}
+
+// mapMoney applies legacyMoney to the given fields of every element of the
+// array at expr.
+func mapMoney(expr, currency string, scale int, fields ...string) bson.M {
+	converted := bson.M{}
+	for _, field := range fields {
+		converted[field] = legacyMoney("$$item."+field, currency, scale)
+	}
+	return bson.M{"$cond": bson.A{
+		bson.M{"$isArray": expr},
+		bson.M{"$map": bson.M{
+			"input": expr,
+			"as":    "item",
+			"in":    bson.M{"$mergeObjects": bson.A{"$$item", converted}},
+		}},
+		expr,
+	}}
+}
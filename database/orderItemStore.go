@@ -0,0 +1,258 @@
+package database
+
+import (
+	"context"
+	"restaurant-management/models"
+	"restaurant-management/store"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type OrderItemStore struct {
+	collection *mongo.Collection
+}
+
+func NewOrderItemStore(client *mongo.Client) *OrderItemStore {
+	return &OrderItemStore{collection: OpenCollection(client, "orderItem")}
+}
+
+func (s *OrderItemStore) List(ctx context.Context) ([]models.OrderItem, error) {
+	return s.find(ctx, bson.M{})
+}
+
+func (s *OrderItemStore) ListByOrder(ctx context.Context, orderID string) ([]models.OrderItem, error) {
+	return s.find(ctx, bson.M{"order_id": orderID})
+}
+
+func (s *OrderItemStore) find(ctx context.Context, filter bson.M) ([]models.OrderItem, error) {
+	cursor, err := s.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	orderItems := []models.OrderItem{}
+	if err := cursor.All(ctx, &orderItems); err != nil {
+		return nil, err
+	}
+	return orderItems, nil
+}
+
+func (s *OrderItemStore) Find(ctx context.Context, orderItemID string) (models.OrderItem, error) {
+	var orderItem models.OrderItem
+	err := s.collection.FindOne(ctx, bson.M{"order_item_id": orderItemID}).Decode(&orderItem)
+	return orderItem, findOne(err)
+}
+
+func (s *OrderItemStore) InsertMany(ctx context.Context, orderItems []models.OrderItem) error {
+	docs := make([]interface{}, 0, len(orderItems))
+	for _, orderItem := range orderItems {
+		docs = append(docs, orderItem)
+	}
+
+	_, err := s.collection.InsertMany(ctx, docs)
+	return err
+}
+
+func (s *OrderItemStore) Update(ctx context.Context, orderItemID string, orderItem models.OrderItem) (store.UpdateResult, error) {
+	updateObj := bson.D{{Key: "updated_at", Value: time.Now().UTC()}}
+
+	if orderItem.UnitPrice != nil {
+		updateObj = append(updateObj, bson.E{Key: "unit_price", Value: *orderItem.UnitPrice})
+	}
+	if orderItem.Quantity != 0 {
+		updateObj = append(updateObj, bson.E{Key: "quantity", Value: orderItem.Quantity})
+	}
+	if orderItem.Size != nil {
+		updateObj = append(updateObj, bson.E{Key: "size", Value: *orderItem.Size})
+	}
+	if orderItem.FoodID != nil {
+		updateObj = append(updateObj, bson.E{Key: "food_id", Value: *orderItem.FoodID})
+	}
+	if orderItem.Seat != nil {
+		updateObj = append(updateObj, bson.E{Key: "seat", Value: *orderItem.Seat})
+	}
+	if orderItem.Notes != nil {
+		updateObj = append(updateObj, bson.E{Key: "notes", Value: *orderItem.Notes})
+	}
+
+	result, err := s.collection.UpdateOne(
+		ctx,
+		bson.M{"order_item_id": orderItemID},
+		bson.D{{Key: "$set", Value: updateObj}},
+	)
+	if err != nil {
+		return store.UpdateResult{}, err
+	}
+	return updateResult(result)
+}
+
+func (s *OrderItemStore) Void(ctx context.Context, orderItemID string, void models.OrderItemVoid) error {
+	result, err := s.collection.UpdateOne(ctx,
+		bson.M{"order_item_id": orderItemID, "void": nil},
+		bson.D{{Key: "$set", Value: bson.D{
+			{Key: "void", Value: void},
+			{Key: "updated_at", Value: time.Now().UTC()},
+		}}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		if _, err := s.Find(ctx, orderItemID); err != nil {
+			return err
+		}
+		return store.ErrConflict
+	}
+	return nil
+}
+
+func (s *OrderItemStore) ApproveVoid(ctx context.Context, orderItemID, approvedBy string, voidedAt time.Time) error {
+	result, err := s.collection.UpdateOne(ctx,
+		bson.M{"order_item_id": orderItemID, "void": bson.M{"$ne": nil}, "void.voided_at": nil},
+		bson.D{{Key: "$set", Value: bson.D{
+			{Key: "void.approved_by", Value: approvedBy},
+			{Key: "void.voided_at", Value: voidedAt},
+			{Key: "updated_at", Value: time.Now().UTC()},
+		}}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		if _, err := s.Find(ctx, orderItemID); err != nil {
+			return err
+		}
+		return store.ErrConflict
+	}
+	return nil
+}
+
+func (s *OrderItemStore) ItemsByOrder(ctx context.Context, id string) ([]store.OrderItemsGroup, error) {
+	matchStage := bson.D{{Key: "$match", Value: bson.D{{Key: "order_id", Value: id}}}}
+
+	lookupStage := bson.D{{
+		Key: "$lookup", Value: bson.D{
+			{Key: "from", Value: "food"},
+			{Key: "localField", Value: "food_id"},
+			{Key: "foreignField", Value: "food_id"},
+			{Key: "as", Value: "food"},
+		},
+	}}
+
+	unwindStage := bson.D{
+		{
+			Key: "$unwind", Value: bson.D{
+				{Key: "path", Value: "$food"},
+				{Key: "preserveNullAndEmptyArrays", Value: true},
+			},
+		},
+	}
+
+	lookupOrderStage := bson.D{{
+		Key: "$lookup", Value: bson.D{
+			{Key: "from", Value: "order"},
+			{Key: "localField", Value: "order_id"},
+			{Key: "foreignField", Value: "order_id"},
+			{Key: "as", Value: "order"},
+		}},
+	}
+
+	unwindOrderStage := bson.D{{
+		Key: "$unwind", Value: bson.D{
+			{Key: "path", Value: "$order"},
+			{Key: "preserveNullAndEmptyArrays", Value: true},
+		},
+	}}
+
+	lookupTableStage := bson.D{{Key: "$lookup", Value: bson.D{
+		{Key: "from", Value: "table"},
+		{Key: "localField", Value: "order.table_id"},
+		{Key: "foreignField", Value: "table_id"},
+		{Key: "as", Value: "table"},
+	}}}
+	unwindTableStage := bson.D{{Key: "$unwind", Value: bson.D{
+		{Key: "path", Value: "$table"},
+		{Key: "preserveNullAndEmptyArrays", Value: true},
+	}}}
+
+	// Items ordered before they were priced are charged their food's price.
+	priceStage := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "price", Value: bson.D{{Key: "$ifNull", Value: bson.A{"$unit_price", "$food.price"}}}},
+	}}}
+
+	projectStage := bson.D{{Key: "$project", Value: bson.D{
+		{Key: "id", Value: 0},
+		{Key: "order_item_id", Value: 1},
+		{Key: "amount", Value: bson.D{{Key: "$cond", Value: bson.A{
+			"$price",
+			bson.D{
+				{Key: "amount", Value: bson.D{{Key: "$multiply", Value: bson.A{"$price.amount", "$quantity"}}}},
+				{Key: "currency", Value: "$price.currency"},
+			},
+			nil,
+		}}}},
+		{Key: "total_count", Value: 1},
+		{Key: "food_name", Value: "$food.name"},
+		{Key: "food_image", Value: "$food.food_image"},
+		{Key: "table_number", Value: "$table.table_number"},
+		{Key: "table_id", Value: "$table.table_id"},
+		{Key: "order_id", Value: "$order.order_id"},
+		{Key: "price", Value: 1},
+		{Key: "quantity", Value: 1},
+		{Key: "size", Value: 1},
+		{Key: "voided", Value: bson.D{{Key: "$ne", Value: bson.A{
+			bson.D{{Key: "$ifNull", Value: bson.A{"$void.voided_at", nil}}},
+			nil,
+		}}}},
+	}}}
+
+	groupStage := bson.D{{Key: "$group", Value: bson.D{
+		{Key: "_id", Value: bson.D{
+			{Key: "order_id", Value: "$order_id"},
+			{Key: "table_id", Value: "$table_id"},
+			{Key: "table_number", Value: "$table_number"},
+		}},
+		{Key: "payment_due", Value: bson.D{{Key: "$sum", Value: bson.D{{Key: "$cond", Value: bson.A{"$voided", 0, "$amount.amount"}}}}}},
+		{Key: "currency", Value: bson.D{{Key: "$first", Value: "$amount.currency"}}},
+		{Key: "total_count", Value: bson.D{{Key: "$sum", Value: bson.D{{Key: "$cond", Value: bson.A{"$voided", 0, "$quantity"}}}}}},
+		{Key: "order_items", Value: bson.D{{Key: "$push", Value: "$$ROOT"}}},
+	}}}
+
+	projectStage2 := bson.D{{Key: "$project", Value: bson.D{
+		{Key: "id", Value: 0},
+		{Key: "payment_due", Value: bson.D{
+			{Key: "amount", Value: "$payment_due"},
+			{Key: "currency", Value: "$currency"},
+		}},
+		{Key: `total_count`, Value: 1},
+		{Key: "table_number", Value: "$_id.table_number"},
+		{Key: "order_items", Value: 1},
+	}}}
+
+	cursor, err := s.collection.Aggregate(ctx, mongo.Pipeline{
+		matchStage,
+		lookupStage,
+		unwindStage,
+		lookupOrderStage,
+		unwindOrderStage,
+		lookupTableStage,
+		unwindTableStage,
+		priceStage,
+		projectStage,
+		groupStage,
+		projectStage2,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	groups := []store.OrderItemsGroup{}
+	if err := cursor.All(ctx, &groups); err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
@@ -0,0 +1,107 @@
+package database
+
+import (
+	"context"
+	"restaurant-management/models"
+	"restaurant-management/store"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type OrderStore struct {
+	collection *mongo.Collection
+}
+
+func NewOrderStore(client *mongo.Client) *OrderStore {
+	return &OrderStore{collection: OpenCollection(client, "order")}
+}
+
+func (s *OrderStore) List(ctx context.Context) ([]models.Order, error) {
+	cursor, err := s.collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	orders := []models.Order{}
+	if err := cursor.All(ctx, &orders); err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+func (s *OrderStore) Find(ctx context.Context, orderID string) (models.Order, error) {
+	var order models.Order
+	err := s.collection.FindOne(ctx, bson.M{"order_id": orderID}).Decode(&order)
+	return order, findOne(err)
+}
+
+func (s *OrderStore) Insert(ctx context.Context, order models.Order) error {
+	_, err := s.collection.InsertOne(ctx, order)
+	return err
+}
+
+func (s *OrderStore) Update(ctx context.Context, orderID string, order models.Order) (store.UpdateResult, error) {
+	updateObj := bson.D{{Key: "updated_at", Value: time.Now().UTC()}}
+
+	if !order.OrderDate.IsZero() {
+		updateObj = append(updateObj, bson.E{Key: "order_date", Value: order.OrderDate})
+	}
+	if order.TableID != nil {
+		updateObj = append(updateObj, bson.E{Key: "table_id", Value: *order.TableID})
+	}
+
+	result, err := s.collection.UpdateOne(
+		ctx,
+		bson.M{"order_id": orderID},
+		bson.D{{Key: "$set", Value: updateObj}},
+	)
+	if err != nil {
+		return store.UpdateResult{}, err
+	}
+	return updateResult(result)
+}
+
+func (s *OrderStore) Delete(ctx context.Context, orderID string) error {
+	result, err := s.collection.DeleteOne(ctx, bson.M{"order_id": orderID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (s *OrderStore) Transition(ctx context.Context, orderID string, transition models.OrderTransition) error {
+	filter := bson.M{"order_id": orderID, "status": transition.From}
+	if transition.From == models.OrderStatusPlaced {
+		// Orders stored before statuses existed have no status and count as PLACED.
+		filter["status"] = bson.M{"$in": bson.A{transition.From, nil}}
+	}
+
+	// An update pipeline lets the history be appended even on documents where
+	// status_history is missing or null.
+	update := mongo.Pipeline{{{Key: "$set", Value: bson.D{
+		{Key: "status", Value: transition.To},
+		{Key: "updated_at", Value: transition.ChangedAt},
+		{Key: "status_history", Value: bson.D{{Key: "$concatArrays", Value: bson.A{
+			bson.D{{Key: "$ifNull", Value: bson.A{"$status_history", bson.A{}}}},
+			bson.A{transition},
+		}}}},
+	}}}}
+
+	result, err := s.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		if _, err := s.Find(ctx, orderID); err != nil {
+			return err
+		}
+		return store.ErrConflict
+	}
+	return nil
+}
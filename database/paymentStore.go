@@ -0,0 +1,115 @@
+package database
+
+import (
+	"context"
+	"restaurant-management/models"
+	"restaurant-management/store"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type PaymentStore struct {
+	collection *mongo.Collection
+	invoices   *mongo.Collection
+}
+
+func NewPaymentStore(client *mongo.Client) *PaymentStore {
+	return &PaymentStore{
+		collection: OpenCollection(client, "payment"),
+		invoices:   OpenCollection(client, "invoice"),
+	}
+}
+
+func (s *PaymentStore) ListByInvoice(ctx context.Context, invoiceID string) ([]models.Payment, error) {
+	return s.find(ctx, bson.M{"invoice_id": invoiceID})
+}
+
+func (s *PaymentStore) find(ctx context.Context, filter bson.M) ([]models.Payment, error) {
+	cursor, err := s.collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	payments := []models.Payment{}
+	if err := cursor.All(ctx, &payments); err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
+
+func (s *PaymentStore) ListBetween(ctx context.Context, from, to time.Time) ([]models.Payment, error) {
+	return s.find(ctx, bson.M{"created_at": bson.M{"$gte": from, "$lt": to}})
+}
+
+func (s *PaymentStore) Find(ctx context.Context, paymentID string) (models.Payment, error) {
+	var payment models.Payment
+	err := s.collection.FindOne(ctx, bson.M{"payment_id": paymentID}).Decode(&payment)
+	return payment, findOne(err)
+}
+
+// Insert settles the invoice with a compare-and-set on its amount paid before
+// storing the payment, and puts back every field the settlement changed if
+// the payment cannot be stored.
+func (s *PaymentStore) Insert(ctx context.Context, payment models.Payment, settlement store.Settlement) error {
+	updateObj := bson.D{
+		{Key: "updated_at", Value: payment.CreatedAt},
+		{Key: "amount_paid", Value: settlement.AmountPaid},
+		{Key: "payment_status", Value: settlement.PaymentStatus},
+		{Key: "payment_method", Value: settlement.PaymentMethod},
+	}
+	if settlement.ProviderReference != "" {
+		updateObj = append(updateObj,
+			bson.E{Key: "payment_provider", Value: settlement.Provider},
+			bson.E{Key: "provider_reference", Value: settlement.ProviderReference},
+		)
+	}
+
+	var before models.Invoice
+	err := s.invoices.FindOneAndUpdate(ctx,
+		bson.M{
+			"invoice_id":         payment.InvoiceID,
+			"amount_paid.amount": unchanged(settlement.PaidBefore),
+			"payment_status":     bson.M{"$ne": models.PaymentVoid},
+		},
+		bson.D{{Key: "$set", Value: updateObj}},
+		options.FindOneAndUpdate().SetReturnDocument(options.Before),
+	).Decode(&before)
+	if err == mongo.ErrNoDocuments {
+		if err := s.invoices.FindOne(ctx, bson.M{"invoice_id": payment.InvoiceID}).Err(); err != nil {
+			return findOne(err)
+		}
+		return store.ErrConflict
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.collection.InsertOne(ctx, payment); err != nil {
+		s.invoices.UpdateOne(ctx,
+			bson.M{"invoice_id": payment.InvoiceID, "amount_paid.amount": unchanged(settlement.AmountPaid)},
+			bson.D{{Key: "$set", Value: bson.D{
+				{Key: "updated_at", Value: before.UpdatedAt},
+				{Key: "amount_paid", Value: before.AmountPaid},
+				{Key: "payment_status", Value: before.PaymentStatus},
+				{Key: "payment_method", Value: before.PaymentMethod},
+				{Key: "payment_provider", Value: before.PaymentProvider},
+				{Key: "provider_reference", Value: before.ProviderReference},
+			}}},
+		)
+		return err
+	}
+	return nil
+}
+
+// unchanged matches an invoice running total that still holds m. Invoices
+// that have never been paid or refunded have no total at all.
+func unchanged(m models.Money) interface{} {
+	if m.Amount == 0 {
+		return bson.M{"$in": bson.A{int64(0), nil}}
+	}
+	return m.Amount
+}
@@ -0,0 +1,55 @@
+package database
+
+import (
+	"context"
+	"restaurant-management/models"
+	"restaurant-management/store"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type PriceRuleStore struct {
+	collection *mongo.Collection
+}
+
+func NewPriceRuleStore(client *mongo.Client) *PriceRuleStore {
+	return &PriceRuleStore{collection: OpenCollection(client, "price_rule")}
+}
+
+func (s *PriceRuleStore) List(ctx context.Context) ([]models.PriceRule, error) {
+	cursor, err := s.collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	rules := []models.PriceRule{}
+	if err := cursor.All(ctx, &rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func (s *PriceRuleStore) Find(ctx context.Context, priceRuleID string) (models.PriceRule, error) {
+	var rule models.PriceRule
+	err := s.collection.FindOne(ctx, bson.M{"price_rule_id": priceRuleID}).Decode(&rule)
+	return rule, findOne(err)
+}
+
+func (s *PriceRuleStore) Insert(ctx context.Context, rule models.PriceRule) error {
+	_, err := s.collection.InsertOne(ctx, rule)
+	return err
+}
+
+func (s *PriceRuleStore) Replace(ctx context.Context, rule models.PriceRule) error {
+	result, err := s.collection.ReplaceOne(ctx, bson.M{"price_rule_id": rule.PriceRuleID}, rule)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package database
+
+import (
+	"context"
+	"restaurant-management/models"
+	"restaurant-management/store"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ReservationStore struct {
+	collection *mongo.Collection
+}
+
+func NewReservationStore(client *mongo.Client) *ReservationStore {
+	return &ReservationStore{collection: OpenCollection(client, "reservation")}
+}
+
+func (s *ReservationStore) find(ctx context.Context, filter bson.M) ([]models.Reservation, error) {
+	cursor, err := s.collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "start_time", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	reservations := []models.Reservation{}
+	if err := cursor.All(ctx, &reservations); err != nil {
+		return nil, err
+	}
+	return reservations, nil
+}
+
+func (s *ReservationStore) List(ctx context.Context, tableID string) ([]models.Reservation, error) {
+	filter := bson.M{}
+	if tableID != "" {
+		filter["table_id"] = tableID
+	}
+	return s.find(ctx, filter)
+}
+
+func (s *ReservationStore) Find(ctx context.Context, reservationID string) (models.Reservation, error) {
+	var reservation models.Reservation
+	err := s.collection.FindOne(ctx, bson.M{"reservation_id": reservationID}).Decode(&reservation)
+	return reservation, findOne(err)
+}
+
+func overlapFilter(start, end time.Time) bson.M {
+	return bson.M{
+		"status":     models.ReservationBooked,
+		"start_time": bson.M{"$lt": end},
+		"end_time":   bson.M{"$gt": start},
+	}
+}
+
+// Insert writes the reservation first and then looks for any other booking
+// of the table in the same slot, withdrawing its own if it finds one. Without
+// multi-document transactions this is what keeps two concurrent bookings from
+// both succeeding; at worst both withdraw and the caller retries.
+func (s *ReservationStore) Insert(ctx context.Context, reservation models.Reservation) error {
+	if _, err := s.collection.InsertOne(ctx, reservation); err != nil {
+		return err
+	}
+
+	filter := overlapFilter(reservation.StartTime, reservation.EndTime)
+	filter["table_id"] = reservation.TableID
+	filter["reservation_id"] = bson.M{"$ne": reservation.ReservationID}
+
+	count, err := s.collection.CountDocuments(ctx, filter)
+	if err == nil && count == 0 {
+		return nil
+	}
+
+	if _, deleteErr := s.collection.DeleteOne(ctx, bson.M{"reservation_id": reservation.ReservationID}); deleteErr != nil {
+		return deleteErr
+	}
+	if err != nil {
+		return err
+	}
+	return store.ErrConflict
+}
+
+func (s *ReservationStore) UpdateStatus(ctx context.Context, reservationID, status string) error {
+	result, err := s.collection.UpdateOne(
+		ctx,
+		bson.M{"reservation_id": reservationID, "status": models.ReservationBooked},
+		bson.D{{Key: "$set", Value: bson.D{
+			{Key: "status", Value: status},
+			{Key: "updated_at", Value: time.Now().UTC()},
+		}}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		if _, err := s.Find(ctx, reservationID); err != nil {
+			return err
+		}
+		return store.ErrConflict
+	}
+	return nil
+}
+
+func (s *ReservationStore) Overlapping(ctx context.Context, start, end time.Time) ([]models.Reservation, error) {
+	return s.find(ctx, overlapFilter(start, end))
+}
@@ -0,0 +1,245 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"restaurant-management/models"
+	"restaurant-management/store"
+	"time"
+)
+
+const creditNoteColumns = "id, credit_note_id, invoice_id, kind, reason, currency, amount, issued_by, created_at"
+
+type CreditNoteStore struct {
+	db *DB
+}
+
+func scanCreditNote(row scanner) (models.CreditNote, error) {
+	var note models.CreditNote
+	var id, currency string
+	var amount int64
+	err := row.Scan(&id, &note.CreditNoteID, &note.InvoiceID, &note.Kind, &note.Reason, &currency, &amount, &note.IssuedBy, &note.CreatedAt)
+	note.ID = objectID(id)
+	note.Amount = models.NewMoney(amount, currency)
+	return note, err
+}
+
+func (s *CreditNoteStore) ListByInvoice(ctx context.Context, invoiceID string) ([]models.CreditNote, error) {
+	return s.list(ctx, "WHERE invoice_id = ?", invoiceID)
+}
+
+func (s *CreditNoteStore) ListBetween(ctx context.Context, from, to time.Time) ([]models.CreditNote, error) {
+	return s.list(ctx, "WHERE created_at >= ? AND created_at < ?", from, to)
+}
+
+func (s *CreditNoteStore) list(ctx context.Context, where string, args ...interface{}) ([]models.CreditNote, error) {
+	rows, err := s.db.query(ctx, "SELECT "+creditNoteColumns+" FROM credit_notes "+where+" ORDER BY created_at, id", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notes := []models.CreditNote{}
+	for rows.Next() {
+		note, err := scanCreditNote(rows)
+		if err != nil {
+			return nil, err
+		}
+		notes = append(notes, note)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range notes {
+		if err := s.details(ctx, &notes[i]); err != nil {
+			return nil, err
+		}
+	}
+	return notes, nil
+}
+
+func (s *CreditNoteStore) Find(ctx context.Context, creditNoteID string) (models.CreditNote, error) {
+	note, err := scanCreditNote(s.db.queryRow(ctx, "SELECT "+creditNoteColumns+" FROM credit_notes WHERE credit_note_id = ?", creditNoteID))
+	if err != nil {
+		return note, notFound(err)
+	}
+	return note, s.details(ctx, &note)
+}
+
+// details loads the credited lines and refunds of note.
+func (s *CreditNoteStore) details(ctx context.Context, note *models.CreditNote) error {
+	currency := note.Amount.Currency
+
+	rows, err := s.db.query(ctx, `
+		SELECT order_item_id, food_id, food_name, quantity, unit_price, discount, amount, tax_category, tax_rate, tax
+		FROM credit_note_lines WHERE credit_note_id = ? ORDER BY position`, note.CreditNoteID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var line models.InvoiceLine
+		var unitPrice, discount, amount, tax int64
+		err := rows.Scan(&line.OrderItemID, &line.FoodID, &line.FoodName, &line.Quantity, &unitPrice, &discount, &amount, &line.TaxCategory, &line.TaxRate, &tax)
+		if err != nil {
+			return err
+		}
+		line.UnitPrice = models.NewMoney(unitPrice, currency)
+		line.Discount = models.NewMoney(discount, currency)
+		line.Amount = models.NewMoney(amount, currency)
+		line.Tax = models.NewMoney(tax, currency)
+		note.Lines = append(note.Lines, line)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	refundRows, err := s.db.query(ctx, "SELECT method, amount, provider, capture, reference FROM credit_note_refunds WHERE credit_note_id = ? ORDER BY position", note.CreditNoteID)
+	if err != nil {
+		return err
+	}
+	defer refundRows.Close()
+
+	for refundRows.Next() {
+		var refund models.Refund
+		var amount int64
+		if err := refundRows.Scan(&refund.Method, &amount, &refund.Provider, &refund.Capture, &refund.Reference); err != nil {
+			return err
+		}
+		refund.Amount = models.NewMoney(amount, currency)
+		note.Refunds = append(note.Refunds, refund)
+	}
+	return refundRows.Err()
+}
+
+func (s *CreditNoteStore) Insert(ctx context.Context, note models.CreditNote, credit store.Credit) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx,
+		s.db.rebind(`UPDATE invoices SET amount_refunded = ?, payment_status = ?, updated_at = ?
+			WHERE invoice_id = ? AND payment_status = ? AND COALESCE(amount_paid, 0) = ? AND COALESCE(amount_refunded, 0) = ?`),
+		credit.AmountRefunded.Amount, credit.PaymentStatus, note.CreatedAt,
+		note.InvoiceID, credit.StatusBefore, credit.PaidBefore.Amount, credit.RefundedBefore.Amount,
+	)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		var exists int
+		if err := tx.QueryRowContext(ctx, s.db.rebind("SELECT COUNT(*) FROM invoices WHERE invoice_id = ?"), note.InvoiceID).Scan(&exists); err != nil {
+			return err
+		}
+		if exists == 0 {
+			return store.ErrNotFound
+		}
+		return store.ErrConflict
+	}
+
+	_, err = tx.ExecContext(ctx,
+		s.db.rebind("INSERT INTO credit_notes ("+creditNoteColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)"),
+		note.ID.Hex(), note.CreditNoteID, note.InvoiceID, note.Kind, note.Reason, note.Amount.Currency, note.Amount.Amount, note.IssuedBy, note.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	lineQuery := s.db.rebind(`INSERT INTO credit_note_lines
+		(credit_note_id, position, order_item_id, food_id, food_name, quantity, unit_price, discount, amount, tax_category, tax_rate, tax)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	for i, line := range note.Lines {
+		_, err := tx.ExecContext(ctx, lineQuery,
+			note.CreditNoteID, i, line.OrderItemID, line.FoodID, line.FoodName, line.Quantity, line.UnitPrice.Amount, line.Discount.Amount, line.Amount.Amount, line.TaxCategory, line.TaxRate, line.Tax.Amount,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := s.putRefunds(ctx, tx, note.CreditNoteID, note.Refunds); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// putRefunds writes refunds as the refunds of creditNoteID.
+func (s *CreditNoteStore) putRefunds(ctx context.Context, tx *sql.Tx, creditNoteID string, refunds []models.Refund) error {
+	refundQuery := s.db.rebind("INSERT INTO credit_note_refunds (credit_note_id, position, method, amount, provider, capture, reference) VALUES (?, ?, ?, ?, ?, ?, ?)")
+	for i, refund := range refunds {
+		if _, err := tx.ExecContext(ctx, refundQuery, creditNoteID, i, refund.Method, refund.Amount.Amount, refund.Provider, refund.Capture, refund.Reference); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *CreditNoteStore) RecordRefunds(ctx context.Context, creditNoteID string, refunds []models.Refund) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists int
+	if err := tx.QueryRowContext(ctx, s.db.rebind("SELECT COUNT(*) FROM credit_notes WHERE credit_note_id = ?"), creditNoteID).Scan(&exists); err != nil {
+		return err
+	}
+	if exists == 0 {
+		return store.ErrNotFound
+	}
+	if _, err := tx.ExecContext(ctx, s.db.rebind("DELETE FROM credit_note_refunds WHERE credit_note_id = ?"), creditNoteID); err != nil {
+		return err
+	}
+	if err := s.putRefunds(ctx, tx, creditNoteID, refunds); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *CreditNoteStore) Cancel(ctx context.Context, note models.CreditNote, credit store.Credit) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx,
+		s.db.rebind(`UPDATE invoices SET amount_refunded = ?, payment_status = ?, updated_at = ?
+			WHERE invoice_id = ? AND payment_status = ? AND amount_refunded = ?`),
+		credit.RefundedBefore.Amount, credit.StatusBefore, time.Now().UTC(),
+		note.InvoiceID, credit.PaymentStatus, credit.AmountRefunded.Amount,
+	)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		var exists int
+		if err := tx.QueryRowContext(ctx, s.db.rebind("SELECT COUNT(*) FROM invoices WHERE invoice_id = ?"), note.InvoiceID).Scan(&exists); err != nil {
+			return err
+		}
+		if exists == 0 {
+			return store.ErrNotFound
+		}
+		return store.ErrConflict
+	}
+
+	for _, table := range []string{"credit_note_refunds", "credit_note_lines", "credit_notes"} {
+		if _, err := tx.ExecContext(ctx, s.db.rebind("DELETE FROM "+table+" WHERE credit_note_id = ?"), note.CreditNoteID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
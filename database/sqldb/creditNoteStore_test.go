@@ -0,0 +1,141 @@
+package sqldb
+
+import (
+	"context"
+	"restaurant-management/models"
+	"restaurant-management/store"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// newCreditNote returns a credit note for amount in USD of invoice, refunded
+// in cash.
+func newCreditNote(invoice models.Invoice, amount int64) models.CreditNote {
+	credited := models.NewMoney(amount, "USD")
+	note := models.CreditNote{
+		ID:        primitive.NewObjectID(),
+		InvoiceID: invoice.InvoiceID,
+		Kind:      models.CreditNoteRefund,
+		Reason:    "cold food",
+		Amount:    credited,
+		Refunds:   []models.Refund{{Method: "CASH", Amount: credited}},
+		IssuedBy:  "u1",
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+	}
+	note.CreditNoteID = note.ID.Hex()
+	return note
+}
+
+func TestCreditNoteInsertConflictsWhenRefundsHaveChanged(t *testing.T) {
+	ctx := context.Background()
+	stores := NewStores(openTest(t))
+	order, _ := seedOrder(t, stores)
+	invoice := seedInvoice(t, stores, order, 2400)
+
+	paid := store.Settlement{
+		PaidBefore:    models.NewMoney(0, "USD"),
+		AmountPaid:    models.NewMoney(2400, "USD"),
+		PaymentStatus: models.PaymentPaid,
+		PaymentMethod: "CASH",
+	}
+	if err := stores.Payments.Insert(ctx, newPayment(invoice, 2400), paid); err != nil {
+		t.Fatalf("Payments.Insert: %v", err)
+	}
+
+	// Two refunds worked out from the same paid invoice: the first is
+	// stored, the second finds the amount refunded moved on.
+	credit := store.Credit{
+		StatusBefore:   models.PaymentPaid,
+		PaidBefore:     models.NewMoney(2400, "USD"),
+		RefundedBefore: models.NewMoney(0, "USD"),
+		AmountRefunded: models.NewMoney(1500, "USD"),
+		PaymentStatus:  models.PaymentPaid,
+	}
+	first := newCreditNote(invoice, 1500)
+	if err := stores.CreditNotes.Insert(ctx, first, credit); err != nil {
+		t.Fatalf("first Insert: %v", err)
+	}
+	stale := newCreditNote(invoice, 1500)
+	if err := stores.CreditNotes.Insert(ctx, stale, credit); err != store.ErrConflict {
+		t.Fatalf("stale Insert = %v, want ErrConflict", err)
+	}
+	if _, err := stores.CreditNotes.Find(ctx, stale.CreditNoteID); err != store.ErrNotFound {
+		t.Errorf("Find stale note = %v, want ErrNotFound", err)
+	}
+
+	stored, err := stores.Invoices.Find(ctx, invoice.InvoiceID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.AmountRefunded == nil || stored.AmountRefunded.Amount != 1500 {
+		t.Errorf("AmountRefunded = %v, want 1500", stored.AmountRefunded)
+	}
+
+	// A credit worked out from a status the invoice no longer has conflicts
+	// too, even with the amounts right.
+	wrongStatus := credit
+	wrongStatus.StatusBefore = models.PaymentPartiallyPaid
+	wrongStatus.RefundedBefore = models.NewMoney(1500, "USD")
+	wrongStatus.AmountRefunded = models.NewMoney(2400, "USD")
+	if err := stores.CreditNotes.Insert(ctx, newCreditNote(invoice, 900), wrongStatus); err != store.ErrConflict {
+		t.Errorf("Insert from a stale status = %v, want ErrConflict", err)
+	}
+
+	if err := stores.CreditNotes.Insert(ctx, newCreditNote(models.Invoice{InvoiceID: "missing"}, 900), credit); err != store.ErrNotFound {
+		t.Errorf("Insert against a missing invoice = %v, want ErrNotFound", err)
+	}
+}
+
+func TestCreditNoteCancel(t *testing.T) {
+	ctx := context.Background()
+	stores := NewStores(openTest(t))
+	order, _ := seedOrder(t, stores)
+	invoice := seedInvoice(t, stores, order, 2400)
+
+	paid := store.Settlement{
+		PaidBefore:    models.NewMoney(0, "USD"),
+		AmountPaid:    models.NewMoney(2400, "USD"),
+		PaymentStatus: models.PaymentPaid,
+		PaymentMethod: "CASH",
+	}
+	if err := stores.Payments.Insert(ctx, newPayment(invoice, 2400), paid); err != nil {
+		t.Fatalf("Payments.Insert: %v", err)
+	}
+
+	credit := store.Credit{
+		StatusBefore:   models.PaymentPaid,
+		PaidBefore:     models.NewMoney(2400, "USD"),
+		RefundedBefore: models.NewMoney(0, "USD"),
+		AmountRefunded: models.NewMoney(2400, "USD"),
+		PaymentStatus:  models.PaymentRefunded,
+	}
+	note := newCreditNote(invoice, 2400)
+	if err := stores.CreditNotes.Insert(ctx, note, credit); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+	if err := stores.CreditNotes.Cancel(ctx, note, credit); err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+
+	if _, err := stores.CreditNotes.Find(ctx, note.CreditNoteID); err != store.ErrNotFound {
+		t.Errorf("Find cancelled note = %v, want ErrNotFound", err)
+	}
+	stored, err := stores.Invoices.Find(ctx, invoice.InvoiceID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.AmountRefunded == nil || stored.AmountRefunded.Amount != 0 {
+		t.Errorf("AmountRefunded = %v, want 0", stored.AmountRefunded)
+	}
+	if stored.PaymentStatus == nil || *stored.PaymentStatus != models.PaymentPaid {
+		t.Errorf("PaymentStatus = %v, want %s", stored.PaymentStatus, models.PaymentPaid)
+	}
+
+	// Once the invoice has moved on from what the note left it at, the note
+	// can no longer be cancelled.
+	if err := stores.CreditNotes.Cancel(ctx, note, credit); err != store.ErrConflict {
+		t.Errorf("second Cancel = %v, want ErrConflict", err)
+	}
+}
@@ -0,0 +1,214 @@
+// Package sqldb implements every entity store on top of database/sql. It runs
+// against PostgreSQL in production and SQLite for tests and single-site
+// installs that cannot run MongoDB.
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"restaurant-management/models"
+	"restaurant-management/store"
+	"strconv"
+	"strings"
+
+	_ "github.com/lib/pq"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	_ "modernc.org/sqlite"
+)
+
+type Dialect int
+
+const (
+	SQLite Dialect = iota
+	Postgres
+)
+
+// DB pairs a connection pool with the dialect its queries must be written in.
+type DB struct {
+	*sql.DB
+	dialect Dialect
+}
+
+// Open connects to driver ("sqlite" or "postgres") at dsn and applies any
+// pending migrations.
+func Open(ctx context.Context, driver, dsn string) (*DB, error) {
+	var dialect Dialect
+	switch driver {
+	case "sqlite":
+		dialect = SQLite
+	case "postgres":
+		dialect = Postgres
+	default:
+		return nil, fmt.Errorf("unsupported sql driver %q", driver)
+	}
+
+	if dialect == SQLite {
+		dsn = sqliteDSN(dsn)
+	}
+	conn, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	if dialect == SQLite {
+		// SQLite allows a single writer.
+		conn.SetMaxOpenConns(1)
+	}
+
+	db := &DB{DB: conn, dialect: dialect}
+	if err := db.Migrate(ctx); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// sqliteDSN adds the pragma enforcing foreign keys to dsn. SQLite enforces
+// them per connection, so the driver has to apply it to every connection the
+// pool opens, not just the first.
+func sqliteDSN(dsn string) string {
+	if strings.Contains(dsn, "_pragma=foreign_keys") {
+		return dsn
+	}
+	separator := "?"
+	if strings.Contains(dsn, "?") {
+		separator = "&"
+	}
+	return dsn + separator + "_pragma=foreign_keys(1)"
+}
+
+// NewStores returns the SQL implementation of every entity store.
+func NewStores(db *DB) store.Stores {
+	return store.Stores{
+		Foods:        &FoodStore{db: db},
+		Menus:        &MenuStore{db: db},
+		Tables:       &TableStore{db: db},
+		Reservations: &ReservationStore{db: db},
+		Orders:       &OrderStore{db: db},
+		OrderItems:   &OrderItemStore{db: db},
+		Invoices:     &InvoiceStore{db: db},
+		Payments:     &PaymentStore{db: db},
+		CreditNotes:  &CreditNoteStore{db: db},
+		Ingredients:  &IngredientStore{db: db},
+		Recipes:      &RecipeStore{db: db},
+		PriceRules:   &PriceRuleStore{db: db},
+		Vouchers:     &VoucherStore{db: db},
+		Users:        &UserStore{db: db},
+	}
+}
+
+// rebind rewrites the ? placeholders every query is written with into the
+// numbered form PostgreSQL expects. A ? inside a quoted string or identifier
+// is left alone.
+func (db *DB) rebind(query string) string {
+	if db.dialect != Postgres {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	var quote rune
+	for _, r := range query {
+		switch {
+		case quote != 0:
+			// A doubled quote escapes itself, which closes and reopens the
+			// quoted text and so needs no special case.
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == '?':
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func (db *DB) exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return db.ExecContext(ctx, db.rebind(query), args...)
+}
+
+func (db *DB) query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return db.QueryContext(ctx, db.rebind(query), args...)
+}
+
+func (db *DB) queryRow(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return db.QueryRowContext(ctx, db.rebind(query), args...)
+}
+
+// update runs an UPDATE built from the non-empty fields a PATCH supplied.
+func (db *DB) update(ctx context.Context, table, keyColumn, key string, set updateSet) (store.UpdateResult, error) {
+	query := "UPDATE " + table + " SET " + set.String() + " WHERE " + keyColumn + " = ?"
+	result, err := db.exec(ctx, query, append(set.args, key)...)
+	if err != nil {
+		return store.UpdateResult{}, err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return store.UpdateResult{}, err
+	}
+	if n == 0 {
+		return store.UpdateResult{}, store.ErrNotFound
+	}
+	return store.UpdateResult{MatchedCount: n, ModifiedCount: n}, nil
+}
+
+type updateSet struct {
+	columns []string
+	args    []interface{}
+}
+
+func (s *updateSet) set(column string, value interface{}) {
+	s.columns = append(s.columns, column+" = ?")
+	s.args = append(s.args, value)
+}
+
+// String renders the assignments for an UPDATE's SET clause.
+func (s updateSet) String() string {
+	return strings.Join(s.columns, ", ")
+}
+
+func notFound(err error) error {
+	if err == sql.ErrNoRows {
+		return store.ErrNotFound
+	}
+	return err
+}
+
+// money rebuilds an amount stored in minor units from its column and the
+// currency column of its row. Rows written before currencies were stored have
+// none and are in the default currency.
+func money(amount sql.NullInt64, currency sql.NullString) *models.Money {
+	if !amount.Valid {
+		return nil
+	}
+	m := models.NewMoney(amount.Int64, currency.String)
+	return &m
+}
+
+// minorAmount is the value stored in an amount column for m.
+func minorAmount(m *models.Money) interface{} {
+	if m == nil {
+		return nil
+	}
+	return m.Amount
+}
+
+// currencyCode is the value stored in a currency column for m.
+func currencyCode(m *models.Money) interface{} {
+	if m == nil {
+		return nil
+	}
+	return m.Currency
+}
+
+// objectID restores the Mongo-style ID the models carry from its hex column.
+func objectID(hex string) primitive.ObjectID {
+	id, _ := primitive.ObjectIDFromHex(hex)
+	return id
+}
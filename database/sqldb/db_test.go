@@ -0,0 +1,141 @@
+package sqldb
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+// openTest opens a fresh SQLite database, migrated to the latest version,
+// that is removed when the test ends.
+func openTest(t *testing.T) *DB {
+	t.Helper()
+
+	db, err := Open(context.Background(), "sqlite", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestMigrateFromEmpty(t *testing.T) {
+	ctx := context.Background()
+	db := openTest(t)
+
+	rows, err := db.query(ctx, "SELECT version FROM schema_migrations ORDER BY version")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var applied []int
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			t.Fatal(err)
+		}
+		applied = append(applied, version)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(applied) != len(migrations) {
+		t.Fatalf("applied %d migrations, want %d", len(applied), len(migrations))
+	}
+	for i, m := range migrations {
+		if applied[i] != m.version {
+			t.Errorf("migration %d applied as version %d, want %d", i, applied[i], m.version)
+		}
+		if i > 0 && m.version != migrations[i-1].version+1 {
+			t.Errorf("migration %q has version %d after %d", m.name, m.version, migrations[i-1].version)
+		}
+	}
+
+	// Migrating again finds nothing left to apply.
+	if err := db.Migrate(ctx); err != nil {
+		t.Fatalf("Migrate again: %v", err)
+	}
+	var count int
+	if err := db.queryRow(ctx, "SELECT COUNT(*) FROM schema_migrations").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != len(migrations) {
+		t.Errorf("schema_migrations has %d rows after migrating again, want %d", count, len(migrations))
+	}
+
+	// Every store can query its tables once migrated.
+	stores := NewStores(db)
+	if _, err := stores.Invoices.List(ctx); err != nil {
+		t.Errorf("Invoices.List: %v", err)
+	}
+	if _, err := stores.OrderItems.List(ctx); err != nil {
+		t.Errorf("OrderItems.List: %v", err)
+	}
+	if _, err := stores.Vouchers.List(ctx); err != nil {
+		t.Errorf("Vouchers.List: %v", err)
+	}
+}
+
+func TestForeignKeysOnEveryConnection(t *testing.T) {
+	ctx := context.Background()
+	db := openTest(t)
+	db.SetMaxOpenConns(2)
+
+	// Hold two connections at once so the pool has to open a second one.
+	for i := 0; i < 2; i++ {
+		conn, err := db.Conn(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+
+		var enabled int
+		if err := conn.QueryRowContext(ctx, "PRAGMA foreign_keys").Scan(&enabled); err != nil {
+			t.Fatal(err)
+		}
+		if enabled != 1 {
+			t.Errorf("connection %d has foreign_keys = %d, want 1", i, enabled)
+		}
+	}
+}
+
+func TestSQLiteDSN(t *testing.T) {
+	tests := []struct {
+		dsn  string
+		want string
+	}{
+		{"test.db", "test.db?_pragma=foreign_keys(1)"},
+		{"file:test.db?cache=shared", "file:test.db?cache=shared&_pragma=foreign_keys(1)"},
+		{"test.db?_pragma=foreign_keys(0)", "test.db?_pragma=foreign_keys(0)"},
+	}
+	for _, tt := range tests {
+		if got := sqliteDSN(tt.dsn); got != tt.want {
+			t.Errorf("sqliteDSN(%q) = %q, want %q", tt.dsn, got, tt.want)
+		}
+	}
+}
+
+func TestRebind(t *testing.T) {
+	tests := []struct {
+		dialect Dialect
+		query   string
+		want    string
+	}{
+		{SQLite, "SELECT * FROM foods WHERE food_id = ? AND menu_id = ?", "SELECT * FROM foods WHERE food_id = ? AND menu_id = ?"},
+		{Postgres, "SELECT * FROM foods", "SELECT * FROM foods"},
+		{Postgres, "SELECT * FROM foods WHERE food_id = ?", "SELECT * FROM foods WHERE food_id = $1"},
+		{Postgres, "UPDATE foods SET name = ?, price = ? WHERE food_id = ?", "UPDATE foods SET name = $1, price = $2 WHERE food_id = $3"},
+		{Postgres, "INSERT INTO t (a, b) VALUES (?, ?)", "INSERT INTO t (a, b) VALUES ($1, $2)"},
+		{Postgres, "SELECT 'é' FROM t WHERE a IN (?,?,?,?,?,?,?,?,?,?)", "SELECT 'é' FROM t WHERE a IN ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10)"},
+		{Postgres, "SELECT * FROM t WHERE a = 'why?' AND b = ?", "SELECT * FROM t WHERE a = 'why?' AND b = $1"},
+		{Postgres, "SELECT * FROM t WHERE a = 'it''s ?' AND b = ?", "SELECT * FROM t WHERE a = 'it''s ?' AND b = $1"},
+		{Postgres, `SELECT "odd?" FROM t WHERE a = ?`, `SELECT "odd?" FROM t WHERE a = $1`},
+	}
+	for _, tt := range tests {
+		db := &DB{dialect: tt.dialect}
+		if got := db.rebind(tt.query); got != tt.want {
+			t.Errorf("rebind(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
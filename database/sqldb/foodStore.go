@@ -0,0 +1,298 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"restaurant-management/models"
+	"restaurant-management/store"
+	"strings"
+	"time"
+)
+
+const foodColumns = "id, food_id, name, price, currency, food_image, menu_id, station, tax_category, available, unavailable_reason, created_at, updated_at"
+
+type FoodStore struct {
+	db *DB
+}
+
+// scanner is satisfied by both *sql.Row and *sql.Rows.
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanFood(row scanner) (models.Food, error) {
+	var food models.Food
+	var id string
+	var price sql.NullInt64
+	var priceCurrency sql.NullString
+	err := row.Scan(&id, &food.FoodID, &food.Name, &price, &priceCurrency, &food.FoodImage, &food.MenuID, &food.Station, &food.TaxCategory, &food.Available, &food.UnavailableReason, &food.CreatedAt, &food.UpdatedAt)
+	food.ID = objectID(id)
+	food.Price = money(price, priceCurrency)
+	return food, err
+}
+
+func (s *FoodStore) List(ctx context.Context, filter store.FoodFilter, startIndex, recordPerPage int) (int, []models.Food, error) {
+	var conditions []string
+	var args []interface{}
+	if filter.Available {
+		conditions = append(conditions, "(available IS NULL OR available = ?)")
+		args = append(args, true)
+	}
+	if filter.MenuIDs != nil {
+		menus := "menu_id IS NULL"
+		if len(filter.MenuIDs) > 0 {
+			menus += " OR menu_id IN (?" + strings.Repeat(", ?", len(filter.MenuIDs)-1) + ")"
+		}
+		conditions = append(conditions, "("+menus+")")
+		for _, menuID := range filter.MenuIDs {
+			args = append(args, menuID)
+		}
+	}
+	var where string
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := s.db.queryRow(ctx, "SELECT COUNT(*) FROM foods"+where, args...).Scan(&total); err != nil {
+		return 0, nil, err
+	}
+
+	rows, err := s.db.query(ctx, "SELECT "+foodColumns+" FROM foods"+where+" ORDER BY id LIMIT ? OFFSET ?", append(args, recordPerPage, startIndex)...)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer rows.Close()
+
+	foods := []models.Food{}
+	for rows.Next() {
+		food, err := scanFood(rows)
+		if err != nil {
+			return 0, nil, err
+		}
+		foods = append(foods, food)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, nil, err
+	}
+
+	for i := range foods {
+		if err := s.modifierGroups(ctx, &foods[i]); err != nil {
+			return 0, nil, err
+		}
+		if err := s.sizePrices(ctx, &foods[i]); err != nil {
+			return 0, nil, err
+		}
+	}
+	return total, foods, nil
+}
+
+func (s *FoodStore) Find(ctx context.Context, foodID string) (models.Food, error) {
+	food, err := scanFood(s.db.queryRow(ctx, "SELECT "+foodColumns+" FROM foods WHERE food_id = ?", foodID))
+	if err != nil {
+		return food, notFound(err)
+	}
+	if err := s.modifierGroups(ctx, &food); err != nil {
+		return food, err
+	}
+	return food, s.sizePrices(ctx, &food)
+}
+
+func (s *FoodStore) sizePrices(ctx context.Context, food *models.Food) error {
+	rows, err := s.db.query(ctx, "SELECT size, price, currency FROM food_size_prices WHERE food_id = ? ORDER BY position", food.FoodID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	food.SizePrices = []models.SizePrice{}
+	for rows.Next() {
+		var sizePrice models.SizePrice
+		if err := rows.Scan(&sizePrice.Size, &sizePrice.Price.Amount, &sizePrice.Price.Currency); err != nil {
+			return err
+		}
+		food.SizePrices = append(food.SizePrices, sizePrice)
+	}
+	return rows.Err()
+}
+
+// modifierGroups loads the modifier groups of food and their options, kept
+// in food_modifier_groups and food_modifier_options by position.
+func (s *FoodStore) modifierGroups(ctx context.Context, food *models.Food) error {
+	rows, err := s.db.query(ctx, "SELECT position, name, min_selections, max_selections FROM food_modifier_groups WHERE food_id = ? ORDER BY position", food.FoodID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	food.ModifierGroups = []models.ModifierGroup{}
+	index := map[int]int{}
+	for rows.Next() {
+		var position int
+		group := models.ModifierGroup{Options: []models.ModifierOption{}}
+		if err := rows.Scan(&position, &group.Name, &group.MinSelections, &group.MaxSelections); err != nil {
+			return err
+		}
+		index[position] = len(food.ModifierGroups)
+		food.ModifierGroups = append(food.ModifierGroups, group)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	options, err := s.db.query(ctx, "SELECT group_position, name, price_adjustment, currency FROM food_modifier_options WHERE food_id = ? ORDER BY group_position, position", food.FoodID)
+	if err != nil {
+		return err
+	}
+	defer options.Close()
+
+	for options.Next() {
+		var position int
+		var option models.ModifierOption
+		var adjustment sql.NullInt64
+		var currency sql.NullString
+		if err := options.Scan(&position, &option.Name, &adjustment, &currency); err != nil {
+			return err
+		}
+		option.PriceAdjustment = money(adjustment, currency)
+		if i, ok := index[position]; ok {
+			food.ModifierGroups[i].Options = append(food.ModifierGroups[i].Options, option)
+		}
+	}
+	return options.Err()
+}
+
+func (s *FoodStore) Insert(ctx context.Context, food models.Food) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx,
+		s.db.rebind("INSERT INTO foods ("+foodColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"),
+		food.ID.Hex(), food.FoodID, food.Name, minorAmount(food.Price), currencyCode(food.Price), food.FoodImage, food.MenuID, food.Station, food.TaxCategory,
+		food.Available, food.UnavailableReason, food.CreatedAt, food.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	if err := s.putModifierGroups(ctx, tx, food.FoodID, food.ModifierGroups); err != nil {
+		return err
+	}
+	if err := s.putSizePrices(ctx, tx, food.FoodID, food.SizePrices); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *FoodStore) putSizePrices(ctx context.Context, tx *sql.Tx, foodID string, sizePrices []models.SizePrice) error {
+	query := s.db.rebind("INSERT INTO food_size_prices (food_id, position, size, price, currency) VALUES (?, ?, ?, ?, ?)")
+	for i, sizePrice := range sizePrices {
+		if _, err := tx.ExecContext(ctx, query, foodID, i, sizePrice.Size, sizePrice.Price.Amount, sizePrice.Price.Currency); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *FoodStore) putModifierGroups(ctx context.Context, tx *sql.Tx, foodID string, groups []models.ModifierGroup) error {
+	groupQuery := s.db.rebind("INSERT INTO food_modifier_groups (food_id, position, name, min_selections, max_selections) VALUES (?, ?, ?, ?, ?)")
+	optionQuery := s.db.rebind("INSERT INTO food_modifier_options (food_id, group_position, position, name, price_adjustment, currency) VALUES (?, ?, ?, ?, ?, ?)")
+	for i, group := range groups {
+		if _, err := tx.ExecContext(ctx, groupQuery, foodID, i, group.Name, group.MinSelections, group.MaxSelections); err != nil {
+			return err
+		}
+		for j, option := range group.Options {
+			if _, err := tx.ExecContext(ctx, optionQuery, foodID, i, j, option.Name, minorAmount(option.PriceAdjustment), currencyCode(option.PriceAdjustment)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *FoodStore) Update(ctx context.Context, foodID string, food models.Food) (store.UpdateResult, error) {
+	var set updateSet
+	set.set("updated_at", time.Now().UTC())
+
+	if food.Name != nil {
+		set.set("name", *food.Name)
+	}
+	if food.Price != nil {
+		set.set("price", food.Price.Amount)
+		set.set("currency", food.Price.Currency)
+	}
+	if food.FoodImage != nil {
+		set.set("food_image", *food.FoodImage)
+	}
+	if food.MenuID != nil {
+		set.set("menu_id", *food.MenuID)
+	}
+	if food.Station != nil {
+		set.set("station", *food.Station)
+	}
+	if food.TaxCategory != nil {
+		set.set("tax_category", *food.TaxCategory)
+	}
+
+	if food.ModifierGroups == nil && food.SizePrices == nil {
+		return s.db.update(ctx, "foods", "food_id", foodID, set)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return store.UpdateResult{}, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, s.db.rebind("UPDATE foods SET "+set.String()+" WHERE food_id = ?"), append(set.args, foodID)...)
+	if err != nil {
+		return store.UpdateResult{}, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return store.UpdateResult{}, err
+	}
+	if n == 0 {
+		return store.UpdateResult{}, store.ErrNotFound
+	}
+
+	if food.ModifierGroups != nil {
+		if _, err := tx.ExecContext(ctx, s.db.rebind("DELETE FROM food_modifier_options WHERE food_id = ?"), foodID); err != nil {
+			return store.UpdateResult{}, err
+		}
+		if _, err := tx.ExecContext(ctx, s.db.rebind("DELETE FROM food_modifier_groups WHERE food_id = ?"), foodID); err != nil {
+			return store.UpdateResult{}, err
+		}
+		if err := s.putModifierGroups(ctx, tx, foodID, food.ModifierGroups); err != nil {
+			return store.UpdateResult{}, err
+		}
+	}
+	if food.SizePrices != nil {
+		if _, err := tx.ExecContext(ctx, s.db.rebind("DELETE FROM food_size_prices WHERE food_id = ?"), foodID); err != nil {
+			return store.UpdateResult{}, err
+		}
+		if err := s.putSizePrices(ctx, tx, foodID, food.SizePrices); err != nil {
+			return store.UpdateResult{}, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return store.UpdateResult{}, err
+	}
+	return store.UpdateResult{MatchedCount: n, ModifiedCount: n}, nil
+}
+
+func (s *FoodStore) SetAvailability(ctx context.Context, foodID string, available bool, reason string) error {
+	var set updateSet
+	set.set("updated_at", time.Now().UTC())
+	set.set("available", available)
+	if available {
+		set.set("unavailable_reason", nil)
+	} else {
+		set.set("unavailable_reason", reason)
+	}
+
+	_, err := s.db.update(ctx, "foods", "food_id", foodID, set)
+	return err
+}
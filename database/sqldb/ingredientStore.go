@@ -0,0 +1,262 @@
+package sqldb
+
+import (
+	"context"
+	"restaurant-management/models"
+	"restaurant-management/store"
+	"time"
+)
+
+const ingredientColumns = "id, ingredient_id, name, unit, stock, reorder_level, created_at, updated_at"
+
+const movementColumns = "id, movement_id, ingredient_id, change, stock_after, reason, order_id, order_item_id, note, recorded_by, created_at"
+
+type IngredientStore struct {
+	db *DB
+}
+
+func scanIngredient(row scanner) (models.Ingredient, error) {
+	var ingredient models.Ingredient
+	var id string
+	err := row.Scan(&id, &ingredient.IngredientID, &ingredient.Name, &ingredient.Unit, &ingredient.Stock, &ingredient.ReorderLevel, &ingredient.CreatedAt, &ingredient.UpdatedAt)
+	ingredient.ID = objectID(id)
+	return ingredient, err
+}
+
+func scanMovement(row scanner) (models.StockMovement, error) {
+	var movement models.StockMovement
+	var id string
+	err := row.Scan(&id, &movement.MovementID, &movement.IngredientID, &movement.Change, &movement.StockAfter, &movement.Reason,
+		&movement.OrderID, &movement.OrderItemID, &movement.Note, &movement.RecordedBy, &movement.CreatedAt)
+	movement.ID = objectID(id)
+	return movement, err
+}
+
+func (s *IngredientStore) List(ctx context.Context) ([]models.Ingredient, error) {
+	return s.list(ctx, "")
+}
+
+func (s *IngredientStore) ListLowStock(ctx context.Context) ([]models.Ingredient, error) {
+	return s.list(ctx, "WHERE reorder_level IS NOT NULL AND stock <= reorder_level")
+}
+
+func (s *IngredientStore) list(ctx context.Context, where string) ([]models.Ingredient, error) {
+	rows, err := s.db.query(ctx, "SELECT "+ingredientColumns+" FROM ingredients "+where+" ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ingredients := []models.Ingredient{}
+	for rows.Next() {
+		ingredient, err := scanIngredient(rows)
+		if err != nil {
+			return nil, err
+		}
+		ingredients = append(ingredients, ingredient)
+	}
+	return ingredients, rows.Err()
+}
+
+func (s *IngredientStore) Find(ctx context.Context, ingredientID string) (models.Ingredient, error) {
+	ingredient, err := scanIngredient(s.db.queryRow(ctx, "SELECT "+ingredientColumns+" FROM ingredients WHERE ingredient_id = ?", ingredientID))
+	return ingredient, notFound(err)
+}
+
+func (s *IngredientStore) Insert(ctx context.Context, ingredient models.Ingredient) error {
+	_, err := s.db.exec(ctx,
+		"INSERT INTO ingredients ("+ingredientColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		ingredient.ID.Hex(), ingredient.IngredientID, ingredient.Name, ingredient.Unit, ingredient.Stock, ingredient.ReorderLevel, ingredient.CreatedAt, ingredient.UpdatedAt,
+	)
+	return err
+}
+
+func (s *IngredientStore) Update(ctx context.Context, ingredientID string, ingredient models.Ingredient) (store.UpdateResult, error) {
+	var set updateSet
+	set.set("updated_at", time.Now().UTC())
+
+	if ingredient.Name != nil {
+		set.set("name", *ingredient.Name)
+	}
+	if ingredient.ReorderLevel != nil {
+		set.set("reorder_level", *ingredient.ReorderLevel)
+	}
+
+	return s.db.update(ctx, "ingredients", "ingredient_id", ingredientID, set)
+}
+
+func (s *IngredientStore) Move(ctx context.Context, movements []models.StockMovement) error {
+	return s.move(ctx, movements, false)
+}
+
+func (s *IngredientStore) Reserve(ctx context.Context, movements []models.StockMovement) error {
+	return s.move(ctx, movements, true)
+}
+
+// move applies movements in one transaction, which is rolled back if
+// reserving and one would take stock below zero.
+func (s *IngredientStore) move(ctx context.Context, movements []models.StockMovement, reserve bool) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	updateQuery := "UPDATE ingredients SET stock = stock + ?, updated_at = ? WHERE ingredient_id = ?"
+	if reserve {
+		updateQuery += " AND stock + ? >= 0"
+	}
+	updateQuery = s.db.rebind(updateQuery)
+	existsQuery := s.db.rebind("SELECT COUNT(*) FROM ingredients WHERE ingredient_id = ?")
+	stockQuery := s.db.rebind("SELECT stock FROM ingredients WHERE ingredient_id = ?")
+	insertQuery := s.db.rebind("INSERT INTO stock_movements (" + movementColumns + ") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
+	for i := range movements {
+		movement := &movements[i]
+
+		args := []interface{}{movement.Change, movement.CreatedAt, movement.IngredientID}
+		if reserve {
+			args = append(args, movement.Change)
+		}
+		result, err := tx.ExecContext(ctx, updateQuery, args...)
+		if err != nil {
+			return err
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			var exists int
+			if err := tx.QueryRowContext(ctx, existsQuery, movement.IngredientID).Scan(&exists); err != nil {
+				return err
+			}
+			if exists > 0 {
+				return store.ErrConflict
+			}
+			return store.ErrNotFound
+		}
+
+		if err := tx.QueryRowContext(ctx, stockQuery, movement.IngredientID).Scan(&movement.StockAfter); err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, insertQuery,
+			movement.ID.Hex(), movement.MovementID, movement.IngredientID, movement.Change, movement.StockAfter, movement.Reason,
+			movement.OrderID, movement.OrderItemID, movement.Note, movement.RecordedBy, movement.CreatedAt,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *IngredientStore) Movements(ctx context.Context, ingredientID string) ([]models.StockMovement, error) {
+	return s.movements(ctx, "WHERE ingredient_id = ?", ingredientID)
+}
+
+func (s *IngredientStore) MovementsByOrder(ctx context.Context, orderID string) ([]models.StockMovement, error) {
+	return s.movements(ctx, "WHERE order_id = ?", orderID)
+}
+
+func (s *IngredientStore) movements(ctx context.Context, where string, args ...interface{}) ([]models.StockMovement, error) {
+	rows, err := s.db.query(ctx, "SELECT "+movementColumns+" FROM stock_movements "+where+" ORDER BY created_at, id", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	movements := []models.StockMovement{}
+	for rows.Next() {
+		movement, err := scanMovement(rows)
+		if err != nil {
+			return nil, err
+		}
+		movements = append(movements, movement)
+	}
+	return movements, rows.Err()
+}
+
+type RecipeStore struct {
+	db *DB
+}
+
+func (s *RecipeStore) Find(ctx context.Context, foodID string) (models.Recipe, error) {
+	recipe := models.Recipe{FoodID: foodID}
+	err := s.db.queryRow(ctx, "SELECT updated_at FROM recipes WHERE food_id = ?", foodID).Scan(&recipe.UpdatedAt)
+	if err != nil {
+		return recipe, notFound(err)
+	}
+
+	rows, err := s.db.query(ctx, "SELECT ingredient_id, quantity, unit FROM recipe_items WHERE food_id = ? ORDER BY position", foodID)
+	if err != nil {
+		return recipe, err
+	}
+	defer rows.Close()
+
+	recipe.Items = []models.RecipeItem{}
+	for rows.Next() {
+		var item models.RecipeItem
+		if err := rows.Scan(&item.IngredientID, &item.Quantity, &item.Unit); err != nil {
+			return recipe, err
+		}
+		recipe.Items = append(recipe.Items, item)
+	}
+	return recipe, rows.Err()
+}
+
+func (s *RecipeStore) Put(ctx context.Context, recipe models.Recipe) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, s.db.rebind("DELETE FROM recipe_items WHERE food_id = ?"), recipe.FoodID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, s.db.rebind("DELETE FROM recipes WHERE food_id = ?"), recipe.FoodID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, s.db.rebind("INSERT INTO recipes (food_id, updated_at) VALUES (?, ?)"), recipe.FoodID, recipe.UpdatedAt); err != nil {
+		return err
+	}
+
+	itemQuery := s.db.rebind("INSERT INTO recipe_items (food_id, position, ingredient_id, quantity, unit) VALUES (?, ?, ?, ?, ?)")
+	for i, item := range recipe.Items {
+		if _, err := tx.ExecContext(ctx, itemQuery, recipe.FoodID, i, item.IngredientID, item.Quantity, item.Unit); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *RecipeStore) ListByIngredient(ctx context.Context, ingredientID string) ([]models.Recipe, error) {
+	rows, err := s.db.query(ctx, "SELECT DISTINCT food_id FROM recipe_items WHERE ingredient_id = ? ORDER BY food_id", ingredientID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var foodIDs []string
+	for rows.Next() {
+		var foodID string
+		if err := rows.Scan(&foodID); err != nil {
+			return nil, err
+		}
+		foodIDs = append(foodIDs, foodID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	recipes := []models.Recipe{}
+	for _, foodID := range foodIDs {
+		recipe, err := s.Find(ctx, foodID)
+		if err != nil {
+			return nil, err
+		}
+		recipes = append(recipes, recipe)
+	}
+	return recipes, nil
+}
@@ -0,0 +1,322 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"restaurant-management/models"
+	"restaurant-management/store"
+	"time"
+)
+
+const invoiceColumns = "id, invoice_id, order_id, payment_method, payment_status, payment_due_date, created_at, updated_at, " +
+	"currency, subtotal, tax_total, service_charge_rate, service_charge, rounding, grand_total, " +
+	"split_mode, split_part, split_parts, split_seat, amount_paid, payment_provider, provider_reference, amount_refunded, invoice_number, voucher_code, discount"
+
+type InvoiceStore struct {
+	db *DB
+}
+
+func scanInvoice(row scanner) (models.Invoice, error) {
+	var invoice models.Invoice
+	var id string
+	var currency sql.NullString
+	var subtotal, taxTotal, serviceCharge, rounding, grandTotal, amountPaid, amountRefunded, discount sql.NullInt64
+	var splitMode sql.NullString
+	var splitPart, splitParts sql.NullInt64
+	var splitSeat *int
+	var invoiceNumber sql.NullString
+	err := row.Scan(
+		&id, &invoice.InvoiceID, &invoice.OrderID, &invoice.PaymentMethod, &invoice.PaymentStatus, &invoice.PaymentDueDate, &invoice.CreatedAt, &invoice.UpdatedAt,
+		&currency, &subtotal, &taxTotal, &invoice.ServiceChargeRate, &serviceCharge, &rounding, &grandTotal,
+		&splitMode, &splitPart, &splitParts, &splitSeat, &amountPaid, &invoice.PaymentProvider, &invoice.ProviderReference, &amountRefunded, &invoiceNumber, &invoice.VoucherCode, &discount,
+	)
+	invoice.ID = objectID(id)
+	invoice.InvoiceNumber = invoiceNumber.String
+	if splitMode.Valid {
+		invoice.Split = &models.InvoiceSplit{
+			Mode:  splitMode.String,
+			Part:  int(splitPart.Int64),
+			Parts: int(splitParts.Int64),
+			Seat:  splitSeat,
+		}
+	}
+	invoice.Discount = money(discount, currency)
+	invoice.Subtotal = money(subtotal, currency)
+	invoice.TaxTotal = money(taxTotal, currency)
+	invoice.ServiceCharge = money(serviceCharge, currency)
+	invoice.Rounding = money(rounding, currency)
+	invoice.GrandTotal = money(grandTotal, currency)
+	invoice.AmountPaid = money(amountPaid, currency)
+	invoice.AmountRefunded = money(amountRefunded, currency)
+	return invoice, err
+}
+
+func (s *InvoiceStore) List(ctx context.Context) ([]models.Invoice, error) {
+	return s.list(ctx, "")
+}
+
+func (s *InvoiceStore) ListByOrder(ctx context.Context, orderID string) ([]models.Invoice, error) {
+	return s.list(ctx, "WHERE order_id = ?", orderID)
+}
+
+func (s *InvoiceStore) list(ctx context.Context, where string, args ...interface{}) ([]models.Invoice, error) {
+	rows, err := s.db.query(ctx, "SELECT "+invoiceColumns+" FROM invoices "+where+" ORDER BY id", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invoices := []models.Invoice{}
+	for rows.Next() {
+		invoice, err := scanInvoice(rows)
+		if err != nil {
+			return nil, err
+		}
+		invoices = append(invoices, invoice)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range invoices {
+		if err := s.bill(ctx, &invoices[i]); err != nil {
+			return nil, err
+		}
+	}
+	return invoices, nil
+}
+
+func (s *InvoiceStore) Find(ctx context.Context, invoiceID string) (models.Invoice, error) {
+	invoice, err := scanInvoice(s.db.queryRow(ctx, "SELECT "+invoiceColumns+" FROM invoices WHERE invoice_id = ?", invoiceID))
+	if err != nil {
+		return invoice, notFound(err)
+	}
+	return invoice, s.bill(ctx, &invoice)
+}
+
+// bill loads the priced lines and tax breakdown of invoice.
+func (s *InvoiceStore) bill(ctx context.Context, invoice *models.Invoice) error {
+	rows, err := s.db.query(ctx, `
+		SELECT order_item_id, food_id, food_name, quantity, unit_price, discount, amount, tax_category, tax_rate, tax
+		FROM invoice_lines WHERE invoice_id = ? ORDER BY position`, invoice.InvoiceID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	currency := ""
+	if invoice.GrandTotal != nil {
+		currency = invoice.GrandTotal.Currency
+	}
+
+	for rows.Next() {
+		var line models.InvoiceLine
+		var unitPrice, discount, amount, tax int64
+		err := rows.Scan(&line.OrderItemID, &line.FoodID, &line.FoodName, &line.Quantity, &unitPrice, &discount, &amount, &line.TaxCategory, &line.TaxRate, &tax)
+		if err != nil {
+			return err
+		}
+		line.UnitPrice = models.NewMoney(unitPrice, currency)
+		line.Discount = models.NewMoney(discount, currency)
+		line.Amount = models.NewMoney(amount, currency)
+		line.Tax = models.NewMoney(tax, currency)
+		invoice.Lines = append(invoice.Lines, line)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	taxRows, err := s.db.query(ctx, "SELECT category, rate, taxable, amount FROM invoice_taxes WHERE invoice_id = ? ORDER BY position", invoice.InvoiceID)
+	if err != nil {
+		return err
+	}
+	defer taxRows.Close()
+
+	for taxRows.Next() {
+		var tax models.InvoiceTax
+		var taxable, amount int64
+		if err := taxRows.Scan(&tax.Category, &tax.Rate, &taxable, &amount); err != nil {
+			return err
+		}
+		tax.Taxable = models.NewMoney(taxable, currency)
+		tax.Amount = models.NewMoney(amount, currency)
+		invoice.Taxes = append(invoice.Taxes, tax)
+	}
+	return taxRows.Err()
+}
+
+// writeBill replaces the priced lines and tax breakdown stored for invoice.
+func (s *InvoiceStore) writeBill(ctx context.Context, tx *sql.Tx, invoice models.Invoice) error {
+	for _, table := range []string{"invoice_lines", "invoice_taxes"} {
+		if _, err := tx.ExecContext(ctx, s.db.rebind("DELETE FROM "+table+" WHERE invoice_id = ?"), invoice.InvoiceID); err != nil {
+			return err
+		}
+	}
+
+	lineQuery := s.db.rebind(`INSERT INTO invoice_lines
+		(invoice_id, position, order_item_id, food_id, food_name, quantity, unit_price, discount, amount, tax_category, tax_rate, tax)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	for i, line := range invoice.Lines {
+		_, err := tx.ExecContext(ctx, lineQuery,
+			invoice.InvoiceID, i, line.OrderItemID, line.FoodID, line.FoodName, line.Quantity, line.UnitPrice.Amount, line.Discount.Amount, line.Amount.Amount, line.TaxCategory, line.TaxRate, line.Tax.Amount,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	taxQuery := s.db.rebind("INSERT INTO invoice_taxes (invoice_id, position, category, rate, taxable, amount) VALUES (?, ?, ?, ?, ?, ?)")
+	for i, tax := range invoice.Taxes {
+		if _, err := tx.ExecContext(ctx, taxQuery, invoice.InvoiceID, i, tax.Category, tax.Rate, tax.Taxable.Amount, tax.Amount.Amount); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *InvoiceStore) Insert(ctx context.Context, invoice models.Invoice, series string) error {
+	return s.InsertMany(ctx, []models.Invoice{invoice}, series)
+}
+
+func (s *InvoiceStore) InsertMany(ctx context.Context, invoices []models.Invoice, series string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Touching the order row locks it on PostgreSQL, so concurrent attempts
+	// to bill the same order queue here and only the first finds it unbilled.
+	orderID := invoices[0].OrderID
+	if _, err := tx.ExecContext(ctx, s.db.rebind("UPDATE orders SET order_id = order_id WHERE order_id = ?"), orderID); err != nil {
+		return err
+	}
+
+	var existing int
+	err = tx.QueryRowContext(ctx,
+		s.db.rebind("SELECT COUNT(*) FROM invoices WHERE order_id = ? AND COALESCE(payment_status, '') <> ?"),
+		orderID, models.PaymentVoid,
+	).Scan(&existing)
+	if err != nil {
+		return err
+	}
+	if existing > 0 {
+		return store.ErrConflict
+	}
+
+	query := s.db.rebind("INSERT INTO invoices (" + invoiceColumns + ") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
+	for i := range invoices {
+		number, err := s.nextNumber(ctx, tx, series)
+		if err != nil {
+			return err
+		}
+		invoices[i].InvoiceNumber = models.FormatInvoiceNumber(series, number)
+
+		invoice := invoices[i]
+		mode, part, parts, seat := splitValues(invoice.Split)
+		_, err = tx.ExecContext(ctx, query,
+			invoice.ID.Hex(), invoice.InvoiceID, invoice.OrderID, invoice.PaymentMethod, invoice.PaymentStatus, invoice.PaymentDueDate, invoice.CreatedAt, invoice.UpdatedAt,
+			currencyCode(invoice.GrandTotal), minorAmount(invoice.Subtotal), minorAmount(invoice.TaxTotal), invoice.ServiceChargeRate, minorAmount(invoice.ServiceCharge), minorAmount(invoice.Rounding), minorAmount(invoice.GrandTotal),
+			mode, part, parts, seat, minorAmount(invoice.AmountPaid), invoice.PaymentProvider, invoice.ProviderReference, minorAmount(invoice.AmountRefunded), invoice.InvoiceNumber,
+			invoice.VoucherCode, minorAmount(invoice.Discount),
+		)
+		if err != nil {
+			return err
+		}
+		if err := s.writeBill(ctx, tx, invoice); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// nextNumber takes the next invoice number in series within tx. Updating the
+// counter row locks it until tx ends, so concurrent invoices are numbered one
+// after another and a number is only used up if tx commits.
+func (s *InvoiceStore) nextNumber(ctx context.Context, tx *sql.Tx, series string) (int64, error) {
+	_, err := tx.ExecContext(ctx, s.db.rebind("INSERT INTO invoice_counters (series, last_number) VALUES (?, 0) ON CONFLICT (series) DO NOTHING"), series)
+	if err != nil {
+		return 0, err
+	}
+	_, err = tx.ExecContext(ctx, s.db.rebind("UPDATE invoice_counters SET last_number = last_number + 1 WHERE series = ?"), series)
+	if err != nil {
+		return 0, err
+	}
+	var number int64
+	err = tx.QueryRowContext(ctx, s.db.rebind("SELECT last_number FROM invoice_counters WHERE series = ?"), series).Scan(&number)
+	return number, err
+}
+
+func splitValues(split *models.InvoiceSplit) (mode, part, parts, seat interface{}) {
+	if split == nil {
+		return nil, nil, nil, nil
+	}
+	return split.Mode, split.Part, split.Parts, split.Seat
+}
+
+func (s *InvoiceStore) Update(ctx context.Context, invoiceID string, invoice models.Invoice) (store.UpdateResult, error) {
+	var set updateSet
+	set.set("updated_at", time.Now().UTC())
+
+	if invoice.OrderID != "" {
+		set.set("order_id", invoice.OrderID)
+	}
+	if invoice.PaymentMethod != nil {
+		set.set("payment_method", *invoice.PaymentMethod)
+	}
+	if invoice.PaymentStatus != nil {
+		set.set("payment_status", *invoice.PaymentStatus)
+	}
+	if !invoice.PaymentDueDate.IsZero() {
+		set.set("payment_due_date", invoice.PaymentDueDate)
+	}
+
+	if invoice.GrandTotal == nil {
+		return s.db.update(ctx, "invoices", "invoice_id", invoiceID, set)
+	}
+
+	set.set("currency", invoice.GrandTotal.Currency)
+	set.set("voucher_code", invoice.VoucherCode)
+	set.set("discount", minorAmount(invoice.Discount))
+	set.set("subtotal", minorAmount(invoice.Subtotal))
+	set.set("tax_total", minorAmount(invoice.TaxTotal))
+	set.set("service_charge_rate", invoice.ServiceChargeRate)
+	set.set("service_charge", minorAmount(invoice.ServiceCharge))
+	set.set("rounding", minorAmount(invoice.Rounding))
+	set.set("grand_total", minorAmount(invoice.GrandTotal))
+	mode, part, parts, seat := splitValues(invoice.Split)
+	set.set("split_mode", mode)
+	set.set("split_part", part)
+	set.set("split_parts", parts)
+	set.set("split_seat", seat)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return store.UpdateResult{}, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx,
+		s.db.rebind("UPDATE invoices SET "+set.String()+" WHERE invoice_id = ?"),
+		append(set.args, invoiceID)...,
+	)
+	if err != nil {
+		return store.UpdateResult{}, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return store.UpdateResult{}, err
+	}
+	if n == 0 {
+		return store.UpdateResult{}, store.ErrNotFound
+	}
+
+	invoice.InvoiceID = invoiceID
+	if err := s.writeBill(ctx, tx, invoice); err != nil {
+		return store.UpdateResult{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return store.UpdateResult{}, err
+	}
+	return store.UpdateResult{MatchedCount: n, ModifiedCount: n}, nil
+}
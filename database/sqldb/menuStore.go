@@ -0,0 +1,160 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"restaurant-management/models"
+	"restaurant-management/store"
+	"strings"
+	"time"
+)
+
+const menuColumns = "id, menu_id, name, category, start_date, end_date, created_at, updated_at"
+
+type MenuStore struct {
+	db *DB
+}
+
+func scanMenu(row scanner) (models.Menu, error) {
+	var menu models.Menu
+	var id string
+	err := row.Scan(&id, &menu.MenuID, &menu.Name, &menu.Category, &menu.StartDate, &menu.EndDate, &menu.CreatedAt, &menu.UpdatedAt)
+	menu.ID = objectID(id)
+	return menu, err
+}
+
+func (s *MenuStore) List(ctx context.Context) ([]models.Menu, error) {
+	rows, err := s.db.query(ctx, "SELECT "+menuColumns+" FROM menus ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	menus := []models.Menu{}
+	for rows.Next() {
+		menu, err := scanMenu(rows)
+		if err != nil {
+			return nil, err
+		}
+		menus = append(menus, menu)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range menus {
+		if err := s.schedule(ctx, &menus[i]); err != nil {
+			return nil, err
+		}
+	}
+	return menus, nil
+}
+
+func (s *MenuStore) Find(ctx context.Context, menuID string) (models.Menu, error) {
+	menu, err := scanMenu(s.db.queryRow(ctx, "SELECT "+menuColumns+" FROM menus WHERE menu_id = ?", menuID))
+	if err != nil {
+		return menu, notFound(err)
+	}
+	return menu, s.schedule(ctx, &menu)
+}
+
+// schedule loads the slots of menu. Days are stored comma-separated.
+func (s *MenuStore) schedule(ctx context.Context, menu *models.Menu) error {
+	rows, err := s.db.query(ctx, "SELECT days, start_time, end_time FROM menu_slots WHERE menu_id = ? ORDER BY position", menu.MenuID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var slot models.MenuSlot
+		var days string
+		if err := rows.Scan(&days, &slot.Start, &slot.End); err != nil {
+			return err
+		}
+		slot.Days = strings.Split(days, ",")
+		menu.Schedule = append(menu.Schedule, slot)
+	}
+	return rows.Err()
+}
+
+func (s *MenuStore) Insert(ctx context.Context, menu models.Menu) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx,
+		s.db.rebind("INSERT INTO menus ("+menuColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?)"),
+		menu.ID.Hex(), menu.MenuID, menu.Name, menu.Category, menu.StartDate, menu.EndDate, menu.CreatedAt, menu.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	if err := s.putSchedule(ctx, tx, menu.MenuID, menu.Schedule); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *MenuStore) Update(ctx context.Context, menuID string, menu models.Menu) (store.UpdateResult, error) {
+	var set updateSet
+	set.set("updated_at", time.Now().UTC())
+
+	if menu.StartDate != nil {
+		set.set("start_date", *menu.StartDate)
+	}
+	if menu.EndDate != nil {
+		set.set("end_date", *menu.EndDate)
+	}
+	if menu.Name != "" {
+		set.set("name", menu.Name)
+	}
+	if menu.Category != "" {
+		set.set("category", menu.Category)
+	}
+
+	if menu.Schedule == nil {
+		return s.db.update(ctx, "menus", "menu_id", menuID, set)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return store.UpdateResult{}, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, s.db.rebind("UPDATE menus SET "+set.String()+" WHERE menu_id = ?"), append(set.args, menuID)...)
+	if err != nil {
+		return store.UpdateResult{}, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return store.UpdateResult{}, err
+	}
+	if n == 0 {
+		return store.UpdateResult{}, store.ErrNotFound
+	}
+
+	if _, err := tx.ExecContext(ctx, s.db.rebind("DELETE FROM menu_slots WHERE menu_id = ?"), menuID); err != nil {
+		return store.UpdateResult{}, err
+	}
+	if err := s.putSchedule(ctx, tx, menuID, menu.Schedule); err != nil {
+		return store.UpdateResult{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return store.UpdateResult{}, err
+	}
+	return store.UpdateResult{MatchedCount: n, ModifiedCount: n}, nil
+}
+
+func (s *MenuStore) putSchedule(ctx context.Context, tx *sql.Tx, menuID string, schedule []models.MenuSlot) error {
+	query := s.db.rebind("INSERT INTO menu_slots (menu_id, position, days, start_time, end_time) VALUES (?, ?, ?, ?, ?)")
+	for i, slot := range schedule {
+		if _, err := tx.ExecContext(ctx, query, menuID, i, strings.Join(slot.Days, ","), slot.Start, slot.End); err != nil {
+			return err
+		}
+	}
+	return nil
+}
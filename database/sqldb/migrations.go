@@ -0,0 +1,609 @@
+package sqldb
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// migration is one versioned step of the schema. Statements are written in
+// the subset of SQL that PostgreSQL and SQLite share.
+type migration struct {
+	version    int
+	name       string
+	statements []string
+}
+
+// migrations must only ever be appended to; an applied version is never
+// re-run, so editing one in place will not reach existing databases.
+var migrations = []migration{
+	{
+		version: 1,
+		name:    "create entity tables",
+		statements: []string{
+			`CREATE TABLE menus (
+				menu_id TEXT PRIMARY KEY,
+				id TEXT NOT NULL,
+				name TEXT NOT NULL,
+				category TEXT NOT NULL,
+				start_date TIMESTAMP NULL,
+				end_date TIMESTAMP NULL,
+				created_at TIMESTAMP NOT NULL,
+				updated_at TIMESTAMP NOT NULL
+			)`,
+			`CREATE TABLE foods (
+				food_id TEXT PRIMARY KEY,
+				id TEXT NOT NULL,
+				name TEXT NULL,
+				price DOUBLE PRECISION NULL,
+				food_image TEXT NULL,
+				menu_id TEXT NULL REFERENCES menus (menu_id),
+				created_at TIMESTAMP NOT NULL,
+				updated_at TIMESTAMP NOT NULL
+			)`,
+			`CREATE TABLE tables (
+				table_id TEXT PRIMARY KEY,
+				id TEXT NOT NULL,
+				number_of_guests INTEGER NULL,
+				table_number INTEGER NULL,
+				created_at TIMESTAMP NOT NULL,
+				updated_at TIMESTAMP NOT NULL
+			)`,
+			`CREATE TABLE orders (
+				order_id TEXT PRIMARY KEY,
+				id TEXT NOT NULL,
+				order_date TIMESTAMP NOT NULL,
+				table_id TEXT NULL REFERENCES tables (table_id),
+				created_at TIMESTAMP NOT NULL,
+				updated_at TIMESTAMP NOT NULL
+			)`,
+			`CREATE TABLE order_items (
+				order_item_id TEXT PRIMARY KEY,
+				id TEXT NOT NULL,
+				quantity TEXT NULL,
+				unit_price DOUBLE PRECISION NULL,
+				food_id TEXT NULL REFERENCES foods (food_id),
+				order_id TEXT NOT NULL REFERENCES orders (order_id),
+				created_at TIMESTAMP NOT NULL,
+				updated_at TIMESTAMP NOT NULL
+			)`,
+			`CREATE INDEX order_items_order_id ON order_items (order_id)`,
+			`CREATE TABLE invoices (
+				invoice_id TEXT PRIMARY KEY,
+				id TEXT NOT NULL,
+				order_id TEXT NOT NULL REFERENCES orders (order_id),
+				payment_method TEXT NULL,
+				payment_status TEXT NULL,
+				payment_due_date TIMESTAMP NOT NULL,
+				created_at TIMESTAMP NOT NULL,
+				updated_at TIMESTAMP NOT NULL
+			)`,
+			`CREATE TABLE users (
+				user_id TEXT PRIMARY KEY,
+				id TEXT NOT NULL,
+				first_name TEXT NULL,
+				last_name TEXT NULL,
+				password TEXT NULL,
+				email TEXT NULL,
+				avatar TEXT NULL,
+				phone TEXT NULL,
+				token TEXT NULL,
+				refresh_token TEXT NULL,
+				created_at TIMESTAMP NOT NULL,
+				updated_at TIMESTAMP NOT NULL
+			)`,
+			`CREATE INDEX users_email ON users (email)`,
+			`CREATE INDEX users_phone ON users (phone)`,
+			`CREATE TABLE notes (
+				note_id TEXT PRIMARY KEY,
+				id TEXT NOT NULL,
+				text TEXT NOT NULL,
+				title TEXT NOT NULL,
+				created_at TIMESTAMP NOT NULL,
+				updated_at TIMESTAMP NOT NULL
+			)`,
+		},
+	},
+	{
+		version: 2,
+		name:    "add order status and transitions",
+		statements: []string{
+			`ALTER TABLE orders ADD COLUMN status TEXT NULL`,
+			`CREATE TABLE order_transitions (
+				order_id TEXT NOT NULL REFERENCES orders (order_id),
+				position INTEGER NOT NULL,
+				from_status TEXT NOT NULL,
+				to_status TEXT NOT NULL,
+				changed_by TEXT NOT NULL,
+				changed_at TIMESTAMP NOT NULL,
+				PRIMARY KEY (order_id, position)
+			)`,
+		},
+	},
+	{
+		version: 3,
+		name:    "add food station",
+		statements: []string{
+			`ALTER TABLE foods ADD COLUMN station TEXT NULL`,
+		},
+	},
+	{
+		version: 4,
+		name:    "add user role",
+		statements: []string{
+			`ALTER TABLE users ADD COLUMN role TEXT NULL`,
+		},
+	},
+	{
+		version: 5,
+		name:    "create reservations",
+		statements: []string{
+			`CREATE TABLE reservations (
+				reservation_id TEXT PRIMARY KEY,
+				id TEXT NOT NULL,
+				table_id TEXT NOT NULL REFERENCES tables (table_id),
+				guest_name TEXT NULL,
+				phone TEXT NULL,
+				number_of_guests INTEGER NULL,
+				start_time TIMESTAMP NOT NULL,
+				end_time TIMESTAMP NOT NULL,
+				status TEXT NOT NULL,
+				created_at TIMESTAMP NOT NULL,
+				updated_at TIMESTAMP NOT NULL
+			)`,
+			`CREATE INDEX reservations_table_id_start_time ON reservations (table_id, start_time)`,
+		},
+	},
+	{
+		version: 6,
+		name:    "add invoice pricing",
+		statements: []string{
+			`ALTER TABLE foods ADD COLUMN tax_category TEXT NULL`,
+			`ALTER TABLE invoices ADD COLUMN subtotal DOUBLE PRECISION NULL`,
+			`ALTER TABLE invoices ADD COLUMN tax_total DOUBLE PRECISION NULL`,
+			`ALTER TABLE invoices ADD COLUMN service_charge_rate DOUBLE PRECISION NULL`,
+			`ALTER TABLE invoices ADD COLUMN service_charge DOUBLE PRECISION NULL`,
+			`ALTER TABLE invoices ADD COLUMN rounding DOUBLE PRECISION NULL`,
+			`ALTER TABLE invoices ADD COLUMN grand_total DOUBLE PRECISION NULL`,
+			`CREATE TABLE invoice_lines (
+				invoice_id TEXT NOT NULL REFERENCES invoices (invoice_id),
+				position INTEGER NOT NULL,
+				order_item_id TEXT NOT NULL,
+				food_id TEXT NOT NULL,
+				food_name TEXT NOT NULL,
+				quantity INTEGER NOT NULL,
+				unit_price DOUBLE PRECISION NOT NULL,
+				amount DOUBLE PRECISION NOT NULL,
+				tax_category TEXT NOT NULL,
+				tax_rate DOUBLE PRECISION NOT NULL,
+				tax DOUBLE PRECISION NOT NULL,
+				PRIMARY KEY (invoice_id, position)
+			)`,
+			`CREATE TABLE invoice_taxes (
+				invoice_id TEXT NOT NULL REFERENCES invoices (invoice_id),
+				position INTEGER NOT NULL,
+				category TEXT NOT NULL,
+				rate DOUBLE PRECISION NOT NULL,
+				taxable DOUBLE PRECISION NOT NULL,
+				amount DOUBLE PRECISION NOT NULL,
+				PRIMARY KEY (invoice_id, position)
+			)`,
+		},
+	},
+	{
+		// Amounts move from floating point major units to integer minor
+		// units. Existing rows are converted assuming two decimal places and
+		// keep a NULL currency, which reads as the default currency.
+		version: 7,
+		name:    "store money in minor units",
+		statements: concat(
+			[]string{
+				`ALTER TABLE foods ADD COLUMN currency TEXT NULL`,
+				`ALTER TABLE order_items ADD COLUMN currency TEXT NULL`,
+				`ALTER TABLE invoices ADD COLUMN currency TEXT NULL`,
+			},
+			minorUnits("foods", "price"),
+			minorUnits("order_items", "unit_price"),
+			minorUnits("invoices", "subtotal"),
+			minorUnits("invoices", "tax_total"),
+			minorUnits("invoices", "service_charge"),
+			minorUnits("invoices", "rounding"),
+			minorUnits("invoices", "grand_total"),
+			minorUnits("invoice_lines", "unit_price"),
+			minorUnits("invoice_lines", "amount"),
+			minorUnits("invoice_lines", "tax"),
+			minorUnits("invoice_taxes", "taxable"),
+			minorUnits("invoice_taxes", "amount"),
+		),
+	},
+	{
+		version: 8,
+		name:    "add seats and split invoices",
+		statements: []string{
+			`ALTER TABLE order_items ADD COLUMN seat INTEGER NULL`,
+			`ALTER TABLE invoices ADD COLUMN split_mode TEXT NULL`,
+			`ALTER TABLE invoices ADD COLUMN split_part INTEGER NULL`,
+			`ALTER TABLE invoices ADD COLUMN split_parts INTEGER NULL`,
+			`ALTER TABLE invoices ADD COLUMN split_seat INTEGER NULL`,
+			`CREATE INDEX invoices_order_id ON invoices (order_id)`,
+		},
+	},
+	{
+		version: 9,
+		name:    "add payments",
+		statements: []string{
+			`ALTER TABLE invoices ADD COLUMN amount_paid BIGINT NULL`,
+			`CREATE TABLE payments (
+				payment_id TEXT PRIMARY KEY,
+				id TEXT NOT NULL,
+				invoice_id TEXT NOT NULL REFERENCES invoices (invoice_id),
+				currency TEXT NOT NULL,
+				tendered BIGINT NOT NULL,
+				amount BIGINT NOT NULL,
+				change_due BIGINT NOT NULL,
+				balance BIGINT NOT NULL,
+				taken_by TEXT NOT NULL,
+				created_at TIMESTAMP NOT NULL
+			)`,
+			`CREATE INDEX payments_invoice_id ON payments (invoice_id)`,
+			`CREATE TABLE payment_tenders (
+				payment_id TEXT NOT NULL REFERENCES payments (payment_id),
+				position INTEGER NOT NULL,
+				method TEXT NOT NULL,
+				amount BIGINT NOT NULL,
+				reference TEXT NULL,
+				PRIMARY KEY (payment_id, position)
+			)`,
+		},
+	},
+	{
+		version: 10,
+		name:    "add payment provider references",
+		statements: []string{
+			`ALTER TABLE invoices ADD COLUMN payment_provider TEXT NULL`,
+			`ALTER TABLE invoices ADD COLUMN provider_reference TEXT NULL`,
+			`ALTER TABLE payment_tenders ADD COLUMN provider TEXT NULL`,
+		},
+	},
+	{
+		version: 11,
+		name:    "add credit notes",
+		statements: []string{
+			`ALTER TABLE invoices ADD COLUMN amount_refunded BIGINT NULL`,
+			`CREATE INDEX payments_created_at ON payments (created_at)`,
+			`CREATE TABLE credit_notes (
+				credit_note_id TEXT PRIMARY KEY,
+				id TEXT NOT NULL,
+				invoice_id TEXT NOT NULL REFERENCES invoices (invoice_id),
+				kind TEXT NOT NULL,
+				reason TEXT NOT NULL,
+				currency TEXT NOT NULL,
+				amount BIGINT NOT NULL,
+				issued_by TEXT NOT NULL,
+				created_at TIMESTAMP NOT NULL
+			)`,
+			`CREATE INDEX credit_notes_invoice_id ON credit_notes (invoice_id)`,
+			`CREATE INDEX credit_notes_created_at ON credit_notes (created_at)`,
+			`CREATE TABLE credit_note_lines (
+				credit_note_id TEXT NOT NULL REFERENCES credit_notes (credit_note_id),
+				position INTEGER NOT NULL,
+				order_item_id TEXT NOT NULL,
+				food_id TEXT NOT NULL,
+				food_name TEXT NOT NULL,
+				quantity INTEGER NOT NULL,
+				unit_price BIGINT NOT NULL,
+				amount BIGINT NOT NULL,
+				tax_category TEXT NOT NULL,
+				tax_rate DOUBLE PRECISION NOT NULL,
+				tax BIGINT NOT NULL,
+				PRIMARY KEY (credit_note_id, position)
+			)`,
+			`CREATE TABLE credit_note_refunds (
+				credit_note_id TEXT NOT NULL REFERENCES credit_notes (credit_note_id),
+				position INTEGER NOT NULL,
+				method TEXT NOT NULL,
+				amount BIGINT NOT NULL,
+				provider TEXT NULL,
+				capture TEXT NULL,
+				reference TEXT NULL,
+				PRIMARY KEY (credit_note_id, position)
+			)`,
+		},
+	},
+	{
+		version: 12,
+		name:    "add invoice numbers",
+		statements: []string{
+			`ALTER TABLE invoices ADD COLUMN invoice_number TEXT NULL`,
+			`CREATE UNIQUE INDEX invoices_invoice_number ON invoices (invoice_number)`,
+			`CREATE TABLE invoice_counters (
+				series TEXT PRIMARY KEY,
+				last_number BIGINT NOT NULL
+			)`,
+		},
+	},
+	{
+		version: 13,
+		name:    "add inventory",
+		statements: []string{
+			`CREATE TABLE ingredients (
+				ingredient_id TEXT PRIMARY KEY,
+				id TEXT NOT NULL,
+				name TEXT NOT NULL,
+				unit TEXT NOT NULL,
+				stock BIGINT NOT NULL,
+				reorder_level BIGINT NULL,
+				created_at TIMESTAMP NOT NULL,
+				updated_at TIMESTAMP NOT NULL
+			)`,
+			`CREATE TABLE recipes (
+				food_id TEXT PRIMARY KEY REFERENCES foods (food_id),
+				updated_at TIMESTAMP NOT NULL
+			)`,
+			`CREATE TABLE recipe_items (
+				food_id TEXT NOT NULL REFERENCES recipes (food_id),
+				position INTEGER NOT NULL,
+				ingredient_id TEXT NOT NULL REFERENCES ingredients (ingredient_id),
+				quantity BIGINT NOT NULL,
+				unit TEXT NOT NULL,
+				PRIMARY KEY (food_id, position)
+			)`,
+			`CREATE TABLE stock_movements (
+				movement_id TEXT PRIMARY KEY,
+				id TEXT NOT NULL,
+				ingredient_id TEXT NOT NULL REFERENCES ingredients (ingredient_id),
+				change BIGINT NOT NULL,
+				stock_after BIGINT NOT NULL,
+				reason TEXT NOT NULL,
+				order_id TEXT NULL,
+				order_item_id TEXT NULL,
+				note TEXT NULL,
+				recorded_by TEXT NOT NULL,
+				created_at TIMESTAMP NOT NULL
+			)`,
+			`CREATE INDEX stock_movements_ingredient_id ON stock_movements (ingredient_id, created_at)`,
+			`CREATE INDEX stock_movements_order_id ON stock_movements (order_id)`,
+		},
+	},
+	{
+		version: 14,
+		name:    "add food availability",
+		statements: []string{
+			`ALTER TABLE foods ADD COLUMN available BOOLEAN NULL`,
+			`ALTER TABLE foods ADD COLUMN unavailable_reason TEXT NULL`,
+			`CREATE INDEX recipe_items_ingredient_id ON recipe_items (ingredient_id)`,
+		},
+	},
+	{
+		version: 15,
+		name:    "add menu schedules",
+		statements: []string{
+			`CREATE TABLE menu_slots (
+				menu_id TEXT NOT NULL REFERENCES menus (menu_id),
+				position INTEGER NOT NULL,
+				days TEXT NOT NULL,
+				start_time TEXT NOT NULL,
+				end_time TEXT NOT NULL,
+				PRIMARY KEY (menu_id, position)
+			)`,
+		},
+	},
+	{
+		version: 16,
+		name:    "add price rules",
+		statements: []string{
+			`CREATE TABLE price_rules (
+				price_rule_id TEXT PRIMARY KEY,
+				id TEXT NOT NULL,
+				name TEXT NOT NULL,
+				kind TEXT NOT NULL,
+				food_ids TEXT NOT NULL,
+				menu_ids TEXT NOT NULL,
+				discount_rate DOUBLE PRECISION NULL,
+				amount_off BIGINT NULL,
+				amount_off_currency TEXT NULL,
+				price BIGINT NULL,
+				price_currency TEXT NULL,
+				buy_quantity INTEGER NOT NULL,
+				free_quantity INTEGER NOT NULL,
+				start_date TIMESTAMP NULL,
+				end_date TIMESTAMP NULL,
+				created_at TIMESTAMP NOT NULL,
+				updated_at TIMESTAMP NOT NULL
+			)`,
+			`CREATE TABLE price_rule_slots (
+				price_rule_id TEXT NOT NULL REFERENCES price_rules (price_rule_id),
+				position INTEGER NOT NULL,
+				days TEXT NOT NULL,
+				start_time TEXT NOT NULL,
+				end_time TEXT NOT NULL,
+				PRIMARY KEY (price_rule_id, position)
+			)`,
+			`ALTER TABLE order_items ADD COLUMN list_price BIGINT NULL`,
+			`ALTER TABLE order_items ADD COLUMN price_rule_id TEXT NULL`,
+		},
+	},
+	{
+		version: 17,
+		name:    "add vouchers",
+		statements: []string{
+			`CREATE TABLE vouchers (
+				voucher_id TEXT PRIMARY KEY,
+				id TEXT NOT NULL,
+				code TEXT NOT NULL UNIQUE,
+				kind TEXT NOT NULL,
+				rate DOUBLE PRECISION NULL,
+				amount BIGINT NULL,
+				amount_currency TEXT NULL,
+				food_ids TEXT NOT NULL,
+				minimum_spend BIGINT NULL,
+				minimum_spend_currency TEXT NULL,
+				expires_at TIMESTAMP NULL,
+				max_redemptions INTEGER NULL,
+				redemptions INTEGER NOT NULL,
+				created_at TIMESTAMP NOT NULL,
+				updated_at TIMESTAMP NOT NULL
+			)`,
+			`CREATE TABLE voucher_redemptions (
+				redemption_id TEXT PRIMARY KEY,
+				id TEXT NOT NULL,
+				voucher_id TEXT NOT NULL REFERENCES vouchers (voucher_id),
+				code TEXT NOT NULL,
+				order_id TEXT NOT NULL REFERENCES orders (order_id),
+				redeemed_by TEXT NOT NULL,
+				redeemed_at TIMESTAMP NOT NULL,
+				released_at TIMESTAMP NULL
+			)`,
+			`CREATE UNIQUE INDEX voucher_redemptions_order_id ON voucher_redemptions (order_id) WHERE released_at IS NULL`,
+			`ALTER TABLE invoices ADD COLUMN voucher_code TEXT NULL`,
+			`ALTER TABLE invoices ADD COLUMN discount BIGINT NULL`,
+			`ALTER TABLE invoice_lines ADD COLUMN discount BIGINT NOT NULL DEFAULT 0`,
+			`ALTER TABLE credit_note_lines ADD COLUMN discount BIGINT NOT NULL DEFAULT 0`,
+		},
+	},
+	{
+		version: 18,
+		name:    "add modifiers",
+		statements: []string{
+			`CREATE TABLE food_modifier_groups (
+				food_id TEXT NOT NULL REFERENCES foods (food_id),
+				position INTEGER NOT NULL,
+				name TEXT NOT NULL,
+				min_selections INTEGER NOT NULL,
+				max_selections INTEGER NOT NULL,
+				PRIMARY KEY (food_id, position)
+			)`,
+			`CREATE TABLE food_modifier_options (
+				food_id TEXT NOT NULL,
+				group_position INTEGER NOT NULL,
+				position INTEGER NOT NULL,
+				name TEXT NOT NULL,
+				price_adjustment BIGINT NULL,
+				currency TEXT NULL,
+				PRIMARY KEY (food_id, group_position, position),
+				FOREIGN KEY (food_id, group_position) REFERENCES food_modifier_groups (food_id, position)
+			)`,
+			`CREATE TABLE order_item_modifiers (
+				order_item_id TEXT NOT NULL REFERENCES order_items (order_item_id),
+				position INTEGER NOT NULL,
+				group_name TEXT NOT NULL,
+				option_name TEXT NOT NULL,
+				price_adjustment BIGINT NULL,
+				currency TEXT NULL,
+				PRIMARY KEY (order_item_id, position)
+			)`,
+			`ALTER TABLE order_items ADD COLUMN notes TEXT NULL`,
+		},
+	},
+	{
+		version: 19,
+		name:    "split order item quantity from size",
+		statements: []string{
+			`ALTER TABLE order_items ADD COLUMN size TEXT NULL`,
+			`UPDATE order_items SET size = quantity`,
+			`ALTER TABLE order_items DROP COLUMN quantity`,
+			`ALTER TABLE order_items ADD COLUMN quantity INTEGER NOT NULL DEFAULT 1`,
+			`CREATE TABLE food_size_prices (
+				food_id TEXT NOT NULL REFERENCES foods (food_id),
+				position INTEGER NOT NULL,
+				size TEXT NOT NULL,
+				price BIGINT NOT NULL,
+				currency TEXT NOT NULL,
+				PRIMARY KEY (food_id, position)
+			)`,
+		},
+	},
+	{
+		version: 20,
+		name:    "add order item voids",
+		statements: []string{
+			`ALTER TABLE order_items ADD COLUMN void_reason TEXT NULL`,
+			`ALTER TABLE order_items ADD COLUMN void_note TEXT NULL`,
+			`ALTER TABLE order_items ADD COLUMN void_requested_by TEXT NULL`,
+			`ALTER TABLE order_items ADD COLUMN void_requested_at TIMESTAMP NULL`,
+			`ALTER TABLE order_items ADD COLUMN void_approved_by TEXT NULL`,
+			`ALTER TABLE order_items ADD COLUMN voided_at TIMESTAMP NULL`,
+		},
+	},
+	{
+		version: 21,
+		name:    "add bootstrap markers",
+		statements: []string{
+			`CREATE TABLE bootstrap (
+				name TEXT PRIMARY KEY,
+				user_id TEXT NOT NULL
+			)`,
+		},
+	},
+}
+
+// minorUnits replaces a DOUBLE PRECISION amount column with a BIGINT one of
+// the same name holding hundredths. The value goes through NUMERIC so that
+// halves round away from zero on PostgreSQL as they do on SQLite.
+func minorUnits(table, column string) []string {
+	return []string{
+		"ALTER TABLE " + table + " ADD COLUMN " + column + "_minor BIGINT NULL",
+		"UPDATE " + table + " SET " + column + "_minor = CAST(ROUND(CAST(" + column + " AS NUMERIC) * 100) AS BIGINT)",
+		"ALTER TABLE " + table + " DROP COLUMN " + column,
+		"ALTER TABLE " + table + " RENAME COLUMN " + column + "_minor TO " + column,
+	}
+}
+
+func concat(groups ...[]string) []string {
+	var all []string
+	for _, group := range groups {
+		all = append(all, group...)
+	}
+	return all
+}
+
+// Migrate applies every migration newer than the version recorded in
+// schema_migrations, each in its own transaction.
+func (db *DB) Migrate(ctx context.Context) error {
+	_, err := db.exec(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TIMESTAMP NOT NULL
+	)`)
+	if err != nil {
+		return err
+	}
+
+	var current int
+	if err := db.queryRow(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&current); err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		if err := db.apply(ctx, m); err != nil {
+			return fmt.Errorf("migration %d (%s): %w", m.version, m.name, err)
+		}
+	}
+	return nil
+}
+
+func (db *DB) apply(ctx context.Context, m migration) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, statement := range m.statements {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx,
+		db.rebind("INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)"),
+		m.version, m.name, time.Now().UTC(),
+	)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
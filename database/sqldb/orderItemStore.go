@@ -0,0 +1,306 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"restaurant-management/models"
+	"restaurant-management/store"
+	"time"
+)
+
+const orderItemColumns = "id, order_item_id, quantity, unit_price, currency, food_id, order_id, seat, created_at, updated_at, list_price, price_rule_id, notes, size, " +
+	"void_reason, void_note, void_requested_by, void_requested_at, void_approved_by, voided_at"
+
+type OrderItemStore struct {
+	db *DB
+}
+
+func scanOrderItem(row scanner) (models.OrderItem, error) {
+	var orderItem models.OrderItem
+	var id string
+	var unitPrice sql.NullInt64
+	var unitPriceCurrency sql.NullString
+	var listPrice sql.NullInt64
+	var voidReason, voidRequestedBy sql.NullString
+	var voidRequestedAt sql.NullTime
+	var void models.OrderItemVoid
+	err := row.Scan(&id, &orderItem.OrderItemID, &orderItem.Quantity, &unitPrice, &unitPriceCurrency, &orderItem.FoodID, &orderItem.OrderID, &orderItem.Seat, &orderItem.CreatedAt, &orderItem.UpdatedAt,
+		&listPrice, &orderItem.PriceRuleID, &orderItem.Notes, &orderItem.Size,
+		&voidReason, &void.Note, &voidRequestedBy, &voidRequestedAt, &void.ApprovedBy, &void.VoidedAt)
+	orderItem.ID = objectID(id)
+	orderItem.UnitPrice = money(unitPrice, unitPriceCurrency)
+	// The list price is in the same currency as the unit price.
+	orderItem.ListPrice = money(listPrice, unitPriceCurrency)
+	if voidReason.Valid {
+		void.Reason = voidReason.String
+		void.RequestedBy = voidRequestedBy.String
+		void.RequestedAt = voidRequestedAt.Time
+		orderItem.Void = &void
+	}
+	return orderItem, err
+}
+
+func (s *OrderItemStore) List(ctx context.Context) ([]models.OrderItem, error) {
+	return s.list(ctx, "")
+}
+
+func (s *OrderItemStore) ListByOrder(ctx context.Context, orderID string) ([]models.OrderItem, error) {
+	return s.list(ctx, "WHERE order_id = ?", orderID)
+}
+
+func (s *OrderItemStore) list(ctx context.Context, where string, args ...interface{}) ([]models.OrderItem, error) {
+	rows, err := s.db.query(ctx, "SELECT "+orderItemColumns+" FROM order_items "+where+" ORDER BY id", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orderItems := []models.OrderItem{}
+	index := map[string]int{}
+	for rows.Next() {
+		orderItem, err := scanOrderItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		orderItem.Modifiers = []models.OrderItemModifier{}
+		index[orderItem.OrderItemID] = len(orderItems)
+		orderItems = append(orderItems, orderItem)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	err = s.modifiers(ctx, "WHERE order_item_id IN (SELECT order_item_id FROM order_items "+where+")", args, func(orderItemID string, modifier models.OrderItemModifier) {
+		if i, ok := index[orderItemID]; ok {
+			orderItems[i].Modifiers = append(orderItems[i].Modifiers, modifier)
+		}
+	})
+	return orderItems, err
+}
+
+func (s *OrderItemStore) Find(ctx context.Context, orderItemID string) (models.OrderItem, error) {
+	orderItem, err := scanOrderItem(s.db.queryRow(ctx, "SELECT "+orderItemColumns+" FROM order_items WHERE order_item_id = ?", orderItemID))
+	if err != nil {
+		return orderItem, notFound(err)
+	}
+
+	orderItem.Modifiers = []models.OrderItemModifier{}
+	err = s.modifiers(ctx, "WHERE order_item_id = ?", []interface{}{orderItemID}, func(_ string, modifier models.OrderItemModifier) {
+		orderItem.Modifiers = append(orderItem.Modifiers, modifier)
+	})
+	return orderItem, err
+}
+
+// modifiers passes each row of order_item_modifiers matching where to add,
+// in order, with the item it belongs to.
+func (s *OrderItemStore) modifiers(ctx context.Context, where string, args []interface{}, add func(orderItemID string, modifier models.OrderItemModifier)) error {
+	rows, err := s.db.query(ctx, "SELECT order_item_id, group_name, option_name, price_adjustment, currency FROM order_item_modifiers "+where+" ORDER BY order_item_id, position", args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var orderItemID string
+		var modifier models.OrderItemModifier
+		var adjustment sql.NullInt64
+		var currency sql.NullString
+		if err := rows.Scan(&orderItemID, &modifier.Group, &modifier.Option, &adjustment, &currency); err != nil {
+			return err
+		}
+		modifier.PriceAdjustment = money(adjustment, currency)
+		add(orderItemID, modifier)
+	}
+	return rows.Err()
+}
+
+func (s *OrderItemStore) InsertMany(ctx context.Context, orderItems []models.OrderItem) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := s.db.rebind("INSERT INTO order_items (" + orderItemColumns + ") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NULL, NULL, NULL, NULL, NULL, NULL)")
+	modifierQuery := s.db.rebind("INSERT INTO order_item_modifiers (order_item_id, position, group_name, option_name, price_adjustment, currency) VALUES (?, ?, ?, ?, ?, ?)")
+	for _, orderItem := range orderItems {
+		_, err := tx.ExecContext(ctx, query,
+			orderItem.ID.Hex(), orderItem.OrderItemID, orderItem.Quantity, minorAmount(orderItem.UnitPrice), currencyCode(orderItem.UnitPrice), orderItem.FoodID, orderItem.OrderID, orderItem.Seat, orderItem.CreatedAt, orderItem.UpdatedAt,
+			minorAmount(orderItem.ListPrice), orderItem.PriceRuleID, orderItem.Notes, orderItem.Size,
+		)
+		if err != nil {
+			return err
+		}
+		for i, modifier := range orderItem.Modifiers {
+			_, err := tx.ExecContext(ctx, modifierQuery,
+				orderItem.OrderItemID, i, modifier.Group, modifier.Option, minorAmount(modifier.PriceAdjustment), currencyCode(modifier.PriceAdjustment),
+			)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *OrderItemStore) Update(ctx context.Context, orderItemID string, orderItem models.OrderItem) (store.UpdateResult, error) {
+	var set updateSet
+	set.set("updated_at", time.Now().UTC())
+
+	if orderItem.UnitPrice != nil {
+		set.set("unit_price", orderItem.UnitPrice.Amount)
+		set.set("currency", orderItem.UnitPrice.Currency)
+	}
+	if orderItem.Quantity != 0 {
+		set.set("quantity", orderItem.Quantity)
+	}
+	if orderItem.Size != nil {
+		set.set("size", *orderItem.Size)
+	}
+	if orderItem.FoodID != nil {
+		set.set("food_id", *orderItem.FoodID)
+	}
+	if orderItem.Seat != nil {
+		set.set("seat", *orderItem.Seat)
+	}
+	if orderItem.Notes != nil {
+		set.set("notes", *orderItem.Notes)
+	}
+
+	return s.db.update(ctx, "order_items", "order_item_id", orderItemID, set)
+}
+
+func (s *OrderItemStore) Void(ctx context.Context, orderItemID string, void models.OrderItemVoid) error {
+	result, err := s.db.exec(ctx,
+		`UPDATE order_items SET void_reason = ?, void_note = ?, void_requested_by = ?, void_requested_at = ?, void_approved_by = ?, voided_at = ?, updated_at = ?
+		WHERE order_item_id = ? AND void_reason IS NULL`,
+		void.Reason, void.Note, void.RequestedBy, void.RequestedAt, void.ApprovedBy, void.VoidedAt, time.Now().UTC(), orderItemID,
+	)
+	if err != nil {
+		return err
+	}
+	return s.conditional(ctx, result, orderItemID)
+}
+
+func (s *OrderItemStore) ApproveVoid(ctx context.Context, orderItemID, approvedBy string, voidedAt time.Time) error {
+	result, err := s.db.exec(ctx,
+		`UPDATE order_items SET void_approved_by = ?, voided_at = ?, updated_at = ?
+		WHERE order_item_id = ? AND void_reason IS NOT NULL AND voided_at IS NULL`,
+		approvedBy, voidedAt, time.Now().UTC(), orderItemID,
+	)
+	if err != nil {
+		return err
+	}
+	return s.conditional(ctx, result, orderItemID)
+}
+
+// conditional tells a conditional update of orderItemID that changed nothing
+// apart: ErrNotFound if the item does not exist and ErrConflict if it was
+// not in the state the update expected.
+func (s *OrderItemStore) conditional(ctx context.Context, result sql.Result, orderItemID string) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return nil
+	}
+	if _, err := s.Find(ctx, orderItemID); err != nil {
+		return err
+	}
+	return store.ErrConflict
+}
+
+// itemsByOrderJoin is the SQL counterpart of the $lookup/$unwind stages of the
+// Mongo pipeline: left joins keep items whose food, order or table is gone.
+const itemsByOrderJoin = `
+	FROM order_items oi
+	LEFT JOIN foods f ON f.food_id = oi.food_id
+	LEFT JOIN orders o ON o.order_id = oi.order_id
+	LEFT JOIN tables t ON t.table_id = o.table_id
+	WHERE oi.order_id = ?`
+
+// itemPrice and itemCurrency are what one of an item costs: its unit price,
+// or its food's price for items ordered before they were priced.
+const (
+	itemPrice    = "COALESCE(oi.unit_price, f.price)"
+	itemCurrency = "CASE WHEN oi.unit_price IS NULL THEN f.currency ELSE oi.currency END"
+)
+
+func (s *OrderItemStore) ItemsByOrder(ctx context.Context, id string) ([]store.OrderItemsGroup, error) {
+	type groupKey struct {
+		orderID, tableID string
+		tableNumber      int
+	}
+
+	rows, err := s.db.query(ctx, `
+		SELECT COALESCE(o.order_id, ''), COALESCE(t.table_id, ''), t.table_number,
+			COALESCE(SUM(CASE WHEN oi.voided_at IS NULL THEN `+itemPrice+` * oi.quantity ELSE 0 END), 0), MIN(`+itemCurrency+`),
+			SUM(CASE WHEN oi.voided_at IS NULL THEN oi.quantity ELSE 0 END)`+itemsByOrderJoin+`
+		GROUP BY o.order_id, t.table_id, t.table_number`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	groups := []store.OrderItemsGroup{}
+	index := map[groupKey]int{}
+	for rows.Next() {
+		var key groupKey
+		var group store.OrderItemsGroup
+		var paymentDue sql.NullInt64
+		var paymentDueCurrency sql.NullString
+		if err := rows.Scan(&key.orderID, &key.tableID, &group.TableNumber, &paymentDue, &paymentDueCurrency, &group.TotalCount); err != nil {
+			return nil, err
+		}
+		group.PaymentDue = *money(paymentDue, paymentDueCurrency)
+		if group.TableNumber != nil {
+			key.tableNumber = *group.TableNumber
+		}
+		group.OrderItems = []store.OrderItemLine{}
+		index[key] = len(groups)
+		groups = append(groups, group)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	lines, err := s.db.query(ctx, `
+		SELECT oi.order_item_id, `+itemPrice+`, `+itemCurrency+`, f.name, f.food_image, t.table_number, t.table_id, o.order_id, oi.quantity, oi.size, oi.voided_at`+itemsByOrderJoin+`
+		ORDER BY oi.id`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer lines.Close()
+
+	for lines.Next() {
+		var line store.OrderItemLine
+		var price sql.NullInt64
+		var priceCurrency sql.NullString
+		var voidedAt sql.NullTime
+		if err := lines.Scan(&line.OrderItemID, &price, &priceCurrency, &line.FoodName, &line.FoodImage, &line.TableNumber, &line.TableID, &line.OrderID, &line.Quantity, &line.Size, &voidedAt); err != nil {
+			return nil, err
+		}
+		line.Voided = voidedAt.Valid
+		line.Price = money(price, priceCurrency)
+		if line.Price != nil {
+			amount := line.Price.Mul(int64(line.Quantity))
+			line.Amount = &amount
+		}
+
+		var key groupKey
+		if line.OrderID != nil {
+			key.orderID = *line.OrderID
+		}
+		if line.TableID != nil {
+			key.tableID = *line.TableID
+		}
+		if line.TableNumber != nil {
+			key.tableNumber = *line.TableNumber
+		}
+		if i, ok := index[key]; ok {
+			groups[i].OrderItems = append(groups[i].OrderItems, line)
+		}
+	}
+	return groups, lines.Err()
+}
@@ -0,0 +1,131 @@
+package sqldb
+
+import (
+	"context"
+	"restaurant-management/models"
+	"restaurant-management/store"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// seedOrder stores a table, a food and an order for the table, and returns
+// the order and the food.
+func seedOrder(t *testing.T, stores store.Stores) (models.Order, models.Food) {
+	t.Helper()
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	guests, number := 4, 7
+	table := models.Table{ID: primitive.NewObjectID(), NumberOfGuests: &guests, TableNumber: &number, CreatedAt: now, UpdatedAt: now}
+	table.TableID = table.ID.Hex()
+	if err := stores.Tables.Insert(ctx, table); err != nil {
+		t.Fatalf("Tables.Insert: %v", err)
+	}
+
+	name, image := "Pizza", "pizza.png"
+	price := models.NewMoney(1200, "USD")
+	food := models.Food{ID: primitive.NewObjectID(), Name: &name, Price: &price, FoodImage: &image, CreatedAt: now, UpdatedAt: now}
+	food.FoodID = food.ID.Hex()
+	if err := stores.Foods.Insert(ctx, food); err != nil {
+		t.Fatalf("Foods.Insert: %v", err)
+	}
+
+	status := models.OrderStatusPlaced
+	order := models.Order{ID: primitive.NewObjectID(), OrderDate: now, CreatedAt: now, UpdatedAt: now, TableID: &table.TableID, Status: &status}
+	order.OrderID = order.ID.Hex()
+	if err := stores.Orders.Insert(ctx, order); err != nil {
+		t.Fatalf("Orders.Insert: %v", err)
+	}
+	return order, food
+}
+
+// newOrderItem returns an item of quantity of food on order at unitPrice.
+func newOrderItem(order models.Order, food models.Food, quantity int, unitPrice int64) models.OrderItem {
+	now := time.Now().UTC().Truncate(time.Second)
+	price := models.NewMoney(unitPrice, "USD")
+	item := models.OrderItem{
+		ID:        primitive.NewObjectID(),
+		Quantity:  quantity,
+		UnitPrice: &price,
+		ListPrice: &price,
+		FoodID:    &food.FoodID,
+		OrderID:   order.OrderID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	item.OrderItemID = item.ID.Hex()
+	return item
+}
+
+func TestItemsByOrderLeavesVoidedItemsOutOfTotals(t *testing.T) {
+	ctx := context.Background()
+	stores := NewStores(openTest(t))
+	order, food := seedOrder(t, stores)
+
+	kept := newOrderItem(order, food, 2, 1200)
+	voided := newOrderItem(order, food, 3, 500)
+	pending := newOrderItem(order, food, 1, 300)
+	if err := stores.OrderItems.InsertMany(ctx, []models.OrderItem{kept, voided, pending}); err != nil {
+		t.Fatalf("InsertMany: %v", err)
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	if err := stores.OrderItems.Void(ctx, voided.OrderItemID, models.OrderItemVoid{Reason: "WRONG_ITEM", RequestedBy: "u1", RequestedAt: now, VoidedAt: &now}); err != nil {
+		t.Fatalf("Void: %v", err)
+	}
+	// A void still waiting for approval leaves the item billed.
+	if err := stores.OrderItems.Void(ctx, pending.OrderItemID, models.OrderItemVoid{Reason: "WRONG_ITEM", RequestedBy: "u1", RequestedAt: now}); err != nil {
+		t.Fatalf("Void pending: %v", err)
+	}
+
+	groups, err := stores.OrderItems.ItemsByOrder(ctx, order.OrderID)
+	if err != nil {
+		t.Fatalf("ItemsByOrder: %v", err)
+	}
+	if len(groups) != 1 {
+		t.Fatalf("got %d groups, want 1", len(groups))
+	}
+	group := groups[0]
+	if want := models.NewMoney(2*1200+300, "USD"); group.PaymentDue != want {
+		t.Errorf("PaymentDue = %v, want %v", group.PaymentDue, want)
+	}
+	if group.TotalCount != 3 {
+		t.Errorf("TotalCount = %d, want 3", group.TotalCount)
+	}
+	if group.TableNumber == nil || *group.TableNumber != 7 {
+		t.Errorf("TableNumber = %v, want 7", group.TableNumber)
+	}
+
+	if len(group.OrderItems) != 3 {
+		t.Fatalf("got %d lines, want 3", len(group.OrderItems))
+	}
+	for _, line := range group.OrderItems {
+		if want := line.OrderItemID == voided.OrderItemID; line.Voided != want {
+			t.Errorf("line %s Voided = %v, want %v", line.OrderItemID, line.Voided, want)
+		}
+	}
+}
+
+func TestVoidTwiceConflicts(t *testing.T) {
+	ctx := context.Background()
+	stores := NewStores(openTest(t))
+	order, food := seedOrder(t, stores)
+
+	item := newOrderItem(order, food, 1, 1200)
+	if err := stores.OrderItems.InsertMany(ctx, []models.OrderItem{item}); err != nil {
+		t.Fatalf("InsertMany: %v", err)
+	}
+
+	void := models.OrderItemVoid{Reason: "WRONG_ITEM", RequestedBy: "u1", RequestedAt: time.Now().UTC()}
+	if err := stores.OrderItems.Void(ctx, item.OrderItemID, void); err != nil {
+		t.Fatalf("Void: %v", err)
+	}
+	if err := stores.OrderItems.Void(ctx, item.OrderItemID, void); err != store.ErrConflict {
+		t.Errorf("second Void = %v, want ErrConflict", err)
+	}
+	if err := stores.OrderItems.Void(ctx, "missing", void); err != store.ErrNotFound {
+		t.Errorf("Void of a missing item = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,174 @@
+package sqldb
+
+import (
+	"context"
+	"restaurant-management/models"
+	"restaurant-management/store"
+	"time"
+)
+
+const orderColumns = "id, order_id, order_date, table_id, status, created_at, updated_at"
+
+type OrderStore struct {
+	db *DB
+}
+
+func scanOrder(row scanner) (models.Order, error) {
+	var order models.Order
+	var id string
+	err := row.Scan(&id, &order.OrderID, &order.OrderDate, &order.TableID, &order.Status, &order.CreatedAt, &order.UpdatedAt)
+	order.ID = objectID(id)
+	return order, err
+}
+
+func (s *OrderStore) List(ctx context.Context) ([]models.Order, error) {
+	rows, err := s.db.query(ctx, "SELECT "+orderColumns+" FROM orders ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orders := []models.Order{}
+	for rows.Next() {
+		order, err := scanOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	history, err := s.history(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	for i := range orders {
+		orders[i].StatusHistory = history[orders[i].OrderID]
+	}
+	return orders, nil
+}
+
+func (s *OrderStore) Find(ctx context.Context, orderID string) (models.Order, error) {
+	order, err := scanOrder(s.db.queryRow(ctx, "SELECT "+orderColumns+" FROM orders WHERE order_id = ?", orderID))
+	if err != nil {
+		return order, notFound(err)
+	}
+
+	history, err := s.history(ctx, orderID)
+	order.StatusHistory = history[orderID]
+	return order, err
+}
+
+// history loads the status transitions of one order, or of every order when
+// orderID is empty, keyed by order ID.
+func (s *OrderStore) history(ctx context.Context, orderID string) (map[string][]models.OrderTransition, error) {
+	query := "SELECT order_id, from_status, to_status, changed_by, changed_at FROM order_transitions"
+	args := []interface{}{}
+	if orderID != "" {
+		query += " WHERE order_id = ?"
+		args = append(args, orderID)
+	}
+
+	rows, err := s.db.query(ctx, query+" ORDER BY order_id, position", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := map[string][]models.OrderTransition{}
+	for rows.Next() {
+		var id string
+		var transition models.OrderTransition
+		if err := rows.Scan(&id, &transition.From, &transition.To, &transition.ChangedBy, &transition.ChangedAt); err != nil {
+			return nil, err
+		}
+		history[id] = append(history[id], transition)
+	}
+	return history, rows.Err()
+}
+
+func (s *OrderStore) Insert(ctx context.Context, order models.Order) error {
+	_, err := s.db.exec(ctx,
+		"INSERT INTO orders ("+orderColumns+") VALUES (?, ?, ?, ?, ?, ?, ?)",
+		order.ID.Hex(), order.OrderID, order.OrderDate, order.TableID, order.Status, order.CreatedAt, order.UpdatedAt,
+	)
+	return err
+}
+
+func (s *OrderStore) Update(ctx context.Context, orderID string, order models.Order) (store.UpdateResult, error) {
+	var set updateSet
+	set.set("updated_at", time.Now().UTC())
+
+	if !order.OrderDate.IsZero() {
+		set.set("order_date", order.OrderDate)
+	}
+	if order.TableID != nil {
+		set.set("table_id", *order.TableID)
+	}
+
+	return s.db.update(ctx, "orders", "order_id", orderID, set)
+}
+
+func (s *OrderStore) Delete(ctx context.Context, orderID string) error {
+	result, err := s.db.exec(ctx, "DELETE FROM orders WHERE order_id = ?", orderID)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (s *OrderStore) Transition(ctx context.Context, orderID string, transition models.OrderTransition) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	condition := "status = ?"
+	if transition.From == models.OrderStatusPlaced {
+		// Orders stored before statuses existed have no status and count as PLACED.
+		condition = "(status = ? OR status IS NULL)"
+	}
+
+	result, err := tx.ExecContext(ctx,
+		s.db.rebind("UPDATE orders SET status = ?, updated_at = ? WHERE order_id = ? AND "+condition),
+		transition.To, transition.ChangedAt, orderID, transition.From,
+	)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		var count int
+		err := tx.QueryRowContext(ctx, s.db.rebind("SELECT COUNT(*) FROM orders WHERE order_id = ?"), orderID).Scan(&count)
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return store.ErrNotFound
+		}
+		return store.ErrConflict
+	}
+
+	_, err = tx.ExecContext(ctx, s.db.rebind(`
+		INSERT INTO order_transitions (order_id, position, from_status, to_status, changed_by, changed_at)
+		VALUES (?, (SELECT COUNT(*) FROM order_transitions WHERE order_id = ?), ?, ?, ?, ?)`),
+		orderID, orderID, transition.From, transition.To, transition.ChangedBy, transition.ChangedAt,
+	)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,147 @@
+package sqldb
+
+import (
+	"context"
+	"restaurant-management/models"
+	"restaurant-management/store"
+	"time"
+)
+
+const paymentColumns = "id, payment_id, invoice_id, currency, tendered, amount, change_due, balance, taken_by, created_at"
+
+type PaymentStore struct {
+	db *DB
+}
+
+func scanPayment(row scanner) (models.Payment, error) {
+	var payment models.Payment
+	var id, currency string
+	var tendered, amount, change, balance int64
+	err := row.Scan(
+		&id, &payment.PaymentID, &payment.InvoiceID, &currency, &tendered, &amount, &change, &balance, &payment.TakenBy, &payment.CreatedAt,
+	)
+	payment.ID = objectID(id)
+	payment.Tendered = models.NewMoney(tendered, currency)
+	payment.Amount = models.NewMoney(amount, currency)
+	payment.Change = models.NewMoney(change, currency)
+	payment.Balance = models.NewMoney(balance, currency)
+	return payment, err
+}
+
+func (s *PaymentStore) ListByInvoice(ctx context.Context, invoiceID string) ([]models.Payment, error) {
+	return s.list(ctx, "WHERE invoice_id = ?", invoiceID)
+}
+
+func (s *PaymentStore) ListBetween(ctx context.Context, from, to time.Time) ([]models.Payment, error) {
+	return s.list(ctx, "WHERE created_at >= ? AND created_at < ?", from, to)
+}
+
+func (s *PaymentStore) list(ctx context.Context, where string, args ...interface{}) ([]models.Payment, error) {
+	rows, err := s.db.query(ctx, "SELECT "+paymentColumns+" FROM payments "+where+" ORDER BY created_at, id", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	payments := []models.Payment{}
+	for rows.Next() {
+		payment, err := scanPayment(rows)
+		if err != nil {
+			return nil, err
+		}
+		payments = append(payments, payment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range payments {
+		if err := s.tenders(ctx, &payments[i]); err != nil {
+			return nil, err
+		}
+	}
+	return payments, nil
+}
+
+func (s *PaymentStore) Find(ctx context.Context, paymentID string) (models.Payment, error) {
+	payment, err := scanPayment(s.db.queryRow(ctx, "SELECT "+paymentColumns+" FROM payments WHERE payment_id = ?", paymentID))
+	if err != nil {
+		return payment, notFound(err)
+	}
+	return payment, s.tenders(ctx, &payment)
+}
+
+// tenders loads the tenders of payment.
+func (s *PaymentStore) tenders(ctx context.Context, payment *models.Payment) error {
+	rows, err := s.db.query(ctx, "SELECT method, amount, reference, provider FROM payment_tenders WHERE payment_id = ? ORDER BY position", payment.PaymentID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var tender models.Tender
+		var amount int64
+		if err := rows.Scan(&tender.Method, &amount, &tender.Reference, &tender.Provider); err != nil {
+			return err
+		}
+		tender.Amount = models.NewMoney(amount, payment.Amount.Currency)
+		payment.Tenders = append(payment.Tenders, tender)
+	}
+	return rows.Err()
+}
+
+func (s *PaymentStore) Insert(ctx context.Context, payment models.Payment, settlement store.Settlement) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var provider, providerReference interface{}
+	if settlement.ProviderReference != "" {
+		provider, providerReference = settlement.Provider, settlement.ProviderReference
+	}
+	result, err := tx.ExecContext(ctx,
+		s.db.rebind(`UPDATE invoices SET amount_paid = ?, payment_status = ?, payment_method = ?, updated_at = ?,
+			payment_provider = COALESCE(?, payment_provider), provider_reference = COALESCE(?, provider_reference)
+			WHERE invoice_id = ? AND COALESCE(amount_paid, 0) = ? AND COALESCE(payment_status, '') <> ?`),
+		settlement.AmountPaid.Amount, settlement.PaymentStatus, settlement.PaymentMethod, payment.CreatedAt,
+		provider, providerReference,
+		payment.InvoiceID, settlement.PaidBefore.Amount, models.PaymentVoid,
+	)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		var exists int
+		if err := tx.QueryRowContext(ctx, s.db.rebind("SELECT COUNT(*) FROM invoices WHERE invoice_id = ?"), payment.InvoiceID).Scan(&exists); err != nil {
+			return err
+		}
+		if exists == 0 {
+			return store.ErrNotFound
+		}
+		return store.ErrConflict
+	}
+
+	_, err = tx.ExecContext(ctx,
+		s.db.rebind("INSERT INTO payments ("+paymentColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"),
+		payment.ID.Hex(), payment.PaymentID, payment.InvoiceID, payment.Amount.Currency,
+		payment.Tendered.Amount, payment.Amount.Amount, payment.Change.Amount, payment.Balance.Amount, payment.TakenBy, payment.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	tenderQuery := s.db.rebind("INSERT INTO payment_tenders (payment_id, position, method, amount, reference, provider) VALUES (?, ?, ?, ?, ?, ?)")
+	for i, tender := range payment.Tenders {
+		if _, err := tx.ExecContext(ctx, tenderQuery, payment.PaymentID, i, tender.Method, tender.Amount.Amount, tender.Reference, tender.Provider); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,112 @@
+package sqldb
+
+import (
+	"context"
+	"restaurant-management/models"
+	"restaurant-management/store"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// seedInvoice stores an unpaid invoice for order of total in USD.
+func seedInvoice(t *testing.T, stores store.Stores, order models.Order, total int64) models.Invoice {
+	t.Helper()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	status, method := models.PaymentPending, ""
+	grandTotal := models.NewMoney(total, "USD")
+	invoice := models.Invoice{
+		ID:             primitive.NewObjectID(),
+		OrderID:        order.OrderID,
+		PaymentMethod:  &method,
+		PaymentStatus:  &status,
+		PaymentDueDate: now.AddDate(0, 0, 1),
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		Subtotal:       &grandTotal,
+		GrandTotal:     &grandTotal,
+	}
+	invoice.InvoiceID = invoice.ID.Hex()
+	if err := stores.Invoices.Insert(context.Background(), invoice, "INV"); err != nil {
+		t.Fatalf("Invoices.Insert: %v", err)
+	}
+	return invoice
+}
+
+// newPayment returns a cash payment of amount in USD against invoice.
+func newPayment(invoice models.Invoice, amount int64) models.Payment {
+	paid := models.NewMoney(amount, "USD")
+	payment := models.Payment{
+		ID:        primitive.NewObjectID(),
+		InvoiceID: invoice.InvoiceID,
+		Tenders:   []models.Tender{{Method: "CASH", Amount: paid}},
+		Tendered:  paid,
+		Amount:    paid,
+		Change:    models.NewMoney(0, "USD"),
+		Balance:   models.NewMoney(0, "USD"),
+		TakenBy:   "u1",
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+	}
+	payment.PaymentID = payment.ID.Hex()
+	return payment
+}
+
+func TestPaymentInsertConflictsWhenAmountPaidHasChanged(t *testing.T) {
+	ctx := context.Background()
+	stores := NewStores(openTest(t))
+	order, _ := seedOrder(t, stores)
+	invoice := seedInvoice(t, stores, order, 2400)
+
+	// Two payments worked out from the same unpaid invoice: the first
+	// settles, the second finds the amount paid moved on.
+	settlement := store.Settlement{
+		PaidBefore:    models.NewMoney(0, "USD"),
+		AmountPaid:    models.NewMoney(1000, "USD"),
+		PaymentStatus: models.PaymentPartiallyPaid,
+		PaymentMethod: "CASH",
+	}
+	if err := stores.Payments.Insert(ctx, newPayment(invoice, 1000), settlement); err != nil {
+		t.Fatalf("first Insert: %v", err)
+	}
+	stale := newPayment(invoice, 1000)
+	if err := stores.Payments.Insert(ctx, stale, settlement); err != store.ErrConflict {
+		t.Fatalf("stale Insert = %v, want ErrConflict", err)
+	}
+
+	// Nothing of the stale payment is stored.
+	if _, err := stores.Payments.Find(ctx, stale.PaymentID); err != store.ErrNotFound {
+		t.Errorf("Find stale payment = %v, want ErrNotFound", err)
+	}
+	payments, err := stores.Payments.ListByInvoice(ctx, invoice.InvoiceID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(payments) != 1 {
+		t.Errorf("invoice has %d payments, want 1", len(payments))
+	}
+	stored, err := stores.Invoices.Find(ctx, invoice.InvoiceID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.AmountPaid == nil || stored.AmountPaid.Amount != 1000 {
+		t.Errorf("AmountPaid = %v, want 1000", stored.AmountPaid)
+	}
+	if stored.PaymentStatus == nil || *stored.PaymentStatus != models.PaymentPartiallyPaid {
+		t.Errorf("PaymentStatus = %v, want %s", stored.PaymentStatus, models.PaymentPartiallyPaid)
+	}
+
+	// A payment worked out from what is paid now goes through.
+	settlement.PaidBefore = models.NewMoney(1000, "USD")
+	settlement.AmountPaid = models.NewMoney(2400, "USD")
+	settlement.PaymentStatus = models.PaymentPaid
+	if err := stores.Payments.Insert(ctx, newPayment(invoice, 1400), settlement); err != nil {
+		t.Errorf("Insert from the amount paid now: %v", err)
+	}
+
+	missing := newPayment(models.Invoice{InvoiceID: "missing"}, 1000)
+	if err := stores.Payments.Insert(ctx, missing, settlement); err != store.ErrNotFound {
+		t.Errorf("Insert against a missing invoice = %v, want ErrNotFound", err)
+	}
+}
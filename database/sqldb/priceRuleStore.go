@@ -0,0 +1,162 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"restaurant-management/models"
+	"restaurant-management/store"
+	"strings"
+)
+
+const priceRuleColumns = "id, price_rule_id, name, kind, food_ids, menu_ids, discount_rate, amount_off, amount_off_currency, price, price_currency, buy_quantity, free_quantity, start_date, end_date, created_at, updated_at"
+
+type PriceRuleStore struct {
+	db *DB
+}
+
+// scanPriceRule reads a price rule without its schedule. Food and menu IDs
+// are stored comma-separated.
+func scanPriceRule(row scanner) (models.PriceRule, error) {
+	var rule models.PriceRule
+	var id, foodIDs, menuIDs string
+	var amountOff, price sql.NullInt64
+	var amountOffCurrency, priceCurrency sql.NullString
+	err := row.Scan(&id, &rule.PriceRuleID, &rule.Name, &rule.Kind, &foodIDs, &menuIDs, &rule.DiscountRate, &amountOff, &amountOffCurrency, &price, &priceCurrency,
+		&rule.BuyQuantity, &rule.FreeQuantity, &rule.StartDate, &rule.EndDate, &rule.CreatedAt, &rule.UpdatedAt)
+	rule.ID = objectID(id)
+	rule.FoodIDs = splitIDs(foodIDs)
+	rule.MenuIDs = splitIDs(menuIDs)
+	rule.AmountOff = money(amountOff, amountOffCurrency)
+	rule.Price = money(price, priceCurrency)
+	return rule, err
+}
+
+func splitIDs(ids string) []string {
+	if ids == "" {
+		return []string{}
+	}
+	return strings.Split(ids, ",")
+}
+
+func (s *PriceRuleStore) List(ctx context.Context) ([]models.PriceRule, error) {
+	rows, err := s.db.query(ctx, "SELECT "+priceRuleColumns+" FROM price_rules ORDER BY created_at, id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := []models.PriceRule{}
+	for rows.Next() {
+		rule, err := scanPriceRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range rules {
+		if err := s.schedule(ctx, &rules[i]); err != nil {
+			return nil, err
+		}
+	}
+	return rules, nil
+}
+
+func (s *PriceRuleStore) Find(ctx context.Context, priceRuleID string) (models.PriceRule, error) {
+	rule, err := scanPriceRule(s.db.queryRow(ctx, "SELECT "+priceRuleColumns+" FROM price_rules WHERE price_rule_id = ?", priceRuleID))
+	if err != nil {
+		return rule, notFound(err)
+	}
+	return rule, s.schedule(ctx, &rule)
+}
+
+// schedule loads the slots of rule the way MenuStore.schedule does.
+func (s *PriceRuleStore) schedule(ctx context.Context, rule *models.PriceRule) error {
+	rows, err := s.db.query(ctx, "SELECT days, start_time, end_time FROM price_rule_slots WHERE price_rule_id = ? ORDER BY position", rule.PriceRuleID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	rule.Schedule = []models.MenuSlot{}
+	for rows.Next() {
+		var slot models.MenuSlot
+		var days string
+		if err := rows.Scan(&days, &slot.Start, &slot.End); err != nil {
+			return err
+		}
+		slot.Days = strings.Split(days, ",")
+		rule.Schedule = append(rule.Schedule, slot)
+	}
+	return rows.Err()
+}
+
+func (s *PriceRuleStore) Insert(ctx context.Context, rule models.PriceRule) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx,
+		s.db.rebind("INSERT INTO price_rules ("+priceRuleColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"),
+		rule.ID.Hex(), rule.PriceRuleID, rule.Name, rule.Kind, strings.Join(rule.FoodIDs, ","), strings.Join(rule.MenuIDs, ","),
+		rule.DiscountRate, minorAmount(rule.AmountOff), currencyCode(rule.AmountOff), minorAmount(rule.Price), currencyCode(rule.Price),
+		rule.BuyQuantity, rule.FreeQuantity, rule.StartDate, rule.EndDate, rule.CreatedAt, rule.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	if err := s.putSchedule(ctx, tx, rule); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *PriceRuleStore) Replace(ctx context.Context, rule models.PriceRule) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx,
+		s.db.rebind(`UPDATE price_rules SET name = ?, kind = ?, food_ids = ?, menu_ids = ?, discount_rate = ?, amount_off = ?, amount_off_currency = ?,
+			price = ?, price_currency = ?, buy_quantity = ?, free_quantity = ?, start_date = ?, end_date = ?, updated_at = ?
+			WHERE price_rule_id = ?`),
+		rule.Name, rule.Kind, strings.Join(rule.FoodIDs, ","), strings.Join(rule.MenuIDs, ","),
+		rule.DiscountRate, minorAmount(rule.AmountOff), currencyCode(rule.AmountOff), minorAmount(rule.Price), currencyCode(rule.Price),
+		rule.BuyQuantity, rule.FreeQuantity, rule.StartDate, rule.EndDate, rule.UpdatedAt, rule.PriceRuleID,
+	)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return store.ErrNotFound
+	}
+
+	if _, err := tx.ExecContext(ctx, s.db.rebind("DELETE FROM price_rule_slots WHERE price_rule_id = ?"), rule.PriceRuleID); err != nil {
+		return err
+	}
+	if err := s.putSchedule(ctx, tx, rule); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *PriceRuleStore) putSchedule(ctx context.Context, tx *sql.Tx, rule models.PriceRule) error {
+	query := s.db.rebind("INSERT INTO price_rule_slots (price_rule_id, position, days, start_time, end_time) VALUES (?, ?, ?, ?, ?)")
+	for i, slot := range rule.Schedule {
+		if _, err := tx.ExecContext(ctx, query, rule.PriceRuleID, i, strings.Join(slot.Days, ","), slot.Start, slot.End); err != nil {
+			return err
+		}
+	}
+	return nil
+}
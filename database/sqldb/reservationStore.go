@@ -0,0 +1,118 @@
+package sqldb
+
+import (
+	"context"
+	"restaurant-management/models"
+	"restaurant-management/store"
+	"time"
+)
+
+const reservationColumns = "id, reservation_id, table_id, guest_name, phone, number_of_guests, start_time, end_time, status, created_at, updated_at"
+
+type ReservationStore struct {
+	db *DB
+}
+
+func scanReservation(row scanner) (models.Reservation, error) {
+	var reservation models.Reservation
+	var id string
+	err := row.Scan(
+		&id, &reservation.ReservationID, &reservation.TableID, &reservation.GuestName, &reservation.Phone,
+		&reservation.NumberOfGuests, &reservation.StartTime, &reservation.EndTime, &reservation.Status,
+		&reservation.CreatedAt, &reservation.UpdatedAt,
+	)
+	reservation.ID = objectID(id)
+	return reservation, err
+}
+
+func (s *ReservationStore) list(ctx context.Context, where string, args ...interface{}) ([]models.Reservation, error) {
+	rows, err := s.db.query(ctx, "SELECT "+reservationColumns+" FROM reservations "+where+" ORDER BY start_time, id", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reservations := []models.Reservation{}
+	for rows.Next() {
+		reservation, err := scanReservation(rows)
+		if err != nil {
+			return nil, err
+		}
+		reservations = append(reservations, reservation)
+	}
+	return reservations, rows.Err()
+}
+
+func (s *ReservationStore) List(ctx context.Context, tableID string) ([]models.Reservation, error) {
+	if tableID == "" {
+		return s.list(ctx, "")
+	}
+	return s.list(ctx, "WHERE table_id = ?", tableID)
+}
+
+func (s *ReservationStore) Find(ctx context.Context, reservationID string) (models.Reservation, error) {
+	reservation, err := scanReservation(s.db.queryRow(ctx, "SELECT "+reservationColumns+" FROM reservations WHERE reservation_id = ?", reservationID))
+	return reservation, notFound(err)
+}
+
+func (s *ReservationStore) Insert(ctx context.Context, reservation models.Reservation) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Touching the table row locks it on PostgreSQL, so concurrent bookings
+	// for the same table queue here and each sees the ones committed before it.
+	if _, err := tx.ExecContext(ctx, s.db.rebind("UPDATE tables SET table_id = table_id WHERE table_id = ?"), reservation.TableID); err != nil {
+		return err
+	}
+
+	var clashes int
+	err = tx.QueryRowContext(ctx,
+		s.db.rebind("SELECT COUNT(*) FROM reservations WHERE table_id = ? AND status = ? AND start_time < ? AND end_time > ?"),
+		reservation.TableID, models.ReservationBooked, reservation.EndTime, reservation.StartTime,
+	).Scan(&clashes)
+	if err != nil {
+		return err
+	}
+	if clashes > 0 {
+		return store.ErrConflict
+	}
+
+	_, err = tx.ExecContext(ctx,
+		s.db.rebind("INSERT INTO reservations ("+reservationColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"),
+		reservation.ID.Hex(), reservation.ReservationID, reservation.TableID, reservation.GuestName, reservation.Phone,
+		reservation.NumberOfGuests, reservation.StartTime, reservation.EndTime, reservation.Status,
+		reservation.CreatedAt, reservation.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *ReservationStore) UpdateStatus(ctx context.Context, reservationID, status string) error {
+	result, err := s.db.exec(ctx,
+		"UPDATE reservations SET status = ?, updated_at = ? WHERE reservation_id = ? AND status = ?",
+		status, time.Now().UTC(), reservationID, models.ReservationBooked,
+	)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		if _, err := s.Find(ctx, reservationID); err != nil {
+			return err
+		}
+		return store.ErrConflict
+	}
+	return nil
+}
+
+func (s *ReservationStore) Overlapping(ctx context.Context, start, end time.Time) ([]models.Reservation, error) {
+	return s.list(ctx, "WHERE status = ? AND start_time < ? AND end_time > ?", models.ReservationBooked, end, start)
+}
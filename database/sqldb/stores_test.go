@@ -0,0 +1,11 @@
+package sqldb
+
+import (
+	"restaurant-management/store"
+	"restaurant-management/store/storetest"
+	"testing"
+)
+
+func TestStores(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) store.Stores { return NewStores(openTest(t)) })
+}
@@ -0,0 +1,67 @@
+package sqldb
+
+import (
+	"context"
+	"restaurant-management/models"
+	"restaurant-management/store"
+	"time"
+)
+
+const tableColumns = "id, table_id, number_of_guests, table_number, created_at, updated_at"
+
+type TableStore struct {
+	db *DB
+}
+
+func scanTable(row scanner) (models.Table, error) {
+	var table models.Table
+	var id string
+	err := row.Scan(&id, &table.TableID, &table.NumberOfGuests, &table.TableNumber, &table.CreatedAt, &table.UpdatedAt)
+	table.ID = objectID(id)
+	return table, err
+}
+
+func (s *TableStore) List(ctx context.Context) ([]models.Table, error) {
+	rows, err := s.db.query(ctx, "SELECT "+tableColumns+" FROM tables ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tables := []models.Table{}
+	for rows.Next() {
+		table, err := scanTable(rows)
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, table)
+	}
+	return tables, rows.Err()
+}
+
+func (s *TableStore) Find(ctx context.Context, tableID string) (models.Table, error) {
+	table, err := scanTable(s.db.queryRow(ctx, "SELECT "+tableColumns+" FROM tables WHERE table_id = ?", tableID))
+	return table, notFound(err)
+}
+
+func (s *TableStore) Insert(ctx context.Context, table models.Table) error {
+	_, err := s.db.exec(ctx,
+		"INSERT INTO tables ("+tableColumns+") VALUES (?, ?, ?, ?, ?, ?)",
+		table.ID.Hex(), table.TableID, table.NumberOfGuests, table.TableNumber, table.CreatedAt, table.UpdatedAt,
+	)
+	return err
+}
+
+func (s *TableStore) Update(ctx context.Context, tableID string, table models.Table) (store.UpdateResult, error) {
+	var set updateSet
+	set.set("updated_at", time.Now().UTC())
+
+	if table.NumberOfGuests != nil {
+		set.set("number_of_guests", *table.NumberOfGuests)
+	}
+	if table.TableNumber != nil {
+		set.set("table_number", *table.TableNumber)
+	}
+
+	return s.db.update(ctx, "tables", "table_id", tableID, set)
+}
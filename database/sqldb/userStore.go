@@ -0,0 +1,143 @@
+package sqldb
+
+import (
+	"context"
+	"restaurant-management/models"
+	"restaurant-management/store"
+	"time"
+)
+
+const userColumns = "id, user_id, first_name, last_name, password, email, avatar, phone, token, refresh_token, role, created_at, updated_at"
+
+// bootstrapFirstAdmin names the marker ClaimFirstAdmin inserts.
+const bootstrapFirstAdmin = "first_admin"
+
+type UserStore struct {
+	db *DB
+}
+
+func scanUser(row scanner) (models.User, error) {
+	var user models.User
+	var id string
+	err := row.Scan(&id, &user.UserID, &user.FirstName, &user.LastName, &user.Password, &user.Email, &user.Avatar, &user.Phone, &user.Token, &user.RefreshToken, &user.Role, &user.CreatedAt, &user.UpdatedAt)
+	user.ID = objectID(id)
+	return user, err
+}
+
+func (s *UserStore) List(ctx context.Context, startIndex, recordPerPage int) (int, []models.User, error) {
+	var total int
+	if err := s.db.queryRow(ctx, "SELECT COUNT(*) FROM users").Scan(&total); err != nil {
+		return 0, nil, err
+	}
+
+	rows, err := s.db.query(ctx, "SELECT "+userColumns+" FROM users ORDER BY id LIMIT ? OFFSET ?", recordPerPage, startIndex)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return 0, nil, err
+		}
+		users = append(users, user)
+	}
+	return total, users, rows.Err()
+}
+
+func (s *UserStore) Find(ctx context.Context, userID string) (models.User, error) {
+	user, err := scanUser(s.db.queryRow(ctx, "SELECT "+userColumns+" FROM users WHERE user_id = ?", userID))
+	return user, notFound(err)
+}
+
+func (s *UserStore) FindByEmail(ctx context.Context, email string) (models.User, error) {
+	user, err := scanUser(s.db.queryRow(ctx, "SELECT "+userColumns+" FROM users WHERE email = ? ORDER BY id LIMIT 1", email))
+	return user, notFound(err)
+}
+
+func (s *UserStore) CountByEmail(ctx context.Context, email string) (int64, error) {
+	var count int64
+	err := s.db.queryRow(ctx, "SELECT COUNT(*) FROM users WHERE email = ?", email).Scan(&count)
+	return count, err
+}
+
+func (s *UserStore) CountByPhone(ctx context.Context, phone string) (int64, error) {
+	var count int64
+	err := s.db.queryRow(ctx, "SELECT COUNT(*) FROM users WHERE phone = ?", phone).Scan(&count)
+	return count, err
+}
+
+func (s *UserStore) Insert(ctx context.Context, user models.User) error {
+	_, err := s.db.exec(ctx,
+		"INSERT INTO users ("+userColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		user.ID.Hex(), user.UserID, user.FirstName, user.LastName, user.Password, user.Email, user.Avatar, user.Phone, user.Token, user.RefreshToken, user.Role, user.CreatedAt, user.UpdatedAt,
+	)
+	return err
+}
+
+func (s *UserStore) UpdateTokens(ctx context.Context, userID, token, refreshToken string) error {
+	_, err := s.db.exec(ctx,
+		"UPDATE users SET token = ?, refresh_token = ?, updated_at = ? WHERE user_id = ?",
+		token, refreshToken, time.Now().UTC(), userID,
+	)
+	return err
+}
+
+func (s *UserStore) RotateTokens(ctx context.Context, userID, currentRefreshToken, token, refreshToken string) error {
+	result, err := s.db.exec(ctx,
+		"UPDATE users SET token = ?, refresh_token = ?, updated_at = ? WHERE user_id = ? AND refresh_token = ?",
+		token, refreshToken, time.Now().UTC(), userID, currentRefreshToken,
+	)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		if _, err := s.Find(ctx, userID); err != nil {
+			return err
+		}
+		return store.ErrConflict
+	}
+	return nil
+}
+
+func (s *UserStore) UpdateRole(ctx context.Context, userID, role string) (store.UpdateResult, error) {
+	var set updateSet
+	set.set("role", role)
+	set.set("updated_at", time.Now().UTC())
+
+	return s.db.update(ctx, "users", "user_id", userID, set)
+}
+
+// ClaimFirstAdmin inserts the first_admin marker unless it is there already.
+// Of two claims racing, the loser's insert either finds the marker or fails
+// on its primary key; either way the marker then names the winner.
+func (s *UserStore) ClaimFirstAdmin(ctx context.Context, userID string) error {
+	_, insertErr := s.db.exec(ctx,
+		"INSERT INTO bootstrap (name, user_id) SELECT ?, ? WHERE NOT EXISTS (SELECT 1 FROM bootstrap WHERE name = ?)",
+		bootstrapFirstAdmin, userID, bootstrapFirstAdmin,
+	)
+
+	var claimedBy string
+	if err := s.db.queryRow(ctx, "SELECT user_id FROM bootstrap WHERE name = ?", bootstrapFirstAdmin).Scan(&claimedBy); err != nil {
+		if insertErr != nil {
+			return insertErr
+		}
+		return err
+	}
+	if claimedBy != userID {
+		return store.ErrConflict
+	}
+	return nil
+}
+
+func (s *UserStore) ReleaseFirstAdmin(ctx context.Context, userID string) error {
+	_, err := s.db.exec(ctx, "DELETE FROM bootstrap WHERE name = ? AND user_id = ?", bootstrapFirstAdmin, userID)
+	return err
+}
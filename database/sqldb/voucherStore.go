@@ -0,0 +1,201 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"restaurant-management/models"
+	"restaurant-management/store"
+	"strings"
+	"time"
+)
+
+const voucherColumns = "id, voucher_id, code, kind, rate, amount, amount_currency, food_ids, minimum_spend, minimum_spend_currency, expires_at, max_redemptions, redemptions, created_at, updated_at"
+
+const redemptionColumns = "id, redemption_id, voucher_id, code, order_id, redeemed_by, redeemed_at, released_at"
+
+type VoucherStore struct {
+	db *DB
+}
+
+func scanVoucher(row scanner) (models.Voucher, error) {
+	var voucher models.Voucher
+	var id, foodIDs string
+	var amount, minimumSpend sql.NullInt64
+	var amountCurrency, minimumSpendCurrency sql.NullString
+	err := row.Scan(&id, &voucher.VoucherID, &voucher.Code, &voucher.Kind, &voucher.Rate, &amount, &amountCurrency, &foodIDs, &minimumSpend, &minimumSpendCurrency,
+		&voucher.ExpiresAt, &voucher.MaxRedemptions, &voucher.Redemptions, &voucher.CreatedAt, &voucher.UpdatedAt)
+	voucher.ID = objectID(id)
+	voucher.Amount = money(amount, amountCurrency)
+	voucher.FoodIDs = splitIDs(foodIDs)
+	voucher.MinimumSpend = money(minimumSpend, minimumSpendCurrency)
+	return voucher, err
+}
+
+func scanRedemption(row scanner) (models.VoucherRedemption, error) {
+	var redemption models.VoucherRedemption
+	var id string
+	err := row.Scan(&id, &redemption.RedemptionID, &redemption.VoucherID, &redemption.Code, &redemption.OrderID, &redemption.RedeemedBy, &redemption.RedeemedAt, &redemption.ReleasedAt)
+	redemption.ID = objectID(id)
+	return redemption, err
+}
+
+func (s *VoucherStore) List(ctx context.Context) ([]models.Voucher, error) {
+	rows, err := s.db.query(ctx, "SELECT "+voucherColumns+" FROM vouchers ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	vouchers := []models.Voucher{}
+	for rows.Next() {
+		voucher, err := scanVoucher(rows)
+		if err != nil {
+			return nil, err
+		}
+		vouchers = append(vouchers, voucher)
+	}
+	return vouchers, rows.Err()
+}
+
+func (s *VoucherStore) Find(ctx context.Context, voucherID string) (models.Voucher, error) {
+	voucher, err := scanVoucher(s.db.queryRow(ctx, "SELECT "+voucherColumns+" FROM vouchers WHERE voucher_id = ?", voucherID))
+	return voucher, notFound(err)
+}
+
+func (s *VoucherStore) FindByCode(ctx context.Context, code string) (models.Voucher, error) {
+	voucher, err := scanVoucher(s.db.queryRow(ctx, "SELECT "+voucherColumns+" FROM vouchers WHERE code = ?", code))
+	return voucher, notFound(err)
+}
+
+func (s *VoucherStore) Insert(ctx context.Context, voucher models.Voucher) error {
+	var existing int
+	if err := s.db.queryRow(ctx, "SELECT COUNT(*) FROM vouchers WHERE code = ?", voucher.Code).Scan(&existing); err != nil {
+		return err
+	}
+	if existing > 0 {
+		return store.ErrConflict
+	}
+
+	_, err := s.db.exec(ctx,
+		"INSERT INTO vouchers ("+voucherColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		voucher.ID.Hex(), voucher.VoucherID, voucher.Code, voucher.Kind, voucher.Rate, minorAmount(voucher.Amount), currencyCode(voucher.Amount),
+		strings.Join(voucher.FoodIDs, ","), minorAmount(voucher.MinimumSpend), currencyCode(voucher.MinimumSpend),
+		voucher.ExpiresAt, voucher.MaxRedemptions, voucher.Redemptions, voucher.CreatedAt, voucher.UpdatedAt,
+	)
+	return err
+}
+
+func (s *VoucherStore) Update(ctx context.Context, voucherID string, voucher models.Voucher) (store.UpdateResult, error) {
+	var set updateSet
+	set.set("updated_at", time.Now().UTC())
+
+	if voucher.ExpiresAt != nil {
+		set.set("expires_at", *voucher.ExpiresAt)
+	}
+	if voucher.MaxRedemptions != nil {
+		set.set("max_redemptions", *voucher.MaxRedemptions)
+	}
+
+	return s.db.update(ctx, "vouchers", "voucher_id", voucherID, set)
+}
+
+func (s *VoucherStore) Redeem(ctx context.Context, redemption models.VoucherRedemption) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Touching the order row locks it on PostgreSQL, so concurrent attempts
+	// to apply a voucher to the same order queue here.
+	if _, err := tx.ExecContext(ctx, s.db.rebind("UPDATE orders SET order_id = order_id WHERE order_id = ?"), redemption.OrderID); err != nil {
+		return err
+	}
+	var applied int
+	err = tx.QueryRowContext(ctx,
+		s.db.rebind("SELECT COUNT(*) FROM voucher_redemptions WHERE order_id = ? AND released_at IS NULL"),
+		redemption.OrderID,
+	).Scan(&applied)
+	if err != nil {
+		return err
+	}
+	if applied > 0 {
+		return store.ErrConflict
+	}
+
+	// The count is only raised while it is below the limit, in one
+	// statement, so the limit holds however many redeem at once.
+	result, err := tx.ExecContext(ctx,
+		s.db.rebind("UPDATE vouchers SET redemptions = redemptions + 1 WHERE voucher_id = ? AND (max_redemptions IS NULL OR redemptions < max_redemptions)"),
+		redemption.VoucherID,
+	)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		var exists int
+		if err := tx.QueryRowContext(ctx, s.db.rebind("SELECT COUNT(*) FROM vouchers WHERE voucher_id = ?"), redemption.VoucherID).Scan(&exists); err != nil {
+			return err
+		}
+		if exists == 0 {
+			return store.ErrNotFound
+		}
+		return store.ErrConflict
+	}
+
+	_, err = tx.ExecContext(ctx,
+		s.db.rebind("INSERT INTO voucher_redemptions ("+redemptionColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?)"),
+		redemption.ID.Hex(), redemption.RedemptionID, redemption.VoucherID, redemption.Code, redemption.OrderID, redemption.RedeemedBy, redemption.RedeemedAt, redemption.ReleasedAt,
+	)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *VoucherStore) Release(ctx context.Context, orderID string, releasedAt time.Time) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var voucherID string
+	err = tx.QueryRowContext(ctx,
+		s.db.rebind("SELECT voucher_id FROM voucher_redemptions WHERE order_id = ? AND released_at IS NULL"),
+		orderID,
+	).Scan(&voucherID)
+	if err != nil {
+		return notFound(err)
+	}
+
+	result, err := tx.ExecContext(ctx,
+		s.db.rebind("UPDATE voucher_redemptions SET released_at = ? WHERE order_id = ? AND released_at IS NULL"),
+		releasedAt, orderID,
+	)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return store.ErrNotFound
+	}
+
+	if _, err := tx.ExecContext(ctx, s.db.rebind("UPDATE vouchers SET redemptions = redemptions - 1 WHERE voucher_id = ?"), voucherID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *VoucherStore) RedemptionByOrder(ctx context.Context, orderID string) (models.VoucherRedemption, error) {
+	redemption, err := scanRedemption(s.db.queryRow(ctx,
+		"SELECT "+redemptionColumns+" FROM voucher_redemptions WHERE order_id = ? AND released_at IS NULL", orderID))
+	return redemption, notFound(err)
+}
@@ -0,0 +1,46 @@
+package database
+
+import (
+	"restaurant-management/store"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// NewStores returns the MongoDB implementation of every entity store.
+func NewStores(client *mongo.Client) store.Stores {
+	return store.Stores{
+		Foods:        NewFoodStore(client),
+		Menus:        NewMenuStore(client),
+		Tables:       NewTableStore(client),
+		Reservations: NewReservationStore(client),
+		Orders:       NewOrderStore(client),
+		OrderItems:   NewOrderItemStore(client),
+		Invoices:     NewInvoiceStore(client),
+		Payments:     NewPaymentStore(client),
+		CreditNotes:  NewCreditNoteStore(client),
+		Ingredients:  NewIngredientStore(client),
+		Recipes:      NewRecipeStore(client),
+		PriceRules:   NewPriceRuleStore(client),
+		Vouchers:     NewVoucherStore(client),
+		Users:        NewUserStore(client),
+	}
+}
+
+func findOne(err error) error {
+	if err == mongo.ErrNoDocuments {
+		return store.ErrNotFound
+	}
+	return err
+}
+
+func updateResult(result *mongo.UpdateResult) (store.UpdateResult, error) {
+	if result.MatchedCount == 0 {
+		return store.UpdateResult{}, store.ErrNotFound
+	}
+	return store.UpdateResult{
+		MatchedCount:  result.MatchedCount,
+		ModifiedCount: result.ModifiedCount,
+		UpsertedCount: result.UpsertedCount,
+		UpsertedID:    result.UpsertedID,
+	}, nil
+}
@@ -0,0 +1,65 @@
+package database
+
+import (
+	"context"
+	"restaurant-management/models"
+	"restaurant-management/store"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type TableStore struct {
+	collection *mongo.Collection
+}
+
+func NewTableStore(client *mongo.Client) *TableStore {
+	return &TableStore{collection: OpenCollection(client, "table")}
+}
+
+func (s *TableStore) List(ctx context.Context) ([]models.Table, error) {
+	cursor, err := s.collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	tables := []models.Table{}
+	if err := cursor.All(ctx, &tables); err != nil {
+		return nil, err
+	}
+	return tables, nil
+}
+
+func (s *TableStore) Find(ctx context.Context, tableID string) (models.Table, error) {
+	var table models.Table
+	err := s.collection.FindOne(ctx, bson.M{"table_id": tableID}).Decode(&table)
+	return table, findOne(err)
+}
+
+func (s *TableStore) Insert(ctx context.Context, table models.Table) error {
+	_, err := s.collection.InsertOne(ctx, table)
+	return err
+}
+
+func (s *TableStore) Update(ctx context.Context, tableID string, table models.Table) (store.UpdateResult, error) {
+	updateObj := bson.D{{Key: "updated_at", Value: time.Now().UTC()}}
+
+	if table.NumberOfGuests != nil {
+		updateObj = append(updateObj, bson.E{Key: "number_of_guests", Value: *table.NumberOfGuests})
+	}
+	if table.TableNumber != nil {
+		updateObj = append(updateObj, bson.E{Key: "table_number", Value: *table.TableNumber})
+	}
+
+	result, err := s.collection.UpdateOne(
+		ctx,
+		bson.M{"table_id": tableID},
+		bson.D{{Key: "$set", Value: updateObj}},
+	)
+	if err != nil {
+		return store.UpdateResult{}, err
+	}
+	return updateResult(result)
+}
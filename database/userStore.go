@@ -0,0 +1,157 @@
+package database
+
+import (
+	"context"
+	"restaurant-management/models"
+	"restaurant-management/store"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type UserStore struct {
+	collection *mongo.Collection
+	// bootstrap holds one-off markers such as the claim on the first admin
+	// account, keyed by name.
+	bootstrap *mongo.Collection
+}
+
+func NewUserStore(client *mongo.Client) *UserStore {
+	return &UserStore{
+		collection: OpenCollection(client, "user"),
+		bootstrap:  OpenCollection(client, "bootstrap"),
+	}
+}
+
+func (s *UserStore) List(ctx context.Context, startIndex, recordPerPage int) (int, []models.User, error) {
+	total, err := s.collection.CountDocuments(ctx, bson.M{})
+	if err != nil {
+		return 0, nil, err
+	}
+
+	opts := options.Find().SetSkip(int64(startIndex)).SetLimit(int64(recordPerPage))
+	cursor, err := s.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer cursor.Close(ctx)
+
+	users := []models.User{}
+	if err := cursor.All(ctx, &users); err != nil {
+		return 0, nil, err
+	}
+	return int(total), users, nil
+}
+
+func (s *UserStore) Find(ctx context.Context, userID string) (models.User, error) {
+	var user models.User
+	err := s.collection.FindOne(ctx, bson.M{"user_id": userID}).Decode(&user)
+	return user, findOne(err)
+}
+
+func (s *UserStore) FindByEmail(ctx context.Context, email string) (models.User, error) {
+	var user models.User
+	err := s.collection.FindOne(ctx, bson.M{"email": email}).Decode(&user)
+	return user, findOne(err)
+}
+
+func (s *UserStore) CountByEmail(ctx context.Context, email string) (int64, error) {
+	return s.collection.CountDocuments(ctx, bson.M{"email": email})
+}
+
+func (s *UserStore) CountByPhone(ctx context.Context, phone string) (int64, error) {
+	return s.collection.CountDocuments(ctx, bson.M{"phone": phone})
+}
+
+func (s *UserStore) Insert(ctx context.Context, user models.User) error {
+	_, err := s.collection.InsertOne(ctx, user)
+	return err
+}
+
+func (s *UserStore) UpdateTokens(ctx context.Context, userID, token, refreshToken string) error {
+	updateObj := bson.D{
+		{Key: "token", Value: token},
+		{Key: "refresh_token", Value: refreshToken},
+		{Key: "updated_at", Value: time.Now().UTC()},
+	}
+
+	_, err := s.collection.UpdateOne(
+		ctx,
+		bson.M{"user_id": userID},
+		bson.D{{Key: "$set", Value: updateObj}},
+	)
+	return err
+}
+
+func (s *UserStore) UpdateRole(ctx context.Context, userID, role string) (store.UpdateResult, error) {
+	updateObj := bson.D{
+		{Key: "role", Value: role},
+		{Key: "updated_at", Value: time.Now().UTC()},
+	}
+
+	result, err := s.collection.UpdateOne(
+		ctx,
+		bson.M{"user_id": userID},
+		bson.D{{Key: "$set", Value: updateObj}},
+	)
+	if err != nil {
+		return store.UpdateResult{}, err
+	}
+	return updateResult(result)
+}
+
+func (s *UserStore) RotateTokens(ctx context.Context, userID, currentRefreshToken, token, refreshToken string) error {
+	updateObj := bson.D{
+		{Key: "token", Value: token},
+		{Key: "refresh_token", Value: refreshToken},
+		{Key: "updated_at", Value: time.Now().UTC()},
+	}
+
+	result, err := s.collection.UpdateOne(
+		ctx,
+		bson.M{"user_id": userID, "refresh_token": currentRefreshToken},
+		bson.D{{Key: "$set", Value: updateObj}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		if _, err := s.Find(ctx, userID); err != nil {
+			return err
+		}
+		return store.ErrConflict
+	}
+	return nil
+}
+
+// ClaimFirstAdmin upserts the first_admin marker, setting its user only when
+// it is created. The unique _id makes the claim atomic: a racing claim either
+// finds the marker already there or fails as a duplicate, and the marker
+// then names the winner.
+func (s *UserStore) ClaimFirstAdmin(ctx context.Context, userID string) error {
+	var marker struct {
+		UserID string `bson:"user_id"`
+	}
+	err := s.bootstrap.FindOneAndUpdate(ctx,
+		bson.M{"_id": "first_admin"},
+		bson.M{"$setOnInsert": bson.M{"user_id": userID}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&marker)
+	if mongo.IsDuplicateKeyError(err) {
+		return store.ErrConflict
+	}
+	if err != nil {
+		return err
+	}
+	if marker.UserID != userID {
+		return store.ErrConflict
+	}
+	return nil
+}
+
+func (s *UserStore) ReleaseFirstAdmin(ctx context.Context, userID string) error {
+	_, err := s.bootstrap.DeleteOne(ctx, bson.M{"_id": "first_admin", "user_id": userID})
+	return err
+}
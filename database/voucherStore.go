@@ -0,0 +1,169 @@
+package database
+
+import (
+	"context"
+	"log"
+	"restaurant-management/models"
+	"restaurant-management/store"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type VoucherStore struct {
+	collection  *mongo.Collection
+	redemptions *mongo.Collection
+}
+
+func NewVoucherStore(client *mongo.Client) *VoucherStore {
+	return &VoucherStore{
+		collection:  OpenCollection(client, "voucher"),
+		redemptions: OpenCollection(client, "voucher_redemption"),
+	}
+}
+
+func (s *VoucherStore) List(ctx context.Context) ([]models.Voucher, error) {
+	cursor, err := s.collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	vouchers := []models.Voucher{}
+	if err := cursor.All(ctx, &vouchers); err != nil {
+		return nil, err
+	}
+	return vouchers, nil
+}
+
+func (s *VoucherStore) Find(ctx context.Context, voucherID string) (models.Voucher, error) {
+	var voucher models.Voucher
+	err := s.collection.FindOne(ctx, bson.M{"voucher_id": voucherID}).Decode(&voucher)
+	return voucher, findOne(err)
+}
+
+func (s *VoucherStore) FindByCode(ctx context.Context, code string) (models.Voucher, error) {
+	var voucher models.Voucher
+	err := s.collection.FindOne(ctx, bson.M{"code": code}).Decode(&voucher)
+	return voucher, findOne(err)
+}
+
+// Insert stores the voucher and then looks for another with the same code,
+// taking its own back out if there is one, the way InvoiceStore.InsertMany
+// keeps an order to one bill.
+func (s *VoucherStore) Insert(ctx context.Context, voucher models.Voucher) error {
+	if _, err := s.collection.InsertOne(ctx, voucher); err != nil {
+		return err
+	}
+
+	count, err := s.collection.CountDocuments(ctx, bson.M{
+		"code":       voucher.Code,
+		"voucher_id": bson.M{"$ne": voucher.VoucherID},
+	})
+	if err == nil && count == 0 {
+		return nil
+	}
+	if err == nil {
+		err = store.ErrConflict
+	}
+	if _, deleteErr := s.collection.DeleteOne(ctx, bson.M{"voucher_id": voucher.VoucherID}); deleteErr != nil {
+		return deleteErr
+	}
+	return err
+}
+
+func (s *VoucherStore) Update(ctx context.Context, voucherID string, voucher models.Voucher) (store.UpdateResult, error) {
+	updateObj := bson.D{{Key: "updated_at", Value: time.Now().UTC()}}
+
+	if voucher.ExpiresAt != nil {
+		updateObj = append(updateObj, bson.E{Key: "expires_at", Value: *voucher.ExpiresAt})
+	}
+	if voucher.MaxRedemptions != nil {
+		updateObj = append(updateObj, bson.E{Key: "max_redemptions", Value: *voucher.MaxRedemptions})
+	}
+
+	result, err := s.collection.UpdateOne(
+		ctx,
+		bson.M{"voucher_id": voucherID},
+		bson.D{{Key: "$set", Value: updateObj}},
+	)
+	if err != nil {
+		return store.UpdateResult{}, err
+	}
+	return updateResult(result)
+}
+
+// Redeem counts the redemption with one conditional increment, so the limit
+// holds however many redeem at once, and then records it. If the order
+// turns out to have another voucher applied, the redemption is taken back
+// out again.
+func (s *VoucherStore) Redeem(ctx context.Context, redemption models.VoucherRedemption) error {
+	result, err := s.collection.UpdateOne(ctx,
+		bson.M{
+			"voucher_id": redemption.VoucherID,
+			"$or": bson.A{
+				bson.M{"max_redemptions": nil},
+				bson.M{"$expr": bson.M{"$lt": bson.A{"$redemptions", "$max_redemptions"}}},
+			},
+		},
+		bson.M{"$inc": bson.M{"redemptions": 1}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		if _, err := s.Find(ctx, redemption.VoucherID); err != nil {
+			return err
+		}
+		return store.ErrConflict
+	}
+
+	if _, err = s.redemptions.InsertOne(ctx, redemption); err == nil {
+		var count int64
+		count, err = s.redemptions.CountDocuments(ctx, bson.M{
+			"order_id":      redemption.OrderID,
+			"released_at":   nil,
+			"redemption_id": bson.M{"$ne": redemption.RedemptionID},
+		})
+		if err == nil && count == 0 {
+			return nil
+		}
+		if err == nil {
+			err = store.ErrConflict
+		}
+		if _, deleteErr := s.redemptions.DeleteOne(ctx, bson.M{"redemption_id": redemption.RedemptionID}); deleteErr != nil {
+			log.Printf("redemption %s of voucher %s could not be taken back out: %v", redemption.RedemptionID, redemption.VoucherID, deleteErr)
+			return err
+		}
+	}
+	s.uncount(ctx, redemption.VoucherID)
+	return err
+}
+
+func (s *VoucherStore) Release(ctx context.Context, orderID string, releasedAt time.Time) error {
+	var redemption models.VoucherRedemption
+	err := s.redemptions.FindOneAndUpdate(ctx,
+		bson.M{"order_id": orderID, "released_at": nil},
+		bson.M{"$set": bson.M{"released_at": releasedAt}},
+	).Decode(&redemption)
+	if err != nil {
+		return findOne(err)
+	}
+	s.uncount(ctx, redemption.VoucherID)
+	return nil
+}
+
+// uncount stops counting one redemption against voucherID.
+func (s *VoucherStore) uncount(ctx context.Context, voucherID string) {
+	_, err := s.collection.UpdateOne(ctx, bson.M{"voucher_id": voucherID}, bson.M{"$inc": bson.M{"redemptions": -1}})
+	if err != nil {
+		log.Printf("redemptions of voucher %s are one too many: %v", voucherID, err)
+	}
+}
+
+func (s *VoucherStore) RedemptionByOrder(ctx context.Context, orderID string) (models.VoucherRedemption, error) {
+	var redemption models.VoucherRedemption
+	err := s.redemptions.FindOne(ctx, bson.M{"order_id": orderID, "released_at": nil}).Decode(&redemption)
+	return redemption, findOne(err)
+}
@@ -0,0 +1,172 @@
+package gateway
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"restaurant-management/models"
+	"sync"
+)
+
+// Card sources the fake provider declines. Every other source is approved.
+const (
+	FakeSourceDeclined          = "tok_declined"
+	FakeSourceInsufficientFunds = "tok_insufficient_funds"
+)
+
+// Fake is an offline provider for development and demos. It never talks to
+// a network and its references are derived from its inputs, so the same
+// requests always produce the same transactions. It keeps its transactions in
+// memory and forgets them on restart.
+type Fake struct {
+	secret []byte
+
+	mu           sync.Mutex
+	transactions map[string]*fakeTransaction
+}
+
+type fakeTransaction struct {
+	Transaction
+	kind string
+	// parent is the authorization a capture was taken from, or the capture
+	// a refund was made against.
+	parent string
+	// settled is how much has been captured from an authorization or
+	// refunded from a capture.
+	settled int64
+}
+
+const (
+	fakeAuthorization = "authorization"
+	fakeCapture       = "capture"
+	fakeRefund        = "refund"
+)
+
+// NewFake returns a fake provider whose webhooks are signed with secret.
+func NewFake(secret string) *Fake {
+	return &Fake{secret: []byte(secret), transactions: map[string]*fakeTransaction{}}
+}
+
+func (f *Fake) Name() string {
+	return "fake"
+}
+
+// reference derives a transaction reference from the inputs that created it.
+func (f *Fake) reference(prefix string, parts ...string) string {
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return prefix + hex.EncodeToString(h.Sum(nil)[:12])
+}
+
+func (f *Fake) Authorize(ctx context.Context, req AuthorizeRequest) (Transaction, error) {
+	if req.Source == FakeSourceDeclined || req.Source == FakeSourceInsufficientFunds {
+		return Transaction{}, ErrDeclined
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	reference := f.reference("fake_auth_", req.IdempotencyKey)
+	if existing, ok := f.transactions[reference]; ok {
+		return existing.Transaction, nil
+	}
+	t := &fakeTransaction{Transaction: Transaction{Reference: reference, Status: StatusAuthorized, Amount: req.Amount}, kind: fakeAuthorization}
+	f.transactions[reference] = t
+	return t.Transaction, nil
+}
+
+func (f *Fake) Capture(ctx context.Context, authorization string, amount models.Money) (Transaction, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	auth, ok := f.transactions[authorization]
+	if !ok || auth.kind != fakeAuthorization {
+		return Transaction{}, ErrUnknownTransaction
+	}
+	if auth.Status != StatusAuthorized || auth.settled > 0 || amount.Currency != auth.Amount.Currency || amount.Amount > auth.Amount.Amount {
+		return Transaction{}, ErrInvalidState
+	}
+
+	auth.settled = amount.Amount
+	auth.Status = StatusCaptured
+	t := &fakeTransaction{
+		Transaction: Transaction{Reference: f.reference("fake_cap_", authorization), Status: StatusCaptured, Amount: amount},
+		kind:        fakeCapture,
+		parent:      authorization,
+	}
+	f.transactions[t.Reference] = t
+	return t.Transaction, nil
+}
+
+func (f *Fake) Void(ctx context.Context, authorization string) (Transaction, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	auth, ok := f.transactions[authorization]
+	if !ok || auth.kind != fakeAuthorization {
+		return Transaction{}, ErrUnknownTransaction
+	}
+	if auth.Status != StatusAuthorized {
+		return Transaction{}, ErrInvalidState
+	}
+	auth.Status = StatusVoided
+	return auth.Transaction, nil
+}
+
+func (f *Fake) Refund(ctx context.Context, capture string, amount models.Money) (Transaction, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	captured, ok := f.transactions[capture]
+	if !ok || captured.kind != fakeCapture {
+		return Transaction{}, ErrUnknownTransaction
+	}
+	if amount.Amount <= 0 || amount.Currency != captured.Amount.Currency || captured.settled+amount.Amount > captured.Amount.Amount {
+		return Transaction{}, ErrInvalidState
+	}
+
+	// The running total keeps successive partial refunds of one capture
+	// distinct while staying reproducible.
+	captured.settled += amount.Amount
+	if captured.settled == captured.Amount.Amount {
+		captured.Status = StatusRefunded
+	}
+	t := &fakeTransaction{
+		Transaction: Transaction{Reference: f.reference("fake_ref_", capture, amount.Decimal(), models.NewMoney(captured.settled, amount.Currency).Decimal()), Status: StatusRefunded, Amount: amount},
+		kind:        fakeRefund,
+		parent:      capture,
+	}
+	f.transactions[t.Reference] = t
+	return t.Transaction, nil
+}
+
+// Sign returns the signature the fake provider sends with payload, so
+// webhooks can be simulated locally.
+func (f *Fake) Sign(payload []byte) string {
+	mac := hmac.New(sha256.New, f.secret)
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (f *Fake) VerifyWebhook(payload []byte, signature string) (Event, error) {
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return Event{}, ErrBadSignature
+	}
+	mac := hmac.New(sha256.New, f.secret)
+	mac.Write(payload)
+	if !hmac.Equal(mac.Sum(nil), expected) {
+		return Event{}, ErrBadSignature
+	}
+
+	var event Event
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return Event{}, err
+	}
+	return event, nil
+}
@@ -0,0 +1,89 @@
+// Package gateway talks to the card payment provider. Card tenders are
+// authorized and then captured through a Provider before a payment is
+// recorded, and reversed through it when the payment cannot be.
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"restaurant-management/models"
+)
+
+const (
+	StatusAuthorized = "AUTHORIZED"
+	StatusCaptured   = "CAPTURED"
+	StatusVoided     = "VOIDED"
+	StatusRefunded   = "REFUNDED"
+)
+
+var (
+	// ErrDeclined is returned when the card issuer refuses an authorization.
+	ErrDeclined = errors.New("card declined")
+	// ErrUnknownTransaction is returned for a reference the provider never issued.
+	ErrUnknownTransaction = errors.New("unknown transaction")
+	// ErrInvalidState is returned when a transaction cannot move to the
+	// requested state, such as capturing a voided authorization or refunding
+	// more than was captured.
+	ErrInvalidState = errors.New("transaction is not in a state that allows this")
+	// ErrBadSignature is returned when a webhook's signature does not match.
+	ErrBadSignature = errors.New("webhook signature does not match")
+)
+
+// Provider is a card payment provider.
+type Provider interface {
+	// Name identifies the provider on the payments it settled.
+	Name() string
+	// Authorize reserves amount on the card behind source. Retrying with the
+	// same idempotency key returns the original authorization.
+	Authorize(ctx context.Context, req AuthorizeRequest) (Transaction, error)
+	// Capture takes up to the authorized amount from an authorization.
+	Capture(ctx context.Context, authorization string, amount models.Money) (Transaction, error)
+	// Void releases an authorization that has not been captured.
+	Void(ctx context.Context, authorization string) (Transaction, error)
+	// Refund returns up to the captured amount of a capture to the card.
+	Refund(ctx context.Context, capture string, amount models.Money) (Transaction, error)
+	// VerifyWebhook checks that payload was sent by the provider and decodes
+	// the event it carries.
+	VerifyWebhook(payload []byte, signature string) (Event, error)
+}
+
+type AuthorizeRequest struct {
+	Amount models.Money
+	// Source is the provider's token for the card, as returned by its
+	// terminal or hosted card form.
+	Source         string
+	IdempotencyKey string
+}
+
+// Transaction is the provider's record of one authorization, capture, void or
+// refund.
+type Transaction struct {
+	Reference string
+	Status    string
+	Amount    models.Money
+}
+
+// Event is a notification the provider sent about one of its transactions.
+type Event struct {
+	Type      string       `json:"type"`
+	Reference string       `json:"reference"`
+	Amount    models.Money `json:"amount"`
+}
+
+// FromEnv returns the provider named by PAYMENT_PROVIDER. Only the offline
+// "fake" provider exists so far, and it is the default; its webhooks are
+// signed with PAYMENT_WEBHOOK_SECRET.
+func FromEnv() (Provider, error) {
+	switch name := os.Getenv("PAYMENT_PROVIDER"); name {
+	case "", "fake":
+		secret := os.Getenv("PAYMENT_WEBHOOK_SECRET")
+		if secret == "" {
+			secret = "fake-webhook-secret"
+		}
+		return NewFake(secret), nil
+	default:
+		return nil, fmt.Errorf("PAYMENT_PROVIDER: unknown provider %q", name)
+	}
+}
@@ -2,16 +2,18 @@ package helpers
 
 import (
 	"context"
-	"log"
+	"crypto/rand"
+	"encoding/hex"
 	"os"
-	"restaurant-management/database"
+	"restaurant-management/store"
 	"time"
 
 	jwt "github.com/dgrijalva/jwt-go"
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	AccessToken  = "access"
+	RefreshToken = "refresh"
 )
 
 type SignedDetails struct {
@@ -19,35 +21,65 @@ type SignedDetails struct {
 	First_name string
 	Last_name  string
 	Uid        string
+	Role       string
+	TokenType  string
 	jwt.StandardClaims
 }
 
-var userCollection *mongo.Collection = database.OpenCollection(database.Client, "user")
+// secretKey is read on every call rather than at package init so that the
+// value loaded from .env in main is picked up.
+func secretKey() []byte {
+	return []byte(os.Getenv("SECRET_KEY"))
+}
 
-var SECRET_KEY string = os.Getenv("SECRET_KEY")
+// tokenID gives every token a unique jti, so two tokens minted for the same
+// user in the same second still differ and reuse can be told apart.
+func tokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func GenerateAllTokens(email string, firstName string, lastName string, uid string, role string) (signedToken string, signedRefreshToken string, err error) {
+	accessID, err := tokenID()
+	if err != nil {
+		return
+	}
+	refreshID, err := tokenID()
+	if err != nil {
+		return
+	}
 
-func GenerateAllTokens(email string, firstName string, lastName string, uid string) (signedToken string, signedRefreshToken string, err error) {
 	claims := &SignedDetails{
 		Email:      email,
 		First_name: firstName,
 		Last_name:  lastName,
 		Uid:        uid,
+		Role:       role,
+		TokenType:  AccessToken,
 		StandardClaims: jwt.StandardClaims{
+			Id:        accessID,
 			ExpiresAt: time.Now().Local().Add(time.Hour * time.Duration(24)).Unix(),
 		},
 	}
 
 	refreshClaims := &SignedDetails{
+		Uid:       uid,
+		TokenType: RefreshToken,
 		StandardClaims: jwt.StandardClaims{
+			Id:        refreshID,
 			ExpiresAt: time.Now().Local().Add(time.Hour * time.Duration(168)).Unix(),
 		},
 	}
 
-	token, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(SECRET_KEY))
-	refreshToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims).SignedString([]byte(SECRET_KEY))
-
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(secretKey())
+	if err != nil {
+		return
+	}
+	refreshToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims).SignedString(secretKey())
 	if err != nil {
-		log.Panic(err)
 		return
 	}
 
@@ -55,38 +87,12 @@ func GenerateAllTokens(email string, firstName string, lastName string, uid stri
 
 }
 
-func UpdateAllTokens(signedToken string, signedRefreshToken string, userId string) {
+func UpdateAllTokens(users store.UserStore, signedToken string, signedRefreshToken string, userId string) error {
 
 	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
-
-	var updateObj primitive.D
-
-	updateObj = append(updateObj, bson.E{Key: "token", Value: signedToken})
-	updateObj = append(updateObj, bson.E{Key: "refresh_token", Value: signedRefreshToken})
-
-	Updated_at, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
-	updateObj = append(updateObj, bson.E{Key: "updated_at", Value: Updated_at})
-
-	upsert := true
-	filter := bson.M{"user_id": userId}
-	opt := options.UpdateOptions{
-		Upsert: &upsert,
-	}
-
-	_, err := userCollection.UpdateOne(
-		ctx,
-		filter,
-		bson.D{
-			{Key: "$set", Value: updateObj},
-		},
-		&opt,
-	)
 	defer cancel()
 
-	if err != nil {
-		log.Panic(err)
-		return
-	}
+	return users.UpdateTokens(ctx, userId, signedToken, signedRefreshToken)
 
 }
 
@@ -96,21 +102,25 @@ func ValidateToken(signedToken string) (claims *SignedDetails, msg string) {
 		signedToken,
 		&SignedDetails{},
 		func(token *jwt.Token) (interface{}, error) {
-			return []byte(SECRET_KEY), nil
+			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, jwt.ErrSignatureInvalid
+			}
+			return secretKey(), nil
 		},
 	)
-
+	if err != nil {
+		msg = err.Error()
+		return
+	}
 
 	claims, ok := token.Claims.(*SignedDetails)
-	if !ok {
+	if !ok || !token.Valid {
 		msg = "the token is invalid"
-		msg = err.Error()
 		return
 	}
 
 	if claims.ExpiresAt < time.Now().Local().Unix() {
 		msg = "token is expired"
-		msg = err.Error()
 		return
 	}
 
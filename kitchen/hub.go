@@ -0,0 +1,58 @@
+// Package kitchen fans order events out to kitchen display screens.
+package kitchen
+
+import "sync"
+
+const (
+	EventOrderItems      = "order_items"
+	EventOrderStatus     = "order_status"
+	EventOrderItemVoided = "order_item_voided"
+)
+
+// Event is one message on the kitchen stream; Name becomes the SSE event name.
+type Event struct {
+	Name   string
+	Ticket Ticket
+}
+
+// Hub broadcasts events to every subscribed stream. A subscriber that falls
+// behind loses events rather than blocking the request that published them.
+type Hub struct {
+	mu          sync.Mutex
+	subscribers map[chan Event]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{subscribers: map[chan Event]struct{}{}}
+}
+
+func (h *Hub) Subscribe() chan Event {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ch := make(chan Event, 32)
+	h.subscribers[ch] = struct{}{}
+	return ch
+}
+
+func (h *Hub) Unsubscribe(ch chan Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.subscribers[ch]; ok {
+		delete(h.subscribers, ch)
+		close(ch)
+	}
+}
+
+func (h *Hub) Publish(event Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for ch := range h.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
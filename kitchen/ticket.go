@@ -0,0 +1,43 @@
+package kitchen
+
+// Ticket is what the kitchen sees for an order: the items grouped under their
+// order and table number, the same grouping ItemsByOrder uses.
+type Ticket struct {
+	OrderID     string       `json:"order_id"`
+	TableNumber *int         `json:"table_number"`
+	Status      string       `json:"status,omitempty"`
+	Items       []TicketItem `json:"items,omitempty"`
+}
+
+type TicketItem struct {
+	OrderItemID string  `json:"order_item_id"`
+	FoodID      *string `json:"food_id"`
+	FoodName    *string `json:"food_name"`
+	Station     string  `json:"station"`
+	Quantity    int     `json:"quantity"`
+	Size        *string `json:"size"`
+	// Modifiers are the options chosen for the item, each as "group:
+	// option", and Notes what else the kitchen was told about it.
+	Modifiers []string `json:"modifiers,omitempty"`
+	Notes     *string  `json:"notes,omitempty"`
+	// Voided is set on tickets telling the kitchen to stop making the item.
+	Voided bool `json:"voided,omitempty"`
+}
+
+// ForStation narrows the ticket to the items prepared at station. Tickets
+// without items (status changes) are relevant to every station; a ticket
+// whose items all belong elsewhere is not.
+func (t Ticket) ForStation(station string) (Ticket, bool) {
+	if station == "" || len(t.Items) == 0 {
+		return t, true
+	}
+
+	items := []TicketItem{}
+	for _, item := range t.Items {
+		if item.Station == station {
+			items = append(items, item)
+		}
+	}
+	t.Items = items
+	return t, len(items) > 0
+}
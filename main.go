@@ -1,10 +1,22 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"log"
 	"os"
+	"restaurant-management/database"
+	"restaurant-management/database/memory"
+	"restaurant-management/database/sqldb"
+	"restaurant-management/gateway"
+	"restaurant-management/kitchen"
 	"restaurant-management/middleware"
+	"restaurant-management/models"
+	"restaurant-management/pricing"
+	"restaurant-management/printer"
+	"restaurant-management/receipt"
 	"restaurant-management/routes"
+	"restaurant-management/store"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
@@ -12,8 +24,11 @@ import (
 
 func main() {
 
+	backend := flag.String("store", "mongo", "storage backend: mongo, memory, sqlite or postgres")
+	flag.Parse()
+
 	err := godotenv.Load()
-	if err != nil {
+	if err != nil && *backend == "mongo" {
 		log.Fatal("Could not load .env file")
 	}
 
@@ -23,19 +38,85 @@ func main() {
 		port = "8080"
 	}
 
+	var stores store.Stores
+	switch *backend {
+	case "mongo":
+		client := database.DBinstance()
+		if err := database.MigrateMoney(context.Background(), client); err != nil {
+			log.Fatal("MongoDB migration error: ", err)
+		}
+		if err := database.MigrateQuantities(context.Background(), client); err != nil {
+			log.Fatal("MongoDB migration error: ", err)
+		}
+		stores = database.NewStores(client)
+	case "memory":
+		stores = memory.NewStores()
+	case "sqlite", "postgres":
+		dsn := os.Getenv("DATABASE_URL")
+		if dsn == "" && *backend == "sqlite" {
+			dsn = "restaurant.db"
+		}
+		db, err := sqldb.Open(context.Background(), *backend, dsn)
+		if err != nil {
+			log.Fatal("SQL connection error: ", err)
+		}
+		stores = sqldb.NewStores(db)
+	default:
+		log.Fatalf("unknown store %q", *backend)
+	}
+
+	prices, err := pricing.ConfigFromEnv()
+	if err != nil {
+		log.Fatal("pricing configuration error: ", err)
+	}
+
+	menuLocation, err := models.MenuLocationFromEnv()
+	if err != nil {
+		log.Fatal("menu configuration error: ", err)
+	}
+
+	numbering, err := models.InvoiceNumberingFromEnv()
+	if err != nil {
+		log.Fatal("invoice numbering configuration error: ", err)
+	}
+
+	provider, err := gateway.FromEnv()
+	if err != nil {
+		log.Fatal("payment provider configuration error: ", err)
+	}
+
+	receipts, err := receipt.ConfigFromEnv()
+	if err != nil {
+		log.Fatal("receipt configuration error: ", err)
+	}
+
+	printers, err := printer.FromEnv()
+	if err != nil {
+		log.Fatal("printer configuration error: ", err)
+	}
+
+	hub := kitchen.NewHub()
+
 	router := gin.New()
 	router.Use(gin.Logger())
 
-	routes.UserRoutes(router)
+	routes.UserRoutes(router, stores)
+	routes.PaymentRoutes(router, provider)
 
-	router.Use(middleware.Authentication())
+	router.Use(middleware.Authentication(stores.Users))
 
-	routes.FoodRoutes(router)
-	routes.MenuRoutes(router)
-	routes.TableRoutes(router)
-	routes.OrderRoutes(router)
-	routes.OrderItemRoutes(router)
-	routes.InvoiceRoutes(router)
+	routes.FoodRoutes(router, stores, menuLocation)
+	routes.MenuRoutes(router, stores, menuLocation)
+	routes.PriceRuleRoutes(router, stores)
+	routes.TableRoutes(router, stores)
+	routes.ReservationRoutes(router, stores)
+	routes.OrderRoutes(router, stores, hub)
+	routes.OrderItemRoutes(router, stores, hub, printers, menuLocation)
+	routes.InvoiceRoutes(router, stores, prices, provider, receipts, printers, numbering)
+	routes.VoucherRoutes(router, stores)
+	routes.ReportRoutes(router, stores, menuLocation)
+	routes.InventoryRoutes(router, stores)
+	routes.KitchenRoutes(router, hub)
 
 	router.Run(":" + port)
 
@@ -1,24 +1,53 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 	"restaurant-management/helpers"
+	"restaurant-management/store"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-func Authentication() gin.HandlerFunc {
+// Authentication accepts a request only if its token is validly signed and
+// is still the access token stored for the user; logging out or refreshing
+// replaces the stored token, which revokes the old one. The role is the one
+// stored for the user, not the one in the token, so a role change takes
+// effect on the user's next request.
+func Authentication(users store.UserStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		clientToken := c.Request.Header.Get("token")
 		if clientToken == "" {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "No Authorization header provided"})
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "No Authorization header provided"})
 			c.Abort()
 			return
 		}
 
 		claims, err := helpers.ValidateToken(clientToken)
 		if err != "" {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err})
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err})
+			c.Abort()
+			return
+		}
+
+		if claims.TokenType == helpers.RefreshToken {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "a refresh token cannot be used to authenticate requests"})
+			c.Abort()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		user, findErr := users.Find(ctx, claims.Uid)
+		if findErr != nil && findErr != store.ErrNotFound {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occured while checking the token"})
+			c.Abort()
+			return
+		}
+		if findErr == store.ErrNotFound || user.Token == nil || *user.Token != clientToken {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "token has been revoked"})
 			c.Abort()
 			return
 		}
@@ -27,7 +56,25 @@ func Authentication() gin.HandlerFunc {
 		c.Set("first_name", claims.First_name)
 		c.Set("last_name", claims.Last_name)
 		c.Set("uid", claims.Uid)
+		c.Set("role", user.CurrentRole())
 
 		c.Next()
 	}
 }
+
+// Authorize lets the request through only if the role set by Authentication
+// is one of roles. It must run after Authentication.
+func Authorize(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("role")
+		for _, allowed := range roles {
+			if role == allowed {
+				c.Next()
+				return
+			}
+		}
+
+		c.JSON(http.StatusForbidden, gin.H{"error": "your role is not allowed to perform this action"})
+		c.Abort()
+	}
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	CreditNoteRefund = "REFUND"
+	CreditNoteVoid   = "VOID"
+)
+
+// CreditNote reverses all or part of an invoice. The invoice itself is never
+// changed beyond its running totals and status; what was refunded or voided,
+// and how the money went back, is recorded here.
+type CreditNote struct {
+	ID           primitive.ObjectID `bson:"_id"`
+	CreditNoteID string             `json:"credit_note_id" bson:"credit_note_id"`
+	InvoiceID    string             `json:"invoice_id" bson:"invoice_id"`
+	Kind         string             `json:"kind" bson:"kind"`
+	Reason       string             `json:"reason" bson:"reason"`
+	// Lines are the invoice lines credited, when the credit was for
+	// particular items.
+	Lines []InvoiceLine `json:"lines" bson:"lines"`
+	// Amount is the value of the invoice credited; Refunds are the money
+	// handed back, which is less when the invoice was not fully paid.
+	Amount    Money     `json:"amount" bson:"amount"`
+	Refunds   []Refund  `json:"refunds" bson:"refunds"`
+	IssuedBy  string    `json:"issued_by" bson:"issued_by"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+}
+
+// Refund is money handed back to the customer for a credit note. Card refunds
+// go back to the capture they were paid with.
+type Refund struct {
+	Method    string  `json:"method" bson:"method"`
+	Amount    Money   `json:"amount" bson:"amount"`
+	Provider  *string `json:"provider" bson:"provider"`
+	Capture   *string `json:"capture" bson:"capture"`
+	Reference *string `json:"reference" bson:"reference"`
+}
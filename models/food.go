@@ -1,18 +1,127 @@
 package models
 
 import (
+	"errors"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Tax categories a food can be assigned. A food without one is taxed as
+// TaxStandard.
+const (
+	TaxStandard = "standard"
+	TaxReduced  = "reduced"
+	TaxZero     = "zero"
+)
+
+// Why a food is unavailable. Foods taken off because an ingredient ran out
+// come back on their own once it is restocked; foods taken off by hand stay
+// off until someone puts them back.
+const (
+	FoodOutOfStock     = "OUT_OF_STOCK"
+	FoodTakenOffByHand = "MANUAL"
+)
+
+// Portion sizes a food can be ordered in.
+const (
+	SizeSmall  = "S"
+	SizeMedium = "M"
+	SizeLarge  = "L"
+)
+
+// ErrSizeNotOffered is returned when a food is ordered in a size it does not
+// come in.
+var ErrSizeNotOffered = errors.New("food does not come in that size")
+
 type Food struct {
-	ID        primitive.ObjectID `bson:"_id"`
-	Name      *string            `json:"name" validate:"required,min=2,max=100"`
-	Price     *float64           `json:"price" validate:"required"`
-	FoodImage *string            `json:"food_image" validate:"required"`
-	CreatedAt time.Time          `json:"created_at"`
-	UpdatedAt time.Time          `json:"updated_at"`
-	FoodID    string             `json:"food_id"`
-	MenuID    *string            `json:"menu_id"`
+	ID          primitive.ObjectID `bson:"_id"`
+	Name        *string            `json:"name" bson:"name" validate:"required,min=2,max=100"`
+	Price       *Money             `json:"price" bson:"price" validate:"required"`
+	FoodImage   *string            `json:"food_image" bson:"food_image" validate:"required"`
+	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at" bson:"updated_at"`
+	FoodID      string             `json:"food_id" bson:"food_id"`
+	MenuID      *string            `json:"menu_id" bson:"menu_id"`
+	Station     *string            `json:"station" bson:"station" validate:"omitempty,oneof=grill bar cold"`
+	TaxCategory *string            `json:"tax_category" bson:"tax_category" validate:"omitempty,oneof=standard reduced zero"`
+	// Available is false while the food cannot be ordered, and
+	// UnavailableReason then says why. Foods created before availability
+	// was tracked have neither and are available.
+	Available         *bool   `json:"available" bson:"available"`
+	UnavailableReason *string `json:"unavailable_reason" bson:"unavailable_reason"`
+	// ModifierGroups are the choices offered with the food, in the order
+	// they are asked.
+	ModifierGroups []ModifierGroup `json:"modifier_groups" bson:"modifier_groups" validate:"dive"`
+	// SizePrices are the sizes the food comes in and what each costs. A
+	// food without them is sold at Price whatever size is asked for.
+	SizePrices []SizePrice `json:"size_prices" bson:"size_prices" validate:"dive"`
+}
+
+// SizePrice is what a portion of one size of a food costs.
+type SizePrice struct {
+	Size  string `json:"size" bson:"size" validate:"required,oneof=S M L"`
+	Price Money  `json:"price" bson:"price"`
+}
+
+// PriceFor returns what a portion of size costs, or ErrSizeNotOffered if the
+// food has size prices and size is not one of them.
+func (f Food) PriceFor(size *string) (Money, error) {
+	if len(f.SizePrices) == 0 {
+		if f.Price == nil {
+			return Money{}, errors.New("food " + f.FoodID + " has no price")
+		}
+		return *f.Price, nil
+	}
+	if size != nil {
+		for _, sizePrice := range f.SizePrices {
+			if sizePrice.Size == *size {
+				return sizePrice.Price, nil
+			}
+		}
+	}
+	return Money{}, ErrSizeNotOffered
+}
+
+// ModifierGroup is a choice offered with a food, such as how a steak is done
+// or which extras go on a burger. Each item of the food takes between
+// MinSelections and MaxSelections of its options, so a group with a
+// MinSelections of 0 is optional.
+type ModifierGroup struct {
+	Name          string           `json:"name" bson:"name" validate:"required,max=50"`
+	MinSelections int              `json:"min_selections" bson:"min_selections" validate:"min=0,ltefield=MaxSelections"`
+	MaxSelections int              `json:"max_selections" bson:"max_selections" validate:"min=1"`
+	Options       []ModifierOption `json:"options" bson:"options" validate:"required,min=1,dive"`
+}
+
+// ModifierOption is one option of a modifier group. PriceAdjustment, which
+// may be negative, is added to the price of each item it is chosen for.
+type ModifierOption struct {
+	Name            string `json:"name" bson:"name" validate:"required,max=50"`
+	PriceAdjustment *Money `json:"price_adjustment" bson:"price_adjustment"`
+}
+
+// ModifierGroup returns the food's modifier group called name.
+func (f Food) ModifierGroup(name string) (ModifierGroup, bool) {
+	for _, group := range f.ModifierGroups {
+		if group.Name == name {
+			return group, true
+		}
+	}
+	return ModifierGroup{}, false
+}
+
+// Option returns the group's option called name.
+func (g ModifierGroup) Option(name string) (ModifierOption, bool) {
+	for _, option := range g.Options {
+		if option.Name == name {
+			return option, true
+		}
+	}
+	return ModifierOption{}, false
+}
+
+// IsAvailable reports whether the food can be ordered.
+func (f Food) IsAvailable() bool {
+	return f.Available == nil || *f.Available
 }
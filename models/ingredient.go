@@ -0,0 +1,72 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Reasons stock can move. ORDER and RESTORE are recorded as items are ordered
+// and their orders cancelled or voided; the rest are entered by staff.
+const (
+	StockOrder      = "ORDER"
+	StockRestore    = "RESTORE"
+	StockDelivery   = "DELIVERY"
+	StockAdjustment = "ADJUSTMENT"
+	StockWaste      = "WASTE"
+)
+
+// Ingredient is something the kitchen keeps in stock. Stock only changes
+// through stock movements, so it is ignored when an ingredient is created or
+// updated.
+type Ingredient struct {
+	ID           primitive.ObjectID `bson:"_id"`
+	IngredientID string             `json:"ingredient_id" bson:"ingredient_id"`
+	Name         *string            `json:"name" bson:"name" validate:"required,min=2,max=100"`
+	Unit         *string            `json:"unit" bson:"unit" validate:"required,oneof=g kg ml l pc"`
+	Stock        Quantity           `json:"stock" bson:"stock"`
+	// ReorderLevel is the stock at or below which the ingredient is listed
+	// as running low.
+	ReorderLevel *Quantity `json:"reorder_level" bson:"reorder_level" validate:"omitempty,min=0"`
+	CreatedAt    time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// LowOnStock reports whether the ingredient's stock is at or below its
+// reorder level.
+func (i Ingredient) LowOnStock() bool {
+	return i.ReorderLevel != nil && i.Stock <= *i.ReorderLevel
+}
+
+// Recipe is what goes into one portion of a food.
+type Recipe struct {
+	FoodID    string       `json:"food_id" bson:"food_id"`
+	Items     []RecipeItem `json:"items" bson:"items" validate:"dive"`
+	UpdatedAt time.Time    `json:"updated_at" bson:"updated_at"`
+}
+
+// RecipeItem is how much of an ingredient a recipe uses. Quantities may be
+// given in any unit of the same kind as the ingredient's and are stored
+// converted to the ingredient's unit.
+type RecipeItem struct {
+	IngredientID string   `json:"ingredient_id" bson:"ingredient_id" validate:"required"`
+	Quantity     Quantity `json:"quantity" bson:"quantity" validate:"gt=0"`
+	Unit         string   `json:"unit" bson:"unit" validate:"omitempty,oneof=g kg ml l pc"`
+}
+
+// StockMovement is one change to an ingredient's stock. Change is positive
+// when stock comes in and negative when it is used or thrown away.
+type StockMovement struct {
+	ID           primitive.ObjectID `bson:"_id"`
+	MovementID   string             `json:"movement_id" bson:"movement_id"`
+	IngredientID string             `json:"ingredient_id" bson:"ingredient_id"`
+	Change       Quantity           `json:"change" bson:"change"`
+	StockAfter   Quantity           `json:"stock_after" bson:"stock_after"`
+	Reason       string             `json:"reason" bson:"reason"`
+	// OrderID and OrderItemID are set on the movements of ordered items.
+	OrderID     *string   `json:"order_id" bson:"order_id"`
+	OrderItemID *string   `json:"order_item_id" bson:"order_item_id"`
+	Note        *string   `json:"note" bson:"note"`
+	RecordedBy  string    `json:"recorded_by" bson:"recorded_by"`
+	CreatedAt   time.Time `json:"created_at" bson:"created_at"`
+}
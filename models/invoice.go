@@ -8,11 +8,81 @@ import (
 
 type Invoice struct {
 	ID             primitive.ObjectID `bson:"_id"`
-	InvoiceID      string             `json:"invoice_id"`
-	OrderID        string             `json:"order_id"`
-	PaymentMethod  *string            `json:"payment_method" validate:"eq=CARD|eq=CASH|eq="`
-	PaymentStatus  *string            `json:"payment_status" validate:"required,eq=PENDING|eq=PAID"`
-	PaymentDueDate time.Time          `json:"payment_due_date"`
-	CreatedAt      time.Time          `json:"created_at"`
-	UpdatedAt      time.Time          `json:"updated_at"`
+	InvoiceID      string             `json:"invoice_id" bson:"invoice_id"`
+	OrderID        string             `json:"order_id" bson:"order_id"`
+	PaymentMethod  *string            `json:"payment_method" bson:"payment_method" validate:"eq=CARD|eq=CASH|eq=VOUCHER|eq=MIXED|eq="`
+	PaymentStatus  *string            `json:"payment_status" bson:"payment_status" validate:"required,eq=PENDING|eq=PARTIALLY_PAID|eq=PAID|eq=REFUNDED|eq=VOID"`
+	PaymentDueDate time.Time          `json:"payment_due_date" bson:"payment_due_date"`
+	CreatedAt      time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt      time.Time          `json:"updated_at" bson:"updated_at"`
+
+	// The priced bill is computed once when the invoice is created and kept
+	// with it, so later menu price or tax changes do not alter it. Invoices
+	// created before pricing existed have a nil GrandTotal. Subtotal is what
+	// the lines come to after Discount, the total taken off them by the
+	// voucher with VoucherCode, if the order had one.
+	Lines             []InvoiceLine `json:"lines" bson:"lines"`
+	Taxes             []InvoiceTax  `json:"taxes" bson:"taxes"`
+	VoucherCode       *string       `json:"voucher_code" bson:"voucher_code"`
+	Discount          *Money        `json:"discount" bson:"discount"`
+	Subtotal          *Money        `json:"subtotal" bson:"subtotal"`
+	TaxTotal          *Money        `json:"tax_total" bson:"tax_total"`
+	ServiceChargeRate *float64      `json:"service_charge_rate" bson:"service_charge_rate"`
+	ServiceCharge     *Money        `json:"service_charge" bson:"service_charge"`
+	Rounding          *Money        `json:"rounding" bson:"rounding"`
+	GrandTotal        *Money        `json:"grand_total" bson:"grand_total"`
+
+	// Split is set on each of the invoices an order's bill was split into.
+	Split *InvoiceSplit `json:"split" bson:"split"`
+
+	// AmountPaid is what the invoice's payments have covered so far; it is
+	// only ever changed by recording a payment.
+	AmountPaid *Money `json:"amount_paid" bson:"amount_paid"`
+	// AmountRefunded is what the invoice's credit notes have handed back.
+	AmountRefunded *Money `json:"amount_refunded" bson:"amount_refunded"`
+	// PaymentProvider and ProviderReference identify the most recent card
+	// capture settled against the invoice.
+	PaymentProvider   *string `json:"payment_provider" bson:"payment_provider"`
+	ProviderReference *string `json:"provider_reference" bson:"provider_reference"`
+
+	// InvoiceNumber is the sequential number the invoice was issued under,
+	// assigned when it is stored. Invoices issued before numbering have none.
+	InvoiceNumber string `json:"invoice_number" bson:"invoice_number"`
+}
+
+const (
+	SplitByItems = "items"
+	SplitBySeats = "seats"
+	SplitEvenly  = "even"
+)
+
+// InvoiceSplit records which part of a split bill an invoice is.
+type InvoiceSplit struct {
+	Mode  string `json:"mode" bson:"mode"`
+	Part  int    `json:"part" bson:"part"`
+	Parts int    `json:"parts" bson:"parts"`
+	Seat  *int   `json:"seat" bson:"seat"`
+}
+
+// InvoiceLine is one order item as it was priced onto an invoice. Amount is
+// the unit price times the quantity, less Discount; tax is charged on it.
+type InvoiceLine struct {
+	OrderItemID string  `json:"order_item_id" bson:"order_item_id"`
+	FoodID      string  `json:"food_id" bson:"food_id"`
+	FoodName    string  `json:"food_name" bson:"food_name"`
+	Quantity    int     `json:"quantity" bson:"quantity"`
+	UnitPrice   Money   `json:"unit_price" bson:"unit_price"`
+	Discount    Money   `json:"discount" bson:"discount"`
+	Amount      Money   `json:"amount" bson:"amount"`
+	TaxCategory string  `json:"tax_category" bson:"tax_category"`
+	TaxRate     float64 `json:"tax_rate" bson:"tax_rate"`
+	Tax         Money   `json:"tax" bson:"tax"`
+}
+
+// InvoiceTax totals the tax charged at one rate across an invoice's lines.
+type InvoiceTax struct {
+	Category string  `json:"category" bson:"category"`
+	Rate     float64 `json:"rate" bson:"rate"`
+	Taxable  Money   `json:"taxable" bson:"taxable"`
+	Amount   Money   `json:"amount" bson:"amount"`
 }
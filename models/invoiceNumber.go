@@ -0,0 +1,85 @@
+package models
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// InvoiceNumbering says how invoices are numbered. Numbers run without gaps
+// within a series, which is the prefix followed by the fiscal year the
+// invoice was issued in, e.g. "2026" giving "2026-000123", so numbering
+// starts again from 1 every fiscal year.
+type InvoiceNumbering struct {
+	Prefix string
+	// Continuous leaves the year out of the series so numbering never resets.
+	Continuous bool
+	// FiscalYearStart is the month the fiscal year starts in. A fiscal year
+	// is named after the calendar year it starts in.
+	FiscalYearStart time.Month
+	// Location is the time zone that decides which year an invoice falls in.
+	Location *time.Location
+}
+
+// DefaultInvoiceNumbering numbers invoices by calendar year in UTC, with no
+// prefix.
+func DefaultInvoiceNumbering() InvoiceNumbering {
+	return InvoiceNumbering{FiscalYearStart: time.January, Location: time.UTC}
+}
+
+// InvoiceNumberingFromEnv starts from DefaultInvoiceNumbering and applies
+// INVOICE_NUMBER_PREFIX, INVOICE_NUMBER_RESET ("yearly" or "never"),
+// INVOICE_FISCAL_YEAR_START (a month from 1 to 12) and
+// INVOICE_NUMBER_TIMEZONE (an IANA name) where they are set.
+func InvoiceNumberingFromEnv() (InvoiceNumbering, error) {
+	numbering := DefaultInvoiceNumbering()
+	numbering.Prefix = os.Getenv("INVOICE_NUMBER_PREFIX")
+
+	switch value := os.Getenv("INVOICE_NUMBER_RESET"); value {
+	case "", "yearly":
+	case "never":
+		numbering.Continuous = true
+	default:
+		return numbering, fmt.Errorf("INVOICE_NUMBER_RESET: %q is not yearly or never", value)
+	}
+
+	if value := os.Getenv("INVOICE_FISCAL_YEAR_START"); value != "" {
+		month, err := strconv.Atoi(value)
+		if err != nil || month < 1 || month > 12 {
+			return numbering, fmt.Errorf("INVOICE_FISCAL_YEAR_START: %q is not a month from 1 to 12", value)
+		}
+		numbering.FiscalYearStart = time.Month(month)
+	}
+
+	if value := os.Getenv("INVOICE_NUMBER_TIMEZONE"); value != "" {
+		location, err := time.LoadLocation(value)
+		if err != nil {
+			return numbering, fmt.Errorf("INVOICE_NUMBER_TIMEZONE: %w", err)
+		}
+		numbering.Location = location
+	}
+
+	return numbering, nil
+}
+
+// Series is the series an invoice issued at t is numbered in.
+func (n InvoiceNumbering) Series(t time.Time) string {
+	if n.Continuous {
+		return n.Prefix
+	}
+	t = t.In(n.Location)
+	year := t.Year()
+	if t.Month() < n.FiscalYearStart {
+		year--
+	}
+	return n.Prefix + strconv.Itoa(year)
+}
+
+// FormatInvoiceNumber formats the number'th invoice of series.
+func FormatInvoiceNumber(series string, number int64) string {
+	if series == "" {
+		return fmt.Sprintf("%06d", number)
+	}
+	return fmt.Sprintf("%s-%06d", series, number)
+}
@@ -1,18 +1,113 @@
 package models
 
 import (
+	"fmt"
+	"os"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Days of the week a menu slot can recur on.
+var weekdays = map[string]time.Weekday{
+	"SUN": time.Sunday, "MON": time.Monday, "TUE": time.Tuesday, "WED": time.Wednesday,
+	"THU": time.Thursday, "FRI": time.Friday, "SAT": time.Saturday,
+}
+
+// MenuTimeOfDay is the layout of the start and end of a menu slot.
+const MenuTimeOfDay = "15:04"
+
 type Menu struct {
 	ID        primitive.ObjectID `bson:"_id"`
-	Name      string             `json:"name" validate:"required,min=2,max=100"`
-	Category  string             `json:"category" validate:"required"`
-	StartDate *time.Time         `json:"start_date"`
-	EndDate   *time.Time         `json:"end_date"`
-	CreatedAt time.Time          `json:"created_at"`
-	UpdatedAt time.Time          `json:"updated_at"`
-	MenuID    string            `json:"menu_id"`
+	Name      string             `json:"name" bson:"name" validate:"required,min=2,max=100"`
+	Category  string             `json:"category" bson:"category" validate:"required"`
+	StartDate *time.Time         `json:"start_date" bson:"start_date"`
+	EndDate   *time.Time         `json:"end_date" bson:"end_date"`
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time          `json:"updated_at" bson:"updated_at"`
+	MenuID    string             `json:"menu_id" bson:"menu_id"`
+	// Schedule lists the times of the week the menu is served. A menu with
+	// no schedule is served all day, every day, between its dates.
+	Schedule []MenuSlot `json:"schedule" bson:"schedule" validate:"dive"`
+}
+
+// MenuSlot is a time of day a menu is served on each of Days, such as 07:00
+// to 11:00 on MON to FRI. A slot whose End is not after its Start runs past
+// midnight into the next day.
+type MenuSlot struct {
+	Days  []string `json:"days" bson:"days" validate:"required,min=1,dive,oneof=MON TUE WED THU FRI SAT SUN"`
+	Start string   `json:"start" bson:"start" validate:"required,datetime=15:04"`
+	End   string   `json:"end" bson:"end" validate:"required,datetime=15:04"`
+}
+
+// MenuLocationFromEnv reads MENU_TIMEZONE, the IANA name of the time zone
+// menu schedules are kept in, defaulting to UTC.
+func MenuLocationFromEnv() (*time.Location, error) {
+	value := os.Getenv("MENU_TIMEZONE")
+	if value == "" {
+		return time.UTC, nil
+	}
+	location, err := time.LoadLocation(value)
+	if err != nil {
+		return nil, fmt.Errorf("MENU_TIMEZONE: %w", err)
+	}
+	return location, nil
+}
+
+// ActiveAt reports whether the menu is served at t: on or after its start
+// date, before its end date, and in one of its slots in t's time zone.
+func (m Menu) ActiveAt(t time.Time) bool {
+	return scheduledAt(t, m.StartDate, m.EndDate, m.Schedule)
+}
+
+// scheduledAt reports whether t is on or after start, before end and in one
+// of the slots of schedule, if it has any. Either date may be open.
+func scheduledAt(t time.Time, start, end *time.Time, schedule []MenuSlot) bool {
+	if start != nil && t.Before(*start) {
+		return false
+	}
+	if end != nil && !t.Before(*end) {
+		return false
+	}
+	if len(schedule) == 0 {
+		return true
+	}
+	for _, slot := range schedule {
+		if slot.Contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// Contains reports whether t, in its own time zone, falls in the slot.
+func (s MenuSlot) Contains(t time.Time) bool {
+	start, err := time.Parse(MenuTimeOfDay, s.Start)
+	if err != nil {
+		return false
+	}
+	end, err := time.Parse(MenuTimeOfDay, s.End)
+	if err != nil {
+		return false
+	}
+	startMinute := start.Hour()*60 + start.Minute()
+	endMinute := end.Hour()*60 + end.Minute()
+	minute := t.Hour()*60 + t.Minute()
+
+	if endMinute > startMinute {
+		return s.on(t.Weekday()) && minute >= startMinute && minute < endMinute
+	}
+	// The slot runs past midnight: late on a day it starts, or early on the
+	// day after.
+	return s.on(t.Weekday()) && minute >= startMinute ||
+		s.on((t.Weekday()+6)%7) && minute < endMinute
+}
+
+func (s MenuSlot) on(day time.Weekday) bool {
+	for _, name := range s.Days {
+		if d, ok := weekdays[name]; ok && d == day {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,267 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+// Money is an exact amount in the minor unit of its currency, e.g. 949 USD is
+// $9.49. It is encoded in JSON and BSON as {"amount": 949, "currency": "USD"}.
+//
+// Prices used to be float64 in major units, so both decoders also accept a
+// bare number (or, in JSON, a decimal string) in major units of the default
+// currency.
+type Money struct {
+	Amount   int64  `json:"amount" bson:"amount"`
+	Currency string `json:"currency" bson:"currency"`
+}
+
+// ErrCurrencyMismatch is returned when amounts in different currencies are
+// combined.
+var ErrCurrencyMismatch = errors.New("amounts are in different currencies")
+
+// DefaultCurrency is the ISO 4217 code in the CURRENCY environment variable,
+// or USD. It is read on every call so the value loaded from .env is seen.
+func DefaultCurrency() string {
+	if currency := os.Getenv("CURRENCY"); currency != "" {
+		return strings.ToUpper(currency)
+	}
+	return "USD"
+}
+
+// minorDigits lists the currencies whose minor unit is not a hundredth.
+var minorDigits = map[string]int{
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"CLP": 0, "ISK": 0, "JPY": 0, "KRW": 0, "PYG": 0, "UGX": 0, "VND": 0, "XAF": 0, "XOF": 0,
+}
+
+// MinorDigits is the number of decimal places in currency's minor unit.
+func MinorDigits(currency string) int {
+	if digits, ok := minorDigits[currency]; ok {
+		return digits
+	}
+	return 2
+}
+
+func pow10(n int) int64 {
+	p := int64(1)
+	for ; n > 0; n-- {
+		p *= 10
+	}
+	return p
+}
+
+// NewMoney returns amount minor units of currency; an empty currency is the
+// default one.
+func NewMoney(amount int64, currency string) Money {
+	if currency == "" {
+		currency = DefaultCurrency()
+	}
+	return Money{Amount: amount, Currency: currency}
+}
+
+// ParseMoney reads a decimal amount in major units, such as "9.49", exactly.
+// Digits beyond the currency's minor unit are rounded half away from zero.
+func ParseMoney(value, currency string) (Money, error) {
+	m := NewMoney(0, currency)
+	digits := MinorDigits(m.Currency)
+
+	s := strings.TrimSpace(value)
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+")
+
+	whole, fraction, _ := strings.Cut(s, ".")
+	if whole == "" && fraction == "" || strings.Trim(whole+fraction, "0123456789") != "" {
+		return m, fmt.Errorf("invalid amount %q", value)
+	}
+
+	roundUp := len(fraction) > digits && fraction[digits] >= '5'
+	if len(fraction) > digits {
+		fraction = fraction[:digits]
+	}
+	fraction += strings.Repeat("0", digits-len(fraction))
+
+	amount, err := strconv.ParseInt("0"+whole+fraction, 10, 64)
+	if err != nil {
+		return m, fmt.Errorf("invalid amount %q", value)
+	}
+	if roundUp {
+		amount++
+	}
+	if negative {
+		amount = -amount
+	}
+	m.Amount = amount
+	return m, nil
+}
+
+// MoneyFromFloat converts a legacy float64 amount in major units, rounding to
+// the nearest minor unit.
+func MoneyFromFloat(value float64, currency string) Money {
+	m := NewMoney(0, currency)
+	m.Amount = int64(math.Round(value * float64(pow10(MinorDigits(m.Currency)))))
+	return m
+}
+
+// Add returns m + other. A zero amount with no currency takes the other's.
+func (m Money) Add(other Money) (Money, error) {
+	switch {
+	case m.Currency == "":
+		m.Currency = other.Currency
+	case other.Currency != "" && other.Currency != m.Currency:
+		return m, ErrCurrencyMismatch
+	}
+	m.Amount += other.Amount
+	return m, nil
+}
+
+// Sub returns m - other.
+func (m Money) Sub(other Money) (Money, error) {
+	other.Amount = -other.Amount
+	return m.Add(other)
+}
+
+// Mul returns m times a whole quantity.
+func (m Money) Mul(quantity int64) Money {
+	m.Amount *= quantity
+	return m
+}
+
+// MulRate returns m times rate, such as a tax rate of 0.2, rounded half away
+// from zero to the minor unit.
+func (m Money) MulRate(rate float64) Money {
+	m.Amount = int64(math.Round(float64(m.Amount) * rate))
+	return m
+}
+
+// RoundTo rounds m half away from zero to a multiple of increment minor units.
+func (m Money) RoundTo(increment int64) Money {
+	if increment <= 1 {
+		return m
+	}
+	half := increment / 2
+	if m.Amount < 0 {
+		m.Amount = -((-m.Amount + half) / increment * increment)
+	} else {
+		m.Amount = (m.Amount + half) / increment * increment
+	}
+	return m
+}
+
+// Allocate divides m into n parts that are multiples of increment minor units
+// and sum to m exactly. Parts differ by at most one increment, with the larger
+// ones first. m must itself be a multiple of increment.
+func (m Money) Allocate(n int, increment int64) []Money {
+	if increment < 1 {
+		increment = 1
+	}
+	units := m.Amount / increment
+	share, remainder := units/int64(n), units%int64(n)
+
+	parts := make([]Money, n)
+	for i := range parts {
+		parts[i] = Money{Amount: share * increment, Currency: m.Currency}
+		if int64(i) < remainder {
+			parts[i].Amount += increment
+		} else if int64(i) < -remainder {
+			parts[i].Amount -= increment
+		}
+	}
+	return parts
+}
+
+// IsNegative reports whether m is below zero.
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+// String formats m in major units with its currency, e.g. "9.49 USD".
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+// Decimal formats m in major units without a currency, e.g. "9.49".
+func (m Money) Decimal() string {
+	digits := MinorDigits(m.Currency)
+	amount := m.Amount
+	sign := ""
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	if digits == 0 {
+		return sign + strconv.FormatInt(amount, 10)
+	}
+	p := pow10(digits)
+	return fmt.Sprintf("%s%d.%0*d", sign, amount/p, digits, amount%p)
+}
+
+type moneyFields Money
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	switch {
+	case bytes.Equal(data, []byte("null")):
+		return nil
+	case len(data) > 0 && data[0] == '{':
+		var fields moneyFields
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return err
+		}
+		*m = NewMoney(fields.Amount, strings.ToUpper(fields.Currency))
+		return nil
+	case len(data) > 0 && data[0] == '"':
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		parsed, err := ParseMoney(s, "")
+		if err != nil {
+			return err
+		}
+		*m = parsed
+		return nil
+	default:
+		parsed, err := ParseMoney(string(data), "")
+		if err != nil {
+			return err
+		}
+		*m = parsed
+		return nil
+	}
+}
+
+func (m Money) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	return bson.MarshalValue(moneyFields(m))
+}
+
+func (m *Money) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	raw := bson.RawValue{Type: t, Value: data}
+	switch t {
+	case bsontype.EmbeddedDocument:
+		var fields moneyFields
+		if err := raw.Unmarshal(&fields); err != nil {
+			return err
+		}
+		*m = NewMoney(fields.Amount, fields.Currency)
+	case bsontype.Double:
+		*m = MoneyFromFloat(raw.Double(), "")
+	case bsontype.Int32:
+		*m = NewMoney(int64(raw.Int32())*pow10(MinorDigits(DefaultCurrency())), "")
+	case bsontype.Int64:
+		*m = NewMoney(raw.Int64()*pow10(MinorDigits(DefaultCurrency())), "")
+	case bsontype.Null, bsontype.Undefined:
+		*m = Money{}
+	default:
+		return fmt.Errorf("cannot decode %s into Money", t)
+	}
+	return nil
+}
@@ -0,0 +1,209 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		value    string
+		currency string
+		want     int64
+		wantErr  bool
+	}{
+		{value: "9.49", currency: "USD", want: 949},
+		{value: "9.5", currency: "USD", want: 950},
+		{value: "+3", currency: "USD", want: 300},
+		{value: ".5", currency: "USD", want: 50},
+		{value: " 12.00 ", currency: "USD", want: 1200},
+		// Digits beyond the minor unit round half away from zero.
+		{value: "9.494", currency: "USD", want: 949},
+		{value: "9.495", currency: "USD", want: 950},
+		{value: "-9.495", currency: "USD", want: -950},
+		{value: "-0.004", currency: "USD", want: 0},
+		{value: "1.2345", currency: "KWD", want: 1235},
+		{value: "100", currency: "JPY", want: 100},
+		{value: "0.5", currency: "JPY", want: 1},
+		{value: "", currency: "USD", wantErr: true},
+		{value: ".", currency: "USD", wantErr: true},
+		{value: "-", currency: "USD", wantErr: true},
+		{value: "1.2.3", currency: "USD", wantErr: true},
+		{value: "1e3", currency: "USD", wantErr: true},
+		{value: "abc", currency: "USD", wantErr: true},
+		{value: "99999999999999999999", currency: "USD", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseMoney(tt.value, tt.currency)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseMoney(%q, %s) = %v, want an error", tt.value, tt.currency, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseMoney(%q, %s): %v", tt.value, tt.currency, err)
+			continue
+		}
+		if want := NewMoney(tt.want, tt.currency); got != want {
+			t.Errorf("ParseMoney(%q, %s) = %v, want %v", tt.value, tt.currency, got, want)
+		}
+	}
+}
+
+func TestMoneyAddSub(t *testing.T) {
+	usd := func(amount int64) Money { return NewMoney(amount, "USD") }
+
+	if got, err := usd(949).Add(usd(51)); err != nil || got != usd(1000) {
+		t.Errorf("949 + 51 = %v, %v; want 10.00 USD", got, err)
+	}
+	if got, err := usd(300).Sub(usd(1200)); err != nil || got != usd(-900) {
+		t.Errorf("300 - 1200 = %v, %v; want -9.00 USD", got, err)
+	}
+	// A zero value with no currency takes the other's, so sums can start
+	// from Money{}.
+	if got, err := (Money{}).Add(usd(5)); err != nil || got != usd(5) {
+		t.Errorf("Money{} + 5 = %v, %v; want 0.05 USD", got, err)
+	}
+	if got, err := usd(5).Add(Money{}); err != nil || got != usd(5) {
+		t.Errorf("5 + Money{} = %v, %v; want 0.05 USD", got, err)
+	}
+	if _, err := usd(5).Add(NewMoney(5, "EUR")); err != ErrCurrencyMismatch {
+		t.Errorf("USD + EUR error = %v, want ErrCurrencyMismatch", err)
+	}
+	if _, err := usd(5).Sub(NewMoney(5, "EUR")); err != ErrCurrencyMismatch {
+		t.Errorf("USD - EUR error = %v, want ErrCurrencyMismatch", err)
+	}
+}
+
+func TestMoneyMulRate(t *testing.T) {
+	tests := []struct {
+		amount int64
+		rate   float64
+		want   int64
+	}{
+		{amount: 2400, rate: 0.2, want: 480},
+		{amount: 999, rate: 0.1, want: 100},
+		{amount: 994, rate: 0.1, want: 99},
+		// Halves round away from zero on both sides.
+		{amount: 125, rate: 0.1, want: 13},
+		{amount: -125, rate: 0.1, want: -13},
+		{amount: 2700, rate: 0.125, want: 338},
+		{amount: 1200, rate: 0, want: 0},
+	}
+	for _, tt := range tests {
+		if got := NewMoney(tt.amount, "USD").MulRate(tt.rate); got.Amount != tt.want {
+			t.Errorf("%d × %v = %d, want %d", tt.amount, tt.rate, got.Amount, tt.want)
+		}
+	}
+}
+
+func TestMoneyRoundTo(t *testing.T) {
+	tests := []struct {
+		amount    int64
+		increment int64
+		want      int64
+	}{
+		{amount: 1232, increment: 5, want: 1230},
+		{amount: 1233, increment: 5, want: 1235},
+		{amount: 1237, increment: 5, want: 1235},
+		{amount: 1238, increment: 5, want: 1240},
+		{amount: -1232, increment: 5, want: -1230},
+		{amount: -1238, increment: 5, want: -1240},
+		{amount: 1250, increment: 100, want: 1300},
+		{amount: -1250, increment: 100, want: -1300},
+		{amount: 1233, increment: 1, want: 1233},
+		{amount: 1233, increment: 0, want: 1233},
+	}
+	for _, tt := range tests {
+		if got := NewMoney(tt.amount, "USD").RoundTo(tt.increment); got.Amount != tt.want {
+			t.Errorf("%d rounded to %d = %d, want %d", tt.amount, tt.increment, got.Amount, tt.want)
+		}
+	}
+}
+
+func TestMoneyAllocate(t *testing.T) {
+	tests := []struct {
+		amount    int64
+		n         int
+		increment int64
+		want      []int64
+	}{
+		{amount: 900, n: 3, increment: 1, want: []int64{300, 300, 300}},
+		{amount: 100, n: 3, increment: 1, want: []int64{34, 33, 33}},
+		{amount: 2, n: 3, increment: 1, want: []int64{1, 1, 0}},
+		{amount: -100, n: 3, increment: 1, want: []int64{-34, -33, -33}},
+		{amount: 100, n: 3, increment: 5, want: []int64{35, 35, 30}},
+		{amount: 100, n: 3, increment: 0, want: []int64{34, 33, 33}},
+		{amount: 0, n: 2, increment: 1, want: []int64{0, 0}},
+	}
+	for _, tt := range tests {
+		parts := NewMoney(tt.amount, "USD").Allocate(tt.n, tt.increment)
+		if len(parts) != len(tt.want) {
+			t.Errorf("%d / %d: got %d parts, want %d", tt.amount, tt.n, len(parts), len(tt.want))
+			continue
+		}
+		for i, part := range parts {
+			if part.Amount != tt.want[i] || part.Currency != "USD" {
+				t.Errorf("%d / %d by %d: part %d = %v, want %d USD", tt.amount, tt.n, tt.increment, i, part, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestMoneyDecimal(t *testing.T) {
+	tests := []struct {
+		money Money
+		want  string
+	}{
+		{money: NewMoney(949, "USD"), want: "9.49"},
+		{money: NewMoney(5, "USD"), want: "0.05"},
+		{money: NewMoney(-5, "USD"), want: "-0.05"},
+		{money: NewMoney(1235, "KWD"), want: "1.235"},
+		{money: NewMoney(100, "JPY"), want: "100"},
+		{money: NewMoney(-100, "JPY"), want: "-100"},
+	}
+	for _, tt := range tests {
+		if got := tt.money.Decimal(); got != tt.want {
+			t.Errorf("%d %s = %q, want %q", tt.money.Amount, tt.money.Currency, got, tt.want)
+		}
+	}
+}
+
+func TestMoneyUnmarshalJSON(t *testing.T) {
+	t.Setenv("CURRENCY", "eur")
+
+	tests := []struct {
+		json    string
+		want    Money
+		wantErr bool
+	}{
+		{json: `{"amount": 949, "currency": "usd"}`, want: NewMoney(949, "USD")},
+		{json: `{"amount": -949}`, want: NewMoney(-949, "EUR")},
+		// Bare numbers and decimal strings are major units of the default
+		// currency, as prices were before Money.
+		{json: `9.49`, want: NewMoney(949, "EUR")},
+		{json: `"9.495"`, want: NewMoney(950, "EUR")},
+		{json: `-3`, want: NewMoney(-300, "EUR")},
+		{json: `null`, want: Money{}},
+		{json: `"nine"`, wantErr: true},
+		{json: `true`, wantErr: true},
+	}
+	for _, tt := range tests {
+		var got Money
+		err := json.Unmarshal([]byte(tt.json), &got)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Unmarshal(%s) = %v, want an error", tt.json, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Unmarshal(%s): %v", tt.json, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Unmarshal(%s) = %v, want %v", tt.json, got, tt.want)
+		}
+	}
+}
@@ -8,9 +8,9 @@ import (
 
 type Note struct {
 	ID        primitive.ObjectID `bson:"_id"`
-	Text      string             `json:"text"`
-	Title     string             `json:"title"`
-	CreatedAt time.Time          `json:"created_at"`
-	UpdatedAt time.Time          `json:"updated_at"`
-	NoteID    string             `json:"note_id"`
+	Text      string             `json:"text" bson:"text"`
+	Title     string             `json:"title" bson:"title"`
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time          `json:"updated_at" bson:"updated_at"`
+	NoteID    string             `json:"note_id" bson:"note_id"`
 }
@@ -6,11 +6,84 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+const (
+	OrderStatusPlaced    = "PLACED"
+	OrderStatusInKitchen = "IN_KITCHEN"
+	OrderStatusReady     = "READY"
+	OrderStatusServed    = "SERVED"
+	OrderStatusClosed    = "CLOSED"
+	OrderStatusCancelled = "CANCELLED"
+	OrderStatusVoided    = "VOIDED"
+)
+
+// orderTransitions lists, for each status, the statuses an order may move to
+// next. An order can be cancelled until the kitchen picks it up and voided
+// after that; CLOSED, CANCELLED and VOIDED are final.
+var orderTransitions = map[string][]string{
+	OrderStatusPlaced:    {OrderStatusInKitchen, OrderStatusCancelled},
+	OrderStatusInKitchen: {OrderStatusReady, OrderStatusVoided},
+	OrderStatusReady:     {OrderStatusServed, OrderStatusVoided},
+	OrderStatusServed:    {OrderStatusClosed, OrderStatusVoided},
+}
+
+// orderTransitionRoles lists, for each status, the roles that may move an
+// order to it. The floor sends orders to the kitchen, serves and cancels them,
+// the kitchen marks them ready, billing closes them, and only management voids
+// an order the kitchen has already had.
+var orderTransitionRoles = map[string][]string{
+	OrderStatusInKitchen: {RoleAdmin, RoleManager, RoleWaiter, RoleKitchen},
+	OrderStatusReady:     {RoleAdmin, RoleManager, RoleKitchen},
+	OrderStatusServed:    {RoleAdmin, RoleManager, RoleWaiter},
+	OrderStatusClosed:    {RoleAdmin, RoleManager, RoleCashier},
+	OrderStatusCancelled: {RoleAdmin, RoleManager, RoleWaiter},
+	OrderStatusVoided:    {RoleAdmin, RoleManager},
+}
+
 type Order struct {
-	ID        primitive.ObjectID `bson:"_id"`
-	OrderDate time.Time          `json:"order_date" validate:"required"`
-	CreatedAt time.Time          `json:"created_at"`
-	UpdatedAt time.Time          `json:"updated_at"`
-	OrderID   string             `json:"order_id"`
-	TableID   *string            `json:"table_id" validate:"required"`
+	ID            primitive.ObjectID `bson:"_id"`
+	OrderDate     time.Time          `json:"order_date" bson:"order_date" validate:"required"`
+	CreatedAt     time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt     time.Time          `json:"updated_at" bson:"updated_at"`
+	OrderID       string             `json:"order_id" bson:"order_id"`
+	TableID       *string            `json:"table_id" bson:"table_id" validate:"required"`
+	Status        *string            `json:"status" bson:"status"`
+	StatusHistory []OrderTransition  `json:"status_history" bson:"status_history"`
+}
+
+// OrderTransition records one status change: who made it and when.
+type OrderTransition struct {
+	From      string    `json:"from" bson:"from"`
+	To        string    `json:"to" bson:"to"`
+	ChangedBy string    `json:"changed_by" bson:"changed_by"`
+	ChangedAt time.Time `json:"changed_at" bson:"changed_at"`
+}
+
+// CurrentStatus returns the order's status, treating orders created before
+// statuses existed as PLACED.
+func (o Order) CurrentStatus() string {
+	if o.Status == nil || *o.Status == "" {
+		return OrderStatusPlaced
+	}
+	return *o.Status
+}
+
+// CanTransitionTo reports whether the order may move from its current status
+// to status.
+func (o Order) CanTransitionTo(status string) bool {
+	for _, next := range orderTransitions[o.CurrentStatus()] {
+		if next == status {
+			return true
+		}
+	}
+	return false
+}
+
+// RoleCanTransitionOrderTo reports whether role may move an order to status.
+func RoleCanTransitionOrderTo(role, status string) bool {
+	for _, allowed := range orderTransitionRoles[status] {
+		if allowed == role {
+			return true
+		}
+	}
+	return false
 }
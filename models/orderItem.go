@@ -8,11 +8,60 @@ import (
 
 type OrderItem struct {
 	ID          primitive.ObjectID `bson:"_id"`
-	Quantity    *string            `json:"quantity" validate:"required,eq=S|eq=M|eq=L"`
-	UnitPrice   *float64           `json:"unit_price" validate:"required"`
-	CreatedAt   time.Time          `json:"created_at"`
-	UpdatedAt   time.Time          `json:"updated_at"`
-	FoodID      *string            `json:"food_id" validate:"required"`
-	OrderItemID string             `json:"order_item_id"`
-	OrderID     string             `json:"order_id" validate:"required"`
+	Quantity    int                `json:"quantity" bson:"quantity" validate:"required,min=1,max=100"`
+	Size        *string            `json:"size" bson:"size" validate:"omitempty,oneof=S M L"`
+	UnitPrice   *Money             `json:"unit_price" bson:"unit_price"`
+	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at" bson:"updated_at"`
+	FoodID      *string            `json:"food_id" bson:"food_id" validate:"required"`
+	OrderItemID string             `json:"order_item_id" bson:"order_item_id"`
+	OrderID     string             `json:"order_id" bson:"order_id" validate:"required"`
+	Seat        *int               `json:"seat" bson:"seat" validate:"omitempty,min=1"`
+	// UnitPrice is what one of Quantity costs, worked out from the price of
+	// the food in Size when the item is ordered. ListPrice is that price
+	// before any price rule, and PriceRuleID the rule that made UnitPrice
+	// differ from it, if one did.
+	ListPrice   *Money  `json:"list_price" bson:"list_price"`
+	PriceRuleID *string `json:"price_rule_id" bson:"price_rule_id"`
+	// Modifiers are the options chosen from the food's modifier groups and
+	// Notes anything else the kitchen should know about the item.
+	Modifiers []OrderItemModifier `json:"modifiers" bson:"modifiers" validate:"dive"`
+	Notes     *string             `json:"notes" bson:"notes" validate:"omitempty,max=200"`
+	Void      *OrderItemVoid      `json:"void" bson:"void"`
+}
+
+// Why an item was voided.
+const (
+	VoidEnteredInError = "ENTERED_IN_ERROR"
+	VoidChangedMind    = "CUSTOMER_CHANGED_MIND"
+	VoidQuality        = "QUALITY"
+	VoidOutOfStock     = "OUT_OF_STOCK"
+	VoidOther          = "OTHER"
+)
+
+// OrderItemVoid records an item taken off its order. Voided items are kept
+// for audit but no longer billed. Once the kitchen has picked the order up a
+// void only takes effect when a manager approves it; until then VoidedAt is
+// nil and the item is still billed.
+type OrderItemVoid struct {
+	Reason      string     `json:"reason" bson:"reason"`
+	Note        *string    `json:"note" bson:"note"`
+	RequestedBy string     `json:"requested_by" bson:"requested_by"`
+	RequestedAt time.Time  `json:"requested_at" bson:"requested_at"`
+	ApprovedBy  *string    `json:"approved_by" bson:"approved_by"`
+	VoidedAt    *time.Time `json:"voided_at" bson:"voided_at"`
+}
+
+// IsVoided reports whether the item has been taken off its order.
+func (i OrderItem) IsVoided() bool {
+	return i.Void != nil && i.Void.VoidedAt != nil
+}
+
+// OrderItemModifier is an option chosen for an item. PriceAdjustment is
+// copied from the food when the item is ordered, so later changes to the
+// food leave the item as it was charged.
+type OrderItemModifier struct {
+	Group           string `json:"group" bson:"group" validate:"required"`
+	Option          string `json:"option" bson:"option" validate:"required"`
+	PriceAdjustment *Money `json:"price_adjustment" bson:"price_adjustment"`
 }
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	PaymentPending       = "PENDING"
+	PaymentPartiallyPaid = "PARTIALLY_PAID"
+	PaymentPaid          = "PAID"
+	PaymentRefunded      = "REFUNDED"
+	PaymentVoid          = "VOID"
+)
+
+const (
+	TenderCash    = "CASH"
+	TenderCard    = "CARD"
+	TenderVoucher = "VOUCHER"
+)
+
+// PaymentMixed is the payment method of an invoice settled with more than one
+// kind of tender.
+const PaymentMixed = "MIXED"
+
+// Payment is money taken against an invoice at the till, in one or more
+// tenders. Only cash can be over-tendered; the excess is handed back as change.
+type Payment struct {
+	ID        primitive.ObjectID `bson:"_id"`
+	PaymentID string             `json:"payment_id" bson:"payment_id"`
+	InvoiceID string             `json:"invoice_id" bson:"invoice_id"`
+	Tenders   []Tender           `json:"tenders" bson:"tenders" validate:"required,min=1,dive"`
+	Tendered  Money              `json:"tendered" bson:"tendered"`
+	Amount    Money              `json:"amount" bson:"amount"`
+	Change    Money              `json:"change" bson:"change"`
+	Balance   Money              `json:"balance" bson:"balance"`
+	TakenBy   string             `json:"taken_by" bson:"taken_by"`
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+}
+
+// Tender is one means of payment handed over as part of a Payment. Card
+// tenders carry the provider's token for the card in Source when they are
+// taken; once settled, Provider and Reference identify the capture instead.
+type Tender struct {
+	Method    string  `json:"method" bson:"method" validate:"required,oneof=CASH CARD VOUCHER"`
+	Amount    Money   `json:"amount" bson:"amount"`
+	Reference *string `json:"reference" bson:"reference"`
+	Source    *string `json:"source" bson:"-"`
+	Provider  *string `json:"provider" bson:"provider"`
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Kinds of price rule. HAPPY_HOUR and MENU_OVERRIDE change the price of each
+// food they apply to, during the rule's schedule or on the rule's menus.
+// BUY_X_GET_Y and BUNDLE price foods ordered together: every BuyQuantity of
+// them earns FreeQuantity more for nothing, or a set of foods is sold for
+// one price.
+const (
+	PriceRuleHappyHour    = "HAPPY_HOUR"
+	PriceRuleMenuOverride = "MENU_OVERRIDE"
+	PriceRuleBuyXGetY     = "BUY_X_GET_Y"
+	PriceRuleBundle       = "BUNDLE"
+)
+
+// PriceRule changes what foods are charged at when they are ordered. A rule
+// is only in force between its dates and, if it has a schedule, in one of
+// its slots.
+type PriceRule struct {
+	ID          primitive.ObjectID `bson:"_id"`
+	PriceRuleID string             `json:"price_rule_id" bson:"price_rule_id"`
+	Name        string             `json:"name" bson:"name" validate:"required,min=2,max=100"`
+	Kind        string             `json:"kind" bson:"kind" validate:"required,oneof=HAPPY_HOUR MENU_OVERRIDE BUY_X_GET_Y BUNDLE"`
+	// FoodIDs and MenuIDs pick the foods the rule applies to: those listed
+	// in FoodIDs, if any, that are on one of MenuIDs, if any. The foods of a
+	// BUNDLE are the ones in FoodIDs, once for each time they are listed.
+	FoodIDs []string `json:"food_ids" bson:"food_ids" validate:"dive,required"`
+	MenuIDs []string `json:"menu_ids" bson:"menu_ids" validate:"dive,required"`
+	// A HAPPY_HOUR or MENU_OVERRIDE rule takes DiscountRate (0.25 for 25%)
+	// or AmountOff off the price, or charges Price instead. A BUNDLE is
+	// charged Price.
+	DiscountRate *float64   `json:"discount_rate" bson:"discount_rate" validate:"omitempty,gt=0,lte=1"`
+	AmountOff    *Money     `json:"amount_off" bson:"amount_off"`
+	Price        *Money     `json:"price" bson:"price"`
+	BuyQuantity  int        `json:"buy_quantity" bson:"buy_quantity" validate:"min=0"`
+	FreeQuantity int        `json:"free_quantity" bson:"free_quantity" validate:"min=0"`
+	StartDate    *time.Time `json:"start_date" bson:"start_date"`
+	EndDate      *time.Time `json:"end_date" bson:"end_date"`
+	Schedule     []MenuSlot `json:"schedule" bson:"schedule" validate:"dive"`
+	CreatedAt    time.Time  `json:"created_at" bson:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at" bson:"updated_at"`
+}
+
+// ActiveAt reports whether the rule is in force at t, judged in t's time
+// zone the way Menu.ActiveAt is.
+func (r PriceRule) ActiveAt(t time.Time) bool {
+	return scheduledAt(t, r.StartDate, r.EndDate, r.Schedule)
+}
+
+// AppliesTo reports whether food is one of the foods the rule picks.
+func (r PriceRule) AppliesTo(food Food) bool {
+	if len(r.FoodIDs) > 0 && !slices.Contains(r.FoodIDs, food.FoodID) {
+		return false
+	}
+	if len(r.MenuIDs) > 0 && (food.MenuID == nil || !slices.Contains(r.MenuIDs, *food.MenuID)) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,139 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Units an ingredient can be measured in.
+const (
+	UnitGram       = "g"
+	UnitKilogram   = "kg"
+	UnitMillilitre = "ml"
+	UnitLitre      = "l"
+	UnitPiece      = "pc"
+)
+
+// quantityDigits is the number of decimal places a Quantity keeps.
+const quantityDigits = 3
+
+// Quantity is an exact amount of an ingredient in thousandths of its unit, so
+// 1500 is 1.5 kg. It is encoded in JSON as a decimal number such as 1.5; a
+// decimal string is accepted too.
+type Quantity int64
+
+// ParseQuantity reads a decimal quantity such as "0.25" exactly. Digits
+// beyond the third decimal place are rounded half away from zero.
+func ParseQuantity(value string) (Quantity, error) {
+	s := strings.TrimSpace(value)
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+")
+
+	whole, fraction, _ := strings.Cut(s, ".")
+	if whole == "" && fraction == "" || strings.Trim(whole+fraction, "0123456789") != "" {
+		return 0, fmt.Errorf("invalid quantity %q", value)
+	}
+
+	roundUp := len(fraction) > quantityDigits && fraction[quantityDigits] >= '5'
+	if len(fraction) > quantityDigits {
+		fraction = fraction[:quantityDigits]
+	}
+	fraction += strings.Repeat("0", quantityDigits-len(fraction))
+
+	amount, err := strconv.ParseInt("0"+whole+fraction, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid quantity %q", value)
+	}
+	if roundUp {
+		amount++
+	}
+	if negative {
+		amount = -amount
+	}
+	return Quantity(amount), nil
+}
+
+// String formats q in whole units without trailing zeros, e.g. "1.5".
+func (q Quantity) String() string {
+	amount := int64(q)
+	sign := ""
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	p := pow10(quantityDigits)
+	s := sign + strconv.FormatInt(amount/p, 10)
+	if fraction := amount % p; fraction != 0 {
+		s += strings.TrimRight(fmt.Sprintf(".%0*d", quantityDigits, fraction), "0")
+	}
+	return s
+}
+
+// unitScales gives, for each unit that can be converted, the base unit it is
+// measured against and how many thousandths of the base unit one of it is.
+var unitScales = map[string]struct {
+	base  string
+	scale int64
+}{
+	UnitGram:       {UnitGram, 1},
+	UnitKilogram:   {UnitGram, 1000},
+	UnitMillilitre: {UnitMillilitre, 1},
+	UnitLitre:      {UnitMillilitre, 1000},
+	UnitPiece:      {UnitPiece, 1},
+}
+
+// ConvertQuantity converts q from one unit to another of the same kind, such
+// as grams to kilograms, rounding half away from zero to the nearest
+// thousandth.
+func ConvertQuantity(q Quantity, from, to string) (Quantity, error) {
+	source, ok := unitScales[from]
+	if !ok {
+		return 0, fmt.Errorf("unknown unit %q", from)
+	}
+	target, ok := unitScales[to]
+	if !ok {
+		return 0, fmt.Errorf("unknown unit %q", to)
+	}
+	if source.base != target.base {
+		return 0, fmt.Errorf("cannot convert %s to %s", from, to)
+	}
+
+	scaled := int64(q) * source.scale
+	half := target.scale / 2
+	if scaled < 0 {
+		return Quantity(-((-scaled + half) / target.scale)), nil
+	}
+	return Quantity((scaled + half) / target.scale), nil
+}
+
+func (q Quantity) MarshalJSON() ([]byte, error) {
+	return []byte(q.String()), nil
+}
+
+func (q *Quantity) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	switch {
+	case bytes.Equal(data, []byte("null")):
+		return nil
+	case len(data) > 0 && data[0] == '"':
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		parsed, err := ParseQuantity(s)
+		if err != nil {
+			return err
+		}
+		*q = parsed
+		return nil
+	default:
+		parsed, err := ParseQuantity(string(data))
+		if err != nil {
+			return err
+		}
+		*q = parsed
+		return nil
+	}
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	ReservationBooked    = "BOOKED"
+	ReservationCancelled = "CANCELLED"
+	ReservationNoShow    = "NO_SHOW"
+)
+
+// DefaultReservationLength is how long a table is held when a booking does not
+// say when it ends.
+const DefaultReservationLength = 90 * time.Minute
+
+type Reservation struct {
+	ID             primitive.ObjectID `bson:"_id"`
+	ReservationID  string             `json:"reservation_id" bson:"reservation_id"`
+	TableID        *string            `json:"table_id" bson:"table_id"`
+	GuestName      *string            `json:"guest_name" bson:"guest_name" validate:"required,min=2,max=100"`
+	Phone          *string            `json:"phone" bson:"phone"`
+	NumberOfGuests *int               `json:"number_of_guests" bson:"number_of_guests" validate:"required,min=1"`
+	StartTime      time.Time          `json:"start_time" bson:"start_time" validate:"required"`
+	EndTime        time.Time          `json:"end_time" bson:"end_time"`
+	Status         string             `json:"status" bson:"status"`
+	CreatedAt      time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt      time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// Overlaps reports whether the reservation holds its table at any point in
+// [start, end).
+func (r Reservation) Overlaps(start, end time.Time) bool {
+	return r.Status == ReservationBooked && r.StartTime.Before(end) && r.EndTime.After(start)
+}
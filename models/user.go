@@ -6,17 +6,35 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+const (
+	RoleAdmin   = "admin"
+	RoleManager = "manager"
+	RoleCashier = "cashier"
+	RoleWaiter  = "waiter"
+	RoleKitchen = "kitchen"
+)
+
 type User struct {
 	ID           primitive.ObjectID `bson:"_id"`
-	FirstName    *string            `json:"first_name" validate:"required,min=2,max=100"`
-	LastName     *string            `json:"last_name" validate:"required,min=2,max=100"`
-	Password     *string            `json:"Password" validate:"required,min=6"`
-	Email        *string            `json:"email" validate:"required"`
-	Avatar       *string            `json:"avatar"`
-	Phone        *string            `json:"phone" validate:"required"`
-	Token        *string            `json:"token"`
-	RefreshToken *string            `json:"refresh_token"`
-	CreatedAt    time.Time          `json:"created_at"`
-	UpdatedAt    time.Time          `json:"updated_at"`
-	UserID       string             `json:"user_id" validate:"required"`
+	FirstName    *string            `json:"first_name" bson:"first_name" validate:"required,min=2,max=100"`
+	LastName     *string            `json:"last_name" bson:"last_name" validate:"required,min=2,max=100"`
+	Password     *string            `json:"Password" bson:"password" validate:"required,min=6"`
+	Email        *string            `json:"email" bson:"email" validate:"required"`
+	Avatar       *string            `json:"avatar" bson:"avatar"`
+	Phone        *string            `json:"phone" bson:"phone" validate:"required"`
+	Token        *string            `json:"token" bson:"token"`
+	RefreshToken *string            `json:"refresh_token" bson:"refresh_token"`
+	CreatedAt    time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt    time.Time          `json:"updated_at" bson:"updated_at"`
+	UserID       string             `json:"user_id" bson:"user_id" validate:"required"`
+	Role         *string            `json:"role" bson:"role"`
+}
+
+// CurrentRole returns the user's role, treating accounts created before roles
+// existed as waiters.
+func (u User) CurrentRole() string {
+	if u.Role == nil || *u.Role == "" {
+		return RoleWaiter
+	}
+	return *u.Role
 }
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Kinds of voucher. A PERCENTAGE voucher takes Rate off the items it applies
+// to and a FIXED one takes Amount off their total; an ITEM voucher takes
+// Amount off each of them. No voucher takes an item below zero.
+const (
+	VoucherPercentage = "PERCENTAGE"
+	VoucherFixed      = "FIXED"
+	VoucherItem       = "ITEM"
+)
+
+// Voucher is a promo code that can be applied to an order before it is
+// invoiced, discounting its bill.
+type Voucher struct {
+	ID        primitive.ObjectID `bson:"_id"`
+	VoucherID string             `json:"voucher_id" bson:"voucher_id"`
+	// Code is what the customer hands over. Codes are unique and kept in
+	// upper case.
+	Code   string   `json:"code" bson:"code" validate:"required,min=3,max=32,alphanum"`
+	Kind   string   `json:"kind" bson:"kind" validate:"required,oneof=PERCENTAGE FIXED ITEM"`
+	Rate   *float64 `json:"rate" bson:"rate" validate:"omitempty,gt=0,lte=1"`
+	Amount *Money   `json:"amount" bson:"amount"`
+	// FoodIDs limits the voucher to the items of those foods; without any
+	// it applies to every item.
+	FoodIDs []string `json:"food_ids" bson:"food_ids" validate:"dive,required"`
+	// MinimumSpend is what the order's items must come to, before any
+	// discount, for the voucher to apply.
+	MinimumSpend *Money     `json:"minimum_spend" bson:"minimum_spend"`
+	ExpiresAt    *time.Time `json:"expires_at" bson:"expires_at"`
+	// MaxRedemptions is how many orders the voucher can be applied to, or
+	// nil for no limit. Redemptions counts those it has been; it is only
+	// ever changed by redeeming or releasing the voucher.
+	MaxRedemptions *int      `json:"max_redemptions" bson:"max_redemptions" validate:"omitempty,min=1"`
+	Redemptions    int       `json:"redemptions" bson:"redemptions"`
+	CreatedAt      time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// AppliesTo reports whether the voucher discounts the items of foodID.
+func (v Voucher) AppliesTo(foodID string) bool {
+	return len(v.FoodIDs) == 0 || slices.Contains(v.FoodIDs, foodID)
+}
+
+// ExpiredAt reports whether the voucher can no longer be redeemed at t.
+func (v Voucher) ExpiredAt(t time.Time) bool {
+	return v.ExpiresAt != nil && !t.Before(*v.ExpiresAt)
+}
+
+// VoucherRedemption records a voucher applied to an order. An order has at
+// most one voucher applied; taking it off again releases the redemption,
+// which no longer counts against the voucher's limit.
+type VoucherRedemption struct {
+	ID           primitive.ObjectID `bson:"_id"`
+	RedemptionID string             `json:"redemption_id" bson:"redemption_id"`
+	VoucherID    string             `json:"voucher_id" bson:"voucher_id"`
+	Code         string             `json:"code" bson:"code"`
+	OrderID      string             `json:"order_id" bson:"order_id"`
+	RedeemedBy   string             `json:"redeemed_by" bson:"redeemed_by"`
+	RedeemedAt   time.Time          `json:"redeemed_at" bson:"redeemed_at"`
+	ReleasedAt   *time.Time         `json:"released_at" bson:"released_at"`
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestVoucherExpiredAt(t *testing.T) {
+	expiry := time.Date(2026, 3, 31, 23, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		expires *time.Time
+		at      time.Time
+		want    bool
+	}{
+		{name: "no expiry", at: expiry.AddDate(10, 0, 0), want: false},
+		{name: "before expiry", expires: &expiry, at: expiry.Add(-time.Second), want: false},
+		{name: "at expiry", expires: &expiry, at: expiry, want: true},
+		{name: "after expiry", expires: &expiry, at: expiry.Add(time.Second), want: true},
+		// The same instant in another time zone is judged the same.
+		{name: "at expiry elsewhere", expires: &expiry, at: expiry.In(time.FixedZone("UTC+2", 2*60*60)), want: true},
+	}
+	for _, tt := range tests {
+		v := Voucher{ExpiresAt: tt.expires}
+		if got := v.ExpiredAt(tt.at); got != tt.want {
+			t.Errorf("%s: ExpiredAt = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestVoucherAppliesTo(t *testing.T) {
+	if !(Voucher{}).AppliesTo("any") {
+		t.Errorf("a voucher with no foods should apply to every food")
+	}
+	v := Voucher{FoodIDs: []string{"soda"}}
+	if !v.AppliesTo("soda") || v.AppliesTo("burger") {
+		t.Errorf("a voucher for soda should apply to soda only")
+	}
+}
@@ -0,0 +1,279 @@
+// Package pricing turns the items of an order into a priced bill: line
+// amounts, tax per category, service charge and a rounded grand total.
+package pricing
+
+import (
+	"fmt"
+	"os"
+	"restaurant-management/models"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Config holds the rates a bill is priced with. Menu prices are net of tax.
+type Config struct {
+	// TaxRates maps a food tax category to its rate, e.g. 0.2 for 20%.
+	TaxRates map[string]float64
+	// ServiceChargeRate is charged on the subtotal and is not itself taxed.
+	ServiceChargeRate float64
+	// RoundingIncrement is the step, in minor units, the grand total is
+	// rounded to, e.g. 5 where the smallest coin in circulation is five
+	// cents. Line tax and the service charge are always rounded to the
+	// minor unit.
+	RoundingIncrement int64
+}
+
+// DefaultConfig charges no tax or service and rounds to the minor unit.
+func DefaultConfig() Config {
+	return Config{
+		TaxRates: map[string]float64{
+			models.TaxStandard: 0,
+			models.TaxReduced:  0,
+			models.TaxZero:     0,
+		},
+		RoundingIncrement: 1,
+	}
+}
+
+// ConfigFromEnv starts from DefaultConfig and applies TAX_RATES (for example
+// "standard=0.2,reduced=0.05"), SERVICE_CHARGE_RATE and ROUNDING_INCREMENT
+// (in major units of the default currency, e.g. "0.05") where they are set.
+func ConfigFromEnv() (Config, error) {
+	config := DefaultConfig()
+
+	if value := os.Getenv("TAX_RATES"); value != "" {
+		for _, pair := range strings.Split(value, ",") {
+			category, rate, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			if !ok {
+				return config, fmt.Errorf("TAX_RATES: %q is not category=rate", pair)
+			}
+			if _, known := config.TaxRates[category]; !known {
+				return config, fmt.Errorf("TAX_RATES: unknown tax category %q", category)
+			}
+			r, err := parseRate(rate)
+			if err != nil {
+				return config, fmt.Errorf("TAX_RATES: %s: %w", category, err)
+			}
+			config.TaxRates[category] = r
+		}
+	}
+
+	if value := os.Getenv("SERVICE_CHARGE_RATE"); value != "" {
+		r, err := parseRate(value)
+		if err != nil {
+			return config, fmt.Errorf("SERVICE_CHARGE_RATE: %w", err)
+		}
+		config.ServiceChargeRate = r
+	}
+
+	if value := os.Getenv("ROUNDING_INCREMENT"); value != "" {
+		increment, err := models.ParseMoney(value, "")
+		if err != nil || increment.Amount < 1 {
+			return config, fmt.Errorf("ROUNDING_INCREMENT: %q must be at least one minor unit", value)
+		}
+		config.RoundingIncrement = increment.Amount
+	}
+
+	return config, nil
+}
+
+func parseRate(value string) (float64, error) {
+	rate, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || rate < 0 || rate > 1 {
+		return 0, fmt.Errorf("%q must be a rate between 0 and 1", value)
+	}
+	return rate, nil
+}
+
+// Price fills in invoice's lines, tax breakdown and totals. Each line must
+// carry its order item, food, quantity, unit price and tax category; an empty
+// tax category is taxed as standard. Every line must be in the same currency.
+// Lines may also carry the discount Discount set on them.
+func (c Config) Price(invoice *models.Invoice, lines []models.InvoiceLine) error {
+	currency := models.DefaultCurrency()
+	if len(lines) > 0 {
+		currency = lines[0].UnitPrice.Currency
+	}
+
+	priced := make([]models.InvoiceLine, len(lines))
+	taxes := map[string]*models.InvoiceTax{}
+	subtotal := models.NewMoney(0, currency)
+	discount := models.NewMoney(0, currency)
+	taxTotal := models.NewMoney(0, currency)
+
+	for i, line := range lines {
+		if line.UnitPrice.Currency != currency {
+			return models.ErrCurrencyMismatch
+		}
+		if line.TaxCategory == "" {
+			line.TaxCategory = models.TaxStandard
+		}
+		if line.Discount.Currency == "" {
+			line.Discount.Currency = currency
+		}
+		amount, err := line.UnitPrice.Mul(int64(line.Quantity)).Sub(line.Discount)
+		if err != nil {
+			return err
+		}
+		line.Amount = amount
+		line.TaxRate = c.TaxRates[line.TaxCategory]
+		line.Tax = line.Amount.MulRate(line.TaxRate)
+		priced[i] = line
+
+		tax, ok := taxes[line.TaxCategory]
+		if !ok {
+			tax = &models.InvoiceTax{
+				Category: line.TaxCategory,
+				Rate:     line.TaxRate,
+				Taxable:  models.NewMoney(0, currency),
+				Amount:   models.NewMoney(0, currency),
+			}
+			taxes[line.TaxCategory] = tax
+		}
+		tax.Taxable, _ = tax.Taxable.Add(line.Amount)
+		tax.Amount, _ = tax.Amount.Add(line.Tax)
+
+		subtotal, _ = subtotal.Add(line.Amount)
+		discount, _ = discount.Add(line.Discount)
+		taxTotal, _ = taxTotal.Add(line.Tax)
+	}
+
+	breakdown := make([]models.InvoiceTax, 0, len(taxes))
+	for _, tax := range taxes {
+		breakdown = append(breakdown, *tax)
+	}
+	sort.Slice(breakdown, func(i, j int) bool { return breakdown[i].Category < breakdown[j].Category })
+
+	serviceChargeRate := c.ServiceChargeRate
+	serviceCharge := subtotal.MulRate(serviceChargeRate)
+
+	due, err := subtotal.Add(taxTotal)
+	if err != nil {
+		return err
+	}
+	if due, err = due.Add(serviceCharge); err != nil {
+		return err
+	}
+	grandTotal := due.RoundTo(c.RoundingIncrement)
+	rounding, err := grandTotal.Sub(due)
+	if err != nil {
+		return err
+	}
+
+	invoice.Lines = priced
+	invoice.Taxes = breakdown
+	invoice.Discount = &discount
+	invoice.Subtotal = &subtotal
+	invoice.TaxTotal = &taxTotal
+	invoice.ServiceChargeRate = &serviceChargeRate
+	invoice.ServiceCharge = &serviceCharge
+	invoice.Rounding = &rounding
+	invoice.GrandTotal = &grandTotal
+	return nil
+}
+
+// PriceParts prices each group of lines as its own bill, as when an order's
+// items are split across several invoices. Pricing the parts separately can
+// round the service charge and grand total differently from the whole order,
+// so any difference is taken up by the last part's rounding and the parts
+// always add up to what the whole order would have cost.
+func (c Config) PriceParts(groups [][]models.InvoiceLine) ([]models.Invoice, error) {
+	var all []models.InvoiceLine
+	parts := make([]models.Invoice, len(groups))
+	for i, lines := range groups {
+		if err := c.Price(&parts[i], lines); err != nil {
+			return nil, err
+		}
+		all = append(all, lines...)
+	}
+
+	var whole models.Invoice
+	if err := c.Price(&whole, all); err != nil {
+		return nil, err
+	}
+
+	difference := *whole.GrandTotal
+	for _, part := range parts {
+		difference, _ = difference.Sub(*part.GrandTotal)
+	}
+	last := &parts[len(parts)-1]
+	grandTotal, _ := last.GrandTotal.Add(difference)
+	rounding, _ := last.Rounding.Add(difference)
+	last.GrandTotal, last.Rounding = &grandTotal, &rounding
+
+	return parts, nil
+}
+
+// SplitEvenly divides a priced bill into ways equal shares. Every line, the
+// service charge and the grand total are shared out to the minor unit, with
+// the grand totals kept to the rounding increment, so the shares add up to
+// the bill exactly; each share's rounding absorbs what is left over.
+func (c Config) SplitEvenly(bill models.Invoice, ways int) []models.Invoice {
+	parts := make([]models.Invoice, ways)
+	currency := bill.GrandTotal.Currency
+
+	for i := range parts {
+		parts[i].Lines = make([]models.InvoiceLine, len(bill.Lines))
+	}
+	for l, line := range bill.Lines {
+		amounts := line.Amount.Allocate(ways, 1)
+		discounts := line.Discount.Allocate(ways, 1)
+		taxes := line.Tax.Allocate(ways, 1)
+		for i := range parts {
+			// Start each line's larger shares at a different part so the
+			// odd cents do not all land on the first invoice.
+			share := (i + l) % ways
+			part := line
+			part.Amount, part.Discount, part.Tax = amounts[share], discounts[share], taxes[share]
+			parts[i].Lines[l] = part
+		}
+	}
+
+	serviceCharges := bill.ServiceCharge.Allocate(ways, 1)
+	grandTotals := bill.GrandTotal.Allocate(ways, c.RoundingIncrement)
+
+	for i := range parts {
+		part := &parts[i]
+		subtotal := models.NewMoney(0, currency)
+		discount := models.NewMoney(0, currency)
+		taxTotal := models.NewMoney(0, currency)
+		byCategory := map[string]int{}
+		for _, line := range part.Lines {
+			subtotal, _ = subtotal.Add(line.Amount)
+			discount, _ = discount.Add(line.Discount)
+			taxTotal, _ = taxTotal.Add(line.Tax)
+
+			j, ok := byCategory[line.TaxCategory]
+			if !ok {
+				j = len(part.Taxes)
+				byCategory[line.TaxCategory] = j
+				part.Taxes = append(part.Taxes, models.InvoiceTax{
+					Category: line.TaxCategory,
+					Rate:     line.TaxRate,
+					Taxable:  models.NewMoney(0, currency),
+					Amount:   models.NewMoney(0, currency),
+				})
+			}
+			part.Taxes[j].Taxable, _ = part.Taxes[j].Taxable.Add(line.Amount)
+			part.Taxes[j].Amount, _ = part.Taxes[j].Amount.Add(line.Tax)
+		}
+		sort.Slice(part.Taxes, func(a, b int) bool { return part.Taxes[a].Category < part.Taxes[b].Category })
+
+		serviceChargeRate := *bill.ServiceChargeRate
+		serviceCharge := serviceCharges[i]
+		grandTotal := grandTotals[i]
+		due, _ := subtotal.Add(taxTotal)
+		due, _ = due.Add(serviceCharge)
+		rounding, _ := grandTotal.Sub(due)
+
+		part.Discount = &discount
+		part.Subtotal = &subtotal
+		part.TaxTotal = &taxTotal
+		part.ServiceChargeRate = &serviceChargeRate
+		part.ServiceCharge = &serviceCharge
+		part.Rounding = &rounding
+		part.GrandTotal = &grandTotal
+	}
+	return parts
+}
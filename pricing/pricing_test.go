@@ -0,0 +1,239 @@
+package pricing
+
+import (
+	"restaurant-management/models"
+	"testing"
+)
+
+func usd(amount int64) models.Money {
+	return models.NewMoney(amount, "USD")
+}
+
+// testConfig taxes standard food at 10% and reduced at 5%, charges 12.5%
+// service and rounds grand totals to five cents.
+func testConfig() Config {
+	config := DefaultConfig()
+	config.TaxRates[models.TaxStandard] = 0.1
+	config.TaxRates[models.TaxReduced] = 0.05
+	config.ServiceChargeRate = 0.125
+	config.RoundingIncrement = 5
+	return config
+}
+
+func line(quantity int, unitPrice models.Money, category string) models.InvoiceLine {
+	return models.InvoiceLine{Quantity: quantity, UnitPrice: unitPrice, TaxCategory: category}
+}
+
+func TestPrice(t *testing.T) {
+	type totals struct {
+		subtotal, discount, tax, service, rounding, grand int64
+	}
+	discounted := line(2, usd(1200), models.TaxStandard)
+	discounted.Discount = usd(340)
+	foreignDiscount := line(1, usd(1200), models.TaxStandard)
+	foreignDiscount.Discount = models.NewMoney(100, "EUR")
+
+	tests := []struct {
+		name    string
+		lines   []models.InvoiceLine
+		want    totals
+		wantErr error
+	}{
+		{
+			name: "mixed tax categories",
+			lines: []models.InvoiceLine{
+				line(2, usd(1200), models.TaxStandard),
+				line(1, usd(300), models.TaxReduced),
+				line(1, usd(250), models.TaxZero),
+			},
+			// Service is 368.75, rounded to 369; 3574 rounds up to 3575.
+			want: totals{subtotal: 2950, tax: 255, service: 369, rounding: 1, grand: 3575},
+		},
+		{
+			name:  "discounted line",
+			lines: []models.InvoiceLine{discounted},
+			want:  totals{subtotal: 2060, discount: 340, tax: 206, service: 258, rounding: 1, grand: 2525},
+		},
+		{
+			name: "negative line and no tax category",
+			lines: []models.InvoiceLine{
+				line(1, usd(1200), ""),
+				line(1, usd(-200), ""),
+			},
+			want: totals{subtotal: 1000, tax: 100, service: 125, grand: 1225},
+		},
+		{
+			name:  "rounded down",
+			lines: []models.InvoiceLine{line(1, usd(1001), models.TaxZero)},
+			want:  totals{subtotal: 1001, service: 125, rounding: -1, grand: 1125},
+		},
+		{
+			name: "lines in different currencies",
+			lines: []models.InvoiceLine{
+				line(1, usd(1200), models.TaxStandard),
+				line(1, models.NewMoney(1200, "EUR"), models.TaxStandard),
+			},
+			wantErr: models.ErrCurrencyMismatch,
+		},
+		{
+			name:    "discount in another currency",
+			lines:   []models.InvoiceLine{foreignDiscount},
+			wantErr: models.ErrCurrencyMismatch,
+		},
+	}
+
+	config := testConfig()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var invoice models.Invoice
+			err := config.Price(&invoice, tt.lines)
+			if err != tt.wantErr {
+				t.Fatalf("Price error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			got := totals{
+				subtotal: invoice.Subtotal.Amount,
+				discount: invoice.Discount.Amount,
+				tax:      invoice.TaxTotal.Amount,
+				service:  invoice.ServiceCharge.Amount,
+				rounding: invoice.Rounding.Amount,
+				grand:    invoice.GrandTotal.Amount,
+			}
+			if got != tt.want {
+				t.Errorf("totals = %+v, want %+v", got, tt.want)
+			}
+			if invoice.GrandTotal.Currency != "USD" {
+				t.Errorf("currency = %s, want USD", invoice.GrandTotal.Currency)
+			}
+			for _, l := range invoice.Lines {
+				if l.TaxCategory == "" {
+					t.Errorf("line left with no tax category")
+				}
+			}
+		})
+	}
+}
+
+func TestPriceTaxBreakdown(t *testing.T) {
+	var invoice models.Invoice
+	err := testConfig().Price(&invoice, []models.InvoiceLine{
+		line(1, usd(250), models.TaxZero),
+		line(2, usd(1200), models.TaxStandard),
+		line(1, usd(300), models.TaxReduced),
+		line(1, usd(100), ""),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []models.InvoiceTax{
+		{Category: models.TaxReduced, Rate: 0.05, Taxable: usd(300), Amount: usd(15)},
+		{Category: models.TaxStandard, Rate: 0.1, Taxable: usd(2500), Amount: usd(250)},
+		{Category: models.TaxZero, Rate: 0, Taxable: usd(250), Amount: usd(0)},
+	}
+	if len(invoice.Taxes) != len(want) {
+		t.Fatalf("got %d tax rates, want %d: %+v", len(invoice.Taxes), len(want), invoice.Taxes)
+	}
+	for i := range want {
+		if invoice.Taxes[i] != want[i] {
+			t.Errorf("tax %d = %+v, want %+v", i, invoice.Taxes[i], want[i])
+		}
+	}
+}
+
+func TestPricePartsAddUpToTheWhole(t *testing.T) {
+	// Alone, each part's 12.5% service is 125.25, rounded to 125, and
+	// 1127 rounds down to 1125. Together the service is 250.5, rounded to
+	// 251, and the bill is 2255, so the last part takes up the 5 cents.
+	groups := [][]models.InvoiceLine{
+		{line(1, usd(1002), models.TaxZero)},
+		{line(1, usd(1002), models.TaxZero)},
+	}
+	parts, err := testConfig().PriceParts(groups)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantGrand := []int64{1125, 1130}
+	wantRounding := []int64{-2, 3}
+	for i, part := range parts {
+		if part.GrandTotal.Amount != wantGrand[i] || part.Rounding.Amount != wantRounding[i] {
+			t.Errorf("part %d grand total %v, rounding %v; want %d, %d", i, part.GrandTotal, part.Rounding, wantGrand[i], wantRounding[i])
+		}
+	}
+
+	if _, err := testConfig().PriceParts([][]models.InvoiceLine{
+		{line(1, usd(1002), models.TaxZero)},
+		{line(1, models.NewMoney(1002, "EUR"), models.TaxZero)},
+	}); err != models.ErrCurrencyMismatch {
+		t.Errorf("parts in different currencies: error = %v, want ErrCurrencyMismatch", err)
+	}
+}
+
+func TestSplitEvenly(t *testing.T) {
+	config := testConfig()
+	var bill models.Invoice
+	err := config.Price(&bill, []models.InvoiceLine{
+		line(2, usd(1200), models.TaxStandard),
+		line(1, usd(300), models.TaxReduced),
+		line(1, usd(250), models.TaxZero),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		ways  int
+		grand []int64
+		// zeroLine is each part's share of the 2.50 zero-rated line, whose
+		// odd cent starts at the part matching the line's index.
+		zeroLine []int64
+	}{
+		{ways: 1, grand: []int64{3575}, zeroLine: []int64{250}},
+		{ways: 2, grand: []int64{1790, 1785}, zeroLine: []int64{125, 125}},
+		{ways: 3, grand: []int64{1195, 1190, 1190}, zeroLine: []int64{83, 84, 83}},
+		{ways: 4, grand: []int64{895, 895, 895, 890}, zeroLine: []int64{62, 62, 63, 63}},
+	}
+	for _, tt := range tests {
+		parts := config.SplitEvenly(bill, tt.ways)
+		if len(parts) != tt.ways {
+			t.Fatalf("%d ways: got %d parts", tt.ways, len(parts))
+		}
+
+		var grand, service int64
+		lineTotals := make([]int64, len(bill.Lines))
+		taxTotals := make([]int64, len(bill.Lines))
+		for i, part := range parts {
+			if part.GrandTotal.Amount != tt.grand[i] {
+				t.Errorf("%d ways: part %d grand total = %v, want %d", tt.ways, i, part.GrandTotal, tt.grand[i])
+			}
+			if part.Lines[2].Amount.Amount != tt.zeroLine[i] {
+				t.Errorf("%d ways: part %d zero-rated share = %v, want %d", tt.ways, i, part.Lines[2].Amount, tt.zeroLine[i])
+			}
+			due := part.Subtotal.Amount + part.TaxTotal.Amount + part.ServiceCharge.Amount + part.Rounding.Amount
+			if due != part.GrandTotal.Amount {
+				t.Errorf("%d ways: part %d adds up to %d, grand total is %v", tt.ways, i, due, part.GrandTotal)
+			}
+			grand += part.GrandTotal.Amount
+			service += part.ServiceCharge.Amount
+			for l, share := range part.Lines {
+				lineTotals[l] += share.Amount.Amount
+				taxTotals[l] += share.Tax.Amount
+			}
+		}
+
+		if grand != bill.GrandTotal.Amount {
+			t.Errorf("%d ways: grand totals add up to %d, want %v", tt.ways, grand, bill.GrandTotal)
+		}
+		if service != bill.ServiceCharge.Amount {
+			t.Errorf("%d ways: service charges add up to %d, want %v", tt.ways, service, bill.ServiceCharge)
+		}
+		for l, billed := range bill.Lines {
+			if lineTotals[l] != billed.Amount.Amount || taxTotals[l] != billed.Tax.Amount {
+				t.Errorf("%d ways: line %d shares add up to %d tax %d, want %v tax %v", tt.ways, l, lineTotals[l], taxTotals[l], billed.Amount, billed.Tax)
+			}
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package pricing
+
+import (
+	"fmt"
+	"restaurant-management/models"
+	"sort"
+	"time"
+)
+
+// UnitPrice is what one item ordered is charged, and the rule, if any, that
+// made it differ from the food's list price.
+type UnitPrice struct {
+	Price     models.Money
+	ListPrice models.Money
+	Rule      *models.PriceRule
+}
+
+// Line is Quantity items of Food ordered together with the other lines.
+type Line struct {
+	Food     models.Food
+	Quantity int
+}
+
+// LinePrice is what Quantity of the items of a line are each charged.
+type LinePrice struct {
+	UnitPrice
+	Quantity int
+}
+
+// Prices prices lines, ordered together at now, under the rules in force
+// then, and returns the prices each line's items come to, in the order they
+// first come up among its items. Each food's Price is taken as its list
+// price, so callers pass foods at the price of the size ordered. Rules count
+// a line of three as they would three lines of one, but prices are worked
+// out from the counts, however many items are ordered. Each item is priced
+// by at most one rule:
+//
+//   - BUNDLE rules come first, in the order given. Each takes one item of
+//     every food it lists, as many times over as the order allows, provided
+//     the bundle costs less than the items would, and shares its price out
+//     between them in proportion to their list prices.
+//   - BUY_X_GET_Y rules then group the items left that they apply to, dearest
+//     first, and make the cheapest FreeQuantity of each full group free.
+//   - Every item still at its list price is then charged the lowest price any
+//     HAPPY_HOUR or MENU_OVERRIDE rule that applies to it gives, if that is
+//     lower.
+//
+// A rule whose amounts are in another currency from a food's price leaves
+// that food alone.
+func Prices(lines []Line, rules []models.PriceRule, now time.Time) ([][]LinePrice, error) {
+	priced := make([]pricedLine, len(lines))
+	for i, line := range lines {
+		if line.Food.Price == nil {
+			return nil, fmt.Errorf("food %s has no price", line.Food.FoodID)
+		}
+		priced[i] = pricedLine{food: line.Food, listPrice: *line.Food.Price, left: line.Quantity}
+	}
+
+	for i := range rules {
+		rule := &rules[i]
+		if rule.Kind == models.PriceRuleBundle && rule.ActiveAt(now) {
+			applyBundle(rule, priced)
+		}
+	}
+	for i := range rules {
+		rule := &rules[i]
+		if rule.Kind == models.PriceRuleBuyXGetY && rule.ActiveAt(now) {
+			applyBuyXGetY(rule, priced)
+		}
+	}
+
+	prices := make([][]LinePrice, len(lines))
+	for i := range priced {
+		line := &priced[i]
+		line.add(line.listPrice, nil, line.left)
+		line.left = 0
+
+		// Every item still at its list price is charged the same, so the
+		// lowest price is found once for the line.
+		price, rule := line.listPrice, (*models.PriceRule)(nil)
+		for j := range rules {
+			candidate := &rules[j]
+			if candidate.Kind != models.PriceRuleHappyHour && candidate.Kind != models.PriceRuleMenuOverride {
+				continue
+			}
+			if !candidate.ActiveAt(now) || !candidate.AppliesTo(line.food) {
+				continue
+			}
+			if adjusted, ok := adjust(candidate, line.listPrice); ok && adjusted.Amount < price.Amount {
+				price, rule = adjusted, candidate
+			}
+		}
+
+		for _, part := range line.parts {
+			if part.Rule == nil {
+				part.Price, part.Rule = price, rule
+			}
+			prices[i] = addPrice(prices[i], part)
+		}
+	}
+	return prices, nil
+}
+
+// pricedLine is a line being priced: the prices its items have come to so
+// far, and how many of them no bundle or buy-x-get-y rule has counted yet.
+type pricedLine struct {
+	food      models.Food
+	listPrice models.Money
+	left      int
+	parts     []LinePrice
+}
+
+// add prices quantity more of the line's items at price under rule.
+func (l *pricedLine) add(price models.Money, rule *models.PriceRule, quantity int) {
+	if quantity > 0 {
+		l.parts = addPrice(l.parts, LinePrice{UnitPrice: UnitPrice{Price: price, ListPrice: l.listPrice, Rule: rule}, Quantity: quantity})
+	}
+}
+
+// addPrice adds part to prices, counting it with any price already there
+// that is the same and by the same rule.
+func addPrice(prices []LinePrice, part LinePrice) []LinePrice {
+	for i := range prices {
+		if prices[i].Price == part.Price && prices[i].Rule == part.Rule {
+			prices[i].Quantity += part.Quantity
+			return prices
+		}
+	}
+	return append(prices, part)
+}
+
+// adjust returns price as a HAPPY_HOUR or MENU_OVERRIDE rule changes it,
+// never below zero, and false if the rule cannot change it.
+func adjust(rule *models.PriceRule, price models.Money) (models.Money, bool) {
+	switch {
+	case rule.Price != nil:
+		if rule.Price.Currency != price.Currency {
+			return price, false
+		}
+		return *rule.Price, true
+	case rule.AmountOff != nil:
+		if rule.AmountOff.Currency != price.Currency {
+			return price, false
+		}
+		price.Amount -= rule.AmountOff.Amount
+	case rule.DiscountRate != nil:
+		price.Amount -= price.MulRate(*rule.DiscountRate).Amount
+	default:
+		return price, false
+	}
+	if price.Amount < 0 {
+		price.Amount = 0
+	}
+	return price, true
+}
+
+// applyBundle sells as many bundles of rule's foods as can be made from the
+// items not yet grouped. Each of the foods listed is taken from the first
+// line that has an item of it left, and bundles are made from the same lines
+// for as long as they all have enough left.
+func applyBundle(rule *models.PriceRule, lines []pricedLine) {
+	if rule.Price == nil || len(rule.FoodIDs) == 0 {
+		return
+	}
+	for {
+		uses := map[int]int{}
+		slots := []int{}
+		for _, foodID := range rule.FoodIDs {
+			for i := range lines {
+				if lines[i].left > uses[i] && lines[i].food.FoodID == foodID {
+					uses[i]++
+					slots = append(slots, i)
+					break
+				}
+			}
+		}
+		if len(slots) < len(rule.FoodIDs) {
+			return
+		}
+
+		var total int64
+		for _, i := range slots {
+			if lines[i].listPrice.Currency != rule.Price.Currency {
+				return
+			}
+			total += lines[i].listPrice.Amount
+		}
+		if rule.Price.Amount >= total {
+			return
+		}
+
+		bundles := -1
+		for i, n := range uses {
+			if bundles < 0 || lines[i].left/n < bundles {
+				bundles = lines[i].left / n
+			}
+		}
+
+		// Each item gets its share of the bundle price, rounded down, and
+		// the first makes up what rounding left over.
+		shares := make([]int64, len(slots))
+		var shared int64
+		for n, i := range slots {
+			shares[n] = rule.Price.Amount * lines[i].listPrice.Amount / total
+			shared += shares[n]
+		}
+		shares[0] += rule.Price.Amount - shared
+		for n, i := range slots {
+			lines[i].add(models.NewMoney(shares[n], rule.Price.Currency), rule, bundles)
+			lines[i].left -= bundles
+		}
+	}
+}
+
+// applyBuyXGetY makes the cheapest FreeQuantity items of every full group of
+// BuyQuantity+FreeQuantity items rule applies to free.
+func applyBuyXGetY(rule *models.PriceRule, lines []pricedLine) {
+	size := rule.BuyQuantity + rule.FreeQuantity
+	if rule.BuyQuantity < 1 || rule.FreeQuantity < 1 {
+		return
+	}
+
+	eligible := []int{}
+	count := 0
+	for i := range lines {
+		if lines[i].left > 0 && rule.AppliesTo(lines[i].food) {
+			eligible = append(eligible, i)
+			count += lines[i].left
+		}
+	}
+	sort.SliceStable(eligible, func(a, b int) bool {
+		return lines[eligible[a]].listPrice.Amount > lines[eligible[b]].listPrice.Amount
+	})
+
+	// free counts the free items among the first n of the items lined up
+	// dearest first.
+	free := func(n int) int {
+		return n/size*rule.FreeQuantity + max(n%size-rule.BuyQuantity, 0)
+	}
+	grouped := count / size * size
+	start := 0
+	for _, i := range eligible {
+		line := &lines[i]
+		taken := max(min(start+line.left, grouped)-start, 0)
+		if taken > 0 {
+			freed := free(start+taken) - free(start)
+			paid := taken - freed
+			zero := models.NewMoney(0, line.listPrice.Currency)
+			if start%size < rule.BuyQuantity {
+				line.add(line.listPrice, nil, paid)
+				line.add(zero, rule, freed)
+			} else {
+				line.add(zero, rule, freed)
+				line.add(line.listPrice, nil, paid)
+			}
+		}
+		start += line.left
+		line.left -= taken
+	}
+}
@@ -0,0 +1,228 @@
+package pricing
+
+import (
+	"fmt"
+	"restaurant-management/models"
+	"testing"
+	"time"
+)
+
+func food(id string, price int64, menuID string) models.Food {
+	listPrice := usd(price)
+	f := models.Food{FoodID: id, Price: &listPrice}
+	if menuID != "" {
+		f.MenuID = &menuID
+	}
+	return f
+}
+
+// summarize writes a line's prices as "price×quantity(rule)" so a test can
+// compare them, and their order, in one go.
+func summarize(prices []LinePrice) string {
+	s := ""
+	for _, p := range prices {
+		rule := ""
+		if p.Rule != nil {
+			rule = p.Rule.Name
+		}
+		s += fmt.Sprintf("%d×%d(%s) ", p.Price.Amount, p.Quantity, rule)
+	}
+	return s
+}
+
+func rate(r float64) *float64 {
+	return &r
+}
+
+func money(amount int64) *models.Money {
+	m := usd(amount)
+	return &m
+}
+
+var everyDay = []string{"MON", "TUE", "WED", "THU", "FRI", "SAT", "SUN"}
+
+func TestHappyHourWindow(t *testing.T) {
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	rules := []models.PriceRule{
+		{Name: "happy", Kind: models.PriceRuleHappyHour, DiscountRate: rate(0.25), StartDate: &start, EndDate: &end,
+			Schedule: []models.MenuSlot{{Days: everyDay, Start: "17:00", End: "19:00"}}},
+		// Saturday late: runs past midnight into Sunday morning.
+		{Name: "late", Kind: models.PriceRuleHappyHour, AmountOff: money(500), StartDate: &start, EndDate: &end,
+			Schedule: []models.MenuSlot{{Days: []string{"SAT"}, Start: "22:00", End: "02:00"}}},
+	}
+	lines := []Line{{Food: food("beer", 1000, ""), Quantity: 1}}
+
+	// 14 March 2026 is a Saturday.
+	at := func(day, hour, minute, second int) time.Time {
+		return time.Date(2026, 3, day, hour, minute, second, 0, time.UTC)
+	}
+	tests := []struct {
+		name string
+		now  time.Time
+		want string
+	}{
+		{name: "just before the slot", now: at(13, 16, 59, 59), want: "1000×1() "},
+		{name: "as the slot starts", now: at(13, 17, 0, 0), want: "750×1(happy) "},
+		{name: "last minute of the slot", now: at(13, 18, 59, 59), want: "750×1(happy) "},
+		{name: "as the slot ends", now: at(13, 19, 0, 0), want: "1000×1() "},
+		{name: "as the rule starts", now: time.Date(2026, 3, 1, 17, 0, 0, 0, time.UTC), want: "750×1(happy) "},
+		{name: "after the rule ends", now: time.Date(2026, 4, 1, 17, 0, 0, 0, time.UTC), want: "1000×1() "},
+		{name: "late slot on its day", now: at(14, 23, 30, 0), want: "500×1(late) "},
+		{name: "late slot after midnight", now: at(15, 1, 59, 0), want: "500×1(late) "},
+		{name: "late slot over", now: at(15, 2, 0, 0), want: "1000×1() "},
+		{name: "late slot on another day", now: at(15, 23, 30, 0), want: "1000×1() "},
+	}
+	for _, tt := range tests {
+		prices, err := Prices(lines, rules, tt.now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := summarize(prices[0]); got != tt.want {
+			t.Errorf("%s: priced %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPriceRulePrecedence(t *testing.T) {
+	now := time.Date(2026, 3, 13, 18, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		lines []Line
+		rules []models.PriceRule
+		want  []string
+	}{
+		{
+			name:  "the lowest adjusted price wins and discounts do not stack",
+			lines: []Line{{Food: food("steak", 1000, "dinner"), Quantity: 2}},
+			rules: []models.PriceRule{
+				{Name: "quarter", Kind: models.PriceRuleHappyHour, DiscountRate: rate(0.25)},
+				{Name: "three", Kind: models.PriceRuleMenuOverride, MenuIDs: []string{"dinner"}, AmountOff: money(300)},
+				{Name: "fixed", Kind: models.PriceRuleMenuOverride, Price: money(800)},
+			},
+			want: []string{"700×2(three) "},
+		},
+		{
+			name:  "a rule for other menus or foods leaves the food alone",
+			lines: []Line{{Food: food("steak", 1000, "lunch"), Quantity: 1}},
+			rules: []models.PriceRule{
+				{Name: "dinner", Kind: models.PriceRuleMenuOverride, MenuIDs: []string{"dinner"}, Price: money(500)},
+				{Name: "fish", Kind: models.PriceRuleHappyHour, FoodIDs: []string{"fish"}, Price: money(500)},
+			},
+			want: []string{"1000×1() "},
+		},
+		{
+			name:  "a price never goes below zero",
+			lines: []Line{{Food: food("soda", 300, ""), Quantity: 1}},
+			rules: []models.PriceRule{{Name: "big", Kind: models.PriceRuleHappyHour, AmountOff: money(500)}},
+			want:  []string{"0×1(big) "},
+		},
+		{
+			name:  "a rule in another currency is ignored",
+			lines: []Line{{Food: food("soda", 300, ""), Quantity: 1}},
+			rules: []models.PriceRule{{Name: "euro", Kind: models.PriceRuleHappyHour, Price: &models.Money{Amount: 100, Currency: "EUR"}}},
+			want:  []string{"300×1() "},
+		},
+		{
+			// The bundle takes a burger and the fries first, buy one get
+			// one then pairs the two burgers left, and happy hour only
+			// reaches the burger still at its list price.
+			name: "bundles, then buy-x-get-y, then happy hour",
+			lines: []Line{
+				{Food: food("burger", 1000, ""), Quantity: 3},
+				{Food: food("fries", 400, ""), Quantity: 1},
+			},
+			rules: []models.PriceRule{
+				{Name: "half", Kind: models.PriceRuleHappyHour, DiscountRate: rate(0.5)},
+				{Name: "bogo", Kind: models.PriceRuleBuyXGetY, FoodIDs: []string{"burger"}, BuyQuantity: 1, FreeQuantity: 1},
+				{Name: "meal", Kind: models.PriceRuleBundle, FoodIDs: []string{"burger", "fries"}, Price: money(1200)},
+			},
+			// 1200 shared 1000:400 is 857.14 and 342.86; the burger takes
+			// the cent rounding down left over.
+			want: []string{"858×1(meal) 500×1(half) 0×1(bogo) ", "342×1(meal) "},
+		},
+		{
+			name: "a bundle that costs more than its items is not applied",
+			lines: []Line{
+				{Food: food("burger", 1000, ""), Quantity: 1},
+				{Food: food("fries", 400, ""), Quantity: 1},
+			},
+			rules: []models.PriceRule{
+				{Name: "meal", Kind: models.PriceRuleBundle, FoodIDs: []string{"burger", "fries"}, Price: money(1400)},
+			},
+			want: []string{"1000×1() ", "400×1() "},
+		},
+		{
+			name: "a bundle is made as many times as the items allow",
+			lines: []Line{
+				{Food: food("taco", 300, ""), Quantity: 5},
+			},
+			rules: []models.PriceRule{
+				{Name: "pair", Kind: models.PriceRuleBundle, FoodIDs: []string{"taco", "taco"}, Price: money(500)},
+			},
+			want: []string{"250×4(pair) 300×1() "},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prices, err := Prices(tt.lines, tt.rules, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, want := range tt.want {
+				if got := summarize(prices[i]); got != want {
+					t.Errorf("line %d priced %q, want %q", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestBuyXGetYCounts(t *testing.T) {
+	now := time.Date(2026, 3, 13, 18, 0, 0, 0, time.UTC)
+	buy2get1 := []models.PriceRule{{Name: "3for2", Kind: models.PriceRuleBuyXGetY, BuyQuantity: 2, FreeQuantity: 1}}
+
+	tests := []struct {
+		name  string
+		lines []Line
+		want  []string
+	}{
+		{name: "fewer than a group", lines: []Line{{Food: food("pizza", 1500, ""), Quantity: 2}}, want: []string{"1500×2() "}},
+		{name: "one full group", lines: []Line{{Food: food("pizza", 1500, ""), Quantity: 3}}, want: []string{"1500×2() 0×1(3for2) "}},
+		{name: "a group and two over", lines: []Line{{Food: food("pizza", 1500, ""), Quantity: 5}}, want: []string{"1500×4() 0×1(3for2) "}},
+		{name: "two groups and one over", lines: []Line{{Food: food("pizza", 1500, ""), Quantity: 7}}, want: []string{"1500×5() 0×2(3for2) "}},
+		{
+			// Lined up dearest first the items are P P P | P S S | S: the
+			// third of each full group is free and the last soda is not in
+			// a group.
+			name: "groups across foods",
+			lines: []Line{
+				{Food: food("soda", 300, ""), Quantity: 3},
+				{Food: food("pizza", 1500, ""), Quantity: 4},
+			},
+			want: []string{"300×2() 0×1(3for2) ", "1500×3() 0×1(3for2) "},
+		},
+		{
+			name: "items split across lines count together",
+			lines: []Line{
+				{Food: food("pizza", 1500, ""), Quantity: 1},
+				{Food: food("pizza", 1500, ""), Quantity: 1},
+				{Food: food("pizza", 1500, ""), Quantity: 2},
+			},
+			want: []string{"1500×1() ", "1500×1() ", "0×1(3for2) 1500×1() "},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prices, err := Prices(tt.lines, buy2get1, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, want := range tt.want {
+				if got := summarize(prices[i]); got != want {
+					t.Errorf("line %d priced %q, want %q", i, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package pricing
+
+import (
+	"errors"
+	"restaurant-management/models"
+)
+
+// ErrMinimumSpend is returned when an order's items come to less than its
+// voucher's minimum spend.
+var ErrMinimumSpend = errors.New("order does not reach the voucher's minimum spend")
+
+// Discount sets the Discount of each of lines that voucher applies to, ready
+// for Price. Lines the voucher does not apply to are left alone.
+func Discount(voucher models.Voucher, lines []models.InvoiceLine) error {
+	if len(lines) == 0 {
+		return nil
+	}
+	currency := lines[0].UnitPrice.Currency
+	for _, amount := range []*models.Money{voucher.Amount, voucher.MinimumSpend} {
+		if amount != nil && amount.Currency != currency {
+			return models.ErrCurrencyMismatch
+		}
+	}
+
+	var spend, eligible int64
+	gross := make([]int64, len(lines))
+	for i, line := range lines {
+		gross[i] = line.UnitPrice.Mul(int64(line.Quantity)).Amount
+		spend += gross[i]
+		if voucher.AppliesTo(line.FoodID) {
+			eligible += gross[i]
+		}
+	}
+	if voucher.MinimumSpend != nil && spend < voucher.MinimumSpend.Amount {
+		return ErrMinimumSpend
+	}
+
+	// FIXED shares what it takes off out between the lines in proportion to
+	// their amounts; the largest line makes up what rounding leaves over.
+	var fixed, shared int64
+	largest := -1
+	if voucher.Kind == models.VoucherFixed && voucher.Amount != nil && eligible > 0 {
+		fixed = min(voucher.Amount.Amount, eligible)
+	}
+
+	for i, line := range lines {
+		if !voucher.AppliesTo(line.FoodID) {
+			continue
+		}
+		var off int64
+		switch voucher.Kind {
+		case models.VoucherPercentage:
+			if voucher.Rate != nil {
+				off = models.NewMoney(gross[i], currency).MulRate(*voucher.Rate).Amount
+			}
+		case models.VoucherItem:
+			if voucher.Amount != nil {
+				off = voucher.Amount.Amount * int64(line.Quantity)
+			}
+		case models.VoucherFixed:
+			if fixed > 0 {
+				off = fixed * gross[i] / eligible
+				shared += off
+				if largest < 0 || gross[i] > gross[largest] {
+					largest = i
+				}
+			}
+		}
+		lines[i].Discount = models.NewMoney(min(off, gross[i]), currency)
+	}
+	if largest >= 0 {
+		lines[largest].Discount.Amount += fixed - shared
+	}
+	return nil
+}
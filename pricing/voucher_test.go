@@ -0,0 +1,119 @@
+package pricing
+
+import (
+	"restaurant-management/models"
+	"testing"
+)
+
+func TestDiscount(t *testing.T) {
+	// The order comes to 32.00: two burgers, a soda and fries.
+	order := func() []models.InvoiceLine {
+		return []models.InvoiceLine{
+			{FoodID: "burger", Quantity: 2, UnitPrice: usd(1200)},
+			{FoodID: "soda", Quantity: 1, UnitPrice: usd(300)},
+			{FoodID: "fries", Quantity: 1, UnitPrice: usd(500)},
+		}
+	}
+	tests := []struct {
+		name    string
+		voucher models.Voucher
+		want    []int64
+		wantErr error
+	}{
+		{
+			name:    "percentage off every item",
+			voucher: models.Voucher{Kind: models.VoucherPercentage, Rate: rate(0.1)},
+			want:    []int64{240, 30, 50},
+		},
+		{
+			name:    "percentage off some foods",
+			voucher: models.Voucher{Kind: models.VoucherPercentage, Rate: rate(0.1), FoodIDs: []string{"soda"}},
+			want:    []int64{0, 30, 0},
+		},
+		{
+			// 10.00 shared 24:3:5 is 7.50, 0.9375 and 1.5625; the burgers,
+			// the largest line, take the cent rounding down left over.
+			name:    "fixed amount shared in proportion",
+			voucher: models.Voucher{Kind: models.VoucherFixed, Amount: money(1000)},
+			want:    []int64{751, 93, 156},
+		},
+		{
+			name:    "fixed amount capped at what it applies to",
+			voucher: models.Voucher{Kind: models.VoucherFixed, Amount: money(5000), FoodIDs: []string{"soda", "fries"}},
+			want:    []int64{0, 300, 500},
+		},
+		{
+			name:    "amount off each item",
+			voucher: models.Voucher{Kind: models.VoucherItem, Amount: money(200)},
+			want:    []int64{400, 200, 200},
+		},
+		{
+			name:    "amount off an item capped at its price",
+			voucher: models.Voucher{Kind: models.VoucherItem, Amount: money(400), FoodIDs: []string{"soda"}},
+			want:    []int64{0, 300, 0},
+		},
+		{
+			name:    "minimum spend reached exactly",
+			voucher: models.Voucher{Kind: models.VoucherPercentage, Rate: rate(0.1), MinimumSpend: money(3200)},
+			want:    []int64{240, 30, 50},
+		},
+		{
+			name:    "minimum spend not reached",
+			voucher: models.Voucher{Kind: models.VoucherPercentage, Rate: rate(0.1), MinimumSpend: money(3201)},
+			wantErr: ErrMinimumSpend,
+		},
+		{
+			name:    "amount in another currency",
+			voucher: models.Voucher{Kind: models.VoucherFixed, Amount: &models.Money{Amount: 1000, Currency: "EUR"}},
+			wantErr: models.ErrCurrencyMismatch,
+		},
+		{
+			name:    "minimum spend in another currency",
+			voucher: models.Voucher{Kind: models.VoucherPercentage, Rate: rate(0.1), MinimumSpend: &models.Money{Amount: 1, Currency: "EUR"}},
+			wantErr: models.ErrCurrencyMismatch,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := order()
+			err := Discount(tt.voucher, lines)
+			if err != tt.wantErr {
+				t.Fatalf("Discount error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				for i, line := range lines {
+					if line.Discount.Amount != 0 {
+						t.Errorf("line %d discounted %v after an error", i, line.Discount)
+					}
+				}
+				return
+			}
+			for i, line := range lines {
+				if line.Discount.Amount != tt.want[i] {
+					t.Errorf("line %d discount = %v, want %d", i, line.Discount, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDiscountThenPrice(t *testing.T) {
+	lines := []models.InvoiceLine{
+		{FoodID: "burger", Quantity: 2, UnitPrice: usd(1200), TaxCategory: models.TaxStandard},
+		{FoodID: "soda", Quantity: 1, UnitPrice: usd(300), TaxCategory: models.TaxReduced},
+	}
+	if err := Discount(models.Voucher{Kind: models.VoucherFixed, Amount: money(2700)}, lines); err != nil {
+		t.Fatal(err)
+	}
+	var invoice models.Invoice
+	if err := testConfig().Price(&invoice, lines); err != nil {
+		t.Fatal(err)
+	}
+	// The whole order is given away: nothing is taxed or charged.
+	if invoice.Subtotal.Amount != 0 || invoice.TaxTotal.Amount != 0 || invoice.GrandTotal.Amount != 0 {
+		t.Errorf("subtotal %v, tax %v, grand total %v; want all zero", invoice.Subtotal, invoice.TaxTotal, invoice.GrandTotal)
+	}
+	if invoice.Discount.Amount != 2700 {
+		t.Errorf("discount = %v, want 27.00", invoice.Discount)
+	}
+}
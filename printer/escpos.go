@@ -0,0 +1,96 @@
+// Package printer prints kitchen tickets and receipts on ESC/POS thermal
+// printers, sending the encoded jobs to a Sink.
+package printer
+
+import "bytes"
+
+const (
+	esc = 0x1b
+	gs  = 0x1d
+)
+
+const (
+	AlignLeft   = 0
+	AlignCenter = 1
+	AlignRight  = 2
+)
+
+// codePageWPC1252 selects the Windows-1252 character table, which covers
+// the accented letters of Western European menus.
+const codePageWPC1252 = 16
+
+// Encoder builds an ESC/POS byte stream for one print job.
+type Encoder struct {
+	buf bytes.Buffer
+}
+
+// NewEncoder starts a job with the printer reset to its defaults and set to
+// the Windows-1252 character table.
+func NewEncoder() *Encoder {
+	e := &Encoder{}
+	e.buf.Write([]byte{esc, '@'})
+	e.buf.Write([]byte{esc, 't', codePageWPC1252})
+	return e
+}
+
+// Align sets the alignment of the lines that follow.
+func (e *Encoder) Align(align byte) *Encoder {
+	e.buf.Write([]byte{esc, 'a', align})
+	return e
+}
+
+// Bold turns emphasized printing on or off.
+func (e *Encoder) Bold(on bool) *Encoder {
+	e.buf.Write([]byte{esc, 'E', onOff(on)})
+	return e
+}
+
+// Size sets the character size as multiples of the normal width and height,
+// each from 1 to 8.
+func (e *Encoder) Size(width, height byte) *Encoder {
+	e.buf.Write([]byte{gs, '!', (width-1)<<4 | (height - 1)})
+	return e
+}
+
+// Line prints s and ends the line. Characters the Windows-1252 table lacks
+// print as "?".
+func (e *Encoder) Line(s string) *Encoder {
+	for _, r := range s {
+		switch {
+		case r >= ' ' && r <= '~' || r >= 0xA0 && r <= 0xFF:
+			e.buf.WriteByte(byte(r))
+		case r == '€':
+			e.buf.WriteByte(0x80)
+		default:
+			e.buf.WriteByte('?')
+		}
+	}
+	e.buf.WriteByte('\n')
+	return e
+}
+
+// Feed advances the paper n lines.
+func (e *Encoder) Feed(n byte) *Encoder {
+	e.buf.Write([]byte{esc, 'd', n})
+	return e
+}
+
+// Cut feeds the paper clear of the cutter and makes a partial cut, leaving
+// the slip hanging until it is torn off.
+func (e *Encoder) Cut() *Encoder {
+	e.buf.Write([]byte{gs, 'V', 66, 0})
+	return e
+}
+
+// Bytes returns the job encoded so far.
+func (e *Encoder) Bytes() []byte {
+	return e.buf.Bytes()
+}
+
+// onOff is the parameter byte ESC/POS switches a mode on or off with.
+func onOff(on bool) byte {
+	if on {
+		return 1
+	}
+	return 0
+}
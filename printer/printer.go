@@ -0,0 +1,181 @@
+package printer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"restaurant-management/kitchen"
+	"restaurant-management/receipt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrNoPrinter is returned when nothing is configured to print a job on.
+var ErrNoPrinter = errors.New("no printer is configured")
+
+// DefaultStation is the key of the kitchen printer that takes the tickets of
+// stations without a printer of their own.
+const DefaultStation = "*"
+
+// Printers says where jobs are printed. Kitchen maps a station to its ticket
+// printer; Receipt is the cashier's printer.
+type Printers struct {
+	Kitchen map[string]Sink
+	Receipt Sink
+}
+
+// FromEnv reads KITCHEN_PRINTERS (for example
+// "grill=tcp://10.0.0.21,bar=tcp://10.0.0.22:9100,*=stdout") and
+// RECEIPT_PRINTER (a single printer address). Either may be left unset to
+// print nothing there.
+func FromEnv() (Printers, error) {
+	printers := Printers{Kitchen: map[string]Sink{}}
+
+	if value := os.Getenv("KITCHEN_PRINTERS"); value != "" {
+		for _, pair := range strings.Split(value, ",") {
+			station, address, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			if !ok {
+				return printers, fmt.Errorf("KITCHEN_PRINTERS: %q is not station=printer", pair)
+			}
+			sink, err := ParseSink(address)
+			if err != nil {
+				return printers, fmt.Errorf("KITCHEN_PRINTERS: %s: %w", station, err)
+			}
+			printers.Kitchen[station] = sink
+		}
+	}
+
+	if value := os.Getenv("RECEIPT_PRINTER"); value != "" {
+		sink, err := ParseSink(value)
+		if err != nil {
+			return printers, fmt.Errorf("RECEIPT_PRINTER: %w", err)
+		}
+		printers.Receipt = sink
+	}
+
+	return printers, nil
+}
+
+// kitchenSink is the printer station's tickets go to, or nil if none does.
+func (p Printers) kitchenSink(station string) Sink {
+	if sink, ok := p.Kitchen[station]; ok {
+		return sink
+	}
+	return p.Kitchen[DefaultStation]
+}
+
+// PrintTicket prints a slip for each station with items on ticket, on that
+// station's printer. Stations without a printer are skipped; the first
+// printer error is returned after every slip has been tried.
+func (p Printers) PrintTicket(ctx context.Context, ticket kitchen.Ticket, at time.Time) error {
+	var firstErr error
+	for _, station := range ticketStations(ticket) {
+		sink := p.kitchenSink(station)
+		if sink == nil {
+			continue
+		}
+		if err := sink.Print(ctx, EncodeTicket(ticket, station, at)); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("printing %s ticket: %w", stationName(station), err)
+		}
+	}
+	return firstErr
+}
+
+// PrintReceipt prints r on the receipt printer.
+func (p Printers) PrintReceipt(ctx context.Context, r receipt.Receipt) error {
+	if p.Receipt == nil {
+		return ErrNoPrinter
+	}
+	return p.Receipt.Print(ctx, EncodeReceipt(r))
+}
+
+// ticketStations lists the stations ticket has items for, in order.
+func ticketStations(ticket kitchen.Ticket) []string {
+	seen := map[string]bool{}
+	var stations []string
+	for _, item := range ticket.Items {
+		if !seen[item.Station] {
+			seen[item.Station] = true
+			stations = append(stations, item.Station)
+		}
+	}
+	sort.Strings(stations)
+	return stations
+}
+
+func stationName(station string) string {
+	if station == "" {
+		return "kitchen"
+	}
+	return station
+}
+
+// EncodeTicket lays out the items of ticket prepared at station as a slip
+// that is cut off at the end.
+func EncodeTicket(ticket kitchen.Ticket, station string, at time.Time) []byte {
+	width := receipt.TextWidth
+	e := NewEncoder()
+
+	e.Align(AlignCenter).Bold(true).Size(2, 2).Line(strings.ToUpper(stationName(station)))
+	if ticket.TableNumber != nil {
+		e.Line(fmt.Sprintf("Table %d", *ticket.TableNumber))
+	}
+	e.Size(1, 1).Bold(false).Align(AlignLeft)
+	e.Line("Order " + ticket.OrderID)
+	e.Line(at.Format("2006-01-02 15:04"))
+	e.Line(strings.Repeat("-", width))
+
+	e.Bold(true).Size(1, 2)
+	for _, item := range ticket.Items {
+		if item.Station != station {
+			continue
+		}
+		name := "(unknown item)"
+		if item.FoodName != nil {
+			name = *item.FoodName
+		}
+		if item.Size != nil && *item.Size != "" {
+			name += " (" + *item.Size + ")"
+		}
+		if item.Quantity > 1 {
+			name = fmt.Sprintf("%d x %s", item.Quantity, name)
+		}
+		if item.Voided {
+			name = "VOID " + name
+		}
+		e.Line(name)
+		for _, modifier := range item.Modifiers {
+			e.Line("  + " + modifier)
+		}
+		if item.Notes != nil && *item.Notes != "" {
+			e.Line("  ! " + *item.Notes)
+		}
+	}
+	e.Size(1, 1).Bold(false)
+
+	return e.Feed(3).Cut().Bytes()
+}
+
+// EncodeReceipt prints the text layout of r with its header and total
+// emphasized.
+func EncodeReceipt(r receipt.Receipt) []byte {
+	e := NewEncoder()
+
+	lines := r.TextLines(receipt.TextWidth)
+	for i, line := range lines {
+		switch {
+		case i == 0:
+			e.Align(AlignCenter).Bold(true).Size(2, 2).Line(strings.TrimSpace(line)).Size(1, 1).Bold(false).Align(AlignLeft)
+		case i < len(r.Header):
+			e.Align(AlignCenter).Line(strings.TrimSpace(line)).Align(AlignLeft)
+		case strings.HasPrefix(line, "TOTAL "):
+			e.Bold(true).Size(1, 2).Line(line).Size(1, 1).Bold(false)
+		default:
+			e.Line(line)
+		}
+	}
+
+	return e.Feed(3).Cut().Bytes()
+}
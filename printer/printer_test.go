@@ -0,0 +1,131 @@
+package printer
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"restaurant-management/kitchen"
+	"restaurant-management/models"
+	"restaurant-management/receipt"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// fileSink returns the sink a file: address in dir names, and the path of
+// the file it prints to.
+func fileSink(t *testing.T, dir, name string) (Sink, string) {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	sink, err := ParseSink("file://" + path)
+	if err != nil {
+		t.Fatalf("ParseSink: %v", err)
+	}
+	return sink, path
+}
+
+// checkGolden compares what was printed to path with testdata/golden.
+func checkGolden(t *testing.T, path, golden string) {
+	t.Helper()
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading the printed job: %v", err)
+	}
+	goldenPath := filepath.Join("testdata", golden)
+	if *update {
+		if err := os.WriteFile(goldenPath, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(goldenPath)
+	if err != nil {
+		t.Fatalf("reading the golden file: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s printed\n%q\nwant\n%q", golden, got, want)
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestPrintTicket(t *testing.T) {
+	dir := t.TempDir()
+	grill, grillPath := fileSink(t, dir, "grill.prn")
+	other, otherPath := fileSink(t, dir, "other.prn")
+	printers := Printers{Kitchen: map[string]Sink{"grill": grill, DefaultStation: other}}
+
+	ticket := kitchen.Ticket{
+		OrderID:     "6ad44407f20c019a019425c7",
+		TableNumber: ptr(7),
+		Items: []kitchen.TicketItem{
+			{OrderItemID: "1", FoodName: ptr("Burger"), Station: "grill", Quantity: 2, Modifiers: []string{"Cooking: Medium", "Extras: Bacon"}, Notes: ptr("no onions")},
+			{OrderItemID: "2", FoodName: ptr("Soda"), Station: "bar", Quantity: 1, Size: ptr("L")},
+			{OrderItemID: "3", FoodName: ptr("Crème brûlée"), Station: "cold", Quantity: 1},
+			{OrderItemID: "4", FoodName: ptr("Steak"), Station: "grill", Quantity: 1, Voided: true},
+		},
+	}
+	at := time.Date(2026, 3, 14, 19, 30, 0, 0, time.UTC)
+	if err := printers.PrintTicket(context.Background(), ticket, at); err != nil {
+		t.Fatalf("PrintTicket: %v", err)
+	}
+
+	checkGolden(t, grillPath, "ticket_grill.golden")
+	// The bar and cold stations have no printer of their own, so both their
+	// slips are appended to the default printer's file, bar first.
+	checkGolden(t, otherPath, "ticket_default.golden")
+}
+
+func TestPrintReceipt(t *testing.T) {
+	sink, path := fileSink(t, t.TempDir(), "receipt.prn")
+	printers := Printers{Receipt: sink}
+
+	usd := func(amount int64) models.Money { return models.NewMoney(amount, "USD") }
+	r := receipt.Receipt{
+		Header:        []string{"Trattoria Roma", "1 Main Street"},
+		InvoiceID:     "6ad44407f20c019a019425c9",
+		InvoiceNumber: "INV-000042",
+		OrderID:       "6ad44407f20c019a019425c7",
+		IssuedAt:      time.Date(2026, 3, 14, 21, 5, 0, 0, time.UTC),
+		TableNumber:   ptr(7),
+		Lines: []receipt.Line{
+			{Name: "Burger", Quantity: 2, Amount: usd(2400)},
+			{Name: "Soda", Size: "L", Quantity: 1, Amount: usd(300)},
+			{Name: "Crème brûlée", Quantity: 1, Amount: usd(650)},
+		},
+		Discount:      &receipt.Tax{Label: "Voucher TENOFF", Amount: usd(-335)},
+		Subtotal:      ptr(usd(3015)),
+		Taxes:         []receipt.Tax{{Label: "Tax standard 10%", Amount: usd(302)}},
+		ServiceCharge: &receipt.Tax{Label: "Service 12.5%", Amount: usd(377)},
+		Rounding:      ptr(usd(1)),
+		Total:         usd(3695),
+		PaymentMethod: "CARD",
+		PaymentStatus: models.PaymentPaid,
+		Paid:          ptr(usd(3695)),
+		Footer:        "Grazie!",
+	}
+	if err := printers.PrintReceipt(context.Background(), r); err != nil {
+		t.Fatalf("PrintReceipt: %v", err)
+	}
+
+	checkGolden(t, path, "receipt.golden")
+}
+
+func TestEncoderLine(t *testing.T) {
+	got := NewEncoder().Bold(true).Line("Café €5 ✓").Cut().Bytes()
+	want := []byte{
+		esc, '@', esc, 't', codePageWPC1252,
+		esc, 'E', 1,
+		'C', 'a', 'f', 0xE9, ' ', 0x80, '5', ' ', '?', '\n',
+		gs, 'V', 66, 0,
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("encoded % x, want % x", got, want)
+	}
+}
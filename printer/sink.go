@@ -0,0 +1,106 @@
+package printer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Sink delivers encoded print jobs to a printer.
+type Sink interface {
+	Print(ctx context.Context, job []byte) error
+}
+
+// TCPSink sends each job over its own connection to a printer's raw port,
+// usually 9100.
+type TCPSink struct {
+	Addr    string
+	Timeout time.Duration
+}
+
+func (s TCPSink) Print(ctx context.Context, job []byte) error {
+	dialer := net.Dialer{Timeout: s.Timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", s.Addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetWriteDeadline(deadline)
+	}
+	_, err = conn.Write(job)
+	return err
+}
+
+// FileSink appends each job to a file, such as a printer device or a
+// capture to inspect in tests.
+type FileSink struct {
+	Path string
+}
+
+func (s FileSink) Print(ctx context.Context, job []byte) error {
+	f, err := os.OpenFile(s.Path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(job); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// WriterSink writes jobs to w one at a time.
+type WriterSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{w: w}
+}
+
+func (s *WriterSink) Print(ctx context.Context, job []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.w.Write(job)
+	return err
+}
+
+var stdout = NewWriterSink(os.Stdout)
+
+// ParseSink returns the sink a printer address names: "tcp://host:port"
+// (the port defaults to 9100), "file:///path/to/file" or "stdout".
+func ParseSink(address string) (Sink, error) {
+	if address == "stdout" {
+		return stdout, nil
+	}
+
+	u, err := url.Parse(address)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "tcp":
+		host := u.Host
+		if u.Port() == "" {
+			host = net.JoinHostPort(strings.Trim(u.Host, "[]"), "9100")
+		}
+		return TCPSink{Addr: host, Timeout: 5 * time.Second}, nil
+	case "file":
+		if u.Path == "" {
+			return nil, fmt.Errorf("%q has no file path", address)
+		}
+		return FileSink{Path: u.Path}, nil
+	default:
+		return nil, fmt.Errorf("%q is not tcp://host:port, file:///path or stdout", address)
+	}
+}
@@ -0,0 +1,63 @@
+package receipt
+
+import (
+	"html/template"
+	"io"
+)
+
+var htmlReceipt = template.Must(template.New("receipt").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Receipt {{.InvoiceID}}</title>
+<style>
+body { font-family: "Courier New", monospace; font-size: 12px; width: 72mm; margin: 0 auto; padding: 4mm 0; }
+header, footer { text-align: center; }
+header p { margin: 0; }
+header p:first-child { font-size: 16px; font-weight: bold; }
+table { width: 100%; border-collapse: collapse; border-top: 1px dashed #000; margin-top: 2mm; padding-top: 2mm; }
+td { padding: 1px 0; vertical-align: top; }
+td.amount { text-align: right; white-space: nowrap; padding-left: 2mm; }
+tr.total td { font-weight: bold; font-size: 14px; }
+footer { border-top: 1px dashed #000; margin-top: 2mm; padding-top: 2mm; }
+@media print { @page { size: 80mm auto; margin: 0; } }
+</style>
+</head>
+<body>
+<header>
+{{range .Header}}<p>{{.}}</p>
+{{end}}</header>
+<table>
+{{with .InvoiceNumber}}<tr><td>Invoice No</td><td class="amount">{{.}}</td></tr>
+{{end}}<tr><td>Invoice</td><td class="amount">{{.InvoiceID}}</td></tr>
+<tr><td>Order</td><td class="amount">{{.OrderID}}</td></tr>
+{{with .TableNumber}}<tr><td>Table</td><td class="amount">{{.}}</td></tr>
+{{end}}{{with .SplitLabel}}<tr><td>Split bill</td><td class="amount">{{.}}</td></tr>
+{{end}}<tr><td>Date</td><td class="amount">{{.IssuedAt.Format "2006-01-02 15:04"}}</td></tr>
+</table>
+<table>
+{{range .Lines}}<tr><td>{{.ItemLabel}}</td><td class="amount">{{.Amount.Decimal}}</td></tr>
+{{end}}</table>
+<table>
+{{with .Discount}}<tr><td>{{.Label}}</td><td class="amount">{{.Amount.Decimal}}</td></tr>
+{{end}}{{with .Subtotal}}<tr><td>Subtotal</td><td class="amount">{{.Decimal}}</td></tr>
+{{end}}{{range .Taxes}}<tr><td>{{.Label}}</td><td class="amount">{{.Amount.Decimal}}</td></tr>
+{{end}}{{with .ServiceCharge}}<tr><td>{{.Label}}</td><td class="amount">{{.Amount.Decimal}}</td></tr>
+{{end}}{{with .Rounding}}{{if .Amount}}<tr><td>Rounding</td><td class="amount">{{.Decimal}}</td></tr>
+{{end}}{{end}}<tr class="total"><td>TOTAL</td><td class="amount">{{.Total}}</td></tr>
+</table>
+<table>
+{{with .PaymentMethod}}<tr><td>Payment</td><td class="amount">{{.}}</td></tr>
+{{end}}{{with .Paid}}{{if .Amount}}<tr><td>Paid</td><td class="amount">{{.Decimal}}</td></tr>
+{{end}}{{end}}{{with .Refunded}}{{if .Amount}}<tr><td>Refunded</td><td class="amount">{{.Decimal}}</td></tr>
+{{end}}{{end}}{{with .PaymentStatus}}<tr><td>Status</td><td class="amount">{{.}}</td></tr>
+{{end}}</table>
+{{with .Footer}}<footer>{{.}}</footer>
+{{end}}</body>
+</html>
+`))
+
+// WriteHTML writes r as a standalone HTML page sized for a till roll.
+func WriteHTML(w io.Writer, r Receipt) error {
+	return htmlReceipt.Execute(w, r)
+}
@@ -0,0 +1,220 @@
+// Package receipt lays out the printable receipt for an invoice and renders
+// it as plain text, HTML or PDF.
+package receipt
+
+import (
+	"fmt"
+	"os"
+	"restaurant-management/models"
+	"restaurant-management/store"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config is what every receipt prints besides the invoice itself.
+type Config struct {
+	Name    string
+	Address []string
+	Phone   string
+	TaxID   string
+	Footer  string
+	// Location is the time zone receipt dates are printed in.
+	Location *time.Location
+}
+
+// DefaultConfig prints a generic header and footer with dates in UTC.
+func DefaultConfig() Config {
+	return Config{
+		Name:     "Restaurant",
+		Footer:   "Thank you for dining with us!",
+		Location: time.UTC,
+	}
+}
+
+// ConfigFromEnv starts from DefaultConfig and applies RESTAURANT_NAME,
+// RESTAURANT_ADDRESS (lines separated by ";"), RESTAURANT_PHONE,
+// RESTAURANT_TAX_ID, RECEIPT_FOOTER and RECEIPT_TIMEZONE (an IANA name such as
+// "Europe/London") where they are set.
+func ConfigFromEnv() (Config, error) {
+	config := DefaultConfig()
+
+	if value := os.Getenv("RESTAURANT_NAME"); value != "" {
+		config.Name = value
+	}
+	if value := os.Getenv("RESTAURANT_ADDRESS"); value != "" {
+		for _, line := range strings.Split(value, ";") {
+			if line = strings.TrimSpace(line); line != "" {
+				config.Address = append(config.Address, line)
+			}
+		}
+	}
+	config.Phone = os.Getenv("RESTAURANT_PHONE")
+	config.TaxID = os.Getenv("RESTAURANT_TAX_ID")
+	if value := os.Getenv("RECEIPT_FOOTER"); value != "" {
+		config.Footer = value
+	}
+	if value := os.Getenv("RECEIPT_TIMEZONE"); value != "" {
+		location, err := time.LoadLocation(value)
+		if err != nil {
+			return config, fmt.Errorf("RECEIPT_TIMEZONE: %w", err)
+		}
+		config.Location = location
+	}
+
+	return config, nil
+}
+
+// Receipt is an invoice laid out for printing.
+type Receipt struct {
+	Header        []string
+	InvoiceID     string
+	InvoiceNumber string
+	OrderID       string
+	IssuedAt      time.Time
+	TableNumber   *int
+	Split         *models.InvoiceSplit
+	Lines         []Line
+	Discount      *Tax
+	Subtotal      *models.Money
+	Taxes         []Tax
+	ServiceCharge *Tax
+	Rounding      *models.Money
+	Total         models.Money
+	PaymentMethod string
+	PaymentStatus string
+	Paid          *models.Money
+	Refunded      *models.Money
+	Footer        string
+}
+
+// Line is one item on a receipt; Amount is what all Quantity of it cost.
+type Line struct {
+	Name     string
+	Size     string
+	Quantity int
+	Amount   models.Money
+}
+
+// Tax is a labelled charge added to the subtotal, or a discount, as a
+// negative amount, taken off the items before it.
+type Tax struct {
+	Label  string
+	Amount models.Money
+}
+
+// New lays out invoice with the items ItemsByOrder returned for it, which
+// for a split invoice should already be narrowed to the items billed on it.
+// Items are printed at the price they were billed at, before any voucher
+// discount, which is printed on its own; invoices created before pricing
+// fall back to the food's current price and the group's total. Voided items
+// are left off.
+func New(config Config, invoice models.Invoice, group store.OrderItemsGroup) Receipt {
+	r := Receipt{
+		Header:        receiptHeader(config),
+		InvoiceID:     invoice.InvoiceID,
+		InvoiceNumber: invoice.InvoiceNumber,
+		OrderID:       invoice.OrderID,
+		IssuedAt:      invoice.CreatedAt.In(config.Location),
+		TableNumber:   group.TableNumber,
+		Split:         invoice.Split,
+		Subtotal:      invoice.Subtotal,
+		Rounding:      invoice.Rounding,
+		Total:         group.PaymentDue,
+		Paid:          invoice.AmountPaid,
+		Refunded:      invoice.AmountRefunded,
+		Footer:        config.Footer,
+		PaymentMethod: deref(invoice.PaymentMethod),
+		PaymentStatus: deref(invoice.PaymentStatus),
+	}
+	if invoice.GrandTotal != nil {
+		r.Total = *invoice.GrandTotal
+	}
+
+	billed := make(map[string]models.InvoiceLine, len(invoice.Lines))
+	for _, line := range invoice.Lines {
+		billed[line.OrderItemID] = line
+	}
+	for _, item := range group.OrderItems {
+		if item.Voided {
+			continue
+		}
+		line := Line{Name: deref(item.FoodName), Size: deref(item.Size), Quantity: item.Quantity}
+		if priced, ok := billed[item.OrderItemID]; ok {
+			line.Amount, _ = priced.Amount.Add(priced.Discount)
+			if line.Name == "" {
+				line.Name = priced.FoodName
+			}
+		} else if item.Amount != nil {
+			line.Amount = *item.Amount
+		}
+		r.Lines = append(r.Lines, line)
+	}
+
+	if invoice.Discount != nil && invoice.Discount.Amount != 0 {
+		discount := *invoice.Discount
+		discount.Amount = -discount.Amount
+		r.Discount = &Tax{Label: "Voucher " + deref(invoice.VoucherCode), Amount: discount}
+	}
+	for _, tax := range invoice.Taxes {
+		r.Taxes = append(r.Taxes, Tax{Label: "Tax " + tax.Category + " " + percent(tax.Rate), Amount: tax.Amount})
+	}
+	if invoice.ServiceCharge != nil && invoice.ServiceCharge.Amount != 0 {
+		label := "Service charge"
+		if invoice.ServiceChargeRate != nil {
+			label += " " + percent(*invoice.ServiceChargeRate)
+		}
+		r.ServiceCharge = &Tax{Label: label, Amount: *invoice.ServiceCharge}
+	}
+
+	return r
+}
+
+func receiptHeader(config Config) []string {
+	header := append([]string{config.Name}, config.Address...)
+	if config.Phone != "" {
+		header = append(header, "Tel "+config.Phone)
+	}
+	if config.TaxID != "" {
+		header = append(header, "Tax ID "+config.TaxID)
+	}
+	return header
+}
+
+// percent formats a rate such as 0.125 as "12.5%".
+func percent(rate float64) string {
+	return strconv.FormatFloat(rate*100, 'f', -1, 64) + "%"
+}
+
+// SplitLabel describes which part of a split bill the receipt is, or is
+// empty for a whole bill.
+func (r Receipt) SplitLabel() string {
+	if r.Split == nil {
+		return ""
+	}
+	label := fmt.Sprintf("part %d of %d", r.Split.Part, r.Split.Parts)
+	if r.Split.Seat != nil {
+		label += fmt.Sprintf(" (seat %d)", *r.Split.Seat)
+	}
+	return label
+}
+
+// ItemLabel is how a line's item is named on the receipt, with how many were
+// had when it is more than one.
+func (l Line) ItemLabel() string {
+	label := l.Name
+	if l.Size != "" {
+		label += " (" + l.Size + ")"
+	}
+	if l.Quantity > 1 {
+		label = strconv.Itoa(l.Quantity) + " x " + label
+	}
+	return label
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,147 @@
+package receipt
+
+import (
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// TextWidth is how many characters wide a text receipt is laid out: what
+// fits on 80mm till roll in the printer's standard font.
+const TextWidth = 42
+
+// WriteText writes r as fixed-width plain text.
+func WriteText(w io.Writer, r Receipt) error {
+	_, err := io.WriteString(w, strings.Join(r.TextLines(TextWidth), "\n")+"\n")
+	return err
+}
+
+// TextLines lays r out as lines of at most width characters.
+func (r Receipt) TextLines(width int) []string {
+	rule := strings.Repeat("-", width)
+
+	var lines []string
+	for _, line := range r.Header {
+		lines = append(lines, center(line, width))
+	}
+	lines = append(lines, rule)
+
+	if r.InvoiceNumber != "" {
+		lines = append(lines, columns("Invoice No", r.InvoiceNumber, width))
+	}
+	lines = append(lines, columns("Invoice", r.InvoiceID, width))
+	lines = append(lines, columns("Order", r.OrderID, width))
+	if r.TableNumber != nil {
+		lines = append(lines, columns("Table", strconv.Itoa(*r.TableNumber), width))
+	}
+	if split := r.SplitLabel(); split != "" {
+		lines = append(lines, columns("Split bill", split, width))
+	}
+	lines = append(lines, columns("Date", r.IssuedAt.Format("2006-01-02 15:04"), width))
+	lines = append(lines, rule)
+
+	for _, line := range r.Lines {
+		amount := line.Amount.Decimal()
+		// Long item names run on below rather than losing their size.
+		parts := wrap(line.ItemLabel(), width-utf8.RuneCountInString(amount)-1)
+		if len(parts) == 0 {
+			parts = []string{""}
+		}
+		for i, part := range parts {
+			if i > 0 {
+				amount = ""
+			}
+			lines = append(lines, columns(part, amount, width))
+		}
+	}
+	lines = append(lines, rule)
+
+	if r.Discount != nil {
+		lines = append(lines, columns(r.Discount.Label, r.Discount.Amount.Decimal(), width))
+	}
+	if r.Subtotal != nil {
+		lines = append(lines, columns("Subtotal", r.Subtotal.Decimal(), width))
+	}
+	for _, tax := range r.Taxes {
+		lines = append(lines, columns(tax.Label, tax.Amount.Decimal(), width))
+	}
+	if r.ServiceCharge != nil {
+		lines = append(lines, columns(r.ServiceCharge.Label, r.ServiceCharge.Amount.Decimal(), width))
+	}
+	if r.Rounding != nil && r.Rounding.Amount != 0 {
+		lines = append(lines, columns("Rounding", r.Rounding.Decimal(), width))
+	}
+	lines = append(lines, columns("TOTAL", r.Total.String(), width))
+	lines = append(lines, rule)
+
+	if r.PaymentMethod != "" {
+		lines = append(lines, columns("Payment", r.PaymentMethod, width))
+	}
+	if r.Paid != nil && r.Paid.Amount != 0 {
+		lines = append(lines, columns("Paid", r.Paid.Decimal(), width))
+	}
+	if r.Refunded != nil && r.Refunded.Amount != 0 {
+		lines = append(lines, columns("Refunded", r.Refunded.Decimal(), width))
+	}
+	if r.PaymentStatus != "" {
+		lines = append(lines, columns("Status", r.PaymentStatus, width))
+	}
+
+	if r.Footer != "" {
+		lines = append(lines, "")
+		for _, line := range wrap(r.Footer, width) {
+			lines = append(lines, center(line, width))
+		}
+	}
+	return lines
+}
+
+// columns puts left and right at either end of a line, cutting left short if
+// both do not fit.
+func columns(left, right string, width int) string {
+	if right == "" {
+		return truncate(left, width)
+	}
+	room := width - utf8.RuneCountInString(right) - 1
+	if room < 0 {
+		return truncate(right, width)
+	}
+	left = truncate(left, room)
+	return left + strings.Repeat(" ", width-utf8.RuneCountInString(left)-utf8.RuneCountInString(right)) + right
+}
+
+func center(s string, width int) string {
+	s = truncate(s, width)
+	return strings.Repeat(" ", (width-utf8.RuneCountInString(s))/2) + s
+}
+
+func truncate(s string, width int) string {
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	return string([]rune(s)[:width])
+}
+
+// wrap breaks s into lines of at most width characters at spaces, cutting
+// words longer than a line.
+func wrap(s string, width int) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(s) {
+		word = truncate(word, width)
+		switch {
+		case line == "":
+			line = word
+		case utf8.RuneCountInString(line)+1+utf8.RuneCountInString(word) <= width:
+			line += " " + word
+		default:
+			lines = append(lines, line)
+			line = word
+		}
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -2,15 +2,18 @@ package routes
 
 import (
 	controller "restaurant-management/controllers"
+	"restaurant-management/middleware"
+	"restaurant-management/store"
+	"time"
 
 	"github.com/gin-gonic/gin"
-
 )
 
-func FoodRoutes(incomingRoutes *gin.Engine){
+func FoodRoutes(incomingRoutes *gin.Engine, stores store.Stores, location *time.Location) {
 
-	incomingRoutes.GET("/foods",controller.GetFoods())
-	incomingRoutes.GET("/foods/:food_id",controller.GetFood())
-	incomingRoutes.POST("/foods",controller.CreateFood())
-	incomingRoutes.PATCH("/foods/:food_id",controller.UpdateFood())
-}
\ No newline at end of file
+	incomingRoutes.GET("/foods", middleware.Authorize(allStaff...), controller.GetFoods(stores.Foods, stores.Menus, location))
+	incomingRoutes.GET("/foods/:food_id", middleware.Authorize(allStaff...), controller.GetFood(stores.Foods))
+	incomingRoutes.POST("/foods", middleware.Authorize(management...), controller.CreateFood(stores.Foods, stores.Menus))
+	incomingRoutes.PATCH("/foods/:food_id", middleware.Authorize(management...), controller.UpdateFood(stores.Foods, stores.Menus))
+	incomingRoutes.PUT("/foods/:food_id/availability", middleware.Authorize(stockKeeping...), controller.SetFoodAvailability(stores.Foods))
+}
@@ -0,0 +1,22 @@
+package routes
+
+import (
+	controller "restaurant-management/controllers"
+	"restaurant-management/middleware"
+	"restaurant-management/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+func InventoryRoutes(incomingRoutes *gin.Engine, stores store.Stores) {
+
+	incomingRoutes.GET("/inventory/ingredients", middleware.Authorize(allStaff...), controller.GetIngredients(stores.Ingredients))
+	incomingRoutes.GET("/inventory/ingredients/:ingredient_id", middleware.Authorize(allStaff...), controller.GetIngredient(stores.Ingredients))
+	incomingRoutes.POST("/inventory/ingredients", middleware.Authorize(management...), controller.CreateIngredient(stores.Ingredients))
+	incomingRoutes.PATCH("/inventory/ingredients/:ingredient_id", middleware.Authorize(management...), controller.UpdateIngredient(stores.Ingredients))
+	incomingRoutes.GET("/inventory/ingredients/:ingredient_id/movements", middleware.Authorize(allStaff...), controller.GetStockMovements(stores.Ingredients))
+	incomingRoutes.POST("/inventory/ingredients/:ingredient_id/movements", middleware.Authorize(stockKeeping...), controller.RecordStockMovement(stores.Ingredients, stores.Recipes, stores.Foods))
+	incomingRoutes.GET("/inventory/low-stock", middleware.Authorize(allStaff...), controller.GetLowStock(stores.Ingredients))
+	incomingRoutes.GET("/foods/:food_id/recipe", middleware.Authorize(allStaff...), controller.GetRecipe(stores.Recipes))
+	incomingRoutes.PUT("/foods/:food_id/recipe", middleware.Authorize(management...), controller.PutRecipe(stores.Recipes, stores.Foods, stores.Ingredients))
+}
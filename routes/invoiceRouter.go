@@ -2,17 +2,32 @@ package routes
 
 import (
 	controller "restaurant-management/controllers"
+	"restaurant-management/gateway"
+	"restaurant-management/middleware"
+	"restaurant-management/models"
+	"restaurant-management/pricing"
+	"restaurant-management/printer"
+	"restaurant-management/receipt"
+	"restaurant-management/store"
 
 	"github.com/gin-gonic/gin"
-
 )
 
+func InvoiceRoutes(incomingRoutes *gin.Engine, stores store.Stores, prices pricing.Config, provider gateway.Provider, receipts receipt.Config, printers printer.Printers, numbering models.InvoiceNumbering) {
 
-func InvoiceRoutes(incomingRoutes *gin.Engine) {
-
-	incomingRoutes.GET("/invoices",controller.GetInvoices())
-	incomingRoutes.GET("/invoices/:invoice_id",controller.GetInvoice())
-	incomingRoutes.POST("/invoices",controller.CreateInvoice())
-	incomingRoutes.PATCH("/invoices/:invoice_id",controller.UpdateInvoice())
+	incomingRoutes.GET("/invoices", middleware.Authorize(frontOfHouse...), controller.GetInvoices(stores.Invoices))
+	incomingRoutes.GET("/invoices/:invoice_id", middleware.Authorize(frontOfHouse...), controller.GetInvoice(stores.Invoices, stores.OrderItems))
+	incomingRoutes.GET("/invoices/:invoice_id/receipt", middleware.Authorize(frontOfHouse...), controller.GetReceipt(stores.Invoices, stores.OrderItems, receipts))
+	incomingRoutes.POST("/invoices/:invoice_id/receipt/print", middleware.Authorize(frontOfHouse...), controller.PrintReceipt(stores.Invoices, stores.OrderItems, receipts, printers))
+	incomingRoutes.POST("/invoices", middleware.Authorize(frontOfHouse...), controller.CreateInvoice(stores.Invoices, stores.Orders, stores.OrderItems, stores.Foods, stores.Vouchers, prices, numbering))
+	incomingRoutes.POST("/invoices/split", middleware.Authorize(frontOfHouse...), controller.SplitInvoice(stores.Invoices, stores.Orders, stores.OrderItems, stores.Foods, stores.Vouchers, prices, numbering))
+	incomingRoutes.PATCH("/invoices/:invoice_id", middleware.Authorize(billing...), controller.UpdateInvoice(stores.Invoices, stores.Orders, stores.OrderItems, stores.Foods, stores.Vouchers, prices))
+	incomingRoutes.GET("/invoices/:invoice_id/payments", middleware.Authorize(frontOfHouse...), controller.GetPayments(stores.Invoices, stores.Payments))
+	incomingRoutes.GET("/invoices/:invoice_id/payments/:payment_id", middleware.Authorize(frontOfHouse...), controller.GetPayment(stores.Payments))
+	incomingRoutes.POST("/invoices/:invoice_id/payments", middleware.Authorize(billing...), controller.CreatePayment(stores.Invoices, stores.OrderItems, stores.Payments, provider))
+	incomingRoutes.GET("/invoices/:invoice_id/credit-notes", middleware.Authorize(billing...), controller.GetCreditNotes(stores.Invoices, stores.CreditNotes))
+	incomingRoutes.GET("/invoices/:invoice_id/credit-notes/:credit_note_id", middleware.Authorize(billing...), controller.GetCreditNote(stores.CreditNotes))
+	incomingRoutes.POST("/invoices/:invoice_id/refunds", middleware.Authorize(management...), controller.RefundInvoice(stores.Invoices, stores.OrderItems, stores.Payments, stores.CreditNotes, provider))
+	incomingRoutes.POST("/invoices/:invoice_id/void", middleware.Authorize(management...), controller.VoidInvoice(stores.Invoices, stores.OrderItems, stores.Payments, stores.CreditNotes, provider))
 
-}
\ No newline at end of file
+}
@@ -0,0 +1,14 @@
+package routes
+
+import (
+	controller "restaurant-management/controllers"
+	"restaurant-management/kitchen"
+	"restaurant-management/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func KitchenRoutes(incomingRoutes *gin.Engine, hub *kitchen.Hub) {
+
+	incomingRoutes.GET("/kitchen/stream", middleware.Authorize(allStaff...), controller.StreamKitchen(hub))
+}
@@ -2,15 +2,18 @@ package routes
 
 import (
 	controller "restaurant-management/controllers"
+	"restaurant-management/middleware"
+	"restaurant-management/store"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-func MenuRoutes(incomingRoutes *gin.Engine) {
+func MenuRoutes(incomingRoutes *gin.Engine, stores store.Stores, location *time.Location) {
 
-	incomingRoutes.GET("/menus", controller.GetMenus())
-	incomingRoutes.GET("/menus/:menu_id", controller.GetMenu())
-	incomingRoutes.POST("/menus", controller.CreateMenu())
-	incomingRoutes.PATCH("/menus/:menu_id", controller.UpdateMenu())
+	incomingRoutes.GET("/menus", middleware.Authorize(allStaff...), controller.GetMenus(stores.Menus, location))
+	incomingRoutes.GET("/menus/:menu_id", middleware.Authorize(allStaff...), controller.GetMenu(stores.Menus))
+	incomingRoutes.POST("/menus", middleware.Authorize(management...), controller.CreateMenu(stores.Menus))
+	incomingRoutes.PATCH("/menus/:menu_id", middleware.Authorize(management...), controller.UpdateMenu(stores.Menus))
 
 }
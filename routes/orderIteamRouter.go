@@ -2,15 +2,23 @@ package routes
 
 import (
 	controller "restaurant-management/controllers"
+	"restaurant-management/kitchen"
+	"restaurant-management/middleware"
+	"restaurant-management/printer"
+	"restaurant-management/store"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-func OrderItemRoutes(incomingRoutes *gin.Engine) {
+func OrderItemRoutes(incomingRoutes *gin.Engine, stores store.Stores, hub *kitchen.Hub, printers printer.Printers, location *time.Location) {
 
-	incomingRoutes.GET("/orderItems", controller.GetOrderItems())
-	incomingRoutes.GET("/orderItems/:orderItem_id", controller.GetOrderItem())
-	incomingRoutes.GET("/orderItems-order/:order_id", controller.GetOrderItemsByOrder())
-	incomingRoutes.POST("/orderItems", controller.CreateOrderItem())
-	incomingRoutes.PATCH("/orderItems/:orderItem_id", controller.UpdateOrderItem())
+	incomingRoutes.GET("/orderItems", middleware.Authorize(allStaff...), controller.GetOrderItems(stores.OrderItems))
+	incomingRoutes.GET("/orderItems/:orderItem_id", middleware.Authorize(allStaff...), controller.GetOrderItem(stores.OrderItems))
+	incomingRoutes.GET("/orderItems-order/:order_id", middleware.Authorize(allStaff...), controller.GetOrderItemsByOrder(stores.OrderItems))
+	incomingRoutes.POST("/orderItems", middleware.Authorize(floorStaff...), controller.CreateOrderItem(stores.OrderItems, stores.Orders, stores.Foods, stores.Menus, stores.Tables, stores.Recipes, stores.Ingredients, stores.PriceRules, hub, printers, location))
+	incomingRoutes.POST("/orders/:order_id/items", middleware.Authorize(floorStaff...), controller.AddOrderItems(stores.OrderItems, stores.Orders, stores.Invoices, stores.Foods, stores.Menus, stores.Tables, stores.Recipes, stores.Ingredients, stores.PriceRules, hub, printers, location))
+	incomingRoutes.PATCH("/orderItems/:orderItem_id", middleware.Authorize(floorStaff...), controller.UpdateOrderItem(stores.OrderItems, stores.Orders, stores.Invoices))
+	incomingRoutes.POST("/orderItems/:orderItem_id/void", middleware.Authorize(floorStaff...), controller.VoidOrderItem(stores.OrderItems, stores.Orders, stores.Invoices, stores.Foods, stores.Tables, stores.Recipes, stores.Ingredients, hub, printers))
+	incomingRoutes.POST("/orderItems/:orderItem_id/void/approve", middleware.Authorize(management...), controller.ApproveVoid(stores.OrderItems, stores.Orders, stores.Invoices, stores.Foods, stores.Tables, hub, printers))
 }
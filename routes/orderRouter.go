@@ -2,14 +2,20 @@ package routes
 
 import (
 	controller "restaurant-management/controllers"
+	"restaurant-management/kitchen"
+	"restaurant-management/middleware"
+	"restaurant-management/store"
 
 	"github.com/gin-gonic/gin"
 )
 
-func OrderRoutes(incomingRoutes *gin.Engine){
+func OrderRoutes(incomingRoutes *gin.Engine, stores store.Stores, hub *kitchen.Hub) {
 
-	incomingRoutes.GET("/orders",controller.GetOrders())
-	incomingRoutes.GET("/orders/:order_id",controller.GetOrder())
-	incomingRoutes.POST("/orders",controller.CreateOrder())
-	incomingRoutes.PATCH("/orders/:order_id",controller.UpdateOrder())
-}
\ No newline at end of file
+	incomingRoutes.GET("/orders", middleware.Authorize(allStaff...), controller.GetOrders(stores.Orders))
+	incomingRoutes.GET("/orders/:order_id", middleware.Authorize(allStaff...), controller.GetOrder(stores.Orders))
+	incomingRoutes.POST("/orders", middleware.Authorize(floorStaff...), controller.CreateOrder(stores.Orders, stores.Tables))
+	incomingRoutes.PATCH("/orders/:order_id", middleware.Authorize(floorStaff...), controller.UpdateOrder(stores.Orders, stores.Tables))
+	incomingRoutes.POST("/orders/:order_id/transitions", middleware.Authorize(allStaff...), controller.TransitionOrder(stores.Orders, stores.Tables, stores.Foods, stores.Recipes, stores.Ingredients, stores.Vouchers, hub))
+	incomingRoutes.POST("/orders/:order_id/voucher", middleware.Authorize(frontOfHouse...), controller.ApplyVoucher(stores.Vouchers, stores.Orders, stores.OrderItems, stores.Invoices, stores.Foods))
+	incomingRoutes.DELETE("/orders/:order_id/voucher", middleware.Authorize(frontOfHouse...), controller.RemoveVoucher(stores.Vouchers, stores.Orders, stores.Invoices))
+}
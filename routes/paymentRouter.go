@@ -0,0 +1,17 @@
+package routes
+
+import (
+	controller "restaurant-management/controllers"
+	"restaurant-management/gateway"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PaymentRoutes are called by the payment provider rather than staff, and
+// are registered before main installs Authentication: webhooks prove who
+// sent them with a signature instead of a token.
+func PaymentRoutes(incomingRoutes *gin.Engine, provider gateway.Provider) {
+
+	incomingRoutes.POST("/payments/webhook", controller.PaymentWebhook(provider))
+
+}
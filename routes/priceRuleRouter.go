@@ -0,0 +1,17 @@
+package routes
+
+import (
+	controller "restaurant-management/controllers"
+	"restaurant-management/middleware"
+	"restaurant-management/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+func PriceRuleRoutes(incomingRoutes *gin.Engine, stores store.Stores) {
+
+	incomingRoutes.GET("/price-rules", middleware.Authorize(allStaff...), controller.GetPriceRules(stores.PriceRules))
+	incomingRoutes.GET("/price-rules/:price_rule_id", middleware.Authorize(allStaff...), controller.GetPriceRule(stores.PriceRules))
+	incomingRoutes.POST("/price-rules", middleware.Authorize(management...), controller.CreatePriceRule(stores.PriceRules))
+	incomingRoutes.PUT("/price-rules/:price_rule_id", middleware.Authorize(management...), controller.ReplacePriceRule(stores.PriceRules))
+}
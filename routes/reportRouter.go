@@ -0,0 +1,16 @@
+package routes
+
+import (
+	controller "restaurant-management/controllers"
+	"restaurant-management/middleware"
+	"restaurant-management/store"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ReportRoutes(incomingRoutes *gin.Engine, stores store.Stores, location *time.Location) {
+
+	incomingRoutes.GET("/reports/daily-sales", middleware.Authorize(management...), controller.GetDailySales(stores.Payments, stores.CreditNotes, location))
+
+}
@@ -0,0 +1,18 @@
+package routes
+
+import (
+	controller "restaurant-management/controllers"
+	"restaurant-management/middleware"
+	"restaurant-management/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ReservationRoutes(incomingRoutes *gin.Engine, stores store.Stores) {
+
+	incomingRoutes.GET("/reservations", middleware.Authorize(allStaff...), controller.GetReservations(stores.Reservations))
+	incomingRoutes.GET("/reservations/:reservation_id", middleware.Authorize(allStaff...), controller.GetReservation(stores.Reservations))
+	incomingRoutes.POST("/reservations", middleware.Authorize(frontOfHouse...), controller.CreateReservation(stores.Reservations, stores.Tables))
+	incomingRoutes.POST("/reservations/:reservation_id/cancel", middleware.Authorize(frontOfHouse...), controller.CancelReservation(stores.Reservations))
+	incomingRoutes.POST("/reservations/:reservation_id/no-show", middleware.Authorize(frontOfHouse...), controller.MarkReservationNoShow(stores.Reservations))
+}
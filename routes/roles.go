@@ -0,0 +1,15 @@
+package routes
+
+import "restaurant-management/models"
+
+// Role groups the routes authorize against. Every route behind
+// Authentication declares one of these.
+var (
+	allStaff     = []string{models.RoleAdmin, models.RoleManager, models.RoleCashier, models.RoleWaiter, models.RoleKitchen}
+	management   = []string{models.RoleAdmin, models.RoleManager}
+	adminOnly    = []string{models.RoleAdmin}
+	floorStaff   = []string{models.RoleAdmin, models.RoleManager, models.RoleWaiter}
+	billing      = []string{models.RoleAdmin, models.RoleManager, models.RoleCashier}
+	frontOfHouse = []string{models.RoleAdmin, models.RoleManager, models.RoleCashier, models.RoleWaiter}
+	stockKeeping = []string{models.RoleAdmin, models.RoleManager, models.RoleKitchen}
+)
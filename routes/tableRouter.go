@@ -2,14 +2,17 @@ package routes
 
 import (
 	controller "restaurant-management/controllers"
+	"restaurant-management/middleware"
+	"restaurant-management/store"
 
 	"github.com/gin-gonic/gin"
 )
 
-func TableRoutes(incomingRoutes *gin.Engine) {
+func TableRoutes(incomingRoutes *gin.Engine, stores store.Stores) {
 
-	incomingRoutes.GET("tables",controller.GetTables())
-	incomingRoutes.GET("tables/:table_id",controller.GetTable())
-	incomingRoutes.POST("tables",controller.CreateTable())
-	incomingRoutes.PATCH("tables/:table_id",controller.GetTables())
-}
\ No newline at end of file
+	incomingRoutes.GET("tables", middleware.Authorize(allStaff...), controller.GetTables(stores.Tables))
+	incomingRoutes.GET("tables/availability", middleware.Authorize(allStaff...), controller.GetTableAvailability(stores.Tables, stores.Reservations))
+	incomingRoutes.GET("tables/:table_id", middleware.Authorize(allStaff...), controller.GetTable(stores.Tables))
+	incomingRoutes.POST("tables", middleware.Authorize(management...), controller.CreateTable(stores.Tables))
+	incomingRoutes.PATCH("tables/:table_id", middleware.Authorize(management...), controller.UpdateTable(stores.Tables))
+}
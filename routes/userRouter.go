@@ -2,15 +2,23 @@ package routes
 
 import (
 	controller "restaurant-management/controllers"
+	"restaurant-management/middleware"
+	"restaurant-management/store"
 
 	"github.com/gin-gonic/gin"
 )
 
-func UserRoutes(incomingRoutes *gin.Engine) {
+func UserRoutes(incomingRoutes *gin.Engine, stores store.Stores) {
 
-	incomingRoutes.GET("/users", controller.GetUsers())
-	incomingRoutes.GET("/users/:user_id", controller.GetUser())
-	incomingRoutes.POST("/users/signup", controller.SignUp())
-	incomingRoutes.POST("/users/login", controller.Login())
+	incomingRoutes.POST("/users/signup", controller.SignUp(stores.Users))
+	incomingRoutes.POST("/users/login", controller.Login(stores.Users))
+	incomingRoutes.POST("/users/refresh", controller.RefreshTokens(stores.Users))
+
+	// The user routes are registered before main installs Authentication for
+	// everything else, so the protected ones authenticate themselves.
+	incomingRoutes.GET("/users", middleware.Authentication(stores.Users), middleware.Authorize(management...), controller.GetUsers(stores.Users))
+	incomingRoutes.GET("/users/:user_id", middleware.Authentication(stores.Users), middleware.Authorize(management...), controller.GetUser(stores.Users))
+	incomingRoutes.POST("/users/logout", middleware.Authentication(stores.Users), middleware.Authorize(allStaff...), controller.Logout(stores.Users))
+	incomingRoutes.PATCH("/users/:user_id/role", middleware.Authentication(stores.Users), middleware.Authorize(adminOnly...), controller.UpdateUserRole(stores.Users))
 
 }
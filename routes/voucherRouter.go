@@ -0,0 +1,17 @@
+package routes
+
+import (
+	controller "restaurant-management/controllers"
+	"restaurant-management/middleware"
+	"restaurant-management/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+func VoucherRoutes(incomingRoutes *gin.Engine, stores store.Stores) {
+
+	incomingRoutes.GET("/vouchers", middleware.Authorize(management...), controller.GetVouchers(stores.Vouchers))
+	incomingRoutes.GET("/vouchers/:voucher_id", middleware.Authorize(management...), controller.GetVoucher(stores.Vouchers))
+	incomingRoutes.POST("/vouchers", middleware.Authorize(management...), controller.CreateVoucher(stores.Vouchers))
+	incomingRoutes.PATCH("/vouchers/:voucher_id", middleware.Authorize(management...), controller.UpdateVoucher(stores.Vouchers))
+}
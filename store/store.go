@@ -0,0 +1,348 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"restaurant-management/models"
+	"time"
+)
+
+// ErrNotFound is returned by every store when the requested record does not
+// exist.
+var ErrNotFound = errors.New("record not found")
+
+// ErrConflict is returned when a conditional write finds the record no longer
+// in the state the caller expected.
+var ErrConflict = errors.New("record was modified concurrently")
+
+// InsertResult mirrors the shape the API has always returned after a create.
+type InsertResult struct {
+	InsertedID interface{}
+}
+
+// InsertManyResult mirrors the shape the API has always returned after a bulk
+// create.
+type InsertManyResult struct {
+	InsertedIDs []interface{}
+}
+
+// UpdateResult mirrors the shape the API has always returned after a PATCH.
+type UpdateResult struct {
+	MatchedCount  int64
+	ModifiedCount int64
+	UpsertedCount int64
+	UpsertedID    interface{}
+}
+
+type FoodStore interface {
+	// List returns a page of the foods that match filter, and how many match
+	// it in all.
+	List(ctx context.Context, filter FoodFilter, startIndex, recordPerPage int) (int, []models.Food, error)
+	Find(ctx context.Context, foodID string) (models.Food, error)
+	Insert(ctx context.Context, food models.Food) error
+	// Update applies the non-nil fields of food to the record with foodID.
+	// Availability is left alone; see SetAvailability.
+	Update(ctx context.Context, foodID string, food models.Food) (UpdateResult, error)
+	// SetAvailability marks the food with foodID available, or unavailable
+	// for reason.
+	SetAvailability(ctx context.Context, foodID string, available bool, reason string) error
+}
+
+// FoodFilter narrows the foods FoodStore.List returns.
+type FoodFilter struct {
+	// Available leaves out the foods that cannot be ordered.
+	Available bool
+	// MenuIDs, when not nil, leaves out the foods on any other menu. Foods
+	// on no menu are kept.
+	MenuIDs []string
+}
+
+type MenuStore interface {
+	List(ctx context.Context) ([]models.Menu, error)
+	Find(ctx context.Context, menuID string) (models.Menu, error)
+	Insert(ctx context.Context, menu models.Menu) error
+	// Update applies the non-empty fields of menu to the record with menuID.
+	// A non-nil Schedule replaces the stored one, so an empty one clears it.
+	Update(ctx context.Context, menuID string, menu models.Menu) (UpdateResult, error)
+}
+
+type TableStore interface {
+	List(ctx context.Context) ([]models.Table, error)
+	Find(ctx context.Context, tableID string) (models.Table, error)
+	Insert(ctx context.Context, table models.Table) error
+	// Update applies the non-nil fields of table to the record with tableID.
+	Update(ctx context.Context, tableID string, table models.Table) (UpdateResult, error)
+}
+
+type ReservationStore interface {
+	// List returns the reservations for tableID, or for every table when
+	// tableID is empty, ordered by start time.
+	List(ctx context.Context, tableID string) ([]models.Reservation, error)
+	Find(ctx context.Context, reservationID string) (models.Reservation, error)
+	// Insert stores reservation unless a BOOKED reservation for the same
+	// table overlaps its slot, in which case it returns ErrConflict.
+	Insert(ctx context.Context, reservation models.Reservation) error
+	// UpdateStatus moves a BOOKED reservation to status; it returns
+	// ErrConflict if the reservation is no longer BOOKED.
+	UpdateStatus(ctx context.Context, reservationID, status string) error
+	// Overlapping returns the BOOKED reservations, on any table, that hold
+	// their table at some point in [start, end).
+	Overlapping(ctx context.Context, start, end time.Time) ([]models.Reservation, error)
+}
+
+type OrderStore interface {
+	List(ctx context.Context) ([]models.Order, error)
+	Find(ctx context.Context, orderID string) (models.Order, error)
+	Insert(ctx context.Context, order models.Order) error
+	// Update applies the non-empty fields of order to the record with orderID.
+	Update(ctx context.Context, orderID string, order models.Order) (UpdateResult, error)
+	// Transition moves the order to transition.To and appends transition to
+	// its history, but only if its current status is still transition.From;
+	// otherwise it returns ErrConflict.
+	Transition(ctx context.Context, orderID string, transition models.OrderTransition) error
+	// Delete removes the order with orderID, as when its items could not be
+	// stored after it was, or returns ErrNotFound. Nothing may have been
+	// recorded against the order yet.
+	Delete(ctx context.Context, orderID string) error
+}
+
+type OrderItemStore interface {
+	List(ctx context.Context) ([]models.OrderItem, error)
+	// ListByOrder returns the items of orderID in the order they were added.
+	ListByOrder(ctx context.Context, orderID string) ([]models.OrderItem, error)
+	Find(ctx context.Context, orderItemID string) (models.OrderItem, error)
+	InsertMany(ctx context.Context, orderItems []models.OrderItem) error
+	// Update applies the non-nil fields of orderItem to the record with
+	// orderItemID.
+	Update(ctx context.Context, orderItemID string, orderItem models.OrderItem) (UpdateResult, error)
+	// Void records void on the item with orderItemID. It returns ErrConflict
+	// if the item already has a void, pending or not.
+	Void(ctx context.Context, orderItemID string, void models.OrderItemVoid) error
+	// ApproveVoid makes the pending void of the item with orderItemID take
+	// effect at voidedAt. It returns ErrConflict if the item has no pending
+	// void.
+	ApproveVoid(ctx context.Context, orderItemID, approvedBy string, voidedAt time.Time) error
+	// ItemsByOrder joins the items of an order with their food and table and
+	// groups them into a single summary per order. Voided items are listed
+	// but left out of the totals.
+	ItemsByOrder(ctx context.Context, orderID string) ([]OrderItemsGroup, error)
+}
+
+type InvoiceStore interface {
+	List(ctx context.Context) ([]models.Invoice, error)
+	ListByOrder(ctx context.Context, orderID string) ([]models.Invoice, error)
+	Find(ctx context.Context, invoiceID string) (models.Invoice, error)
+	// Insert stores invoice as the bill for its order; see InsertMany.
+	Insert(ctx context.Context, invoice models.Invoice, series string) error
+	// InsertMany stores invoices, which must all be for the same order, as
+	// that order's bill. Each order is billed once: if it already has an
+	// invoice that has not been voided, nothing is stored and ErrConflict is
+	// returned.
+	//
+	// The invoices are given the next numbers in series, in order, as they
+	// are stored, setting InvoiceNumber on the elements of invoices. Numbers
+	// are only taken by invoices that are stored, so a series has no gaps.
+	InsertMany(ctx context.Context, invoices []models.Invoice, series string) error
+	// Update applies the non-empty fields of invoice to the record with
+	// invoiceID. When invoice carries a priced bill (GrandTotal is set) its
+	// lines, taxes, voucher discount, totals and split replace the stored
+	// ones.
+	Update(ctx context.Context, invoiceID string, invoice models.Invoice) (UpdateResult, error)
+}
+
+// PaymentStore records the payments taken against invoices.
+type PaymentStore interface {
+	ListByInvoice(ctx context.Context, invoiceID string) ([]models.Payment, error)
+	// ListBetween returns the payments taken in [from, to).
+	ListBetween(ctx context.Context, from, to time.Time) ([]models.Payment, error)
+	Find(ctx context.Context, paymentID string) (models.Payment, error)
+	// Insert stores payment and settles its invoice as settlement describes.
+	// If the invoice's amount paid is no longer settlement.PaidBefore, as when
+	// another payment was taken meanwhile, or the invoice has been voided,
+	// nothing is stored and ErrConflict is returned.
+	Insert(ctx context.Context, payment models.Payment, settlement Settlement) error
+}
+
+// Settlement is how recording a payment changes its invoice.
+type Settlement struct {
+	PaidBefore    models.Money
+	AmountPaid    models.Money
+	PaymentStatus string
+	PaymentMethod string
+	// Provider and ProviderReference identify the card capture the payment
+	// settled, if it had a card tender, and are left unchanged otherwise.
+	Provider          string
+	ProviderReference string
+}
+
+// CreditNoteStore records the refunds and voids issued against invoices.
+type CreditNoteStore interface {
+	ListByInvoice(ctx context.Context, invoiceID string) ([]models.CreditNote, error)
+	// ListBetween returns the credit notes issued in [from, to).
+	ListBetween(ctx context.Context, from, to time.Time) ([]models.CreditNote, error)
+	Find(ctx context.Context, creditNoteID string) (models.CreditNote, error)
+	// Insert stores note and applies credit to its invoice. If the invoice's
+	// status, amount paid or amount refunded has changed since credit was
+	// worked out, nothing is stored and ErrConflict is returned. Card refunds
+	// are made after the note is stored, so the credit is reserved before any
+	// money moves; their references are filled in with RecordRefunds.
+	Insert(ctx context.Context, note models.CreditNote, credit Credit) error
+	// RecordRefunds replaces the refunds of creditNoteID with refunds, once
+	// they have been made.
+	RecordRefunds(ctx context.Context, creditNoteID string, refunds []models.Refund) error
+	// Cancel takes back note, inserted with credit, when its refunds could
+	// not be made: the note is removed and its invoice's status and amount
+	// refunded are put back as they were. ErrConflict is returned, and
+	// nothing changed, if the invoice has moved on since.
+	Cancel(ctx context.Context, note models.CreditNote, credit Credit) error
+}
+
+// Credit is how issuing a credit note changes its invoice.
+type Credit struct {
+	StatusBefore   string
+	PaidBefore     models.Money
+	RefundedBefore models.Money
+	AmountRefunded models.Money
+	PaymentStatus  string
+}
+
+// IngredientStore keeps the ingredients in stock and every movement of their
+// stock.
+type IngredientStore interface {
+	List(ctx context.Context) ([]models.Ingredient, error)
+	// ListLowStock returns the ingredients whose stock is at or below their
+	// reorder level.
+	ListLowStock(ctx context.Context) ([]models.Ingredient, error)
+	Find(ctx context.Context, ingredientID string) (models.Ingredient, error)
+	Insert(ctx context.Context, ingredient models.Ingredient) error
+	// Update applies the non-nil name and reorder level of ingredient to the
+	// record with ingredientID. Its unit and stock are never changed.
+	Update(ctx context.Context, ingredientID string, ingredient models.Ingredient) (UpdateResult, error)
+	// Move records movements and applies their changes to the stock of their
+	// ingredients, setting StockAfter on the elements of movements. Either
+	// every movement is applied or, if one fails, none is; ErrNotFound is
+	// returned when an ingredient does not exist.
+	Move(ctx context.Context, movements []models.StockMovement) error
+	// Reserve is Move for stock taken by an order: if a movement would take
+	// its ingredient's stock below zero, none is applied and ErrConflict is
+	// returned.
+	Reserve(ctx context.Context, movements []models.StockMovement) error
+	// Movements returns the movements of ingredientID, oldest first.
+	Movements(ctx context.Context, ingredientID string) ([]models.StockMovement, error)
+	// MovementsByOrder returns the movements recorded for the items of
+	// orderID, oldest first.
+	MovementsByOrder(ctx context.Context, orderID string) ([]models.StockMovement, error)
+}
+
+// RecipeStore keeps the recipe of each food.
+type RecipeStore interface {
+	// Find returns the recipe of foodID, or ErrNotFound if it has none.
+	Find(ctx context.Context, foodID string) (models.Recipe, error)
+	// Put stores recipe as the recipe of its food, replacing any it had.
+	Put(ctx context.Context, recipe models.Recipe) error
+	// ListByIngredient returns the recipes that use ingredientID.
+	ListByIngredient(ctx context.Context, ingredientID string) ([]models.Recipe, error)
+}
+
+// PriceRuleStore keeps the rules that price the items ordered.
+type PriceRuleStore interface {
+	// List returns every price rule in the order they were created.
+	List(ctx context.Context) ([]models.PriceRule, error)
+	Find(ctx context.Context, priceRuleID string) (models.PriceRule, error)
+	Insert(ctx context.Context, rule models.PriceRule) error
+	// Replace stores rule in place of the record with its PriceRuleID, or
+	// returns ErrNotFound if there is none.
+	Replace(ctx context.Context, rule models.PriceRule) error
+}
+
+// VoucherStore keeps vouchers and counts their redemptions.
+type VoucherStore interface {
+	List(ctx context.Context) ([]models.Voucher, error)
+	Find(ctx context.Context, voucherID string) (models.Voucher, error)
+	// FindByCode returns the voucher with code, which is in upper case.
+	FindByCode(ctx context.Context, code string) (models.Voucher, error)
+	// Insert stores voucher unless another already has its code, in which
+	// case it returns ErrConflict.
+	Insert(ctx context.Context, voucher models.Voucher) error
+	// Update applies the non-nil expiry and redemption limit of voucher to
+	// the record with voucherID.
+	Update(ctx context.Context, voucherID string, voucher models.Voucher) (UpdateResult, error)
+	// Redeem counts a redemption of its voucher and records it. If the
+	// voucher has been redeemed as often as it may be, or the order already
+	// has a voucher applied, nothing is stored and ErrConflict is returned.
+	Redeem(ctx context.Context, redemption models.VoucherRedemption) error
+	// Release marks the redemption applied to orderID released at
+	// releasedAt and stops counting it against its voucher. It returns
+	// ErrNotFound if the order has no voucher applied.
+	Release(ctx context.Context, orderID string, releasedAt time.Time) error
+	// RedemptionByOrder returns the redemption applied to orderID that has
+	// not been released, or ErrNotFound.
+	RedemptionByOrder(ctx context.Context, orderID string) (models.VoucherRedemption, error)
+}
+
+type UserStore interface {
+	List(ctx context.Context, startIndex, recordPerPage int) (int, []models.User, error)
+	Find(ctx context.Context, userID string) (models.User, error)
+	FindByEmail(ctx context.Context, email string) (models.User, error)
+	CountByEmail(ctx context.Context, email string) (int64, error)
+	CountByPhone(ctx context.Context, phone string) (int64, error)
+	Insert(ctx context.Context, user models.User) error
+	UpdateTokens(ctx context.Context, userID, token, refreshToken string) error
+	// RotateTokens replaces the user's tokens only if the stored refresh token
+	// is still currentRefreshToken; otherwise it returns ErrConflict.
+	RotateTokens(ctx context.Context, userID, currentRefreshToken, token, refreshToken string) error
+	UpdateRole(ctx context.Context, userID, role string) (UpdateResult, error)
+	// ClaimFirstAdmin records userID as the first admin, the account a fresh
+	// install is bootstrapped with. Only one account can ever claim it; for
+	// any other ErrConflict is returned.
+	ClaimFirstAdmin(ctx context.Context, userID string) error
+	// ReleaseFirstAdmin gives up userID's claim on the first admin, as when
+	// the account could not be stored after claiming it, so another account
+	// can claim it. It does nothing if userID does not hold the claim.
+	ReleaseFirstAdmin(ctx context.Context, userID string) error
+}
+
+// Stores bundles one implementation of every entity store so a backend can be
+// handed to the routes as a unit.
+type Stores struct {
+	Foods        FoodStore
+	Menus        MenuStore
+	Tables       TableStore
+	Reservations ReservationStore
+	Orders       OrderStore
+	OrderItems   OrderItemStore
+	Invoices     InvoiceStore
+	Payments     PaymentStore
+	CreditNotes  CreditNoteStore
+	Ingredients  IngredientStore
+	Recipes      RecipeStore
+	PriceRules   PriceRuleStore
+	Vouchers     VoucherStore
+	Users        UserStore
+}
+
+// OrderItemLine is one order item joined with its food and table, as listed
+// inside an OrderItemsGroup. Price is what one of Quantity costs and Amount
+// what they all do.
+type OrderItemLine struct {
+	OrderItemID string        `json:"order_item_id" bson:"order_item_id"`
+	Amount      *models.Money `json:"amount" bson:"amount"`
+	FoodName    *string       `json:"food_name" bson:"food_name"`
+	FoodImage   *string       `json:"food_image" bson:"food_image"`
+	TableNumber *int          `json:"table_number" bson:"table_number"`
+	TableID     *string       `json:"table_id" bson:"table_id"`
+	OrderID     *string       `json:"order_id" bson:"order_id"`
+	Price       *models.Money `json:"price" bson:"price"`
+	Quantity    int           `json:"quantity" bson:"quantity"`
+	Size        *string       `json:"size" bson:"size"`
+	Voided      bool          `json:"voided" bson:"voided"`
+}
+
+// OrderItemsGroup is the per-order summary produced by ItemsByOrder.
+// PaymentDue sums the amounts of its items and TotalCount their quantities.
+type OrderItemsGroup struct {
+	PaymentDue  models.Money    `json:"payment_due" bson:"payment_due"`
+	TotalCount  int             `json:"total_count" bson:"total_count"`
+	TableNumber *int            `json:"table_number" bson:"table_number"`
+	OrderItems  []OrderItemLine `json:"order_items" bson:"order_items"`
+}
@@ -0,0 +1,648 @@
+// Package storetest checks that an implementation of the entity stores
+// behaves the way the controllers rely on: records are found by the IDs and
+// keys the API looks them up by, missing records are reported with
+// store.ErrNotFound, and conditional writes that lose a race with
+// store.ErrConflict. Every backend runs the same suite from its own tests.
+package storetest
+
+import (
+	"context"
+	"restaurant-management/models"
+	"restaurant-management/store"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Run runs the suite against the stores newStores returns. It is called
+// once per subtest and must return stores with nothing in them.
+func Run(t *testing.T, newStores func(t *testing.T) store.Stores) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, stores store.Stores)
+	}{
+		{"Foods", testFoods},
+		{"Menus", testMenus},
+		{"Tables", testTables},
+		{"Orders", testOrders},
+		{"OrderItems", testOrderItems},
+		{"Invoices", testInvoices},
+		{"Users", testUsers},
+		{"Vouchers", testVouchers},
+		{"Ingredients", testIngredients},
+		{"VoucherRedemptions", testVoucherRedemptions},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, newStores(t))
+		})
+	}
+}
+
+// now is the time records are created at, to the second, as some backends
+// store no finer.
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Second)
+}
+
+func newMenu(t *testing.T, stores store.Stores, name string) models.Menu {
+	t.Helper()
+
+	created := now()
+	menu := models.Menu{ID: primitive.NewObjectID(), Name: name, Category: "main", CreatedAt: created, UpdatedAt: created}
+	menu.MenuID = menu.ID.Hex()
+	if err := stores.Menus.Insert(context.Background(), menu); err != nil {
+		t.Fatalf("Menus.Insert: %v", err)
+	}
+	return menu
+}
+
+func newFood(t *testing.T, stores store.Stores, name string, price int64, menuID *string) models.Food {
+	t.Helper()
+
+	created := now()
+	image := name + ".png"
+	amount := models.NewMoney(price, "USD")
+	food := models.Food{ID: primitive.NewObjectID(), Name: &name, Price: &amount, FoodImage: &image, MenuID: menuID, CreatedAt: created, UpdatedAt: created}
+	food.FoodID = food.ID.Hex()
+	if err := stores.Foods.Insert(context.Background(), food); err != nil {
+		t.Fatalf("Foods.Insert: %v", err)
+	}
+	return food
+}
+
+func newTable(t *testing.T, stores store.Stores, number int) models.Table {
+	t.Helper()
+
+	created := now()
+	guests := 4
+	table := models.Table{ID: primitive.NewObjectID(), NumberOfGuests: &guests, TableNumber: &number, CreatedAt: created, UpdatedAt: created}
+	table.TableID = table.ID.Hex()
+	if err := stores.Tables.Insert(context.Background(), table); err != nil {
+		t.Fatalf("Tables.Insert: %v", err)
+	}
+	return table
+}
+
+func newOrder(t *testing.T, stores store.Stores, table models.Table) models.Order {
+	t.Helper()
+
+	created := now()
+	status := models.OrderStatusPlaced
+	order := models.Order{ID: primitive.NewObjectID(), OrderDate: created, CreatedAt: created, UpdatedAt: created, TableID: &table.TableID, Status: &status}
+	order.OrderID = order.ID.Hex()
+	if err := stores.Orders.Insert(context.Background(), order); err != nil {
+		t.Fatalf("Orders.Insert: %v", err)
+	}
+	return order
+}
+
+func newOrderItem(order models.Order, food models.Food, quantity int) models.OrderItem {
+	created := now()
+	item := models.OrderItem{
+		ID:        primitive.NewObjectID(),
+		Quantity:  quantity,
+		UnitPrice: food.Price,
+		ListPrice: food.Price,
+		FoodID:    &food.FoodID,
+		OrderID:   order.OrderID,
+		CreatedAt: created,
+		UpdatedAt: created,
+	}
+	item.OrderItemID = item.ID.Hex()
+	return item
+}
+
+func newInvoice(order models.Order, total int64) models.Invoice {
+	created := now()
+	status, method := models.PaymentPending, ""
+	grandTotal := models.NewMoney(total, "USD")
+	invoice := models.Invoice{
+		ID:             primitive.NewObjectID(),
+		OrderID:        order.OrderID,
+		PaymentMethod:  &method,
+		PaymentStatus:  &status,
+		PaymentDueDate: created.AddDate(0, 0, 1),
+		CreatedAt:      created,
+		UpdatedAt:      created,
+		Subtotal:       &grandTotal,
+		GrandTotal:     &grandTotal,
+	}
+	invoice.InvoiceID = invoice.ID.Hex()
+	return invoice
+}
+
+func newUser(t *testing.T, stores store.Stores, email, phone string) models.User {
+	t.Helper()
+
+	created := now()
+	first, last, password, role := "Ann", "Bee", "hashed", models.RoleWaiter
+	user := models.User{
+		ID:        primitive.NewObjectID(),
+		FirstName: &first,
+		LastName:  &last,
+		Password:  &password,
+		Email:     &email,
+		Phone:     &phone,
+		Role:      &role,
+		CreatedAt: created,
+		UpdatedAt: created,
+	}
+	user.UserID = user.ID.Hex()
+	if err := stores.Users.Insert(context.Background(), user); err != nil {
+		t.Fatalf("Users.Insert: %v", err)
+	}
+	return user
+}
+
+func testFoods(t *testing.T, stores store.Stores) {
+	ctx := context.Background()
+	lunch := newMenu(t, stores, "Lunch")
+	dinner := newMenu(t, stores, "Dinner")
+	pizza := newFood(t, stores, "Pizza", 1200, &lunch.MenuID)
+	newFood(t, stores, "Steak", 2500, &dinner.MenuID)
+
+	found, err := stores.Foods.Find(ctx, pizza.FoodID)
+	if err != nil {
+		t.Fatalf("Find by food_id: %v", err)
+	}
+	if found.FoodID != pizza.FoodID || *found.Name != "Pizza" || *found.Price != *pizza.Price {
+		t.Errorf("Find by food_id = %s %s %v, want %s Pizza %v", found.FoodID, *found.Name, *found.Price, pizza.FoodID, *pizza.Price)
+	}
+	if found.MenuID == nil || *found.MenuID != lunch.MenuID {
+		t.Errorf("MenuID = %v, want %s", found.MenuID, lunch.MenuID)
+	}
+
+	total, foods, err := stores.Foods.List(ctx, store.FoodFilter{MenuIDs: []string{lunch.MenuID}}, 0, 10)
+	if err != nil {
+		t.Fatalf("List by menu_id: %v", err)
+	}
+	if total != 1 || len(foods) != 1 || foods[0].FoodID != pizza.FoodID {
+		t.Errorf("List by menu_id = %d foods, total %d, want only %s", len(foods), total, pizza.FoodID)
+	}
+
+	if _, err := stores.Foods.Find(ctx, "missing"); err != store.ErrNotFound {
+		t.Errorf("Find missing = %v, want ErrNotFound", err)
+	}
+	name := "Calzone"
+	if _, err := stores.Foods.Update(ctx, "missing", models.Food{Name: &name}); err != store.ErrNotFound {
+		t.Errorf("Update missing = %v, want ErrNotFound", err)
+	}
+	if err := stores.Foods.SetAvailability(ctx, "missing", false, "sold out"); err != store.ErrNotFound {
+		t.Errorf("SetAvailability missing = %v, want ErrNotFound", err)
+	}
+}
+
+func testMenus(t *testing.T, stores store.Stores) {
+	ctx := context.Background()
+	lunch := newMenu(t, stores, "Lunch")
+	newMenu(t, stores, "Dinner")
+
+	found, err := stores.Menus.Find(ctx, lunch.MenuID)
+	if err != nil {
+		t.Fatalf("Find by menu_id: %v", err)
+	}
+	if found.MenuID != lunch.MenuID || found.Name != "Lunch" {
+		t.Errorf("Find by menu_id = %s %s, want %s Lunch", found.MenuID, found.Name, lunch.MenuID)
+	}
+
+	if _, err := stores.Menus.Find(ctx, "missing"); err != store.ErrNotFound {
+		t.Errorf("Find missing = %v, want ErrNotFound", err)
+	}
+	if _, err := stores.Menus.Update(ctx, "missing", models.Menu{Name: "Brunch"}); err != store.ErrNotFound {
+		t.Errorf("Update missing = %v, want ErrNotFound", err)
+	}
+}
+
+func testTables(t *testing.T, stores store.Stores) {
+	ctx := context.Background()
+	table := newTable(t, stores, 7)
+	newTable(t, stores, 8)
+
+	found, err := stores.Tables.Find(ctx, table.TableID)
+	if err != nil {
+		t.Fatalf("Find by table_id: %v", err)
+	}
+	if found.TableID != table.TableID || found.TableNumber == nil || *found.TableNumber != 7 {
+		t.Errorf("Find by table_id = %s table %v, want %s table 7", found.TableID, found.TableNumber, table.TableID)
+	}
+
+	if _, err := stores.Tables.Find(ctx, "missing"); err != store.ErrNotFound {
+		t.Errorf("Find missing = %v, want ErrNotFound", err)
+	}
+	guests := 2
+	if _, err := stores.Tables.Update(ctx, "missing", models.Table{NumberOfGuests: &guests}); err != store.ErrNotFound {
+		t.Errorf("Update missing = %v, want ErrNotFound", err)
+	}
+}
+
+func testOrders(t *testing.T, stores store.Stores) {
+	ctx := context.Background()
+	table := newTable(t, stores, 7)
+	order := newOrder(t, stores, table)
+	empty := newOrder(t, stores, table)
+
+	found, err := stores.Orders.Find(ctx, order.OrderID)
+	if err != nil {
+		t.Fatalf("Find by order_id: %v", err)
+	}
+	if found.OrderID != order.OrderID || found.TableID == nil || *found.TableID != table.TableID {
+		t.Errorf("Find by order_id = %s on %v, want %s on %s", found.OrderID, found.TableID, order.OrderID, table.TableID)
+	}
+
+	// Of two transitions made from the same status only the first is made.
+	transition := models.OrderTransition{From: models.OrderStatusPlaced, To: models.OrderStatusInKitchen, ChangedBy: "u1", ChangedAt: now()}
+	if err := stores.Orders.Transition(ctx, order.OrderID, transition); err != nil {
+		t.Fatalf("Transition: %v", err)
+	}
+	cancel := models.OrderTransition{From: models.OrderStatusPlaced, To: models.OrderStatusCancelled, ChangedBy: "u2", ChangedAt: now()}
+	if err := stores.Orders.Transition(ctx, order.OrderID, cancel); err != store.ErrConflict {
+		t.Errorf("stale Transition = %v, want ErrConflict", err)
+	}
+	found, err = stores.Orders.Find(ctx, order.OrderID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.CurrentStatus() != models.OrderStatusInKitchen || len(found.StatusHistory) != 1 {
+		t.Errorf("order is %s with %d transitions, want %s with 1", found.CurrentStatus(), len(found.StatusHistory), models.OrderStatusInKitchen)
+	}
+
+	if _, err := stores.Orders.Find(ctx, "missing"); err != store.ErrNotFound {
+		t.Errorf("Find missing = %v, want ErrNotFound", err)
+	}
+	if err := stores.Orders.Transition(ctx, "missing", transition); err != store.ErrNotFound {
+		t.Errorf("Transition missing = %v, want ErrNotFound", err)
+	}
+
+	if err := stores.Orders.Delete(ctx, empty.OrderID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := stores.Orders.Find(ctx, empty.OrderID); err != store.ErrNotFound {
+		t.Errorf("Find deleted = %v, want ErrNotFound", err)
+	}
+	if err := stores.Orders.Delete(ctx, empty.OrderID); err != store.ErrNotFound {
+		t.Errorf("second Delete = %v, want ErrNotFound", err)
+	}
+	if _, err := stores.Orders.Find(ctx, order.OrderID); err != nil {
+		t.Errorf("Find after deleting another order: %v", err)
+	}
+}
+
+func newIngredient(t *testing.T, stores store.Stores, name string, stock models.Quantity) models.Ingredient {
+	t.Helper()
+
+	created := now()
+	unit := models.UnitGram
+	ingredient := models.Ingredient{ID: primitive.NewObjectID(), Name: &name, Unit: &unit, Stock: stock, CreatedAt: created, UpdatedAt: created}
+	ingredient.IngredientID = ingredient.ID.Hex()
+	if err := stores.Ingredients.Insert(context.Background(), ingredient); err != nil {
+		t.Fatalf("Ingredients.Insert: %v", err)
+	}
+	return ingredient
+}
+
+func newMovement(ingredient models.Ingredient, change models.Quantity) models.StockMovement {
+	movement := models.StockMovement{ID: primitive.NewObjectID(), IngredientID: ingredient.IngredientID, Change: change, Reason: models.StockOrder, RecordedBy: "u1", CreatedAt: now()}
+	movement.MovementID = movement.ID.Hex()
+	return movement
+}
+
+func testIngredients(t *testing.T, stores store.Stores) {
+	ctx := context.Background()
+	flour := newIngredient(t, stores, "Flour", 1000)
+	cheese := newIngredient(t, stores, "Cheese", 500)
+
+	stock := func(ingredient models.Ingredient, want models.Quantity) {
+		t.Helper()
+		found, err := stores.Ingredients.Find(ctx, ingredient.IngredientID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if found.Stock != want {
+			t.Errorf("%s stock = %s, want %s", *ingredient.Name, found.Stock, want)
+		}
+	}
+
+	reserved := []models.StockMovement{newMovement(flour, -600), newMovement(cheese, -500)}
+	if err := stores.Ingredients.Reserve(ctx, reserved); err != nil {
+		t.Fatalf("Reserve: %v", err)
+	}
+	if reserved[0].StockAfter != 400 || reserved[1].StockAfter != 0 {
+		t.Errorf("StockAfter = %s, %s; want 0.4, 0", reserved[0].StockAfter, reserved[1].StockAfter)
+	}
+	stock(flour, 400)
+	stock(cheese, 0)
+
+	// A reservation one movement of which cannot be covered takes nothing,
+	// even when that is only so once earlier movements of the same
+	// ingredient are taken.
+	if err := stores.Ingredients.Reserve(ctx, []models.StockMovement{newMovement(flour, -100), newMovement(cheese, -1)}); err != store.ErrConflict {
+		t.Errorf("Reserve beyond stock = %v, want ErrConflict", err)
+	}
+	if err := stores.Ingredients.Reserve(ctx, []models.StockMovement{newMovement(flour, -300), newMovement(flour, -300)}); err != store.ErrConflict {
+		t.Errorf("Reserve beyond stock in two movements = %v, want ErrConflict", err)
+	}
+	if err := stores.Ingredients.Reserve(ctx, []models.StockMovement{newMovement(flour, -100), newMovement(models.Ingredient{IngredientID: "missing"}, -1)}); err != store.ErrNotFound {
+		t.Errorf("Reserve of a missing ingredient = %v, want ErrNotFound", err)
+	}
+	stock(flour, 400)
+	stock(cheese, 0)
+	movements, err := stores.Ingredients.Movements(ctx, flour.IngredientID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(movements) != 1 {
+		t.Errorf("flour has %d movements after the failed reservations, want 1", len(movements))
+	}
+
+	// Moving stock by hand is not held to what is in stock.
+	if err := stores.Ingredients.Move(ctx, []models.StockMovement{newMovement(cheese, -200)}); err != nil {
+		t.Fatalf("Move: %v", err)
+	}
+	stock(flour, 400)
+	stock(cheese, -200)
+}
+
+func testOrderItems(t *testing.T, stores store.Stores) {
+	ctx := context.Background()
+	table := newTable(t, stores, 7)
+	order := newOrder(t, stores, table)
+	other := newOrder(t, stores, table)
+	pizza := newFood(t, stores, "Pizza", 1200, nil)
+	soda := newFood(t, stores, "Soda", 300, nil)
+
+	kept := newOrderItem(order, pizza, 2)
+	voided := newOrderItem(order, soda, 3)
+	elsewhere := newOrderItem(other, soda, 1)
+	if err := stores.OrderItems.InsertMany(ctx, []models.OrderItem{kept, voided, elsewhere}); err != nil {
+		t.Fatalf("InsertMany: %v", err)
+	}
+
+	found, err := stores.OrderItems.Find(ctx, kept.OrderItemID)
+	if err != nil {
+		t.Fatalf("Find by order_item_id: %v", err)
+	}
+	if found.OrderItemID != kept.OrderItemID || found.Quantity != 2 || found.FoodID == nil || *found.FoodID != pizza.FoodID {
+		t.Errorf("Find by order_item_id = %s x%d, want %s x2", found.OrderItemID, found.Quantity, kept.OrderItemID)
+	}
+
+	items, err := stores.OrderItems.ListByOrder(ctx, order.OrderID)
+	if err != nil {
+		t.Fatalf("ListByOrder: %v", err)
+	}
+	if len(items) != 2 || items[0].OrderItemID != kept.OrderItemID || items[1].OrderItemID != voided.OrderItemID {
+		t.Errorf("ListByOrder returned %d items, want %s and %s in order", len(items), kept.OrderItemID, voided.OrderItemID)
+	}
+
+	voidedAt := now()
+	void := models.OrderItemVoid{Reason: "WRONG_ITEM", RequestedBy: "u1", RequestedAt: voidedAt, VoidedAt: &voidedAt}
+	if err := stores.OrderItems.Void(ctx, voided.OrderItemID, void); err != nil {
+		t.Fatalf("Void: %v", err)
+	}
+	if err := stores.OrderItems.Void(ctx, voided.OrderItemID, void); err != store.ErrConflict {
+		t.Errorf("second Void = %v, want ErrConflict", err)
+	}
+	if err := stores.OrderItems.ApproveVoid(ctx, kept.OrderItemID, "u2", voidedAt); err != store.ErrConflict {
+		t.Errorf("ApproveVoid with no void pending = %v, want ErrConflict", err)
+	}
+
+	groups, err := stores.OrderItems.ItemsByOrder(ctx, order.OrderID)
+	if err != nil {
+		t.Fatalf("ItemsByOrder: %v", err)
+	}
+	if len(groups) != 1 {
+		t.Fatalf("ItemsByOrder returned %d groups, want 1", len(groups))
+	}
+	if want := models.NewMoney(2400, "USD"); groups[0].PaymentDue != want || groups[0].TotalCount != 2 {
+		t.Errorf("ItemsByOrder due %v for %d items, want %v for 2", groups[0].PaymentDue, groups[0].TotalCount, want)
+	}
+	if len(groups[0].OrderItems) != 2 {
+		t.Errorf("ItemsByOrder listed %d items, want 2", len(groups[0].OrderItems))
+	}
+
+	if _, err := stores.OrderItems.Find(ctx, "missing"); err != store.ErrNotFound {
+		t.Errorf("Find missing = %v, want ErrNotFound", err)
+	}
+	if err := stores.OrderItems.Void(ctx, "missing", void); err != store.ErrNotFound {
+		t.Errorf("Void missing = %v, want ErrNotFound", err)
+	}
+}
+
+func testInvoices(t *testing.T, stores store.Stores) {
+	ctx := context.Background()
+	table := newTable(t, stores, 7)
+	order := newOrder(t, stores, table)
+
+	invoice := newInvoice(order, 2400)
+	if err := stores.Invoices.Insert(ctx, invoice, "INV"); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+
+	found, err := stores.Invoices.Find(ctx, invoice.InvoiceID)
+	if err != nil {
+		t.Fatalf("Find by invoice_id: %v", err)
+	}
+	if found.InvoiceID != invoice.InvoiceID || found.OrderID != order.OrderID || found.InvoiceNumber == "" {
+		t.Errorf("Find by invoice_id = %s for %s numbered %q, want %s for %s numbered", found.InvoiceID, found.OrderID, found.InvoiceNumber, invoice.InvoiceID, order.OrderID)
+	}
+
+	byOrder, err := stores.Invoices.ListByOrder(ctx, order.OrderID)
+	if err != nil {
+		t.Fatalf("ListByOrder: %v", err)
+	}
+	if len(byOrder) != 1 || byOrder[0].InvoiceID != invoice.InvoiceID {
+		t.Errorf("ListByOrder returned %d invoices, want only %s", len(byOrder), invoice.InvoiceID)
+	}
+
+	// An order is billed once.
+	if err := stores.Invoices.Insert(ctx, newInvoice(order, 2400), "INV"); err != store.ErrConflict {
+		t.Errorf("second Insert for the order = %v, want ErrConflict", err)
+	}
+
+	if _, err := stores.Invoices.Find(ctx, "missing"); err != store.ErrNotFound {
+		t.Errorf("Find missing = %v, want ErrNotFound", err)
+	}
+	method := "CASH"
+	if _, err := stores.Invoices.Update(ctx, "missing", models.Invoice{PaymentMethod: &method}); err != store.ErrNotFound {
+		t.Errorf("Update missing = %v, want ErrNotFound", err)
+	}
+}
+
+func testUsers(t *testing.T, stores store.Stores) {
+	ctx := context.Background()
+	ann := newUser(t, stores, "ann@example.com", "555-0100")
+	bob := newUser(t, stores, "bob@example.com", "555-0101")
+
+	found, err := stores.Users.FindByEmail(ctx, "ann@example.com")
+	if err != nil {
+		t.Fatalf("FindByEmail: %v", err)
+	}
+	if found.UserID != ann.UserID {
+		t.Errorf("FindByEmail = %s, want %s", found.UserID, ann.UserID)
+	}
+	if found, err = stores.Users.Find(ctx, bob.UserID); err != nil || found.UserID != bob.UserID {
+		t.Errorf("Find by user_id = %s, %v, want %s", found.UserID, err, bob.UserID)
+	}
+
+	if n, err := stores.Users.CountByEmail(ctx, "ann@example.com"); err != nil || n != 1 {
+		t.Errorf("CountByEmail = %d, %v, want 1", n, err)
+	}
+	if n, err := stores.Users.CountByPhone(ctx, "555-0101"); err != nil || n != 1 {
+		t.Errorf("CountByPhone = %d, %v, want 1", n, err)
+	}
+	if n, err := stores.Users.CountByPhone(ctx, "555-0199"); err != nil || n != 0 {
+		t.Errorf("CountByPhone of an unknown phone = %d, %v, want 0", n, err)
+	}
+
+	if _, err := stores.Users.FindByEmail(ctx, "nobody@example.com"); err != store.ErrNotFound {
+		t.Errorf("FindByEmail missing = %v, want ErrNotFound", err)
+	}
+	if _, err := stores.Users.Find(ctx, "missing"); err != store.ErrNotFound {
+		t.Errorf("Find missing = %v, want ErrNotFound", err)
+	}
+
+	// Of two refreshes made with the same refresh token only the first
+	// rotates the tokens.
+	if err := stores.Users.UpdateTokens(ctx, ann.UserID, "t1", "r1"); err != nil {
+		t.Fatalf("UpdateTokens: %v", err)
+	}
+	if err := stores.Users.RotateTokens(ctx, ann.UserID, "r1", "t2", "r2"); err != nil {
+		t.Fatalf("RotateTokens: %v", err)
+	}
+	if err := stores.Users.RotateTokens(ctx, ann.UserID, "r1", "t3", "r3"); err != store.ErrConflict {
+		t.Errorf("stale RotateTokens = %v, want ErrConflict", err)
+	}
+
+	// Only one account is ever the first admin.
+	if err := stores.Users.ClaimFirstAdmin(ctx, ann.UserID); err != nil {
+		t.Fatalf("ClaimFirstAdmin: %v", err)
+	}
+	if err := stores.Users.ClaimFirstAdmin(ctx, bob.UserID); err != store.ErrConflict {
+		t.Errorf("second ClaimFirstAdmin = %v, want ErrConflict", err)
+	}
+
+	// Only the holder can give the claim up, and then another can take it.
+	if err := stores.Users.ReleaseFirstAdmin(ctx, bob.UserID); err != nil {
+		t.Fatalf("ReleaseFirstAdmin by another account: %v", err)
+	}
+	if err := stores.Users.ClaimFirstAdmin(ctx, bob.UserID); err != store.ErrConflict {
+		t.Errorf("ClaimFirstAdmin after another account's release = %v, want ErrConflict", err)
+	}
+	if err := stores.Users.ReleaseFirstAdmin(ctx, ann.UserID); err != nil {
+		t.Fatalf("ReleaseFirstAdmin: %v", err)
+	}
+	if err := stores.Users.ClaimFirstAdmin(ctx, bob.UserID); err != nil {
+		t.Errorf("ClaimFirstAdmin after release = %v, want nil", err)
+	}
+}
+
+func testVouchers(t *testing.T, stores store.Stores) {
+	ctx := context.Background()
+	rate := 0.1
+	created := now()
+	voucher := models.Voucher{ID: primitive.NewObjectID(), Code: "TENOFF", Kind: "PERCENTAGE", Rate: &rate, CreatedAt: created, UpdatedAt: created}
+	voucher.VoucherID = voucher.ID.Hex()
+	if err := stores.Vouchers.Insert(ctx, voucher); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+
+	found, err := stores.Vouchers.FindByCode(ctx, "TENOFF")
+	if err != nil {
+		t.Fatalf("FindByCode: %v", err)
+	}
+	if found.VoucherID != voucher.VoucherID {
+		t.Errorf("FindByCode = %s, want %s", found.VoucherID, voucher.VoucherID)
+	}
+
+	duplicate := voucher
+	duplicate.ID = primitive.NewObjectID()
+	duplicate.VoucherID = duplicate.ID.Hex()
+	if err := stores.Vouchers.Insert(ctx, duplicate); err != store.ErrConflict {
+		t.Errorf("Insert of a duplicate code = %v, want ErrConflict", err)
+	}
+
+	if _, err := stores.Vouchers.FindByCode(ctx, "NOSUCH"); err != store.ErrNotFound {
+		t.Errorf("FindByCode missing = %v, want ErrNotFound", err)
+	}
+	if err := stores.Vouchers.Release(ctx, "missing", created); err != store.ErrNotFound {
+		t.Errorf("Release with no voucher applied = %v, want ErrNotFound", err)
+	}
+}
+
+func newRedemption(voucher models.Voucher, order models.Order) models.VoucherRedemption {
+	redemption := models.VoucherRedemption{
+		ID:         primitive.NewObjectID(),
+		VoucherID:  voucher.VoucherID,
+		Code:       voucher.Code,
+		OrderID:    order.OrderID,
+		RedeemedBy: "u1",
+		RedeemedAt: now(),
+	}
+	redemption.RedemptionID = redemption.ID.Hex()
+	return redemption
+}
+
+func testVoucherRedemptions(t *testing.T, stores store.Stores) {
+	ctx := context.Background()
+	created := now()
+	limit := 1
+	voucher := models.Voucher{ID: primitive.NewObjectID(), Code: "ONCE", Kind: models.VoucherFixed, Amount: &models.Money{Amount: 500, Currency: "USD"}, MaxRedemptions: &limit, CreatedAt: created, UpdatedAt: created}
+	voucher.VoucherID = voucher.ID.Hex()
+	if err := stores.Vouchers.Insert(ctx, voucher); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+	table := newTable(t, stores, 1)
+	first, second := newOrder(t, stores, table), newOrder(t, stores, table)
+
+	redemptions := func(want int) {
+		t.Helper()
+		stored, err := stores.Vouchers.Find(ctx, voucher.VoucherID)
+		if err != nil {
+			t.Fatalf("Find: %v", err)
+		}
+		if stored.Redemptions != want {
+			t.Errorf("Redemptions = %d, want %d", stored.Redemptions, want)
+		}
+	}
+
+	if err := stores.Vouchers.Redeem(ctx, newRedemption(voucher, first)); err != nil {
+		t.Fatalf("Redeem: %v", err)
+	}
+	redemptions(1)
+
+	// The voucher is used up, and the first order cannot take it twice.
+	if err := stores.Vouchers.Redeem(ctx, newRedemption(voucher, second)); err != store.ErrConflict {
+		t.Errorf("Redeem past the limit = %v, want ErrConflict", err)
+	}
+	if err := stores.Vouchers.Redeem(ctx, newRedemption(voucher, first)); err != store.ErrConflict {
+		t.Errorf("second Redeem on one order = %v, want ErrConflict", err)
+	}
+	if _, err := stores.Vouchers.RedemptionByOrder(ctx, second.OrderID); err != store.ErrNotFound {
+		t.Errorf("RedemptionByOrder after a refused Redeem = %v, want ErrNotFound", err)
+	}
+	redemptions(1)
+
+	// Releasing it, as voiding the order does, lets another order have it.
+	if err := stores.Vouchers.Release(ctx, first.OrderID, now()); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	redemptions(0)
+	if _, err := stores.Vouchers.RedemptionByOrder(ctx, first.OrderID); err != store.ErrNotFound {
+		t.Errorf("RedemptionByOrder after Release = %v, want ErrNotFound", err)
+	}
+	if err := stores.Vouchers.Release(ctx, first.OrderID, now()); err != store.ErrNotFound {
+		t.Errorf("second Release = %v, want ErrNotFound", err)
+	}
+	if err := stores.Vouchers.Redeem(ctx, newRedemption(voucher, second)); err != nil {
+		t.Fatalf("Redeem after Release: %v", err)
+	}
+	redemptions(1)
+	applied, err := stores.Vouchers.RedemptionByOrder(ctx, second.OrderID)
+	if err != nil {
+		t.Fatalf("RedemptionByOrder: %v", err)
+	}
+	if applied.Code != "ONCE" {
+		t.Errorf("RedemptionByOrder code = %q, want ONCE", applied.Code)
+	}
+}